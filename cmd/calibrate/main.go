@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/calibration"
+	"github.com/franky69420/crypto-oracle/internal/storage/db"
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	input := flag.String("input", "", "Replayed observations file (JSON Lines); reads X-Score history from the database when empty")
+	days := flag.Int("days", 30, "Number of days of X-Score history to use")
+	target := flag.String("target", "pump", "Outcome to predict: pump (2x within 24h) or dump (-80% within 24h)")
+	horizon := flag.Duration("horizon", 24*time.Hour, "Outcome horizon")
+	holdout := flag.Float64("holdout", 0.2, "Fraction of the most recent samples kept for evaluation")
+	l2 := flag.Float64("l2", calibration.DefaultOptions().L2, "L2 regularisation strength")
+	iterations := flag.Int("iterations", calibration.DefaultOptions().Iterations, "Maximum gradient descent iterations")
+	current := flag.String("current", "", "Weights file currently used by the engine (default weights when empty)")
+	out := flag.String("out", "xscore_weights.json", "Candidate weights file to write")
+	reportPath := flag.String("report", "", "Optional path for the JSON evaluation report")
+	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	flag.Parse()
+
+	log := logrus.New()
+	log.SetOutput(os.Stderr)
+	if level, err := logrus.ParseLevel(*logLevel); err == nil {
+		log.SetLevel(level)
+	}
+
+	outcome, err := parseOutcome(*target, *horizon)
+	if err != nil {
+		log.WithError(err).Fatal("Invalid target")
+	}
+
+	// Charger les observations (rejouées ou historisées)
+	var observations []calibration.Observation
+	if *input != "" {
+		observations, err = calibration.LoadObservationsFile(*input)
+	} else {
+		observations, err = loadHistory(*days, outcome.Horizon, log)
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to load observations")
+	}
+
+	dataset := calibration.BuildDataset(observations, outcome)
+	log.WithFields(logrus.Fields{
+		"observations": len(observations),
+		"samples":      len(dataset.Samples),
+		"positives":    dataset.Positives(),
+		"skipped":      dataset.Skipped,
+		"target":       outcome.Name,
+	}).Info("Dataset built")
+
+	currentWeights := token.DefaultWeights()
+	if *current != "" {
+		file, err := token.LoadWeightsFile(*current)
+		if err != nil {
+			log.WithError(err).Fatal("Failed to load current weights")
+		}
+		currentWeights = file.Weights
+	}
+
+	opts := calibration.DefaultOptions()
+	opts.L2 = *l2
+	opts.Iterations = *iterations
+
+	result, err := calibration.Run(dataset, *holdout, opts, currentWeights)
+	if err != nil {
+		log.WithError(err).Fatal("Calibration failed")
+	}
+
+	if err := token.WriteWeightsFile(*out, result.WeightsFile()); err != nil {
+		log.WithError(err).Fatal("Failed to write weights file")
+	}
+
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(result.Report, "", "  ")
+		if err == nil {
+			err = os.WriteFile(*reportPath, data, 0644)
+		}
+		if err != nil {
+			log.WithError(err).Fatal("Failed to write report")
+		}
+	}
+
+	fmt.Print(result.Report.String())
+	log.WithField("path", *out).Info("Candidate weights written")
+}
+
+// parseOutcome traduit la cible demandée en outcome d'étiquetage
+func parseOutcome(target string, horizon time.Duration) (calibration.Outcome, error) {
+	var outcome calibration.Outcome
+	switch target {
+	case "pump":
+		outcome = calibration.OutcomePump2x24h
+	case "dump":
+		outcome = calibration.OutcomeDump80
+	default:
+		return outcome, fmt.Errorf("unknown target %q (expected pump or dump)", target)
+	}
+
+	if horizon != outcome.Horizon {
+		outcome.Horizon = horizon
+		outcome.Name = fmt.Sprintf("%s_%s", target, horizon)
+	}
+	return outcome, nil
+}
+
+// loadHistory lit l'historique des X-Scores et les prix qui ont suivi depuis la base de données
+func loadHistory(days int, horizon time.Duration, log *logrus.Logger) ([]calibration.Observation, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	database, err := db.NewConnection(cfg.Database, logger.NewLogger(cfg.LogLevel))
+	if err != nil {
+		return nil, err
+	}
+	defer database.Close()
+
+	to := time.Now()
+	from := to.Add(-time.Duration(days) * 24 * time.Hour)
+
+	history, err := database.GetXScoreHistory(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Charger les prix une seule fois par token
+	prices := make(map[string][]models.TokenPricePoint)
+	observations := make([]calibration.Observation, 0, len(history))
+	for _, result := range history {
+		points, ok := prices[result.TokenAddress]
+		if !ok {
+			points, err = database.GetTokenPricePoints(result.TokenAddress, from, to.Add(horizon))
+			if err != nil {
+				log.WithError(err).WithField("token_address", result.TokenAddress).Warn("Failed to load price points")
+			}
+			prices[result.TokenAddress] = points
+		}
+		observations = append(observations, calibration.ObservationFromXScore(result, points))
+	}
+
+	return observations, nil
+}
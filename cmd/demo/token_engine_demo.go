@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"os"
 	"os/signal"
 	"syscall"
@@ -166,7 +167,15 @@ func (p *PipelineProcessor) GetName() string {
 	return p.name
 }
 
+// Subscriptions subscribes the demo processor to every token event
+func (p *PipelineProcessor) Subscriptions() []pipeline.Subscription {
+	return []pipeline.Subscription{{Stream: pipeline.StreamTokenEvents}}
+}
+
 func main() {
+	inMemory := flag.Bool("memory", false, "Use the in-memory broker instead of Redis")
+	flag.Parse()
+
 	// Initialize logger
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.TextFormatter{
@@ -176,31 +185,33 @@ func main() {
 
 	logger.Info("Starting Token Engine Demo")
 
-	// Create Redis connection
-	redisConfig := &config.RedisConfig{
-		Host: "localhost",
-		Port: 6379,
+	// Create pipeline on Redis Streams, or in memory when Redis is not available
+	var pipelineSvc *pipeline.Pipeline
+	if *inMemory {
+		pipelineSvc = pipeline.NewPipelineWithBroker(pipeline.NewMemoryBroker(), logger)
+	} else {
+		redisConfig := &config.RedisConfig{
+			Host: "localhost",
+			Port: 6379,
+		}
+		redisClient, err := cache.NewRedisConnection(redisConfig, logger)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to connect to Redis")
+		}
+		defer redisClient.Close()
+		pipelineSvc = pipeline.NewPipeline(redisClient, logger)
 	}
-	redisClient, err := cache.NewRedisConnection(redisConfig, logger)
-	if err != nil {
-		logger.WithError(err).Fatal("Failed to connect to Redis")
-	}
-	defer redisClient.Close()
-
-	// Create and start pipeline
-	pipelineSvc := pipeline.NewPipeline(redisClient, logger)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	err = pipelineSvc.Start(ctx)
+	processor := NewPipelineProcessor(logger)
+	pipelineSvc.RegisterProcessor(processor)
+
+	err := pipelineSvc.Start(ctx)
 	if err != nil {
 		logger.WithError(err).Fatal("Failed to start pipeline")
 	}
 
-	// Register pipeline processor
-	processor := NewPipelineProcessor(logger)
-	pipelineSvc.RegisterProcessor(processor)
-
 	// Create mocks
 	mockGMGN := &MockGMGNClient{Logger: logger}
 	mockMemory := &MockMemoryOfTrust{Logger: logger}
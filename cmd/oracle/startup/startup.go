@@ -7,15 +7,20 @@ import (
 
 	"github.com/franky69420/crypto-oracle/internal/api"
 	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/internal/digest"
 	"github.com/franky69420/crypto-oracle/internal/gateway/gmgn"
+	"github.com/franky69420/crypto-oracle/internal/maintenance"
 	"github.com/franky69420/crypto-oracle/internal/memory"
 	"github.com/franky69420/crypto-oracle/internal/pipeline"
 	"github.com/franky69420/crypto-oracle/internal/reactivation"
 	"github.com/franky69420/crypto-oracle/internal/storage/cache"
 	"github.com/franky69420/crypto-oracle/internal/storage/db"
+	"github.com/franky69420/crypto-oracle/internal/stream"
 	"github.com/franky69420/crypto-oracle/internal/token"
 	"github.com/franky69420/crypto-oracle/internal/wallet"
 	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	applog "github.com/franky69420/crypto-oracle/pkg/utils/logger"
 
 	"github.com/sirupsen/logrus"
 )
@@ -29,10 +34,15 @@ type Application struct {
 	gmgnGateway   *gmgn.Client
 	memoryOfTrust *memory.MemoryOfTrust
 	tokenEngine   *token.Engine
+	projector     *token.Projector
 	walletEngine  *wallet.Intelligence
 	reactivation  *reactivation.System
 	pipeline      *pipeline.Pipeline
 	alertManager  *alerting.Manager
+	digests       *digest.Generator
+	streamHub     *stream.Hub
+	apiKeys       *auth.Manager
+	jobs          *maintenance.Manager
 	apiServer     *api.Server
 	ctx           context.Context
 	cancel        context.CancelFunc
@@ -74,13 +84,84 @@ func InitializeApplication(cfg *config.Config, logger *logrus.Logger) (*Applicat
 	gmgnClient := gmgn.NewClient(gmgnConfig)
 	memoryTrust := memory.NewMemoryOfTrust(database, redisClient, logger)
 	tokenEng := token.NewEngine(gmgnClient, memoryTrust, logger)
+	// Poids du X-Score issus de la calibration hors ligne
+	if cfg.XScore != nil {
+		if cfg.XScore.WeightsFile != "" {
+			if err := tokenEng.LoadWeights(cfg.XScore.WeightsFile); err != nil {
+				cancel()
+				return nil, fmt.Errorf("échec du chargement des poids X-Score: %w", err)
+			}
+		}
+		if cfg.XScore.RecordHistory {
+			tokenEng.SetXScoreHistoryStore(database)
+		}
+	}
+
 	walletEng := wallet.NewIntelligence(memoryTrust, logger)
 	reactivationSys := reactivation.NewSystem(tokenEng, walletEng, logger)
 	pipelineSys := pipeline.NewPipeline(redisClient, logger)
+	pipelineSys.SetRetryPolicy(pipeline.RetryPolicyFromConfig(cfg.Pipeline))
+	pipelineSys.SetConsumerOptions(pipeline.ConsumerOptionsFromConfig(cfg.Pipeline))
+	pipelineSys.SetCoalescePolicy(pipeline.CoalescePolicyFromConfig(cfg.Pipeline))
+	for stream, policy := range pipeline.RetentionPoliciesFromConfig(cfg.Pipeline) {
+		pipelineSys.SetRetention(stream, policy)
+	}
+	pipelineSys.RegisterProcessor(pipeline.NewTokenProcessor(tokenEng, logger), pipeline.Validate())
+	projector := token.NewProjector(logger)
+	pipelineSys.RegisterProcessor(projector)
 	alertMgr := alerting.NewManager(logger)
+	alertMgr.SetStore(database)
+	alertMgr.SetRuleStore(database)
+	alertMgr.SetOutcomeStore(database)
+	alertMgr.SetSubscriberStore(database)
+	if err := alertMgr.ApplyConfig(cfg.Alerts); err != nil {
+		cancel()
+		return nil, fmt.Errorf("configuration des alertes invalide: %w", err)
+	}
+	alertMgr.SetPipeline(pipelineSys)
+	pipelineSys.RegisterProcessor(alerting.NewRuleProcessor(alertMgr, tokenEng, logger))
+	pipelineSys.RegisterProcessor(alerting.NewOutcomeProcessor(alertMgr))
+
+	// Digests périodiques envoyés sur les canaux des alertes
+	digests := digest.NewGenerator(projector, alertMgr, logger)
+	digests.SetStore(database)
+	digests.SetWalletSource(memoryTrust)
+	if cfg.Alerts != nil {
+		if err := digests.ApplyConfig(cfg.Alerts.Digests); err != nil {
+			cancel()
+			return nil, fmt.Errorf("configuration des digests invalide: %w", err)
+		}
+	}
+
+	// Flux d'événements en direct pour les clients WebSocket et SSE
+	streamHub := stream.NewHub(pipelineSys, logger)
+	streamHub.ApplyConfig(cfg.API.Stream)
+
+	// Clés d'API: scopes, limitation de débit et audit
+	apiKeys := auth.NewManager(logger)
+	apiKeys.SetStore(database)
+	if err := apiKeys.ApplyConfig(cfg.API.Auth); err != nil {
+		cancel()
+		return nil, fmt.Errorf("configuration de l'authentification invalide: %w", err)
+	}
+
+	// Jobs de maintenance du Memory of Trust lancés par l'API d'administration
+	jobs := maintenance.NewManager(logger)
+	jobs.SetStore(database)
+	jobs.SetOperations(memoryTrust)
+	memoryTrust.SetMaintenanceJobs(jobs)
 
 	// Initialiser le serveur API
-	apiSrv := api.NewServer(cfg.API, tokenEng, walletEng, memoryTrust, pipelineSys, alertMgr, logger)
+	apiSrv := api.NewServer(cfg.API, memoryTrust, applog.NewLogger(cfg.LogLevel))
+	apiSrv.SetTokens(tokenEng, projector)
+	apiSrv.SetWallets(memoryTrust, wallet.NewAnalyzer(gmgnClient, memoryTrust, logger))
+	apiSrv.SetTrustGraph(memoryTrust)
+	apiSrv.SetTokenHistory(projector, pipelineSys)
+	apiSrv.SetAlerts(alertMgr)
+	apiSrv.SetDigests(digests)
+	apiSrv.SetStream(streamHub)
+	apiSrv.SetAuth(apiKeys)
+	apiSrv.SetMaintenance(jobs)
 
 	return &Application{
 		cfg:           cfg,
@@ -90,10 +171,15 @@ func InitializeApplication(cfg *config.Config, logger *logrus.Logger) (*Applicat
 		gmgnGateway:   gmgnClient,
 		memoryOfTrust: memoryTrust,
 		tokenEngine:   tokenEng,
+		projector:     projector,
 		walletEngine:  walletEng,
 		reactivation:  reactivationSys,
 		pipeline:      pipelineSys,
 		alertManager:  alertMgr,
+		digests:       digests,
+		streamHub:     streamHub,
+		apiKeys:       apiKeys,
+		jobs:          jobs,
 		apiServer:     apiSrv,
 		ctx:           ctx,
 		cancel:        cancel,
@@ -102,6 +188,12 @@ func InitializeApplication(cfg *config.Config, logger *logrus.Logger) (*Applicat
 
 // Start démarre l'application
 func (app *Application) Start() error {
+	// Reconstruire l'état des tokens à partir du journal d'événements
+	if _, err := app.projector.Rebuild(app.pipeline); err != nil {
+		return fmt.Errorf("échec de la reconstruction des tokens: %w", err)
+	}
+	app.tokenEngine.Restore(app.projector.Tokens())
+
 	// Démarrer le pipeline de traitement
 	if err := app.pipeline.Start(app.ctx); err != nil {
 		return fmt.Errorf("échec du démarrage du pipeline: %w", err)
@@ -117,6 +209,26 @@ func (app *Application) Start() error {
 		return fmt.Errorf("échec du démarrage du gestionnaire d'alertes: %w", err)
 	}
 
+	// Planifier les digests
+	if err := app.digests.Start(app.ctx); err != nil {
+		return fmt.Errorf("échec du démarrage des digests: %w", err)
+	}
+
+	// Diffuser les événements en direct
+	if err := app.streamHub.Start(app.ctx); err != nil {
+		return fmt.Errorf("échec du démarrage du flux d'événements: %w", err)
+	}
+
+	// Charger les clés d'API avant d'accepter des requêtes
+	if err := app.apiKeys.Start(app.ctx); err != nil {
+		return fmt.Errorf("échec du démarrage de l'authentification: %w", err)
+	}
+
+	// Recharger l'historique des jobs de maintenance
+	if err := app.jobs.Start(app.ctx); err != nil {
+		return fmt.Errorf("échec du démarrage des jobs de maintenance: %w", err)
+	}
+
 	// Démarrer le serveur API
 	go func() {
 		if err := app.apiServer.Start(); err != nil {
@@ -129,30 +241,62 @@ func (app *Application) Start() error {
 	return nil
 }
 
+// shutdownTimeout borne l'arrêt de l'ensemble des composants
+const shutdownTimeout = 30 * time.Second
+
 // Stop arrête l'application
 func (app *Application) Stop() error {
 	// Annuler le contexte pour signaler à tous les composants de s'arrêter
 	app.cancel()
 
-	// Arrêter les composants dans l'ordre inverse
-	if err := app.apiServer.Shutdown(app.ctx); err != nil {
+	// Le contexte de l'application est annulé: l'arrêt a sa propre échéance
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Arrêter les composants dans l'ordre inverse. Fermer les flux en direct d'abord,
+	// sinon les connexions ouvertes retardent l'arrêt du serveur API
+	if err := app.streamHub.Shutdown(ctx); err != nil {
+		app.logger.Errorf("Erreur lors de l'arrêt du flux d'événements: %v", err)
+	}
+
+	if err := app.apiServer.Shutdown(ctx); err != nil {
 		app.logger.Errorf("Erreur lors de l'arrêt du serveur API: %v", err)
 	}
 
-	if err := app.alertManager.Shutdown(app.ctx); err != nil {
-		app.logger.Errorf("Erreur lors de l'arrêt du gestionnaire d'alertes: %v", err)
+	if err := app.reactivation.Shutdown(ctx); err != nil {
+		app.logger.Errorf("Erreur lors de l'arrêt du système de réactivation: %v", err)
 	}
 
-	if err := app.pipeline.Shutdown(app.ctx); err != nil {
+	// Drainer le pipeline avant les alertes: les messages en cours peuvent encore en lever
+	if err := app.pipeline.Shutdown(ctx); err != nil {
 		app.logger.Errorf("Erreur lors de l'arrêt du pipeline: %v", err)
 	}
 
-	if err := app.reactivation.Shutdown(app.ctx); err != nil {
-		app.logger.Errorf("Erreur lors de l'arrêt du système de réactivation: %v", err)
+	// Écrire l'historique des X-Scores calculés par le pipeline
+	if err := app.tokenEngine.Shutdown(ctx); err != nil {
+		app.logger.Errorf("Erreur lors de l'arrêt du moteur de token: %v", err)
+	}
+
+	// Annuler les jobs de maintenance en cours
+	if err := app.jobs.Shutdown(ctx); err != nil {
+		app.logger.Errorf("Erreur lors de l'arrêt des jobs de maintenance: %v", err)
+	}
+
+	// Écrire les dernières entrées d'audit des clés
+	if err := app.apiKeys.Shutdown(ctx); err != nil {
+		app.logger.Errorf("Erreur lors de l'arrêt de l'authentification: %v", err)
+	}
+
+	if err := app.digests.Shutdown(ctx); err != nil {
+		app.logger.Errorf("Erreur lors de l'arrêt des digests: %v", err)
+	}
+
+	if err := app.alertManager.Shutdown(ctx); err != nil {
+		app.logger.Errorf("Erreur lors de l'arrêt du gestionnaire d'alertes: %v", err)
 	}
 
 	app.redis.Close()
 	app.db.Close()
 
 	return nil
-} 
\ No newline at end of file
+}
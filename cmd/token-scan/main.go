@@ -89,30 +89,25 @@ func (m *SimpleMemoryOfTrust) Stop() error {
 	return nil
 }
 
-// SimplePipeline implements NotificationPipeline interface
-type SimplePipeline struct {
-	logger   *logrus.Logger
-	handlers []pipeline.NotificationHandler
+// ConsoleProcessor logs every token event published by the engine
+type ConsoleProcessor struct {
+	logger *logrus.Logger
 }
 
-func (p *SimplePipeline) Publish(msg pipeline.NotificationMessage) error {
-	p.logger.WithField("message", fmt.Sprintf("%+v", msg)).Info("Published notification")
+func (p *ConsoleProcessor) Process(message pipeline.Message) error {
+	p.logger.WithFields(logrus.Fields{
+		"type":    message.Type,
+		"payload": fmt.Sprintf("%+v", message.Payload),
+	}).Info("Token event")
 	return nil
 }
 
-func (p *SimplePipeline) AddHandler(handler pipeline.NotificationHandler) {
-	p.handlers = append(p.handlers, handler)
-	p.logger.WithField("handler", handler.Name()).Info("Added notification handler")
+func (p *ConsoleProcessor) GetName() string {
+	return "console"
 }
 
-func (p *SimplePipeline) RemoveHandler(handlerName string) {
-	for i, h := range p.handlers {
-		if h.Name() == handlerName {
-			p.handlers = append(p.handlers[:i], p.handlers[i+1:]...)
-			p.logger.WithField("handler", handlerName).Info("Removed notification handler")
-			return
-		}
-	}
+func (p *ConsoleProcessor) Subscriptions() []pipeline.Subscription {
+	return []pipeline.Subscription{{Stream: pipeline.StreamTokenEvents}}
 }
 
 func main() {
@@ -141,26 +136,25 @@ func main() {
 	}
 
 	// Create GMGN client and adapter with real data
-	client := gmgn.NewClientWithLogger(clientConfig, logger)
-	adapter := gmgn.NewRealAdapter(client, logger)
+	client := gmgn.NewClient(clientConfig)
+	adapter := gmgn.NewAdapter(client)
 
-	// Create memory of trust and notification pipeline
+	// Create memory of trust and an in-memory pipeline (no Redis required)
 	memoryOfTrust := &SimpleMemoryOfTrust{logger: logger}
-	notificationPipeline := &SimplePipeline{
-		logger:   logger,
-		handlers: []pipeline.NotificationHandler{},
-	}
-
-	// Add a console handler to show notifications
-	notificationPipeline.AddHandler(pipeline.NewConsoleHandler(logger))
+	eventPipeline := pipeline.NewPipelineWithBroker(pipeline.NewMemoryBroker(), logger)
+	eventPipeline.RegisterProcessor(&ConsoleProcessor{logger: logger})
 
 	// Create token engine
-	tokenEngine := token.NewEngine(adapter, memoryOfTrust, notificationPipeline, logger)
+	tokenEngine := token.NewEngine(adapter, memoryOfTrust, eventPipeline, logger)
 
 	// Set up context with cancellation for clean shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if err := eventPipeline.Start(ctx); err != nil {
+		logger.WithError(err).Fatal("Failed to start pipeline")
+	}
+
 	// Start token engine
 	if err := tokenEngine.Start(ctx); err != nil {
 		logger.WithError(err).Fatal("Failed to start token engine")
@@ -174,9 +168,12 @@ func main() {
 	logger.Info("Shutting down...")
 
 	// Clean shutdown
-	if err := tokenEngine.Stop(); err != nil {
+	if err := tokenEngine.Shutdown(ctx); err != nil {
 		logger.WithError(err).Error("Error shutting down token engine")
 	}
+	if err := eventPipeline.Shutdown(ctx); err != nil {
+		logger.WithError(err).Error("Error shutting down pipeline")
+	}
 
 	logger.Info("Shutdown complete")
 }
@@ -2,111 +2,1129 @@ package alerting
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
 	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
 	"github.com/sirupsen/logrus"
 )
 
-// Manager gère les alertes pour les tokens et wallets
+var (
+	// ErrAlertNotFound est retournée pour une alerte inconnue
+	ErrAlertNotFound = errors.New("alert not found")
+	// ErrInvalidCursor est retournée pour un curseur de pagination illisible
+	ErrInvalidCursor = errors.New("invalid alert cursor")
+)
+
+// DefaultAlertPageSize est le nombre d'alertes par page si aucune limite n'est donnée
+const DefaultAlertPageSize = 50
+
+// AlertPage est une page d'alertes, des plus récentes aux plus anciennes
+type AlertPage struct {
+	Alerts     []models.TokenAlert `json:"alerts"`
+	NextCursor string              `json:"next_cursor,omitempty"` // Vide sur la dernière page
+}
+
+// Manager gère les alertes pour les tokens et wallets.
+// Les alertes sont persistées dans un Store; le Manager peut être utilisé
+// depuis plusieurs goroutines.
 type Manager struct {
-	logger *logrus.Logger
-	alerts []models.TokenAlert
+	logger      *logrus.Logger
+	store       Store
+	pipelineSvc *pipeline.Pipeline
+	dispatcher  *Dispatcher
+	rules       *RuleSet
+	ruleStore   RuleStore
+
+	mu       sync.Mutex
+	dedup    DedupPolicy
+	open     map[string]*openAlert // Alertes ouvertes par clé de déduplication
+	resolved map[string]time.Time  // Date de la dernière résolution par clé
+	outcomes *OutcomeTracker
+
+	// Les écritures dans le store sont faites hors de mu, ordonnées par clé
+	writeLocks [32]sync.Mutex
+
+	httpClient      *http.Client
+	subscriberStore SubscriberStore
+	subMu           sync.RWMutex
+	subscribers     map[string]models.Subscriber
+
+	retention         time.Duration // Âge maximal des alertes, 0 = conservées indéfiniment
+	retentionInterval time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
-// NewManager crée un nouveau gestionnaire d'alertes
+// NewManager crée un nouveau gestionnaire d'alertes, avec un store en mémoire
 func NewManager(logger *logrus.Logger) *Manager {
-	return &Manager{
-		logger: logger,
-		alerts: make([]models.TokenAlert, 0),
+	rules, err := NewRuleSet(DefaultRules)
+	if err != nil {
+		panic(err) // Les règles par défaut sont valides
 	}
+
+	store := NewMemoryStore()
+	m := &Manager{
+		logger:            logger,
+		store:             store,
+		dispatcher:        NewDispatcher(logger, DefaultDeliveryPolicy),
+		rules:             rules,
+		dedup:             DefaultDedupPolicy,
+		open:              make(map[string]*openAlert),
+		resolved:          make(map[string]time.Time),
+		httpClient:        &http.Client{},
+		subscribers:       make(map[string]models.Subscriber),
+		retentionInterval: time.Hour,
+	}
+	m.SetOutcomeStore(store)
+	return m
+}
+
+// SetStore remplace le store des alertes, par exemple par la base de données
+func (m *Manager) SetStore(store Store) {
+	m.store = store
+}
+
+// SetOutcomeStore remplace le store du suivi des résultats d'alertes
+func (m *Manager) SetOutcomeStore(store OutcomeStore) {
+	criteria := DefaultOutcomeCriteria
+	if m.outcomes != nil {
+		criteria = m.outcomes.criteria
+	}
+	m.outcomes = NewOutcomeTracker(store, criteria, m.logger)
+	m.outcomes.onConfirmed = m.confirmOutcome
+}
+
+// SetSubscriberStore active la persistance des abonnés
+func (m *Manager) SetSubscriberStore(store SubscriberStore) {
+	m.subscriberStore = store
+}
+
+// SetRuleStore active la persistance des règles créées par l'API
+func (m *Manager) SetRuleStore(store RuleStore) {
+	m.ruleStore = store
+}
+
+// SetPipeline active la publication d'un événement alert_raised pour chaque alerte créée
+func (m *Manager) SetPipeline(p *pipeline.Pipeline) {
+	m.pipelineSvc = p
+}
+
+// SetRetention supprime périodiquement les alertes plus anciennes que maxAge
+func (m *Manager) SetRetention(maxAge, interval time.Duration) {
+	m.retention = maxAge
+	if interval > 0 {
+		m.retentionInterval = interval
+	}
+}
+
+// SetDedupPolicy remplace la politique de déduplication et d'escalade
+func (m *Manager) SetDedupPolicy(policy DedupPolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dedup = policy
+}
+
+// AddChannel ajoute un canal de notification
+func (m *Manager) AddChannel(route Route) {
+	m.dispatcher.AddRoute(route)
+}
+
+// ApplyConfig applique la configuration des alertes: rétention, distribution, canaux activés
+// et règles. Doit être appelée avant Start.
+func (m *Manager) ApplyConfig(cfg *config.AlertsConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	if rules := RulesFromConfig(cfg); len(rules) > 0 {
+		if err := m.rules.Replace(rules); err != nil {
+			return err
+		}
+	}
+	m.SetDedupPolicy(DedupPolicyFromConfig(cfg))
+	m.outcomes.SetCriteria(OutcomeCriteriaFromConfig(cfg))
+	m.SetRetention(
+		time.Duration(cfg.RetentionDays)*24*time.Hour,
+		time.Duration(cfg.RetentionIntervalMs)*time.Millisecond,
+	)
+
+	m.dispatcher = NewDispatcher(m.logger, DeliveryPolicyFromConfig(cfg))
+	if err := m.routeSubscribers(); err != nil {
+		return err
+	}
+	for _, channel := range cfg.Channels {
+		if !channel.Enabled {
+			continue
+		}
+		notifier, err := NotifierFromConfig(channel, m.httpClient, m.logger)
+		if err != nil {
+			return err
+		}
+		m.AddChannel(Route{
+			Notifier:   notifier,
+			Severities: channel.Severities,
+			AlertTypes: channel.AlertTypes,
+		})
+		m.logger.WithFields(logrus.Fields{
+			"channel":     notifier.Name(),
+			"type":        channel.Type,
+			"severities":  channel.Severities,
+			"alert_types": channel.AlertTypes,
+		}).Info("Alert channel enabled")
+	}
+	return nil
 }
 
 // Start démarre le service d'alertes
 func (m *Manager) Start(ctx context.Context) error {
 	m.logger.Info("Starting Alert Manager")
+
+	if err := m.LoadRules(); err != nil {
+		return err
+	}
+	if err := m.loadOpenAlerts(); err != nil {
+		return err
+	}
+	if err := m.outcomes.Load(); err != nil {
+		return err
+	}
+	if err := m.LoadSubscribers(); err != nil {
+		return err
+	}
+	m.dispatcher.Start()
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	if m.retention > 0 {
+		m.wg.Add(1)
+		go m.runRetention(ctx)
+	}
+	m.wg.Add(1)
+	go m.runExpiry(ctx)
 	return nil
 }
 
 // Shutdown arrête le service d'alertes
 func (m *Manager) Shutdown(ctx context.Context) error {
 	m.logger.Info("Shutting down Alert Manager")
+
+	if m.cancel != nil {
+		m.cancel()
+	}
+	m.wg.Wait()
+
+	// Laisser partir les notifications déjà en file
+	m.dispatcher.Stop(ctx)
 	return nil
 }
 
-// CreateAlert crée une nouvelle alerte
+// runRetention supprime les alertes expirées à chaque intervalle
+func (m *Manager) runRetention(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.PruneAlerts(); err != nil {
+			m.logger.WithError(err).Error("Failed to prune expired alerts")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PruneAlerts supprime les alertes plus anciennes que la rétention et retourne leur nombre
+func (m *Manager) PruneAlerts() (int64, error) {
+	if m.retention <= 0 {
+		return 0, nil
+	}
+
+	deleted, err := m.store.DeleteTokenAlertsBefore(time.Now().Add(-m.retention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		m.logger.WithFields(logrus.Fields{
+			"deleted":   deleted,
+			"retention": m.retention.String(),
+		}).Info("Expired alerts pruned")
+	}
+	return deleted, nil
+}
+
+// CreateAlert lève une alerte sans grandeur pilote. Voir Raise pour la déduplication.
 func (m *Manager) CreateAlert(tokenAddress, tokenSymbol, alertType, severity, message string) (*models.TokenAlert, error) {
+	return m.Raise(AlertSignal{
+		TokenAddress: tokenAddress,
+		TokenSymbol:  tokenSymbol,
+		AlertType:    alertType,
+		Severity:     severity,
+		Message:      message,
+		Driver:       math.NaN(),
+	})
+}
+
+// Raise signale qu'une condition d'alerte est vraie pour un token.
+// Sans alerte ouverte de même clé, une alerte est créée, sauf pendant le délai de
+// refroidissement qui suit une résolution: Raise retourne alors nil. Sinon l'alerte
+// ouverte est mise à jour et escaladée si la sévérité du signal est plus haute ou si la
+// grandeur pilote s'est aggravée. Les canaux ne sont notifiés qu'à la création et à l'escalade.
+func (m *Manager) Raise(signal AlertSignal) (*models.TokenAlert, error) {
+	if signal.At.IsZero() {
+		signal.At = time.Now().UTC()
+	}
+	key := DedupKey(signal.TokenAddress, signal.AlertType)
+
+	m.mu.Lock()
+	var (
+		open    *openAlert
+		created bool
+		notify  bool
+	)
+	if current, ok := m.open[key]; ok {
+		open, notify = current, m.refreshAlert(current, signal)
+	} else if resolvedAt, ok := m.resolved[key]; ok && signal.At.Sub(resolvedAt) < m.dedup.cooldown(signal.AlertType) {
+		m.logger.WithFields(logrus.Fields{
+			"token_address": signal.TokenAddress,
+			"alert_type":    signal.AlertType,
+			"resolved_at":   resolvedAt,
+		}).Debug("Alert suppressed during cooldown")
+	} else {
+		open, created, notify = m.openNewAlert(key, signal), true, true
+	}
+	var alert models.TokenAlert
+	if open != nil {
+		alert = copyAlert(open.alert)
+	}
+	m.mu.Unlock()
+
+	if open == nil {
+		return nil, nil
+	}
+	if err := m.persist(open); err != nil {
+		return nil, err
+	}
+	if created {
+		if err := m.outcomes.Track(alert, signal.Price, signal.MarketCap); err != nil {
+			m.logger.WithError(err).WithField("alert_id", alert.ID).Warn("Failed to start alert outcome tracking")
+		}
+	}
+	if notify {
+		m.notify(alert)
+	}
+	return &alert, nil
+}
+
+// openNewAlert crée et suit une nouvelle alerte. Appelée avec m.mu verrouillé.
+func (m *Manager) openNewAlert(key string, signal AlertSignal) *openAlert {
 	alert := models.TokenAlert{
-		ID:               fmt.Sprintf("alert_%d", time.Now().UnixNano()),
-		TokenAddress:     tokenAddress,
-		TokenSymbol:      tokenSymbol,
-		AlertType:        alertType,
-		Severity:         severity,
-		Message:          message,
-		DetectedAt:       time.Now(),
+		ID:                newAlertID(),
+		TokenAddress:      signal.TokenAddress,
+		TokenSymbol:       signal.TokenSymbol,
+		AlertType:         signal.AlertType,
+		Severity:          signal.Severity,
+		Message:           signal.Message,
+		DetectedAt:        signal.At,
 		ConfirmationCount: 0,
-		IsConfirmed:      false,
+		IsConfirmed:       false,
+		RelatedWallets:    signal.RelatedWallets,
+		DedupKey:          key,
+		Status:            AlertStatusOpen,
+		DriverValue:       driverPtr(signal.Driver),
+		OccurrenceCount:   1,
+		LastSeenAt:        signal.At,
+		XScore:            signal.XScore,
+		TokenState:        signal.State,
 	}
 
-	m.alerts = append(m.alerts, alert)
+	open := &openAlert{alert: alert, base: signal.Driver}
+	open.queueChange(TransitionOpened, "condition met", signal.At)
+	m.open[key] = open
+	delete(m.resolved, key)
+
 	m.logger.WithFields(logrus.Fields{
-		"token_address": tokenAddress,
-		"token_symbol":  tokenSymbol,
-		"alert_type":    alertType,
-		"severity":      severity,
+		"alert_id":      alert.ID,
+		"token_address": alert.TokenAddress,
+		"token_symbol":  alert.TokenSymbol,
+		"alert_type":    alert.AlertType,
+		"severity":      alert.Severity,
 	}).Info("Alert created")
+	return open
+}
 
-	return &alert, nil
+// newAlertID génère un identifiant d'alerte unique, même pour des alertes levées au même instant
+func newAlertID() string {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("alert_%d", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("alert_%d_%s", time.Now().UnixNano(), hex.EncodeToString(suffix))
+}
+
+// refreshAlert met à jour une alerte ouverte observée à nouveau et l'escalade si besoin.
+// Indique si l'alerte a été escaladée. Appelée avec m.mu verrouillé.
+func (m *Manager) refreshAlert(open *openAlert, signal AlertSignal) bool {
+	alert := open.alert
+	alert.LastSeenAt = signal.At
+	alert.OccurrenceCount++
+	if driver := driverPtr(signal.Driver); driver != nil {
+		alert.DriverValue = driver
+	}
+	if len(signal.RelatedWallets) > 0 {
+		alert.RelatedWallets = signal.RelatedWallets
+	}
+	if signal.XScore > 0 {
+		alert.XScore = signal.XScore
+	}
+	if signal.State != "" {
+		alert.TokenState = signal.State
+	}
+
+	severity, reason := alert.Severity, ""
+	if SeverityRank(signal.Severity) > SeverityRank(alert.Severity) {
+		severity, reason = signal.Severity, fmt.Sprintf("severity raised to %s", signal.Severity)
+	} else if m.dedup.worsened(open.base, signal.Driver) {
+		severity, reason = nextSeverity(alert.Severity), fmt.Sprintf("driver worsened from %.4g to %.4g", open.base, signal.Driver)
+	}
+
+	escalated := severity != alert.Severity
+	if escalated {
+		alert.Severity = severity
+		alert.Message = signal.Message
+	}
+	open.alert = alert
+	open.clears = 0
+
+	if escalated {
+		if !math.IsNaN(signal.Driver) {
+			open.base = signal.Driver
+		}
+		open.queueChange(TransitionEscalated, reason, signal.At)
+		m.logger.WithFields(logrus.Fields{
+			"alert_id":      alert.ID,
+			"token_address": alert.TokenAddress,
+			"alert_type":    alert.AlertType,
+			"severity":      alert.Severity,
+			"reason":        reason,
+		}).Info("Alert escalated")
+	}
+	return escalated
+}
+
+// Clear signale que la condition d'une alerte est fausse pour un token. L'alerte ouverte
+// de même clé est résolue après DedupPolicy.ResolveAfter signaux consécutifs.
+func (m *Manager) Clear(tokenAddress, alertType string, at time.Time) error {
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	key := DedupKey(tokenAddress, alertType)
+
+	m.mu.Lock()
+	open, ok := m.open[key]
+	if !ok {
+		m.mu.Unlock()
+		return nil
+	}
+	open.clears++
+	if open.clears < m.dedup.ResolveAfter {
+		m.mu.Unlock()
+		return nil
+	}
+	m.resolveAlert(key, open, "condition cleared", at)
+	m.mu.Unlock()
+
+	return m.persistResolved(key, open, at)
+}
+
+// ResolveAlert résout manuellement une alerte ouverte
+func (m *Manager) ResolveAlert(alertID, reason string) (*models.TokenAlert, error) {
+	if reason == "" {
+		reason = "resolved manually"
+	}
+
+	m.mu.Lock()
+	var key string
+	var open *openAlert
+	for k, candidate := range m.open {
+		if candidate.alert.ID == alertID {
+			key, open = k, candidate
+			break
+		}
+	}
+	if open == nil {
+		m.mu.Unlock()
+		// Alerte déjà résolue ou inconnue
+		return m.GetAlert(alertID)
+	}
+	at := time.Now().UTC()
+	m.resolveAlert(key, open, reason, at)
+	result := copyAlert(open.alert)
+	m.mu.Unlock()
+
+	if err := m.persistResolved(key, open, at); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ExpireAlerts résout les alertes ouvertes qui ne sont plus observées depuis DedupPolicy.StaleAfter
+// et oublie les résolutions dont le délai de refroidissement est écoulé
+func (m *Manager) ExpireAlerts(now time.Time) (int, error) {
+	m.mu.Lock()
+	m.pruneResolved(now)
+	if m.dedup.StaleAfter <= 0 {
+		m.mu.Unlock()
+		return 0, nil
+	}
+
+	stale := make(map[string]*openAlert)
+	for key, open := range m.open {
+		if now.Sub(open.alert.LastSeenAt) < m.dedup.StaleAfter {
+			continue
+		}
+		m.resolveAlert(key, open, "no longer observed", now)
+		stale[key] = open
+	}
+	m.mu.Unlock()
+
+	expired := 0
+	var firstErr error
+	for key, open := range stale {
+		if err := m.persistResolved(key, open, now); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		expired++
+	}
+	return expired, firstErr
+}
+
+// resolveAlert clôt une alerte ouverte et démarre son délai de refroidissement.
+// Appelée avec m.mu verrouillé; l'écriture est faite ensuite par persistResolved.
+func (m *Manager) resolveAlert(key string, open *openAlert, reason string, at time.Time) {
+	open.alert.Status = AlertStatusResolved
+	open.alert.ResolvedAt = &at
+	open.queueChange(TransitionResolved, reason, at)
+
+	delete(m.open, key)
+	m.resolved[key] = at
+
+	m.logger.WithFields(logrus.Fields{
+		"alert_id":      open.alert.ID,
+		"token_address": open.alert.TokenAddress,
+		"alert_type":    open.alert.AlertType,
+		"reason":        reason,
+	}).Info("Alert resolved")
+}
+
+// persistResolved enregistre une résolution. En cas d'échec, l'alerte est de nouveau suivie
+// comme ouverte pour qu'une prochaine résolution réessaie l'écriture.
+func (m *Manager) persistResolved(key string, open *openAlert, at time.Time) error {
+	err := m.persist(open)
+	if err == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, reopened := m.open[key]; !reopened {
+		open.alert.Status = AlertStatusOpen
+		open.alert.ResolvedAt = nil
+		open.dropChanges(TransitionResolved)
+		m.open[key] = open
+		if m.resolved[key].Equal(at) {
+			delete(m.resolved, key)
+		}
+	}
+	return err
+}
+
+// persist enregistre l'état courant d'une alerte suivie et les étapes de son historique en
+// attente. Appelée sans m.mu: le verrou d'écriture de la clé garde l'ordre des écritures
+// d'une même alerte sans bloquer les autres.
+func (m *Manager) persist(open *openAlert) error {
+	lock := m.writeLock(open.alert.DedupKey)
+	lock.Lock()
+	defer lock.Unlock()
+
+	m.mu.Lock()
+	alert := copyAlert(open.alert)
+	changes := open.pending
+	open.pending = nil
+	m.mu.Unlock()
+
+	if err := m.store.SaveTokenAlert(&alert); err != nil {
+		// Les étapes seront écrites avec la prochaine écriture de l'alerte
+		m.mu.Lock()
+		open.pending = append(changes, open.pending...)
+		m.mu.Unlock()
+		return fmt.Errorf("failed to save alert: %w", err)
+	}
+	for i := range changes {
+		if err := m.store.SaveAlertStateChange(&changes[i]); err != nil {
+			m.logger.WithError(err).WithField("alert_id", alert.ID).Warn("Failed to save alert state change")
+		}
+	}
+	return nil
+}
+
+// writeLock retourne le verrou d'écriture d'une clé de déduplication
+func (m *Manager) writeLock(key string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &m.writeLocks[h.Sum32()%uint32(len(m.writeLocks))]
+}
+
+// pruneResolved oublie les résolutions dont le délai de refroidissement est écoulé.
+// Appelée avec m.mu verrouillé.
+func (m *Manager) pruneResolved(now time.Time) {
+	for key, resolvedAt := range m.resolved {
+		alertType := key[strings.LastIndex(key, ":")+1:]
+		if now.Sub(resolvedAt) >= m.dedup.cooldown(alertType) {
+			delete(m.resolved, key)
+		}
+	}
+}
+
+// notify envoie une alerte créée ou escaladée aux canaux et la journalise dans l'historique du token
+func (m *Manager) notify(alert models.TokenAlert) {
+	// Notifier les canaux sans attendre les envois
+	m.dispatcher.Dispatch(alert)
+
+	if m.pipelineSvc != nil {
+		event := &pipeline.AlertRaised{
+			AlertID:      alert.ID,
+			TokenAddress: alert.TokenAddress,
+			TokenSymbol:  alert.TokenSymbol,
+			AlertType:    alert.AlertType,
+			Severity:     alert.Severity,
+			Message:      alert.Message,
+			DetectedAt:   alert.LastSeenAt,
+		}
+		if err := m.pipelineSvc.PublishEvent(pipeline.StreamTokenEvents, event); err != nil {
+			m.logger.WithError(err).Warn("Failed to publish alert event")
+		}
+	}
+}
+
+// loadOpenAlerts reprend le suivi des alertes restées ouvertes au dernier arrêt
+func (m *Manager) loadOpenAlerts() error {
+	alerts, err := m.store.QueryTokenAlerts(models.AlertFilter{Status: AlertStatusOpen})
+	if err != nil {
+		return fmt.Errorf("failed to load open alerts: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, alert := range alerts {
+		// Les alertes antérieures à la déduplication n'ont pas de clé
+		if alert.DedupKey == "" {
+			continue
+		}
+		// Les alertes sont triées de la plus récente à la plus ancienne
+		if _, ok := m.open[alert.DedupKey]; !ok {
+			m.open[alert.DedupKey] = &openAlert{alert: alert, base: driverOrNaN(alert.DriverValue)}
+		}
+	}
+	return nil
 }
 
-// GetAlerts récupère toutes les alertes
-func (m *Manager) GetAlerts() []models.TokenAlert {
-	return m.alerts
+// runExpiry résout périodiquement les alertes qui ne sont plus observées
+// et clôt les suivis de résultats arrivés à terme
+func (m *Manager) runExpiry(ctx context.Context) {
+	defer m.wg.Done()
+
+	interval := time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := m.ExpireAlerts(now.UTC()); err != nil {
+				m.logger.WithError(err).Error("Failed to expire stale alerts")
+			}
+			if _, err := m.outcomes.Expire(now.UTC()); err != nil {
+				m.logger.WithError(err).Error("Failed to close alert outcomes")
+			}
+		}
+	}
+}
+
+// Deliveries retourne les envois d'une alerte sur les canaux de notification
+func (m *Manager) Deliveries(alertID string) []DeliveryRecord {
+	return m.dispatcher.Log().ForAlert(alertID)
+}
+
+// RecentDeliveries retourne les derniers envois, filtrés par statut si status n'est pas vide
+func (m *Manager) RecentDeliveries(status string, limit int) []DeliveryRecord {
+	return m.dispatcher.Log().Recent(status, limit)
+}
+
+// GetAlert récupère une alerte par son ID, avec l'historique de ses états
+func (m *Manager) GetAlert(alertID string) (*models.TokenAlert, error) {
+	alert, err := m.store.GetTokenAlert(alertID)
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
+	if alert.History, err = m.store.GetAlertStateChanges(alertID); err != nil {
+		return nil, err
+	}
+	if alert.Outcome, err = m.outcomes.store.GetAlertOutcome(alertID); err != nil {
+		return nil, err
+	}
+	return alert, nil
+}
+
+// QueryAlerts retourne une page d'alertes correspondant au filtre.
+// cursor est le NextCursor de la page précédente, vide pour la première page.
+func (m *Manager) QueryAlerts(filter models.AlertFilter, cursor string) (*AlertPage, error) {
+	if cursor != "" {
+		before, id, err := decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter.BeforeTime, filter.BeforeID = before, id
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAlertPageSize
+	}
+	// Une alerte de plus pour savoir s'il reste une page
+	filter.Limit = limit + 1
+
+	alerts, err := m.store.QueryTokenAlerts(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &AlertPage{Alerts: alerts}
+	if len(alerts) > limit {
+		page.Alerts = alerts[:limit]
+		last := page.Alerts[limit-1]
+		page.NextCursor = encodeCursor(last.DetectedAt, last.ID)
+	}
+	return page, nil
 }
 
 // ConfirmAlert confirme une alerte existante
 func (m *Manager) ConfirmAlert(alertID string) error {
-	for i, alert := range m.alerts {
-		if alert.ID == alertID {
-			m.alerts[i].ConfirmationCount++
-			m.alerts[i].IsConfirmed = true
-			return nil
+	current, err := m.store.GetTokenAlert(alertID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
+
+	// Le verrou d'écriture empêche une écriture concurrente de l'alerte d'effacer la confirmation
+	lock := m.writeLock(current.DedupKey)
+	lock.Lock()
+	defer lock.Unlock()
+
+	alert, err := m.store.ConfirmTokenAlert(alertID)
+	if err != nil {
+		return err
+	}
+	if alert == nil {
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
+
+	// Garder la confirmation lors des prochaines mises à jour de l'alerte ouverte
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if open, ok := m.open[alert.DedupKey]; ok && open.alert.ID == alertID {
+		open.alert.ConfirmationCount = alert.ConfirmationCount
+		open.alert.IsConfirmed = alert.IsConfirmed
+	}
+	return nil
+}
+
+// ObservePrice transmet le prix d'un token au suivi des résultats de ses alertes
+func (m *Manager) ObservePrice(tokenAddress string, price, marketCap float64, at time.Time) error {
+	if at.IsZero() {
+		at = time.Now().UTC()
+	}
+	return m.outcomes.Observe(tokenAddress, price, marketCap, at)
+}
+
+// AlertOutcome retourne le suivi du marché après une alerte
+func (m *Manager) AlertOutcome(alertID string) (*models.AlertOutcome, error) {
+	outcome, err := m.outcomes.store.GetAlertOutcome(alertID)
+	if err != nil {
+		return nil, err
+	}
+	if outcome == nil {
+		return nil, fmt.Errorf("%w: %s", ErrAlertNotFound, alertID)
+	}
+	return outcome, nil
+}
+
+// OutcomeStats retourne les résultats par type des alertes détectées depuis since,
+// limités à un type si alertType n'est pas vide
+func (m *Manager) OutcomeStats(since time.Time, alertType string) ([]models.AlertTypeStats, error) {
+	outcomes, err := m.outcomes.store.QueryAlertOutcomes(since)
+	if err != nil {
+		return nil, err
+	}
+	if alertType != "" {
+		filtered := outcomes[:0]
+		for _, outcome := range outcomes {
+			if strings.EqualFold(outcome.AlertType, alertType) {
+				filtered = append(filtered, outcome)
+			}
 		}
+		outcomes = filtered
 	}
-	return fmt.Errorf("alert not found: %s", alertID)
+	return OutcomeStats(outcomes), nil
 }
 
-// CreateTokenAlert crée une alerte pour un token basée sur des critères
-func (m *Manager) CreateTokenAlert(token models.Token, xScore float64, walletAnalysis *models.WalletAnalysis) error {
-	// Alerte pour token à score élevé
-	if xScore > 80 {
-		_, err := m.CreateAlert(
-			token.Address,
-			token.Symbol,
-			"HIGH_SCORE",
-			"URGENT",
-			fmt.Sprintf("Token %s has a high X-Score of %.2f", token.Symbol, xScore),
-		)
-		return err
+// DecidedOutcomes retourne les suivis d'alertes décidés entre from (inclus) et to (exclu)
+func (m *Manager) DecidedOutcomes(from, to time.Time) ([]models.AlertOutcome, error) {
+	// Une alerte est décidée à la fin de sa fenêtre de suivi, ou à la reprise après un arrêt
+	outcomes, err := m.outcomes.store.QueryAlertOutcomes(from.Add(-2 * m.outcomes.Window()))
+	if err != nil {
+		return nil, err
+	}
+
+	decided := make([]models.AlertOutcome, 0)
+	for _, outcome := range outcomes {
+		if outcome.DecidedAt != nil && !outcome.DecidedAt.Before(from) && outcome.DecidedAt.Before(to) {
+			decided = append(decided, outcome)
+		}
+	}
+	sort.Slice(decided, func(i, j int) bool { return decided[i].DecidedAt.Before(*decided[j].DecidedAt) })
+	return decided, nil
+}
+
+// DispatchReport envoie un rapport périodique sur les canaux de la configuration,
+// limités à channels si la liste n'est pas vide, et retourne le nombre d'envois programmés
+func (m *Manager) DispatchReport(report Report, channels []string) int {
+	return m.dispatcher.DispatchReport(report, channels)
+}
+
+// ReportDeliveries retourne les envois d'un rapport
+func (m *Manager) ReportDeliveries(reportID string) []DeliveryRecord {
+	return m.dispatcher.Log().ForReport(reportID)
+}
+
+// confirmOutcome confirme une alerte dont le marché a validé le signal
+func (m *Manager) confirmOutcome(outcome models.AlertOutcome) {
+	if err := m.ConfirmAlert(outcome.AlertID); err != nil && !errors.Is(err, ErrAlertNotFound) {
+		m.logger.WithError(err).WithField("alert_id", outcome.AlertID).Warn("Failed to confirm alert from its outcome")
+	}
+}
+
+// LoadSubscribers charge les abonnés enregistrés et active leurs canaux
+func (m *Manager) LoadSubscribers() error {
+	if m.subscriberStore == nil {
+		return nil
+	}
+	stored, err := m.subscriberStore.ListAlertSubscribers()
+	if err != nil {
+		return fmt.Errorf("failed to load subscribers: %w", err)
 	}
 
-	// Alerte pour forte présence de smart money
-	if walletAnalysis != nil && walletAnalysis.TrustMetrics.SmartMoneyRatio > 0.3 {
-		_, err := m.CreateAlert(
-			token.Address,
-			token.Symbol,
-			"SMART_MONEY",
-			"ALERT",
-			fmt.Sprintf("Token %s has high smart money presence (%.1f%%)", 
-				token.Symbol, walletAnalysis.TrustMetrics.SmartMoneyRatio*100),
-		)
+	m.subMu.Lock()
+	for _, sub := range stored {
+		m.subscribers[sub.ID] = sub
+	}
+	m.subMu.Unlock()
+
+	if err := m.routeSubscribers(); err != nil {
 		return err
 	}
+	m.logger.WithField("subscribers", len(stored)).Info("Alert subscribers loaded")
+	return nil
+}
+
+// routeSubscribers enregistre les canaux de tous les abonnés auprès du distributeur
+func (m *Manager) routeSubscribers() error {
+	m.subMu.RLock()
+	defer m.subMu.RUnlock()
+
+	for _, sub := range m.subscribers {
+		routes, err := subscriberRoutes(sub, m.httpClient, m.logger)
+		if err != nil {
+			return fmt.Errorf("subscriber %s: %w", sub.ID, err)
+		}
+		m.dispatcher.SetSubscriberRoutes(sub.ID, routes)
+	}
+	return nil
+}
+
+// Subscribers retourne les abonnés triés par identifiant
+func (m *Manager) Subscribers() []models.Subscriber {
+	m.subMu.RLock()
+	defer m.subMu.RUnlock()
+
+	subscribers := make([]models.Subscriber, 0, len(m.subscribers))
+	for _, sub := range m.subscribers {
+		subscribers = append(subscribers, sub)
+	}
+	sort.Slice(subscribers, func(i, j int) bool { return subscribers[i].ID < subscribers[j].ID })
+	return subscribers
+}
+
+// GetSubscriber retourne un abonné
+func (m *Manager) GetSubscriber(id string) (*models.Subscriber, error) {
+	m.subMu.RLock()
+	defer m.subMu.RUnlock()
+
+	sub, ok := m.subscribers[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSubscriberNotFound, id)
+	}
+	return &sub, nil
+}
+
+// SaveSubscriber valide, enregistre et active un abonné; remplace l'abonné de même identifiant
+func (m *Manager) SaveSubscriber(sub models.Subscriber) (*models.Subscriber, error) {
+	if err := ValidateSubscriber(&sub); err != nil {
+		return nil, err
+	}
+	routes, err := subscriberRoutes(sub, m.httpClient, m.logger)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSubscriber, err)
+	}
+
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	now := time.Now().UTC()
+	sub.CreatedAt, sub.UpdatedAt = now, now
+	if existing, ok := m.subscribers[sub.ID]; ok {
+		sub.CreatedAt = existing.CreatedAt
+	}
 
+	if m.subscriberStore != nil {
+		if err := m.subscriberStore.SaveAlertSubscriber(&sub); err != nil {
+			return nil, fmt.Errorf("failed to save subscriber: %w", err)
+		}
+	}
+	m.subscribers[sub.ID] = sub
+	m.dispatcher.SetSubscriberRoutes(sub.ID, routes)
+
+	m.logger.WithFields(logrus.Fields{
+		"subscriber": sub.ID,
+		"channels":   len(sub.Channels),
+		"enabled":    sub.Enabled,
+	}).Info("Alert subscriber saved")
+	return &sub, nil
+}
+
+// DeleteSubscriber supprime un abonné et ses canaux
+func (m *Manager) DeleteSubscriber(id string) error {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	if _, ok := m.subscribers[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrSubscriberNotFound, id)
+	}
+	if m.subscriberStore != nil {
+		if _, err := m.subscriberStore.DeleteAlertSubscriber(id); err != nil {
+			return fmt.Errorf("failed to delete subscriber: %w", err)
+		}
+	}
+	delete(m.subscribers, id)
+	m.dispatcher.SetSubscriberRoutes(id, nil)
 	return nil
 }
 
+// encodeCursor encode la position de la dernière alerte d'une page
+func encodeCursor(detectedAt time.Time, id string) string {
+	raw := detectedAt.UTC().Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor décode un curseur produit par encodeCursor
+func decodeCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	detectedAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", ErrInvalidCursor
+	}
+	return detectedAt, parts[1], nil
+}
+
+// LoadRules charge les règles enregistrées par l'API; elles remplacent les règles de même nom
+func (m *Manager) LoadRules() error {
+	if m.ruleStore == nil {
+		return nil
+	}
+	stored, err := m.ruleStore.ListAlertRules()
+	if err != nil {
+		return fmt.Errorf("failed to load alert rules: %w", err)
+	}
+	for _, rule := range stored {
+		if _, err := m.rules.Set(rule); err != nil {
+			return fmt.Errorf("stored rule rejected: %w", err)
+		}
+	}
+	m.logger.WithFields(logrus.Fields{
+		"stored": len(stored),
+		"rules":  len(m.rules.Rules()),
+	}).Info("Alert rules loaded")
+	return nil
+}
+
+// Rules retourne les règles d'alerte triées par nom
+func (m *Manager) Rules() []models.AlertRule {
+	return m.rules.Rules()
+}
+
+// GetRule retourne une règle d'alerte
+func (m *Manager) GetRule(name string) (*models.AlertRule, error) {
+	rule := m.rules.Get(name)
+	if rule == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRuleNotFound, name)
+	}
+	return rule, nil
+}
+
+// SaveRule valide, enregistre et active une règle; remplace la règle de même nom
+func (m *Manager) SaveRule(rule models.AlertRule) (*models.AlertRule, error) {
+	if err := ValidateRule(&rule); err != nil {
+		return nil, err
+	}
+	rule.UpdatedAt = time.Now().UTC()
+
+	if m.ruleStore != nil {
+		if err := m.ruleStore.SaveAlertRule(&rule); err != nil {
+			return nil, fmt.Errorf("failed to save alert rule: %w", err)
+		}
+	}
+	saved, err := m.rules.Set(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	m.logger.WithFields(logrus.Fields{
+		"rule":       rule.Name,
+		"expression": rule.Expression,
+		"enabled":    rule.Enabled,
+	}).Info("Alert rule saved")
+	return saved, nil
+}
+
+// DeleteRule supprime une règle d'alerte
+func (m *Manager) DeleteRule(name string) error {
+	if m.ruleStore != nil {
+		if _, err := m.ruleStore.DeleteAlertRule(name); err != nil {
+			return fmt.Errorf("failed to delete alert rule: %w", err)
+		}
+	}
+	if !m.rules.Delete(name) {
+		return fmt.Errorf("%w: %s", ErrRuleNotFound, name)
+	}
+	return nil
+}
+
+// EvaluateRules lève une alerte par type d'alerte dont une règle active est satisfaite par
+// l'état d'un token, et signale la fin de la condition pour les autres types évalués.
+// Retourne les alertes créées ou mises à jour.
+func (m *Manager) EvaluateRules(input RuleInput) ([]*models.TokenAlert, error) {
+	if input.At.IsZero() {
+		input.At = time.Now().UTC()
+	}
+	result := m.rules.Evaluate(input)
+
+	// Plusieurs règles d'un même type partagent une alerte: la plus sévère l'emporte
+	byType := make(map[string]RuleMatch)
+	types := make([]string, 0)
+	for _, match := range result.Matches {
+		current, ok := byType[match.Rule.AlertType]
+		if !ok {
+			types = append(types, match.Rule.AlertType)
+		}
+		if !ok || SeverityRank(match.Rule.Severity) > SeverityRank(current.Rule.Severity) {
+			byType[match.Rule.AlertType] = match
+		}
+	}
+
+	alerts := make([]*models.TokenAlert, 0)
+	for _, alertType := range types {
+		match := byType[alertType]
+		alert, err := m.Raise(AlertSignal{
+			TokenAddress: input.TokenAddress,
+			TokenSymbol:  input.TokenSymbol,
+			AlertType:    alertType,
+			Severity:     match.Rule.Severity,
+			Message:      match.Message,
+			Driver:       match.Driver,
+			Price:        input.Metrics["price"],
+			MarketCap:    input.Metrics["market_cap"],
+			XScore:       input.XScore,
+			State:        input.State,
+			At:           input.At,
+		})
+		if err != nil {
+			return alerts, err
+		}
+		if alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	for _, rule := range result.Cleared {
+		if _, matched := byType[rule.AlertType]; matched {
+			continue
+		}
+		if err := m.Clear(input.TokenAddress, rule.AlertType, input.At); err != nil {
+			return alerts, err
+		}
+	}
+	return alerts, nil
+}
+
+// CreateTokenAlert évalue les règles d'alerte pour un token dont le X-Score vient d'être calculé
+func (m *Manager) CreateTokenAlert(token models.Token, xScore float64, walletAnalysis *models.WalletAnalysis) error {
+	input := RuleInput{
+		TokenAddress: token.Address,
+		TokenSymbol:  token.Symbol,
+		XScore:       xScore,
+		Metrics:      map[string]float64{"holder_count": float64(token.HolderCount)},
+	}
+	if walletAnalysis != nil {
+		input.Wallets = walletAnalysis.Values()
+	}
+
+	_, err := m.EvaluateRules(input)
+	return err
+}
+
 // CreateDumpAlert crée une alerte de dump potentiel
 func (m *Manager) CreateDumpAlert(tokenAddress, tokenSymbol string, severity float64) error {
 	severityText := "LOW"
@@ -118,24 +1136,26 @@ func (m *Manager) CreateDumpAlert(tokenAddress, tokenSymbol string, severity flo
 		severityText = "MEDIUM"
 	}
 
-	_, err := m.CreateAlert(
-		tokenAddress,
-		tokenSymbol,
-		"DUMP_DETECTED",
-		severityText,
-		fmt.Sprintf("Potential dump detected for %s (severity: %.1f)", tokenSymbol, severity),
-	)
+	_, err := m.Raise(AlertSignal{
+		TokenAddress: tokenAddress,
+		TokenSymbol:  tokenSymbol,
+		AlertType:    "DUMP_DETECTED",
+		Severity:     severityText,
+		Message:      fmt.Sprintf("Potential dump detected for %s (severity: %.1f)", tokenSymbol, severity),
+		Driver:       severity,
+	})
 	return err
 }
 
 // CreateReactivationAlert crée une alerte de réactivation de token
 func (m *Manager) CreateReactivationAlert(tokenAddress, tokenSymbol string, reactivationScore float64) error {
-	_, err := m.CreateAlert(
-		tokenAddress,
-		tokenSymbol,
-		"REACTIVATION",
-		"ALERT",
-		fmt.Sprintf("Token %s is reactivating with score %.1f", tokenSymbol, reactivationScore),
-	)
+	_, err := m.Raise(AlertSignal{
+		TokenAddress: tokenAddress,
+		TokenSymbol:  tokenSymbol,
+		AlertType:    "REACTIVATION",
+		Severity:     "ALERT",
+		Message:      fmt.Sprintf("Token %s is reactivating with score %.1f", tokenSymbol, reactivationScore),
+		Driver:       reactivationScore,
+	})
 	return err
-} 
\ No newline at end of file
+}
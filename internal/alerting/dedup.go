@@ -0,0 +1,184 @@
+package alerting
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+)
+
+// Statuts d'une alerte
+const (
+	AlertStatusOpen     = "OPEN"
+	AlertStatusResolved = "RESOLVED"
+)
+
+// Transitions enregistrées dans l'historique d'une alerte
+const (
+	TransitionOpened    = "OPENED"
+	TransitionEscalated = "ESCALATED"
+	TransitionResolved  = "RESOLVED"
+)
+
+// escalationLadder est l'ordre d'escalade des alertes ouvertes; une alerte
+// de sévérité inférieure à ALERT monte d'abord à ALERT
+var escalationLadder = []string{"ALERT", "URGENT", "CRITICAL"}
+
+// DedupPolicy règle la déduplication, l'escalade et la résolution automatique des alertes
+type DedupPolicy struct {
+	// Cooldown est le délai après la résolution d'une alerte pendant lequel
+	// une nouvelle alerte de même clé est ignorée
+	Cooldown  time.Duration
+	Cooldowns map[string]time.Duration // Délais par type d'alerte, prioritaires sur Cooldown
+	// EscalationStep est l'aggravation relative de la grandeur pilote, depuis l'ouverture
+	// ou la dernière escalade, qui fait monter l'alerte d'un niveau (0.1 = +10%)
+	EscalationStep float64
+	// ResolveAfter est le nombre d'évaluations consécutives où la condition est fausse
+	// avant la résolution automatique
+	ResolveAfter int
+	// StaleAfter résout les alertes qui ne sont plus observées depuis cette durée, 0 = jamais
+	StaleAfter time.Duration
+}
+
+// DefaultDedupPolicy est la politique utilisée sans configuration
+var DefaultDedupPolicy = DedupPolicy{
+	Cooldown:       30 * time.Minute,
+	EscalationStep: 0.1,
+	ResolveAfter:   2,
+	StaleAfter:     6 * time.Hour,
+}
+
+// DedupPolicyFromConfig construit la politique de déduplication depuis la configuration
+func DedupPolicyFromConfig(cfg *config.AlertsConfig) DedupPolicy {
+	policy := DefaultDedupPolicy
+	if cfg == nil {
+		return policy
+	}
+	if cfg.CooldownMs > 0 {
+		policy.Cooldown = time.Duration(cfg.CooldownMs) * time.Millisecond
+	}
+	if len(cfg.Cooldowns) > 0 {
+		policy.Cooldowns = make(map[string]time.Duration, len(cfg.Cooldowns))
+		for alertType, ms := range cfg.Cooldowns {
+			policy.Cooldowns[strings.ToUpper(alertType)] = time.Duration(ms) * time.Millisecond
+		}
+	}
+	if cfg.EscalationStep > 0 {
+		policy.EscalationStep = cfg.EscalationStep
+	}
+	if cfg.ResolveAfter > 0 {
+		policy.ResolveAfter = cfg.ResolveAfter
+	}
+	if cfg.StaleAfterMs > 0 {
+		policy.StaleAfter = time.Duration(cfg.StaleAfterMs) * time.Millisecond
+	}
+	return policy
+}
+
+// cooldown retourne le délai de refroidissement d'un type d'alerte
+func (p DedupPolicy) cooldown(alertType string) time.Duration {
+	if d, ok := p.Cooldowns[strings.ToUpper(alertType)]; ok {
+		return d
+	}
+	return p.Cooldown
+}
+
+// worsened indique si la grandeur pilote s'est assez aggravée depuis base pour escalader
+func (p DedupPolicy) worsened(base, value float64) bool {
+	if math.IsNaN(base) || math.IsNaN(value) {
+		return false
+	}
+	return value-base >= p.EscalationStep*math.Max(math.Abs(base), 1e-9)
+}
+
+// AlertSignal est une condition d'alerte observée sur un token
+type AlertSignal struct {
+	TokenAddress   string
+	TokenSymbol    string
+	AlertType      string
+	Severity       string
+	Message        string
+	Driver         float64 // Grandeur pilote, plus grande = plus grave; NaN si inconnue
+	Price          float64 // Prix du token au signal, 0 si inconnu
+	MarketCap      float64
+	XScore         float64 // X-Score du token, 0 si inconnu
+	State          string  // État du cycle de vie du token, vide si inconnu
+	RelatedWallets []string
+	At             time.Time
+}
+
+// DedupKey est la clé de déduplication d'une alerte: une seule alerte ouverte par (token, type)
+func DedupKey(tokenAddress, alertType string) string {
+	return tokenAddress + ":" + strings.ToUpper(alertType)
+}
+
+// openAlert est une alerte ouverte suivie par le Manager
+type openAlert struct {
+	alert   models.TokenAlert
+	base    float64                   // Grandeur pilote à l'ouverture ou à la dernière escalade
+	clears  int                       // Évaluations consécutives où la condition est fausse
+	pending []models.AlertStateChange // Étapes de l'historique pas encore écrites
+}
+
+// queueChange ajoute une étape à l'historique de l'alerte, écrite par la prochaine écriture
+func (o *openAlert) queueChange(transition, reason string, at time.Time) {
+	o.pending = append(o.pending, models.AlertStateChange{
+		AlertID:     o.alert.ID,
+		Transition:  transition,
+		Status:      o.alert.Status,
+		Severity:    o.alert.Severity,
+		DriverValue: o.alert.DriverValue,
+		Reason:      reason,
+		ChangedAt:   at,
+	})
+}
+
+// dropChanges retire les étapes en attente d'une transition
+func (o *openAlert) dropChanges(transition string) {
+	kept := o.pending[:0]
+	for _, change := range o.pending {
+		if change.Transition != transition {
+			kept = append(kept, change)
+		}
+	}
+	o.pending = kept
+}
+
+// SeverityRank retourne le rang d'une sévérité dans Severities, -1 si elle est inconnue
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if strings.EqualFold(s, severity) {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextSeverity retourne le niveau d'escalade suivant, ou la sévérité elle-même au sommet
+func nextSeverity(severity string) string {
+	rank := SeverityRank(severity)
+	for _, s := range escalationLadder {
+		if SeverityRank(s) > rank {
+			return s
+		}
+	}
+	return severity
+}
+
+// driverPtr convertit une grandeur pilote pour l'alerte; nil si elle est inconnue
+func driverPtr(value float64) *float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil
+	}
+	return &value
+}
+
+// driverOrNaN lit la grandeur pilote d'une alerte enregistrée
+func driverOrNaN(value *float64) float64 {
+	if value == nil {
+		return math.NaN()
+	}
+	return *value
+}
@@ -0,0 +1,171 @@
+package alerting
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+func TestManagerDeduplicatesEscalatesAndResolves(t *testing.T) {
+	m := NewManager(testLogger())
+	m.SetDedupPolicy(DedupPolicy{Cooldown: 10 * time.Minute, EscalationStep: 0.1, ResolveAfter: 2})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	evaluate := func(at time.Duration, xscore float64) {
+		t.Helper()
+		if _, err := m.EvaluateRules(RuleInput{TokenAddress: "tok", TokenSymbol: "TOK", XScore: xscore, At: start.Add(at)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openAlerts := func() []models.TokenAlert {
+		t.Helper()
+		page, err := m.QueryAlerts(models.AlertFilter{Status: AlertStatusOpen}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page.Alerts
+	}
+
+	evaluate(0, 82)
+	evaluate(time.Minute, 84) // +2.4%: même alerte, pas d'escalade
+	open := openAlerts()
+	if len(open) != 1 {
+		t.Fatalf("got %d open alerts, want 1: %v", len(open), open)
+	}
+	first := open[0]
+	if first.Severity != "URGENT" || first.OccurrenceCount != 2 {
+		t.Fatalf("unexpected alert: %+v", first)
+	}
+
+	evaluate(2*time.Minute, 91) // +11% depuis l'ouverture
+	alert, _ := m.GetAlert(first.ID)
+	if alert.Severity != "CRITICAL" {
+		t.Fatalf("alert not escalated: %+v", alert)
+	}
+
+	evaluate(3*time.Minute, 70)
+	if alert, _ = m.GetAlert(first.ID); alert.Status != AlertStatusOpen {
+		t.Fatalf("alert resolved after a single clear")
+	}
+	evaluate(4*time.Minute, 70)
+	alert, _ = m.GetAlert(first.ID)
+	if alert.Status != AlertStatusResolved || alert.ResolvedAt == nil {
+		t.Fatalf("alert not resolved: %+v", alert)
+	}
+
+	transitions := make([]string, 0)
+	for _, change := range alert.History {
+		transitions = append(transitions, change.Transition+"/"+change.Severity)
+	}
+	want := []string{"OPENED/URGENT", "ESCALATED/CRITICAL", "RESOLVED/CRITICAL"}
+	if len(transitions) != len(want) {
+		t.Fatalf("history %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("history %v, want %v", transitions, want)
+		}
+	}
+
+	evaluate(5*time.Minute, 85) // Pendant le refroidissement
+	if open := openAlerts(); len(open) != 0 {
+		t.Fatalf("alert reopened during cooldown: %v", open)
+	}
+	evaluate(20*time.Minute, 85)
+	if open := openAlerts(); len(open) != 1 || open[0].ID == first.ID {
+		t.Fatalf("alert not reopened after cooldown: %v", open)
+	}
+}
+
+// gatedStore bloque les écritures des alertes d'un token et peut refuser toutes les écritures
+type gatedStore struct {
+	*MemoryStore
+	token   string
+	entered chan struct{}
+	release chan struct{}
+	fail    bool
+}
+
+func (s *gatedStore) SaveTokenAlert(alert *models.TokenAlert) error {
+	if s.fail {
+		return errors.New("database unreachable")
+	}
+	if alert.TokenAddress == s.token {
+		s.entered <- struct{}{}
+		<-s.release
+	}
+	return s.MemoryStore.SaveTokenAlert(alert)
+}
+
+func TestManagerPersistsOutsideLock(t *testing.T) {
+	store := &gatedStore{MemoryStore: NewMemoryStore(), token: "slow", entered: make(chan struct{}), release: make(chan struct{})}
+	m := NewManager(testLogger())
+	m.SetStore(store)
+
+	done := make(chan error)
+	go func() {
+		_, err := m.CreateAlert("slow", "SLOW", "HIGH_SCORE", "HIGH", "slow write")
+		done <- err
+	}()
+	<-store.entered
+
+	// L'écriture bloquée d'une alerte ne retient pas celles des autres tokens
+	if alert, err := m.CreateAlert("fast", "FAST", "HIGH_SCORE", "HIGH", "fast write"); err != nil || alert == nil {
+		t.Fatalf("raise while another alert is being saved: %v, %v", alert, err)
+	}
+	close(store.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerRetriesFailedResolveAndPrunesCooldowns(t *testing.T) {
+	store := &gatedStore{MemoryStore: NewMemoryStore()}
+	m := NewManager(testLogger())
+	m.SetStore(store)
+	m.SetDedupPolicy(DedupPolicy{Cooldown: 10 * time.Minute, ResolveAfter: 1, StaleAfter: time.Hour})
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	alert, err := m.Raise(AlertSignal{TokenAddress: "tok", AlertType: "HIGH_SCORE", Severity: "HIGH", Driver: math.NaN(), At: start})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Une résolution non enregistrée laisse l'alerte ouverte
+	store.fail = true
+	if expired, err := m.ExpireAlerts(start.Add(2 * time.Hour)); err == nil || expired != 0 {
+		t.Fatalf("expire with failing store: %d, %v", expired, err)
+	}
+	store.fail = false
+	if expired, err := m.ExpireAlerts(start.Add(2 * time.Hour)); err != nil || expired != 1 {
+		t.Fatalf("expire retry: %d, %v", expired, err)
+	}
+	stored, _ := m.GetAlert(alert.ID)
+	if stored.Status != AlertStatusResolved || len(stored.History) != 2 || stored.History[1].Transition != TransitionResolved {
+		t.Fatalf("resolved alert %+v", stored)
+	}
+
+	// La résolution est oubliée une fois le refroidissement écoulé
+	m.ExpireAlerts(start.Add(2*time.Hour + 5*time.Minute))
+	if len(m.resolved) != 1 {
+		t.Fatalf("resolution pruned during cooldown: %v", m.resolved)
+	}
+	m.ExpireAlerts(start.Add(2*time.Hour + 10*time.Minute))
+	if len(m.resolved) != 0 {
+		t.Fatalf("resolution kept after cooldown: %v", m.resolved)
+	}
+}
+
+func TestAlertIDsAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := newAlertID()
+		if seen[id] {
+			t.Fatalf("duplicate alert ID %s", id)
+		}
+		seen[id] = true
+	}
+}
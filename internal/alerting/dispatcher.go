@@ -0,0 +1,510 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Statuts d'un envoi
+const (
+	DeliveryPending   = "pending"
+	DeliveryRetrying  = "retrying"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Route associe un canal aux alertes qu'il reçoit. Une liste vide accepte tout.
+type Route struct {
+	Notifier   Notifier
+	Severities []string
+	AlertTypes []string
+	Subscriber string                       // Abonné propriétaire du canal, vide pour les canaux de la configuration
+	Accept     func(models.TokenAlert) bool // Filtre supplémentaire, nil = aucun
+}
+
+// Matches indique si l'alerte doit être envoyée sur le canal de la route
+func (r Route) Matches(alert models.TokenAlert) bool {
+	return containsFold(r.Severities, alert.Severity) && containsFold(r.AlertTypes, alert.AlertType) &&
+		(r.Accept == nil || r.Accept(alert))
+}
+
+// containsFold indique si value figure dans values, sans tenir compte de la casse; vrai si values est vide
+func containsFold(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeliveryPolicy règle la distribution des alertes
+type DeliveryPolicy struct {
+	Workers     int           // Envois simultanés
+	QueueSize   int           // Envois en attente au-delà desquels les alertes sont refusées
+	MaxAttempts int           // Tentatives par envoi
+	BaseDelay   time.Duration // Délai avant la deuxième tentative, doublé ensuite
+	MaxDelay    time.Duration
+	Timeout     time.Duration // Durée maximale d'une tentative
+	LogSize     int           // Envois conservés dans le journal
+}
+
+// DefaultDeliveryPolicy est la politique de distribution par défaut
+var DefaultDeliveryPolicy = DeliveryPolicy{
+	Workers:     4,
+	QueueSize:   1000,
+	MaxAttempts: 5,
+	BaseDelay:   2 * time.Second,
+	MaxDelay:    time.Minute,
+	Timeout:     10 * time.Second,
+	LogSize:     1000,
+}
+
+// DeliveryPolicyFromConfig construit la politique de distribution, les valeurs absentes gardant leur défaut
+func DeliveryPolicyFromConfig(cfg *config.AlertsConfig) DeliveryPolicy {
+	policy := DefaultDeliveryPolicy
+	if cfg == nil {
+		return policy
+	}
+	if cfg.DeliveryWorkers > 0 {
+		policy.Workers = cfg.DeliveryWorkers
+	}
+	if cfg.DeliveryMaxAttempts > 0 {
+		policy.MaxAttempts = cfg.DeliveryMaxAttempts
+	}
+	if cfg.DeliveryRetryBaseDelayMs > 0 {
+		policy.BaseDelay = time.Duration(cfg.DeliveryRetryBaseDelayMs) * time.Millisecond
+	}
+	if cfg.DeliveryTimeoutMs > 0 {
+		policy.Timeout = time.Duration(cfg.DeliveryTimeoutMs) * time.Millisecond
+	}
+	return policy
+}
+
+// backoff retourne le délai avant la tentative suivant attempt
+func (p DeliveryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// DeliveryRecord est l'état de l'envoi d'une alerte sur un canal
+type DeliveryRecord struct {
+	ID          string     `json:"id"`
+	AlertID     string     `json:"alert_id,omitempty"`
+	ReportID    string     `json:"report_id,omitempty"` // Rapport périodique envoyé à la place d'une alerte
+	Channel     string     `json:"channel"`
+	Subscriber  string     `json:"subscriber,omitempty"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+}
+
+// DeliveryLog conserve les derniers envois, les plus anciens étant oubliés au-delà de sa capacité
+type DeliveryLog struct {
+	mu       sync.RWMutex
+	capacity int
+	records  map[string]*DeliveryRecord
+	order    []string
+}
+
+// NewDeliveryLog crée un journal des envois
+func NewDeliveryLog(capacity int) *DeliveryLog {
+	if capacity <= 0 {
+		capacity = DefaultDeliveryPolicy.LogSize
+	}
+	return &DeliveryLog{
+		capacity: capacity,
+		records:  make(map[string]*DeliveryRecord),
+		order:    make([]string, 0, capacity),
+	}
+}
+
+// add ajoute un envoi au journal
+func (l *DeliveryLog) add(record DeliveryRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.order) >= l.capacity {
+		delete(l.records, l.order[0])
+		l.order = l.order[1:]
+	}
+	l.records[record.ID] = &record
+	l.order = append(l.order, record.ID)
+}
+
+// update modifie un envoi s'il est encore dans le journal
+func (l *DeliveryLog) update(id string, change func(record *DeliveryRecord)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if record, ok := l.records[id]; ok {
+		change(record)
+		record.UpdatedAt = time.Now().UTC()
+	}
+}
+
+// ForAlert retourne les envois d'une alerte
+func (l *DeliveryLog) ForAlert(alertID string) []DeliveryRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	records := make([]DeliveryRecord, 0)
+	for _, id := range l.order {
+		if record := l.records[id]; record.AlertID == alertID {
+			records = append(records, *record)
+		}
+	}
+	return records
+}
+
+// ForReport retourne les envois d'un rapport
+func (l *DeliveryLog) ForReport(reportID string) []DeliveryRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	records := make([]DeliveryRecord, 0)
+	for _, id := range l.order {
+		if record := l.records[id]; record.ReportID == reportID {
+			records = append(records, *record)
+		}
+	}
+	return records
+}
+
+// Recent retourne au plus limit envois, du plus récent au plus ancien, filtrés par statut si status n'est pas vide
+func (l *DeliveryLog) Recent(status string, limit int) []DeliveryRecord {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	records := make([]DeliveryRecord, 0)
+	for i := len(l.order) - 1; i >= 0 && (limit <= 0 || len(records) < limit); i-- {
+		record := l.records[l.order[i]]
+		if status == "" || record.Status == status {
+			records = append(records, *record)
+		}
+	}
+	return records
+}
+
+// delivery est un envoi en attente, d'une alerte ou d'un rapport
+type delivery struct {
+	id       string
+	alert    models.TokenAlert
+	report   *Report
+	notifier Notifier
+}
+
+// send envoie l'alerte ou le rapport sur le canal
+func (item delivery) send(ctx context.Context) error {
+	if item.report != nil {
+		return item.notifier.(ReportNotifier).NotifyReport(ctx, *item.report)
+	}
+	return item.notifier.Notify(ctx, item.alert)
+}
+
+// fields identifie l'envoi dans les logs
+func (item delivery) fields() logrus.Fields {
+	if item.report != nil {
+		return logrus.Fields{"report_id": item.report.ID, "channel": item.notifier.Name()}
+	}
+	return logrus.Fields{"alert_id": item.alert.ID, "channel": item.notifier.Name()}
+}
+
+// Dispatcher distribue les alertes sur les canaux de manière asynchrone,
+// avec nouvelles tentatives espacées exponentiellement
+type Dispatcher struct {
+	logger *logrus.Logger
+	policy DeliveryPolicy
+	log    *DeliveryLog
+
+	mu      sync.RWMutex
+	routes  []Route
+	closed  bool
+	started bool
+
+	queue  chan delivery
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	seq    uint64
+}
+
+// NewDispatcher crée un distributeur sans canal
+func NewDispatcher(logger *logrus.Logger, policy DeliveryPolicy) *Dispatcher {
+	if policy.Workers <= 0 {
+		policy.Workers = DefaultDeliveryPolicy.Workers
+	}
+	if policy.QueueSize <= 0 {
+		policy.QueueSize = DefaultDeliveryPolicy.QueueSize
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		logger: logger,
+		policy: policy,
+		log:    NewDeliveryLog(policy.LogSize),
+		routes: make([]Route, 0),
+		queue:  make(chan delivery, policy.QueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// AddRoute ajoute un canal
+func (d *Dispatcher) AddRoute(route Route) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.routes = append(d.routes, route)
+}
+
+// SetSubscriberRoutes remplace les canaux d'un abonné; sans route, l'abonné ne reçoit plus rien
+func (d *Dispatcher) SetSubscriberRoutes(subscriber string, routes []Route) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	kept := make([]Route, 0, len(d.routes)+len(routes))
+	for _, route := range d.routes {
+		if route.Subscriber != subscriber {
+			kept = append(kept, route)
+		}
+	}
+	for _, route := range routes {
+		route.Subscriber = subscriber
+		kept = append(kept, route)
+	}
+	d.routes = kept
+}
+
+// Routes retourne les canaux configurés
+func (d *Dispatcher) Routes() []Route {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]Route(nil), d.routes...)
+}
+
+// Log retourne le journal des envois
+func (d *Dispatcher) Log() *DeliveryLog {
+	return d.log
+}
+
+// Dispatch met en file l'envoi de l'alerte sur chaque canal dont la route l'accepte
+// et retourne le nombre d'envois programmés. N'attend pas les envois.
+func (d *Dispatcher) Dispatch(alert models.TokenAlert) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	queued := 0
+	for _, route := range d.routes {
+		if !route.Matches(alert) {
+			continue
+		}
+
+		now := time.Now().UTC()
+		record := DeliveryRecord{
+			ID:         fmt.Sprintf("dlv_%d_%d", now.UnixNano(), atomic.AddUint64(&d.seq, 1)),
+			AlertID:    alert.ID,
+			Channel:    route.Notifier.Name(),
+			Subscriber: route.Subscriber,
+			Status:     DeliveryPending,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+
+		if d.closed {
+			record.Status = DeliveryFailed
+			record.LastError = "dispatcher stopped"
+			d.log.add(record)
+			continue
+		}
+
+		d.log.add(record)
+		if d.enqueue(delivery{id: record.ID, alert: alert, notifier: route.Notifier}) {
+			queued++
+		}
+	}
+	return queued
+}
+
+// DispatchReport met en file l'envoi d'un rapport sur les canaux de la configuration capables
+// de l'envoyer, limités à channels si la liste n'est pas vide. Les canaux des abonnés ne
+// reçoivent pas les rapports. Retourne le nombre d'envois programmés.
+func (d *Dispatcher) DispatchReport(report Report, channels []string) int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	queued := 0
+	for _, route := range d.routes {
+		if route.Subscriber != "" || !containsFold(channels, route.Notifier.Name()) {
+			continue
+		}
+		if _, ok := route.Notifier.(ReportNotifier); !ok {
+			continue
+		}
+
+		now := time.Now().UTC()
+		record := DeliveryRecord{
+			ID:        fmt.Sprintf("dlv_%d_%d", now.UnixNano(), atomic.AddUint64(&d.seq, 1)),
+			ReportID:  report.ID,
+			Channel:   route.Notifier.Name(),
+			Status:    DeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+
+		if d.closed {
+			record.Status = DeliveryFailed
+			record.LastError = "dispatcher stopped"
+			d.log.add(record)
+			continue
+		}
+
+		d.log.add(record)
+		if d.enqueue(delivery{id: record.ID, report: &report, notifier: route.Notifier}) {
+			queued++
+		}
+	}
+	return queued
+}
+
+// enqueue ajoute un envoi à la file, ou le marque en échec si la file est pleine
+func (d *Dispatcher) enqueue(item delivery) bool {
+	select {
+	case d.queue <- item:
+		return true
+	default:
+		d.fail(item, "delivery queue full")
+		return false
+	}
+}
+
+// Start lance les envois
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.started {
+		return
+	}
+	d.started = true
+
+	for i := 0; i < d.policy.Workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// Stop refuse les nouvelles alertes et attend les envois en file jusqu'à l'échéance de ctx;
+// les envois restants sont alors abandonnés
+func (d *Dispatcher) Stop(ctx context.Context) {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.queue)
+	started := d.started
+	d.mu.Unlock()
+
+	if !started {
+		d.cancel()
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.logger.Warn("Alert deliveries still in progress at shutdown, abandoning them")
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+// work envoie les alertes de la file
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+	for item := range d.queue {
+		d.deliver(item)
+	}
+}
+
+// deliver envoie une alerte ou un rapport sur un canal avec nouvelles tentatives
+func (d *Dispatcher) deliver(item delivery) {
+	for attempt := 1; ; attempt++ {
+		if d.ctx.Err() != nil {
+			d.fail(item, "dispatcher stopped")
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(d.ctx, d.policy.Timeout)
+		err := item.send(ctx)
+		cancel()
+
+		if err == nil {
+			delivered := time.Now().UTC()
+			d.log.update(item.id, func(record *DeliveryRecord) {
+				record.Status = DeliveryDelivered
+				record.Attempts = attempt
+				record.LastError = ""
+				record.DeliveredAt = &delivered
+			})
+			d.logger.WithFields(item.fields()).WithField("attempts", attempt).Debug("Alert delivered")
+			return
+		}
+
+		if attempt >= d.policy.MaxAttempts || !isRetryable(err) {
+			d.log.update(item.id, func(record *DeliveryRecord) { record.Attempts = attempt })
+			d.fail(item, err.Error())
+			return
+		}
+
+		d.log.update(item.id, func(record *DeliveryRecord) {
+			record.Status = DeliveryRetrying
+			record.Attempts = attempt
+			record.LastError = err.Error()
+		})
+
+		select {
+		case <-d.ctx.Done():
+		case <-time.After(d.policy.backoff(attempt)):
+		}
+	}
+}
+
+// fail marque un envoi en échec
+func (d *Dispatcher) fail(item delivery, reason string) {
+	d.log.update(item.id, func(record *DeliveryRecord) {
+		record.Status = DeliveryFailed
+		record.LastError = reason
+	})
+	d.logger.WithFields(item.fields()).WithField("error", reason).Error("Alert delivery failed")
+}
@@ -0,0 +1,606 @@
+package alerting
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Langage des règles d'alerte.
+//
+// Une expression combine des champs (xscore, holder_count, components.trust_factor,
+// wallets.smart_money_ratio, state...), des littéraux (nombres, chaînes entre
+// guillemets, true/false, durées 30s/15m/1h/2d), les opérateurs || && ! == != < <= > >=
+// + - * / et les fonctions:
+//
+//	delta(champ, durée)  variation relative depuis le dernier relevé d'au moins durée (0.2 = +20%)
+//	diff(champ, durée)   variation absolue depuis ce même relevé
+//	prev(champ)          valeur au relevé précédent
+//	abs(x), min(x, y), max(x, y)
+//
+// Une valeur inconnue (métrique absente, pas de relevé assez ancien) vaut NaN:
+// toute comparaison avec elle est fausse.
+
+// exprType est le type statique d'une expression
+type exprType int
+
+const (
+	typeNumber exprType = iota
+	typeBool
+	typeString
+	typeDuration
+)
+
+func (t exprType) String() string {
+	switch t {
+	case typeNumber:
+		return "number"
+	case typeBool:
+		return "bool"
+	case typeString:
+		return "string"
+	}
+	return "duration"
+}
+
+// exprEnv fournit les valeurs des champs à l'évaluation
+type exprEnv interface {
+	// value retourne la valeur courante d'un champ (float64, string ou bool)
+	value(field string) interface{}
+	// past retourne la valeur numérique d'un champ au dernier relevé datant d'au moins window
+	past(field string, window time.Duration) (float64, bool)
+	// previous retourne la valeur numérique d'un champ au relevé précédent
+	previous(field string) (float64, bool)
+}
+
+// exprNode est un nœud typé de l'arbre d'une expression
+type exprNode interface {
+	typ() exprType
+	eval(env exprEnv) interface{}
+}
+
+// literal est une constante
+type literal struct {
+	t exprType
+	v interface{}
+}
+
+func (n *literal) typ() exprType                { return n.t }
+func (n *literal) eval(env exprEnv) interface{} { return n.v }
+
+// fieldRef est la lecture d'un champ
+type fieldRef struct {
+	name string
+	t    exprType
+}
+
+func (n *fieldRef) typ() exprType                { return n.t }
+func (n *fieldRef) eval(env exprEnv) interface{} { return env.value(n.name) }
+
+// unaryExpr est une négation logique ou arithmétique
+type unaryExpr struct {
+	op string
+	x  exprNode
+}
+
+func (n *unaryExpr) typ() exprType { return n.x.typ() }
+
+func (n *unaryExpr) eval(env exprEnv) interface{} {
+	if n.op == "!" {
+		return !n.x.eval(env).(bool)
+	}
+	return -n.x.eval(env).(float64)
+}
+
+// binaryExpr est une opération à deux opérandes
+type binaryExpr struct {
+	op   string
+	l, r exprNode
+	t    exprType
+}
+
+func (n *binaryExpr) typ() exprType { return n.t }
+
+func (n *binaryExpr) eval(env exprEnv) interface{} {
+	switch n.op {
+	case "&&":
+		return n.l.eval(env).(bool) && n.r.eval(env).(bool)
+	case "||":
+		return n.l.eval(env).(bool) || n.r.eval(env).(bool)
+	}
+
+	l, r := n.l.eval(env), n.r.eval(env)
+	switch n.op {
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	}
+
+	if n.l.typ() == typeString {
+		a, b := l.(string), r.(string)
+		switch n.op {
+		case "<":
+			return a < b
+		case "<=":
+			return a <= b
+		case ">":
+			return a > b
+		}
+		return a >= b
+	}
+
+	a, b := l.(float64), r.(float64)
+	switch n.op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "+":
+		return a + b
+	case "-":
+		return a - b
+	case "*":
+		return a * b
+	}
+	if b == 0 {
+		return math.NaN()
+	}
+	return a / b
+}
+
+// callExpr est un appel de fonction
+type callExpr struct {
+	fn     string
+	field  string        // Champ des fonctions d'historique
+	window time.Duration // Fenêtre de delta et diff
+	args   []exprNode
+}
+
+func (n *callExpr) typ() exprType { return typeNumber }
+
+func (n *callExpr) eval(env exprEnv) interface{} {
+	switch n.fn {
+	case "delta", "diff":
+		past, ok := env.past(n.field, n.window)
+		current, _ := env.value(n.field).(float64)
+		if !ok {
+			return math.NaN()
+		}
+		if n.fn == "diff" {
+			return current - past
+		}
+		if past == 0 {
+			return math.NaN()
+		}
+		return (current - past) / math.Abs(past)
+	case "prev":
+		previous, ok := env.previous(n.field)
+		if !ok {
+			return math.NaN()
+		}
+		return previous
+	case "abs":
+		return math.Abs(n.args[0].eval(env).(float64))
+	case "min":
+		return math.Min(n.args[0].eval(env).(float64), n.args[1].eval(env).(float64))
+	}
+	return math.Max(n.args[0].eval(env).(float64), n.args[1].eval(env).(float64))
+}
+
+// Expression est une expression de règle compilée et typée
+type Expression struct {
+	source  string
+	root    exprNode
+	driver  exprNode                 // Grandeur dont la hausse aggrave la condition, nil si aucune
+	history map[string]time.Duration // Champs lus dans l'historique et fenêtre maximale
+}
+
+// CompileExpression analyse une expression booléenne; fields donne le type des champs connus
+func CompileExpression(source string, fields func(name string) (exprType, bool)) (*Expression, error) {
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, fields: fields, history: make(map[string]time.Duration)}
+	root, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, fmt.Errorf("position %d: unexpected %q", tok.pos, tok.text)
+	}
+	if root.typ() != typeBool {
+		return nil, fmt.Errorf("expression must be a condition, got %s", root.typ())
+	}
+
+	return &Expression{source: source, root: root, driver: driverOf(root), history: p.history}, nil
+}
+
+// String retourne le texte de l'expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// eval évalue l'expression
+func (e *Expression) eval(env exprEnv) bool {
+	return e.root.eval(env).(bool)
+}
+
+// driverValue évalue la grandeur qui pilote la condition; NaN si elle est inconnue
+func (e *Expression) driverValue(env exprEnv) float64 {
+	if e.driver == nil {
+		return math.NaN()
+	}
+	return e.driver.eval(env).(float64)
+}
+
+// driverOf retourne le membre gauche de la première comparaison numérique d'une condition,
+// opposé pour < et <= afin qu'une valeur plus grande soit toujours plus grave
+func driverOf(n exprNode) exprNode {
+	b, ok := n.(*binaryExpr)
+	if !ok {
+		return nil
+	}
+	switch b.op {
+	case "&&", "||":
+		if d := driverOf(b.l); d != nil {
+			return d
+		}
+		return driverOf(b.r)
+	case ">", ">=":
+		if b.l.typ() == typeNumber {
+			return b.l
+		}
+	case "<", "<=":
+		if b.l.typ() == typeNumber {
+			return &unaryExpr{op: "-", x: b.l}
+		}
+	}
+	return nil
+}
+
+// Types de lexèmes
+const (
+	tokEOF = iota
+	tokNumber
+	tokDuration
+	tokString
+	tokIdent
+	tokOp
+)
+
+type exprToken struct {
+	kind int
+	text string
+	pos  int
+	num  float64
+	dur  time.Duration
+}
+
+// exprOperators sont les opérateurs et la ponctuation du langage
+var exprOperators = map[string]bool{
+	"&&": true, "||": true, "==": true, "!=": true, "<=": true, ">=": true, "<": true, ">": true,
+	"!": true, "+": true, "-": true, "*": true, "/": true, "(": true, ")": true, ",": true,
+}
+
+// lexExpression découpe une expression en lexèmes
+func lexExpression(source string) ([]exprToken, error) {
+	tokens := make([]exprToken, 0)
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			num, err := strconv.ParseFloat(string(runes[start:i]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("position %d: invalid number %q", start, string(runes[start:i]))
+			}
+			// Suffixe d'unité: durée
+			unitStart := i
+			for i < len(runes) && unicode.IsLetter(runes[i]) {
+				i++
+			}
+			if unitStart == i {
+				tokens = append(tokens, exprToken{kind: tokNumber, text: string(runes[start:i]), pos: start, num: num})
+				continue
+			}
+			unit := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour, "d": 24 * time.Hour}[string(runes[unitStart:i])]
+			if unit == 0 {
+				return nil, fmt.Errorf("position %d: invalid duration %q (units: s, m, h, d)", start, string(runes[start:i]))
+			}
+			tokens = append(tokens, exprToken{kind: tokDuration, text: string(runes[start:i]), pos: start, dur: time.Duration(num * float64(unit))})
+
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: string(runes[start:i]), pos: start})
+
+		case c == '"' || c == '\'':
+			start := i
+			i++
+			var b strings.Builder
+			for i < len(runes) && runes[i] != c {
+				b.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("position %d: unterminated string", start)
+			}
+			i++
+			tokens = append(tokens, exprToken{kind: tokString, text: b.String(), pos: start})
+
+		default:
+			start := i
+			op := string(c)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					op = two
+				}
+			}
+			if !exprOperators[op] {
+				return nil, fmt.Errorf("position %d: unexpected character %q", start, string(c))
+			}
+			i += len(op)
+			tokens = append(tokens, exprToken{kind: tokOp, text: op, pos: start})
+		}
+	}
+
+	return append(tokens, exprToken{kind: tokEOF, text: "end of expression", pos: len(runes)}), nil
+}
+
+// binaryPrecedence donne la priorité des opérateurs binaires, 0 si le lexème n'en est pas un
+func binaryPrecedence(tok exprToken) int {
+	if tok.kind != tokOp {
+		return 0
+	}
+	switch tok.text {
+	case "||":
+		return 1
+	case "&&":
+		return 2
+	case "==", "!=":
+		return 3
+	case "<", "<=", ">", ">=":
+		return 4
+	case "+", "-":
+		return 5
+	case "*", "/":
+		return 6
+	}
+	return 0
+}
+
+// exprParser est un analyseur à précédence d'opérateurs
+type exprParser struct {
+	tokens  []exprToken
+	pos     int
+	fields  func(name string) (exprType, bool)
+	history map[string]time.Duration
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) expect(op string) error {
+	if tok := p.next(); tok.kind != tokOp || tok.text != op {
+		return fmt.Errorf("position %d: expected %q, got %q", tok.pos, op, tok.text)
+	}
+	return nil
+}
+
+// parseBinary analyse les opérations de priorité au moins minPrec
+func (p *exprParser) parseBinary(minPrec int) (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		prec := binaryPrecedence(tok)
+		if prec == 0 || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parseBinary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		if left, err = newBinary(tok, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// newBinary vérifie le type des opérandes d'une opération binaire
+func newBinary(tok exprToken, l, r exprNode) (exprNode, error) {
+	mismatch := func(want string) error {
+		return fmt.Errorf("position %d: %q needs %s operands, got %s and %s", tok.pos, tok.text, want, l.typ(), r.typ())
+	}
+
+	switch tok.text {
+	case "&&", "||":
+		if l.typ() != typeBool || r.typ() != typeBool {
+			return nil, mismatch("bool")
+		}
+		return &binaryExpr{op: tok.text, l: l, r: r, t: typeBool}, nil
+	case "==", "!=":
+		if l.typ() != r.typ() || l.typ() == typeDuration {
+			return nil, mismatch("comparable")
+		}
+		return &binaryExpr{op: tok.text, l: l, r: r, t: typeBool}, nil
+	case "<", "<=", ">", ">=":
+		if l.typ() != r.typ() || (l.typ() != typeNumber && l.typ() != typeString) {
+			return nil, mismatch("number or string")
+		}
+		return &binaryExpr{op: tok.text, l: l, r: r, t: typeBool}, nil
+	}
+
+	if l.typ() != typeNumber || r.typ() != typeNumber {
+		return nil, mismatch("number")
+	}
+	return &binaryExpr{op: tok.text, l: l, r: r, t: typeNumber}, nil
+}
+
+// parseUnary analyse une négation ou un opérande
+func (p *exprParser) parseUnary() (exprNode, error) {
+	tok := p.peek()
+	if tok.kind == tokOp && (tok.text == "!" || tok.text == "-") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		want := typeNumber
+		if tok.text == "!" {
+			want = typeBool
+		}
+		if x.typ() != want {
+			return nil, fmt.Errorf("position %d: %q needs a %s operand, got %s", tok.pos, tok.text, want, x.typ())
+		}
+		return &unaryExpr{op: tok.text, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary analyse un littéral, un champ, un appel ou une expression parenthésée
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return &literal{t: typeNumber, v: tok.num}, nil
+	case tokDuration:
+		return &literal{t: typeDuration, v: tok.dur}, nil
+	case tokString:
+		return &literal{t: typeString, v: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true", "false":
+			return &literal{t: typeBool, v: tok.text == "true"}, nil
+		}
+		if next := p.peek(); next.kind == tokOp && next.text == "(" {
+			return p.parseCall(tok)
+		}
+		return p.field(tok)
+	case tokOp:
+		if tok.text == "(" {
+			inner, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+	return nil, fmt.Errorf("position %d: unexpected %q", tok.pos, tok.text)
+}
+
+// field résout un champ et son type
+func (p *exprParser) field(tok exprToken) (*fieldRef, error) {
+	t, ok := p.fields(tok.text)
+	if !ok {
+		return nil, fmt.Errorf("position %d: unknown field %q", tok.pos, tok.text)
+	}
+	return &fieldRef{name: tok.text, t: t}, nil
+}
+
+// parseCall analyse un appel de fonction et vérifie ses arguments
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	p.next() // (
+	args := make([]exprNode, 0, 2)
+	if tok := p.peek(); !(tok.kind == tokOp && tok.text == ")") {
+		for {
+			arg, err := p.parseBinary(1)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if tok := p.peek(); tok.kind == tokOp && tok.text == "," {
+				p.next()
+				continue
+			}
+			break
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	signature := func(types ...exprType) error {
+		if len(args) != len(types) {
+			return fmt.Errorf("position %d: %s takes %d arguments, got %d", name.pos, name.text, len(types), len(args))
+		}
+		for i, t := range types {
+			if args[i].typ() != t {
+				return fmt.Errorf("position %d: argument %d of %s must be a %s, got %s", name.pos, i+1, name.text, t, args[i].typ())
+			}
+		}
+		return nil
+	}
+
+	call := &callExpr{fn: name.text, args: args}
+	switch name.text {
+	case "delta", "diff", "prev":
+		types := []exprType{typeNumber, typeDuration}
+		if name.text == "prev" {
+			types = types[:1]
+		}
+		if err := signature(types...); err != nil {
+			return nil, err
+		}
+		ref, ok := args[0].(*fieldRef)
+		if !ok {
+			return nil, fmt.Errorf("position %d: first argument of %s must be a field", name.pos, name.text)
+		}
+		call.field = ref.name
+		if len(args) > 1 {
+			call.window = args[1].eval(nil).(time.Duration)
+		}
+		if call.window > p.history[ref.name] || p.history[ref.name] == 0 {
+			p.history[ref.name] = call.window
+		}
+	case "abs":
+		if err := signature(typeNumber); err != nil {
+			return nil, err
+		}
+	case "min", "max":
+		if err := signature(typeNumber, typeNumber); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("position %d: unknown function %q", name.pos, name.text)
+	}
+	return call, nil
+}
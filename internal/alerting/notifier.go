@@ -0,0 +1,315 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTelegramAPIURL est l'URL de l'API Bot de Telegram
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// Notifier envoie une alerte vers un canal externe
+type Notifier interface {
+	// Name identifie le canal dans le journal des envois
+	Name() string
+	// Notify envoie l'alerte; une erreur non définitive déclenche une nouvelle tentative
+	Notify(ctx context.Context, alert models.TokenAlert) error
+}
+
+// Report est un rapport périodique (digest) envoyé sur les canaux d'alertes
+type Report struct {
+	ID       string      `json:"id"`
+	Title    string      `json:"title"`
+	Markdown string      `json:"markdown"`
+	HTML     string      `json:"html"`
+	Data     interface{} `json:"data,omitempty"` // Contenu structuré, transmis aux webhooks
+}
+
+// ReportNotifier est implémenté par les canaux qui savent envoyer un rapport
+type ReportNotifier interface {
+	NotifyReport(ctx context.Context, report Report) error
+}
+
+// truncateRunes coupe un texte à max caractères
+func truncateRunes(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max {
+		return text
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+// HTTPError est une réponse HTTP en erreur d'un canal
+type HTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+// Retryable indique si l'envoi peut réussir plus tard: erreurs serveur et limitation de débit
+func (e *HTTPError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// isRetryable indique si une erreur d'envoi justifie une nouvelle tentative.
+// Les erreurs réseau sont retentées, les requêtes refusées par le canal ne le sont pas.
+func isRetryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Retryable()
+	}
+	return true
+}
+
+// postJSON envoie un corps JSON et retourne une HTTPError si le statut n'est pas 2xx
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// FormatAlert produit le texte d'une alerte pour les canaux de messagerie
+func FormatAlert(alert models.TokenAlert) string {
+	symbol := alert.TokenSymbol
+	if symbol == "" {
+		symbol = alert.TokenAddress
+	}
+	return fmt.Sprintf("[%s] %s %s\n%s\nToken: %s\nDetected: %s",
+		alert.Severity, alert.AlertType, symbol,
+		alert.Message,
+		alert.TokenAddress,
+		alert.DetectedAt.UTC().Format(time.RFC3339))
+}
+
+// TelegramNotifier envoie les alertes à un chat via l'API Bot de Telegram
+type TelegramNotifier struct {
+	name   string
+	apiURL string
+	token  string
+	chatID string
+	client *http.Client
+}
+
+// NewTelegramNotifier crée un canal Telegram; apiURL vide utilise l'API publique
+func NewTelegramNotifier(name, apiURL, token, chatID string, client *http.Client) *TelegramNotifier {
+	if apiURL == "" {
+		apiURL = DefaultTelegramAPIURL
+	}
+	return &TelegramNotifier{
+		name:   name,
+		apiURL: strings.TrimRight(apiURL, "/"),
+		token:  token,
+		chatID: chatID,
+		client: client,
+	}
+}
+
+// Name retourne le nom du canal
+func (n *TelegramNotifier) Name() string { return n.name }
+
+// Notify envoie l'alerte par sendMessage
+func (n *TelegramNotifier) Notify(ctx context.Context, alert models.TokenAlert) error {
+	url := fmt.Sprintf("%s/bot%s/sendMessage", n.apiURL, n.token)
+	return postJSON(ctx, n.client, url, nil, map[string]interface{}{
+		"chat_id":                  n.chatID,
+		"text":                     FormatAlert(alert),
+		"disable_web_page_preview": true,
+	})
+}
+
+// NotifyReport envoie le rapport en Markdown, sans mise en forme Telegram
+func (n *TelegramNotifier) NotifyReport(ctx context.Context, report Report) error {
+	url := fmt.Sprintf("%s/bot%s/sendMessage", n.apiURL, n.token)
+	return postJSON(ctx, n.client, url, nil, map[string]interface{}{
+		"chat_id":                  n.chatID,
+		"text":                     truncateRunes(report.Markdown, 4096), // Limite de Telegram
+		"disable_web_page_preview": true,
+	})
+}
+
+// DiscordNotifier envoie les alertes à un webhook Discord
+type DiscordNotifier struct {
+	name       string
+	webhookURL string
+	client     *http.Client
+}
+
+// NewDiscordNotifier crée un canal Discord
+func NewDiscordNotifier(name, webhookURL string, client *http.Client) *DiscordNotifier {
+	return &DiscordNotifier{name: name, webhookURL: webhookURL, client: client}
+}
+
+// Name retourne le nom du canal
+func (n *DiscordNotifier) Name() string { return n.name }
+
+// Notify envoie l'alerte comme message du webhook
+func (n *DiscordNotifier) Notify(ctx context.Context, alert models.TokenAlert) error {
+	content := []rune(FormatAlert(alert))
+	if len(content) > 2000 { // Limite de Discord, en caractères
+		content = content[:2000]
+	}
+	return postJSON(ctx, n.client, n.webhookURL, nil, map[string]interface{}{
+		"content": string(content),
+	})
+}
+
+// NotifyReport envoie le rapport en Markdown
+func (n *DiscordNotifier) NotifyReport(ctx context.Context, report Report) error {
+	return postJSON(ctx, n.client, n.webhookURL, nil, map[string]interface{}{
+		"content": truncateRunes(report.Markdown, 2000),
+	})
+}
+
+// SlackNotifier envoie les alertes à un webhook entrant Slack
+type SlackNotifier struct {
+	name       string
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier crée un canal Slack
+func NewSlackNotifier(name, webhookURL string, client *http.Client) *SlackNotifier {
+	return &SlackNotifier{name: name, webhookURL: webhookURL, client: client}
+}
+
+// Name retourne le nom du canal
+func (n *SlackNotifier) Name() string { return n.name }
+
+// Notify envoie l'alerte comme message du webhook
+func (n *SlackNotifier) Notify(ctx context.Context, alert models.TokenAlert) error {
+	return postJSON(ctx, n.client, n.webhookURL, nil, map[string]interface{}{
+		"text": FormatAlert(alert),
+	})
+}
+
+// NotifyReport envoie le rapport en Markdown
+func (n *SlackNotifier) NotifyReport(ctx context.Context, report Report) error {
+	return postJSON(ctx, n.client, n.webhookURL, nil, map[string]interface{}{
+		"text": report.Markdown,
+	})
+}
+
+// WebhookNotifier envoie l'alerte en JSON à une URL quelconque
+type WebhookNotifier struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookNotifier crée un canal webhook; headers est ajouté à chaque requête (authentification)
+func NewWebhookNotifier(name, url string, headers map[string]string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{name: name, url: url, headers: headers, client: client}
+}
+
+// Name retourne le nom du canal
+func (n *WebhookNotifier) Name() string { return n.name }
+
+// Notify envoie l'alerte telle quelle
+func (n *WebhookNotifier) Notify(ctx context.Context, alert models.TokenAlert) error {
+	return postJSON(ctx, n.client, n.url, n.headers, alert)
+}
+
+// NotifyReport envoie le rapport en JSON, avec ses rendus Markdown et HTML
+func (n *WebhookNotifier) NotifyReport(ctx context.Context, report Report) error {
+	return postJSON(ctx, n.client, n.url, n.headers, report)
+}
+
+// ConsoleNotifier écrit les alertes dans les logs
+type ConsoleNotifier struct {
+	name   string
+	logger *logrus.Logger
+}
+
+// NewConsoleNotifier crée un canal console
+func NewConsoleNotifier(name string, logger *logrus.Logger) *ConsoleNotifier {
+	return &ConsoleNotifier{name: name, logger: logger}
+}
+
+// Name retourne le nom du canal
+func (n *ConsoleNotifier) Name() string { return n.name }
+
+// Notify écrit l'alerte dans les logs
+func (n *ConsoleNotifier) Notify(ctx context.Context, alert models.TokenAlert) error {
+	n.logger.WithFields(logrus.Fields{
+		"alert_id":      alert.ID,
+		"token_address": alert.TokenAddress,
+		"token_symbol":  alert.TokenSymbol,
+		"alert_type":    alert.AlertType,
+		"severity":      alert.Severity,
+	}).Warn(alert.Message)
+	return nil
+}
+
+// NotifyReport écrit le rapport dans les logs
+func (n *ConsoleNotifier) NotifyReport(ctx context.Context, report Report) error {
+	n.logger.WithField("report_id", report.ID).Info(report.Title + "\n" + report.Markdown)
+	return nil
+}
+
+// NotifierFromConfig construit le canal décrit par la configuration
+func NotifierFromConfig(cfg config.AlertChannelConfig, client *http.Client, logger *logrus.Logger) (Notifier, error) {
+	name := cfg.Name
+	if name == "" {
+		name = cfg.Type
+	}
+
+	switch cfg.Type {
+	case "telegram":
+		if cfg.Token == "" || cfg.ChatID == "" {
+			return nil, fmt.Errorf("channel %s: telegram requires token and chat_id", name)
+		}
+		return NewTelegramNotifier(name, cfg.URL, cfg.Token, cfg.ChatID, client), nil
+	case "discord", "slack", "webhook":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("channel %s: %s requires url", name, cfg.Type)
+		}
+		switch cfg.Type {
+		case "discord":
+			return NewDiscordNotifier(name, cfg.URL, client), nil
+		case "slack":
+			return NewSlackNotifier(name, cfg.URL, client), nil
+		}
+		return NewWebhookNotifier(name, cfg.URL, cfg.Headers, client), nil
+	case "console":
+		return NewConsoleNotifier(name, logger), nil
+	}
+	return nil, fmt.Errorf("channel %s: unknown type %q", name, cfg.Type)
+}
@@ -0,0 +1,135 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func testAlert(severity, alertType string) models.TokenAlert {
+	return models.TokenAlert{
+		ID:           "alert_1",
+		TokenAddress: "tok",
+		TokenSymbol:  "TOK",
+		AlertType:    alertType,
+		Severity:     severity,
+		Message:      "Token TOK has a high X-Score of 91.00",
+		DetectedAt:   time.Now(),
+	}
+}
+
+func TestNotifiersPostToChannels(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]map[string]interface{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		requests[r.URL.Path] = body
+		mu.Unlock()
+		if r.Header.Get("X-Secret") == "" && r.URL.Path == "/hook" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	notifiers := []Notifier{
+		NewTelegramNotifier("telegram", server.URL, "123:abc", "42", server.Client()),
+		NewDiscordNotifier("discord", server.URL+"/discord", server.Client()),
+		NewSlackNotifier("slack", server.URL+"/slack", server.Client()),
+		NewWebhookNotifier("webhook", server.URL+"/hook", map[string]string{"X-Secret": "s"}, server.Client()),
+	}
+	for _, n := range notifiers {
+		if err := n.Notify(context.Background(), testAlert("URGENT", "HIGH_SCORE")); err != nil {
+			t.Fatalf("%s: %v", n.Name(), err)
+		}
+	}
+
+	if body := requests["/bot123:abc/sendMessage"]; body["chat_id"] != "42" || body["text"] == "" {
+		t.Errorf("unexpected telegram request: %v", body)
+	}
+	if body := requests["/discord"]; body["content"] == "" {
+		t.Errorf("unexpected discord request: %v", body)
+	}
+	if body := requests["/slack"]; body["text"] == "" {
+		t.Errorf("unexpected slack request: %v", body)
+	}
+	if body := requests["/hook"]; body["id"] != "alert_1" || body["severity"] != "URGENT" {
+		t.Errorf("unexpected webhook request: %v", body)
+	}
+
+	err := NewWebhookNotifier("webhook", server.URL+"/hook", nil, server.Client()).Notify(context.Background(), testAlert("URGENT", "HIGH_SCORE"))
+	if err == nil || isRetryable(err) {
+		t.Errorf("expected permanent error on 401, got %v", err)
+	}
+}
+
+func TestDispatcherRoutesAndRetries(t *testing.T) {
+	var calls, rejected int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer flaky.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&rejected, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer broken.Close()
+
+	policy := DeliveryPolicy{Workers: 2, QueueSize: 10, MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Timeout: time.Second}
+	d := NewDispatcher(testLogger(), policy)
+	d.AddRoute(Route{Notifier: NewSlackNotifier("flaky", flaky.URL, flaky.Client()), Severities: []string{"urgent"}})
+	d.AddRoute(Route{Notifier: NewSlackNotifier("broken", broken.URL, broken.Client())})
+	d.AddRoute(Route{Notifier: NewSlackNotifier("dumps", broken.URL, broken.Client()), AlertTypes: []string{"DUMP_DETECTED"}})
+	d.Start()
+
+	if queued := d.Dispatch(testAlert("URGENT", "HIGH_SCORE")); queued != 2 {
+		t.Fatalf("queued %d deliveries, want 2", queued)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	d.Stop(ctx)
+
+	records := d.Log().ForAlert("alert_1")
+	if len(records) != 2 {
+		t.Fatalf("got %d delivery records, want 2", len(records))
+	}
+	for _, record := range records {
+		switch record.Channel {
+		case "flaky":
+			if record.Status != DeliveryDelivered || record.Attempts != 3 {
+				t.Errorf("flaky channel: %+v", record)
+			}
+		case "broken":
+			if record.Status != DeliveryFailed || record.Attempts != 1 || record.LastError == "" {
+				t.Errorf("broken channel: %+v", record)
+			}
+		}
+	}
+	if atomic.LoadInt32(&rejected) != 1 {
+		t.Errorf("rejected request retried %d times", rejected)
+	}
+
+	if queued := d.Dispatch(testAlert("URGENT", "HIGH_SCORE")); queued != 0 {
+		t.Errorf("stopped dispatcher queued %d deliveries", queued)
+	}
+}
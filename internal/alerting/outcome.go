@@ -0,0 +1,424 @@
+package alerting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Statuts du suivi d'une alerte
+const (
+	OutcomeTracking  = "TRACKING"
+	OutcomeConfirmed = "CONFIRMED"
+	OutcomeFailed    = "FAILED"
+	OutcomeExpired   = "EXPIRED" // Aucun prix observé pendant la fenêtre de suivi
+)
+
+// OutcomeStore persiste le suivi des alertes. La base de données (table alert_outcomes) l'implémente.
+type OutcomeStore interface {
+	SaveAlertOutcome(outcome *models.AlertOutcome) error
+	// GetAlertOutcome retourne nil si l'alerte n'est pas suivie
+	GetAlertOutcome(alertID string) (*models.AlertOutcome, error)
+	// QueryAlertOutcomes retourne les suivis des alertes détectées depuis since
+	QueryAlertOutcomes(since time.Time) ([]models.AlertOutcome, error)
+	ListTrackingAlertOutcomes() ([]models.AlertOutcome, error)
+}
+
+// OutcomeCriteria décide du résultat d'une alerte
+type OutcomeCriteria struct {
+	// ConfirmMove est le mouvement dans le sens annoncé qui confirme l'alerte (0.2 = 20%)
+	ConfirmMove float64
+	// FailMove est le mouvement contraire qui la fait échouer
+	FailMove float64
+	// Window est la durée du suivi; sans décision à son terme l'alerte échoue
+	Window time.Duration
+	// BearishTypes sont les types d'alertes qui annoncent une baisse
+	BearishTypes []string
+}
+
+// DefaultOutcomeCriteria sont les critères utilisés sans configuration
+var DefaultOutcomeCriteria = OutcomeCriteria{
+	ConfirmMove:  0.2,
+	FailMove:     0.3,
+	Window:       24 * time.Hour,
+	BearishTypes: []string{"DUMP_DETECTED"},
+}
+
+// outcomeHorizons sont les horizons des rendements enregistrés
+var outcomeHorizons = []time.Duration{time.Hour, 6 * time.Hour, 24 * time.Hour}
+
+// OutcomeCriteriaFromConfig construit les critères de résultat depuis la configuration
+func OutcomeCriteriaFromConfig(cfg *config.AlertsConfig) OutcomeCriteria {
+	criteria := DefaultOutcomeCriteria
+	if cfg == nil {
+		return criteria
+	}
+	if cfg.OutcomeConfirmMove > 0 {
+		criteria.ConfirmMove = cfg.OutcomeConfirmMove
+	}
+	if cfg.OutcomeFailMove > 0 {
+		criteria.FailMove = cfg.OutcomeFailMove
+	}
+	if cfg.OutcomeWindowMs > 0 {
+		criteria.Window = time.Duration(cfg.OutcomeWindowMs) * time.Millisecond
+	}
+	if len(cfg.OutcomeBearishTypes) > 0 {
+		criteria.BearishTypes = cfg.OutcomeBearishTypes
+	}
+	return criteria
+}
+
+// OutcomeTracker suit le prix des tokens après leurs alertes et décide de leur résultat
+type OutcomeTracker struct {
+	mu       sync.Mutex
+	store    OutcomeStore
+	criteria OutcomeCriteria
+	logger   *logrus.Logger
+	tracking map[string]*models.AlertOutcome // Suivis en cours par ID d'alerte
+	byToken  map[string][]string             // IDs des alertes suivies par token
+
+	// onConfirmed est appelée hors verrou pour chaque alerte confirmée
+	onConfirmed func(outcome models.AlertOutcome)
+}
+
+// NewOutcomeTracker crée un suivi des résultats d'alertes
+func NewOutcomeTracker(store OutcomeStore, criteria OutcomeCriteria, logger *logrus.Logger) *OutcomeTracker {
+	return &OutcomeTracker{
+		store:    store,
+		criteria: criteria,
+		logger:   logger,
+		tracking: make(map[string]*models.AlertOutcome),
+		byToken:  make(map[string][]string),
+	}
+}
+
+// Load reprend les suivis en cours au dernier arrêt
+func (t *OutcomeTracker) Load() error {
+	outcomes, err := t.store.ListTrackingAlertOutcomes()
+	if err != nil {
+		return fmt.Errorf("failed to load alert outcomes: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := range outcomes {
+		t.add(&outcomes[i])
+	}
+	return nil
+}
+
+// SetCriteria remplace les critères de résultat des prochaines décisions
+func (t *OutcomeTracker) SetCriteria(criteria OutcomeCriteria) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.criteria = criteria
+}
+
+// Window retourne la durée du suivi d'une alerte
+func (t *OutcomeTracker) Window() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.criteria.Window
+}
+
+// Track commence le suivi d'une alerte. price et marketCap sont les valeurs au moment de
+// l'alerte; un prix nul est remplacé par la première observation.
+func (t *OutcomeTracker) Track(alert models.TokenAlert, price, marketCap float64) error {
+	outcome := &models.AlertOutcome{
+		AlertID:          alert.ID,
+		TokenAddress:     alert.TokenAddress,
+		AlertType:        alert.AlertType,
+		Severity:         alert.Severity,
+		Bearish:          t.bearish(alert.AlertType),
+		DetectedAt:       alert.DetectedAt,
+		PriceAtAlert:     price,
+		MarketCapAtAlert: marketCap,
+		LastPrice:        price,
+		LastMarketCap:    marketCap,
+		Status:           OutcomeTracking,
+		UpdatedAt:        alert.DetectedAt,
+	}
+
+	if err := t.store.SaveAlertOutcome(outcome); err != nil {
+		return fmt.Errorf("failed to save alert outcome: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(outcome)
+	return nil
+}
+
+// bearish indique si un type d'alerte annonce une baisse
+func (t *OutcomeTracker) bearish(alertType string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return containsFold(t.criteria.BearishTypes, alertType)
+}
+
+// add enregistre un suivi en cours. Appelée avec t.mu verrouillé.
+func (t *OutcomeTracker) add(outcome *models.AlertOutcome) {
+	if _, ok := t.tracking[outcome.AlertID]; ok {
+		return
+	}
+	t.tracking[outcome.AlertID] = outcome
+	t.byToken[outcome.TokenAddress] = append(t.byToken[outcome.TokenAddress], outcome.AlertID)
+}
+
+// remove arrête un suivi. Appelée avec t.mu verrouillé.
+func (t *OutcomeTracker) remove(outcome *models.AlertOutcome) {
+	delete(t.tracking, outcome.AlertID)
+	ids := t.byToken[outcome.TokenAddress]
+	for i, id := range ids {
+		if id == outcome.AlertID {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(t.byToken, outcome.TokenAddress)
+	} else {
+		t.byToken[outcome.TokenAddress] = ids
+	}
+}
+
+// Observe met à jour les suivis d'un token avec un nouveau prix
+func (t *OutcomeTracker) Observe(tokenAddress string, price, marketCap float64, at time.Time) error {
+	if price <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	decided := make([]models.AlertOutcome, 0)
+	var firstErr error
+	for _, id := range append([]string(nil), t.byToken[tokenAddress]...) {
+		outcome := t.tracking[id]
+		if at.Before(outcome.DetectedAt) {
+			continue
+		}
+		observe(outcome, price, marketCap, at)
+		t.decide(outcome, at)
+
+		if err := t.store.SaveAlertOutcome(outcome); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to save alert outcome: %w", err)
+		}
+		if outcome.Status != OutcomeTracking {
+			t.remove(outcome)
+			decided = append(decided, *outcome)
+		}
+	}
+	t.mu.Unlock()
+
+	t.report(decided)
+	return firstErr
+}
+
+// Expire clôt les suivis dont la fenêtre est écoulée sans nouvelle observation
+func (t *OutcomeTracker) Expire(now time.Time) (int, error) {
+	t.mu.Lock()
+	decided := make([]models.AlertOutcome, 0)
+	var firstErr error
+	for _, outcome := range t.tracking {
+		if now.Sub(outcome.DetectedAt) < t.criteria.Window {
+			continue
+		}
+		t.decide(outcome, now)
+		if err := t.store.SaveAlertOutcome(outcome); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to save alert outcome: %w", err)
+		}
+		t.remove(outcome)
+		decided = append(decided, *outcome)
+	}
+	t.mu.Unlock()
+
+	t.report(decided)
+	return len(decided), firstErr
+}
+
+// report journalise les décisions et prévient des confirmations
+func (t *OutcomeTracker) report(decided []models.AlertOutcome) {
+	for _, outcome := range decided {
+		t.logger.WithFields(logrus.Fields{
+			"alert_id":      outcome.AlertID,
+			"token_address": outcome.TokenAddress,
+			"alert_type":    outcome.AlertType,
+			"status":        outcome.Status,
+			"max_gain":      outcome.MaxGain,
+			"max_drawdown":  outcome.MaxDrawdown,
+		}).Info("Alert outcome decided")
+
+		if outcome.Status == OutcomeConfirmed && t.onConfirmed != nil {
+			t.onConfirmed(outcome)
+		}
+	}
+}
+
+// observe intègre un prix dans un suivi
+func observe(outcome *models.AlertOutcome, price, marketCap float64, at time.Time) {
+	if outcome.PriceAtAlert <= 0 {
+		outcome.PriceAtAlert = price
+		if outcome.MarketCapAtAlert <= 0 {
+			outcome.MarketCapAtAlert = marketCap
+		}
+	}
+	outcome.LastPrice = price
+	outcome.LastMarketCap = marketCap
+	outcome.UpdatedAt = at
+
+	change := price/outcome.PriceAtAlert - 1
+	if change > outcome.MaxGain {
+		outcome.MaxGain = change
+	}
+	if change < outcome.MaxDrawdown {
+		outcome.MaxDrawdown = change
+	}
+
+	// Le rendement d'un horizon est celui de la première observation qui l'atteint
+	elapsed := at.Sub(outcome.DetectedAt)
+	for _, horizon := range outcomeHorizons {
+		field := outcomeReturn(outcome, horizon)
+		if elapsed >= horizon && *field == nil {
+			value := change
+			*field = &value
+		}
+	}
+}
+
+// outcomeReturn retourne le champ du rendement d'un horizon
+func outcomeReturn(outcome *models.AlertOutcome, horizon time.Duration) **float64 {
+	switch horizon {
+	case time.Hour:
+		return &outcome.Return1h
+	case 6 * time.Hour:
+		return &outcome.Return6h
+	}
+	return &outcome.Return24h
+}
+
+// decide applique les critères à un suivi. Appelée avec t.mu verrouillé.
+func (t *OutcomeTracker) decide(outcome *models.AlertOutcome, at time.Time) {
+	favorable, adverse := outcome.MaxGain, -outcome.MaxDrawdown
+	if outcome.Bearish {
+		favorable, adverse = adverse, favorable
+	}
+
+	switch {
+	case outcome.PriceAtAlert <= 0 && at.Sub(outcome.DetectedAt) >= t.criteria.Window:
+		outcome.Status, outcome.Reason = OutcomeExpired, "no price observed"
+	case adverse >= t.criteria.FailMove:
+		outcome.Status, outcome.Reason = OutcomeFailed, fmt.Sprintf("moved %.1f%% against the alert", adverse*100)
+	case favorable >= t.criteria.ConfirmMove:
+		outcome.Status, outcome.Reason = OutcomeConfirmed, fmt.Sprintf("moved %.1f%% as expected", favorable*100)
+	case at.Sub(outcome.DetectedAt) >= t.criteria.Window:
+		outcome.Status, outcome.Reason = OutcomeFailed, fmt.Sprintf("no %.0f%% move within %s", t.criteria.ConfirmMove*100, t.criteria.Window)
+	default:
+		return
+	}
+	decidedAt := at
+	outcome.DecidedAt = &decidedAt
+}
+
+// OutcomeStats agrège des suivis par type d'alerte, triés par type
+func OutcomeStats(outcomes []models.AlertOutcome) []models.AlertTypeStats {
+	type sums struct {
+		stats          models.AlertTypeStats
+		gain, drawdown float64
+		returns        [3]float64
+		returnCounts   [3]int
+		priced         int
+	}
+
+	byType := make(map[string]*sums)
+	for _, outcome := range outcomes {
+		alertType := strings.ToUpper(outcome.AlertType)
+		s, ok := byType[alertType]
+		if !ok {
+			s = &sums{stats: models.AlertTypeStats{AlertType: alertType}}
+			byType[alertType] = s
+		}
+
+		s.stats.Total++
+		switch outcome.Status {
+		case OutcomeConfirmed:
+			s.stats.Confirmed++
+		case OutcomeFailed:
+			s.stats.Failed++
+		case OutcomeExpired:
+			s.stats.Expired++
+		default:
+			s.stats.Tracking++
+		}
+
+		if outcome.PriceAtAlert > 0 {
+			s.priced++
+			s.gain += outcome.MaxGain
+			s.drawdown += outcome.MaxDrawdown
+		}
+		for i, r := range []*float64{outcome.Return1h, outcome.Return6h, outcome.Return24h} {
+			if r != nil {
+				s.returns[i] += *r
+				s.returnCounts[i]++
+			}
+		}
+	}
+
+	stats := make([]models.AlertTypeStats, 0, len(byType))
+	for _, s := range byType {
+		if decided := s.stats.Confirmed + s.stats.Failed; decided > 0 {
+			s.stats.HitRate = float64(s.stats.Confirmed) / float64(decided)
+		}
+		if s.priced > 0 {
+			s.stats.AvgMaxGain = s.gain / float64(s.priced)
+			s.stats.AvgMaxDrawdown = s.drawdown / float64(s.priced)
+		}
+		averages := []**float64{&s.stats.AvgReturn1h, &s.stats.AvgReturn6h, &s.stats.AvgReturn24h}
+		for i, avg := range averages {
+			if s.returnCounts[i] > 0 {
+				value := s.returns[i] / float64(s.returnCounts[i])
+				*avg = &value
+			}
+		}
+		stats = append(stats, s.stats)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].AlertType < stats[j].AlertType })
+	return stats
+}
+
+// OutcomeProcessor suit le prix des tokens alertés à chaque X-Score calculé
+type OutcomeProcessor struct {
+	manager *Manager
+}
+
+// NewOutcomeProcessor crée le processeur du suivi des résultats d'alertes
+func NewOutcomeProcessor(manager *Manager) *OutcomeProcessor {
+	return &OutcomeProcessor{manager: manager}
+}
+
+// GetName retourne le nom du processeur
+func (p *OutcomeProcessor) GetName() string {
+	return "alert_outcomes"
+}
+
+// Subscriptions retourne les événements consommés par le processeur
+func (p *OutcomeProcessor) Subscriptions() []pipeline.Subscription {
+	return []pipeline.Subscription{{
+		Stream:     pipeline.StreamTokenEvents,
+		EventTypes: []string{pipeline.EventTypeXScore},
+	}}
+}
+
+// Process transmet le prix du token au suivi des alertes
+func (p *OutcomeProcessor) Process(message pipeline.Message) error {
+	event, ok := message.Event.(*pipeline.XScoreCalculated)
+	if !ok {
+		return nil
+	}
+	return p.manager.ObservePrice(event.TokenAddress, event.Price, event.MarketCap, event.CalculatedAt)
+}
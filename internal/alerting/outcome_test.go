@@ -0,0 +1,86 @@
+package alerting
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+func TestOutcomeTrackerDecidesAndAggregates(t *testing.T) {
+	m := NewManager(testLogger())
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	raise := func(token, alertType string, price float64) *models.TokenAlert {
+		t.Helper()
+		alert, err := m.Raise(AlertSignal{
+			TokenAddress: token,
+			AlertType:    alertType,
+			Severity:     "ALERT",
+			Driver:       math.NaN(),
+			Price:        price,
+			At:           start,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return alert
+	}
+	observe := func(token string, at time.Duration, price float64) {
+		t.Helper()
+		if err := m.ObservePrice(token, price, price*1000, start.Add(at)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pump := raise("up", "HIGH_SCORE", 1.0)
+	rug := raise("down", "HIGH_SCORE", 1.0)
+	dump := raise("dump", "DUMP_DETECTED", 2.0)
+	flat := raise("flat", "HIGH_SCORE", 0) // Prix inconnu: base à la première observation
+
+	observe("up", 30*time.Minute, 0.9)
+	observe("up", time.Hour, 1.1)
+	observe("up", 2*time.Hour, 1.25)
+	observe("down", time.Hour, 0.6)
+	observe("dump", time.Hour, 1.5)
+	observe("flat", 10*time.Minute, 4.0)
+	observe("flat", 7*time.Hour, 4.4)
+
+	if _, err := m.outcomes.Expire(start.Add(25 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{pump.ID: OutcomeConfirmed, rug.ID: OutcomeFailed, dump.ID: OutcomeConfirmed, flat.ID: OutcomeFailed}
+	for id, status := range want {
+		outcome, err := m.AlertOutcome(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outcome.Status != status {
+			t.Errorf("%s (%s): status %s, want %s (%s)", outcome.TokenAddress, outcome.AlertType, outcome.Status, status, outcome.Reason)
+		}
+	}
+
+	outcome, _ := m.AlertOutcome(pump.ID)
+	if outcome.Return1h == nil || math.Abs(*outcome.Return1h-0.1) > 1e-9 || math.Abs(outcome.MaxDrawdown+0.1) > 1e-9 {
+		t.Errorf("unexpected returns: %+v", outcome)
+	}
+	if alert, _ := m.GetAlert(pump.ID); !alert.IsConfirmed {
+		t.Errorf("confirmed outcome did not confirm the alert")
+	}
+	if outcome, _ := m.AlertOutcome(flat.ID); outcome.PriceAtAlert != 4.0 || outcome.Return6h == nil {
+		t.Errorf("baseline not taken from the first observation: %+v", outcome)
+	}
+
+	stats, err := m.OutcomeStats(start.Add(-time.Hour), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[1].AlertType != "HIGH_SCORE" {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if high := stats[1]; high.Total != 3 || high.Confirmed != 1 || high.Failed != 2 || math.Abs(high.HitRate-1.0/3) > 1e-9 {
+		t.Errorf("unexpected HIGH_SCORE stats: %+v", high)
+	}
+}
@@ -0,0 +1,85 @@
+package alerting
+
+import (
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/sirupsen/logrus"
+)
+
+// StateSource donne l'état du cycle de vie courant d'un token
+type StateSource interface {
+	GetTokenState(tokenAddress string) string
+}
+
+// RuleProcessor évalue les règles d'alerte à chaque X-Score calculé
+type RuleProcessor struct {
+	manager *Manager
+	states  StateSource
+	logger  *logrus.Logger
+}
+
+// NewRuleProcessor crée le processeur des règles d'alerte; states peut être nil
+func NewRuleProcessor(manager *Manager, states StateSource, logger *logrus.Logger) *RuleProcessor {
+	return &RuleProcessor{
+		manager: manager,
+		states:  states,
+		logger:  logger,
+	}
+}
+
+// GetName retourne le nom du processeur
+func (p *RuleProcessor) GetName() string {
+	return "alert_rules"
+}
+
+// Subscriptions retourne les événements consommés par le processeur
+func (p *RuleProcessor) Subscriptions() []pipeline.Subscription {
+	return []pipeline.Subscription{{
+		Stream:     pipeline.StreamTokenEvents,
+		EventTypes: []string{pipeline.EventTypeXScore},
+	}}
+}
+
+// IdempotencyKey évite de lever deux fois les alertes d'un même calcul
+func (p *RuleProcessor) IdempotencyKey(message pipeline.Message) string {
+	return message.EventID
+}
+
+// Process évalue les règles sur l'état du token décrit par l'événement
+func (p *RuleProcessor) Process(message pipeline.Message) error {
+	event, ok := message.Event.(*pipeline.XScoreCalculated)
+	if !ok {
+		return nil
+	}
+
+	input := RuleInput{
+		TokenAddress:     event.TokenAddress,
+		TokenSymbol:      event.TokenSymbol,
+		XScore:           event.XScore,
+		BaseScore:        event.BaseScore,
+		AntiDump:         event.AntiDumpDetected,
+		AntiDumpSeverity: event.AntiDumpSeverity,
+		Components:       event.Components,
+		Factors:          event.Factors,
+		Metrics:          event.Metrics,
+		Wallets:          event.Wallets,
+		At:               event.CalculatedAt,
+	}
+	if input.Metrics == nil {
+		input.Metrics = map[string]float64{"price": event.Price, "market_cap": event.MarketCap}
+	}
+	if p.states != nil {
+		input.State = p.states.GetTokenState(event.TokenAddress)
+	}
+
+	alerts, err := p.manager.EvaluateRules(input)
+	if err != nil {
+		return err
+	}
+	if len(alerts) > 0 {
+		p.logger.WithFields(logrus.Fields{
+			"token_address": event.TokenAddress,
+			"alerts":        len(alerts),
+		}).Debug("Alert rules matched")
+	}
+	return nil
+}
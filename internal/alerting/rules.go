@@ -0,0 +1,480 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+)
+
+var (
+	// ErrInvalidRule est retournée pour une règle qui ne passe pas la validation
+	ErrInvalidRule = errors.New("invalid alert rule")
+	// ErrRuleNotFound est retournée pour une règle inconnue
+	ErrRuleNotFound = errors.New("alert rule not found")
+)
+
+// Severities sont les sévérités acceptées par les règles
+var Severities = []string{"LOW", "MEDIUM", "HIGH", "ALERT", "URGENT", "CRITICAL"}
+
+// maxRuleSnapshots borne l'historique conservé par token pour delta, diff et prev
+const maxRuleSnapshots = 256
+
+// ruleNamePattern contraint les noms de règles, utilisés dans les URL
+var ruleNamePattern = regexp.MustCompile(`^[a-z0-9_][a-z0-9_-]*$`)
+
+// DefaultRules reproduisent les déclencheurs historiques du gestionnaire d'alertes
+var DefaultRules = []models.AlertRule{
+	{
+		Name:       "high_score",
+		Expression: "xscore > 80",
+		Severity:   "URGENT",
+		AlertType:  "HIGH_SCORE",
+		Message:    `Token {{.symbol}} has a high X-Score of {{printf "%.2f" .xscore}}`,
+		Enabled:    true,
+	},
+	{
+		Name:       "smart_money",
+		Expression: "wallets.smart_money_ratio > 0.3",
+		Severity:   "ALERT",
+		AlertType:  "SMART_MONEY",
+		Message:    `Token {{.symbol}} has high smart money presence ({{printf "%.1f" (pct .wallets.smart_money_ratio)}}%)`,
+		Enabled:    true,
+	},
+}
+
+// RuleStore persiste les règles créées par l'API. La base de données (table alert_rules) l'implémente.
+type RuleStore interface {
+	ListAlertRules() ([]models.AlertRule, error)
+	SaveAlertRule(rule *models.AlertRule) error
+	DeleteAlertRule(name string) (bool, error)
+}
+
+// RuleInput est l'état d'un token soumis aux règles
+type RuleInput struct {
+	TokenAddress     string
+	TokenSymbol      string
+	State            string
+	XScore           float64
+	BaseScore        float64
+	AntiDump         bool
+	AntiDumpSeverity float64
+	Components       map[string]float64
+	Factors          map[string]float64
+	Metrics          map[string]float64 // models.TokenMetrics.Values(); les métriques absentes valent NaN
+	Wallets          map[string]float64 // models.WalletAnalysis.Values(); les ratios absents valent NaN
+	At               time.Time
+}
+
+// RuleMatch est une règle satisfaite et son message
+type RuleMatch struct {
+	Rule    models.AlertRule
+	Message string
+	Driver  float64 // Grandeur qui pilote la condition, NaN si inconnue
+}
+
+// RuleEvaluation est le résultat de l'évaluation des règles sur un état de token
+type RuleEvaluation struct {
+	Matches []RuleMatch
+	Cleared []models.AlertRule // Règles actives dont la condition est fausse
+}
+
+// Champs des règles
+var (
+	metricFields = (&models.TokenMetrics{}).Values()
+	walletFields = (&models.WalletAnalysis{}).Values()
+)
+
+// ruleFieldType donne le type d'un champ utilisable dans les expressions
+func ruleFieldType(name string) (exprType, bool) {
+	switch name {
+	case "xscore", "base_score", "anti_dump_severity":
+		return typeNumber, true
+	case "anti_dump":
+		return typeBool, true
+	case "state", "symbol", "token_address":
+		return typeString, true
+	}
+	if _, ok := metricFields[name]; ok {
+		return typeNumber, true
+	}
+	if prefix, key, ok := strings.Cut(name, "."); ok && key != "" && !strings.Contains(key, ".") {
+		switch prefix {
+		case "components", "factors":
+			return typeNumber, true
+		case "wallets":
+			_, known := walletFields[key]
+			return typeNumber, known
+		}
+	}
+	return 0, false
+}
+
+// compiledRule est une règle validée, prête à être évaluée
+type compiledRule struct {
+	rule    models.AlertRule
+	expr    *Expression
+	message *template.Template
+}
+
+// ruleTemplateFuncs sont les fonctions disponibles dans les messages
+var ruleTemplateFuncs = template.FuncMap{
+	"pct": func(ratio float64) float64 { return ratio * 100 },
+}
+
+// ValidateRule normalise une règle (sévérité en majuscules, type d'alerte et message par défaut)
+// et vérifie son nom, sa sévérité, son expression et son modèle de message
+func ValidateRule(rule *models.AlertRule) error {
+	_, err := compileRule(rule)
+	return err
+}
+
+// compileRule normalise et compile une règle
+func compileRule(rule *models.AlertRule) (*compiledRule, error) {
+	if !ruleNamePattern.MatchString(rule.Name) {
+		return nil, fmt.Errorf("%w: name %q must be lowercase letters, digits, _ or -", ErrInvalidRule, rule.Name)
+	}
+
+	rule.Severity = strings.ToUpper(strings.TrimSpace(rule.Severity))
+	if !containsFold(Severities, rule.Severity) || rule.Severity == "" {
+		return nil, fmt.Errorf("%w: %s: severity %q must be one of %s", ErrInvalidRule, rule.Name, rule.Severity, strings.Join(Severities, ", "))
+	}
+	if rule.AlertType == "" {
+		rule.AlertType = strings.ToUpper(strings.ReplaceAll(rule.Name, "-", "_"))
+	}
+	if rule.Message == "" {
+		rule.Message = "Rule {{.rule}} matched for {{.symbol}}: {{.expression}}"
+	}
+
+	expr, err := CompileExpression(rule.Expression, ruleFieldType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: expression: %v", ErrInvalidRule, rule.Name, err)
+	}
+
+	message, err := template.New(rule.Name).Funcs(ruleTemplateFuncs).Option("missingkey=zero").Parse(rule.Message)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: message: %v", ErrInvalidRule, rule.Name, err)
+	}
+
+	compiled := &compiledRule{rule: *rule, expr: expr, message: message}
+	// Une exécution à vide détecte les erreurs qui ne se révèlent qu'à l'exécution
+	if _, err := compiled.render(newRuleEnv(RuleInput{}, nil)); err != nil {
+		return nil, fmt.Errorf("%w: %s: message: %v", ErrInvalidRule, rule.Name, err)
+	}
+	return compiled, nil
+}
+
+// render produit le message d'une règle satisfaite
+func (r *compiledRule) render(env *ruleEnv) (string, error) {
+	data := env.templateData()
+	data["rule"] = r.rule.Name
+	data["expression"] = r.expr.String()
+
+	var b strings.Builder
+	if err := r.message.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RulesFromConfig construit les règles déclarées dans la configuration; une règle sans enabled est active
+func RulesFromConfig(cfg *config.AlertsConfig) []models.AlertRule {
+	if cfg == nil {
+		return nil
+	}
+	rules := make([]models.AlertRule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, models.AlertRule{
+			Name:       r.Name,
+			Expression: r.Expression,
+			Severity:   r.Severity,
+			AlertType:  r.AlertType,
+			Message:    r.Message,
+			Enabled:    r.Enabled == nil || *r.Enabled,
+		})
+	}
+	return rules
+}
+
+// ruleSnapshot est un relevé des champs suivis d'un token
+type ruleSnapshot struct {
+	at     time.Time
+	values map[string]float64
+}
+
+// RuleSet évalue les règles et conserve les relevés nécessaires aux fonctions d'historique
+type RuleSet struct {
+	mu      sync.Mutex
+	rules   map[string]*compiledRule
+	history map[string][]ruleSnapshot // Relevés par token, du plus ancien au plus récent
+}
+
+// NewRuleSet crée un jeu de règles validées
+func NewRuleSet(rules []models.AlertRule) (*RuleSet, error) {
+	rs := &RuleSet{
+		rules:   make(map[string]*compiledRule),
+		history: make(map[string][]ruleSnapshot),
+	}
+	if err := rs.Replace(rules); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// Replace remplace toutes les règles; aucune n'est changée si l'une d'elles est invalide
+func (rs *RuleSet) Replace(rules []models.AlertRule) error {
+	compiled := make(map[string]*compiledRule, len(rules))
+	for _, rule := range rules {
+		rule := rule
+		c, err := compileRule(&rule)
+		if err != nil {
+			return err
+		}
+		if _, dup := compiled[rule.Name]; dup {
+			return fmt.Errorf("%w: duplicate rule name %q", ErrInvalidRule, rule.Name)
+		}
+		compiled[rule.Name] = c
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.rules = compiled
+	return nil
+}
+
+// Set ajoute ou remplace une règle et retourne sa forme normalisée
+func (rs *RuleSet) Set(rule models.AlertRule) (*models.AlertRule, error) {
+	c, err := compileRule(&rule)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.rules[rule.Name] = c
+	return &c.rule, nil
+}
+
+// Delete supprime une règle et indique si elle existait
+func (rs *RuleSet) Delete(name string) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	_, ok := rs.rules[name]
+	delete(rs.rules, name)
+	return ok
+}
+
+// Get retourne une règle, nil si elle n'existe pas
+func (rs *RuleSet) Get(name string) *models.AlertRule {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if c, ok := rs.rules[name]; ok {
+		rule := c.rule
+		return &rule
+	}
+	return nil
+}
+
+// Rules retourne les règles triées par nom
+func (rs *RuleSet) Rules() []models.AlertRule {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rules := make([]models.AlertRule, 0, len(rs.rules))
+	for _, c := range rs.rules {
+		rules = append(rules, c.rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// Evaluate sépare les règles actives satisfaites par l'état d'un token de celles qui ne le
+// sont pas, triées par nom, puis enregistre cet état comme relevé pour les évaluations suivantes
+func (rs *RuleSet) Evaluate(input RuleInput) RuleEvaluation {
+	if input.At.IsZero() {
+		input.At = time.Now()
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	env := newRuleEnv(input, rs.history[input.TokenAddress])
+
+	names := make([]string, 0, len(rs.rules))
+	for name := range rs.rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := RuleEvaluation{Matches: make([]RuleMatch, 0)}
+	tracked := make(map[string]time.Duration)
+	for _, name := range names {
+		c := rs.rules[name]
+		for field, window := range c.expr.history {
+			if window >= tracked[field] {
+				tracked[field] = window
+			}
+		}
+		if !c.rule.Enabled {
+			continue
+		}
+		if !c.expr.eval(env) {
+			result.Cleared = append(result.Cleared, c.rule)
+			continue
+		}
+
+		message, err := c.render(env)
+		if err != nil {
+			message = fmt.Sprintf("Rule %s matched for %s", c.rule.Name, input.TokenSymbol)
+		}
+		result.Matches = append(result.Matches, RuleMatch{Rule: c.rule, Message: message, Driver: c.expr.driverValue(env)})
+	}
+
+	rs.record(input, env, tracked)
+	return result
+}
+
+// record conserve les champs suivis d'un état. Les relevés plus anciens que la plus
+// grande fenêtre sont oubliés, sauf le plus récent d'entre eux qui sert encore de référence.
+func (rs *RuleSet) record(input RuleInput, env *ruleEnv, tracked map[string]time.Duration) {
+	if len(tracked) == 0 {
+		delete(rs.history, input.TokenAddress)
+		return
+	}
+
+	var maxWindow time.Duration
+	values := make(map[string]float64, len(tracked))
+	for field, window := range tracked {
+		if v, ok := env.value(field).(float64); ok && !math.IsNaN(v) {
+			values[field] = v
+		}
+		if window > maxWindow {
+			maxWindow = window
+		}
+	}
+
+	history := append(rs.history[input.TokenAddress], ruleSnapshot{at: input.At, values: values})
+
+	cutoff := input.At.Add(-maxWindow)
+	keepFrom := 0
+	for i := range history {
+		if history[i].at.After(cutoff) {
+			break
+		}
+		keepFrom = i
+	}
+	if len(history)-keepFrom > maxRuleSnapshots {
+		keepFrom = len(history) - maxRuleSnapshots
+	}
+	rs.history[input.TokenAddress] = append([]ruleSnapshot(nil), history[keepFrom:]...)
+}
+
+// ruleEnv expose l'état d'un token et son historique aux expressions
+type ruleEnv struct {
+	input   RuleInput
+	history []ruleSnapshot
+}
+
+func newRuleEnv(input RuleInput, history []ruleSnapshot) *ruleEnv {
+	return &ruleEnv{input: input, history: history}
+}
+
+// value retourne la valeur courante d'un champ
+func (e *ruleEnv) value(field string) interface{} {
+	in := e.input
+	switch field {
+	case "xscore":
+		return in.XScore
+	case "base_score":
+		return in.BaseScore
+	case "anti_dump_severity":
+		return in.AntiDumpSeverity
+	case "anti_dump":
+		return in.AntiDump
+	case "state":
+		return in.State
+	case "symbol":
+		return in.TokenSymbol
+	case "token_address":
+		return in.TokenAddress
+	}
+
+	if prefix, key, ok := strings.Cut(field, "."); ok {
+		switch prefix {
+		case "components":
+			return in.Components[key] // Une composante absente ne contribue pas au score
+		case "factors":
+			return in.Factors[key]
+		case "wallets":
+			return lookup(in.Wallets, key)
+		}
+	}
+	return lookup(in.Metrics, field)
+}
+
+// lookup retourne la valeur d'une clé, NaN si elle est absente
+func lookup(values map[string]float64, key string) float64 {
+	if v, ok := values[key]; ok {
+		return v
+	}
+	return math.NaN()
+}
+
+// past retourne la valeur d'un champ au dernier relevé datant d'au moins window
+func (e *ruleEnv) past(field string, window time.Duration) (float64, bool) {
+	bound := e.input.At.Add(-window)
+	for i := len(e.history) - 1; i >= 0; i-- {
+		if e.history[i].at.After(bound) {
+			continue
+		}
+		v, ok := e.history[i].values[field]
+		return v, ok
+	}
+	return 0, false
+}
+
+// previous retourne la valeur d'un champ au relevé précédent
+func (e *ruleEnv) previous(field string) (float64, bool) {
+	if len(e.history) == 0 {
+		return 0, false
+	}
+	v, ok := e.history[len(e.history)-1].values[field]
+	return v, ok
+}
+
+// templateData expose l'état du token aux modèles de message
+func (e *ruleEnv) templateData() map[string]interface{} {
+	in := e.input
+	data := make(map[string]interface{}, len(metricFields)+10)
+	for field := range metricFields {
+		data[field] = lookup(in.Metrics, field)
+	}
+	for _, field := range []string{"xscore", "base_score", "anti_dump_severity", "anti_dump", "state", "symbol", "token_address"} {
+		data[field] = e.value(field)
+	}
+
+	wallets := make(map[string]float64, len(walletFields))
+	for field := range walletFields {
+		wallets[field] = lookup(in.Wallets, field)
+	}
+	data["wallets"] = wallets
+	data["components"] = nonNil(in.Components)
+	data["factors"] = nonNil(in.Factors)
+	return data
+}
+
+// nonNil retourne une map vide à la place de nil
+func nonNil(values map[string]float64) map[string]float64 {
+	if values == nil {
+		return map[string]float64{}
+	}
+	return values
+}
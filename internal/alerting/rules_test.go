@@ -0,0 +1,81 @@
+package alerting
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+func TestValidateRuleRejectsInvalidRules(t *testing.T) {
+	cases := map[string]models.AlertRule{
+		"syntax":         {Name: "r", Expression: "xscore > ", Severity: "ALERT"},
+		"unknown field":  {Name: "r", Expression: "hype > 3", Severity: "ALERT"},
+		"not boolean":    {Name: "r", Expression: "xscore + 1", Severity: "ALERT"},
+		"type mismatch":  {Name: "r", Expression: "state > 3", Severity: "ALERT"},
+		"bad window":     {Name: "r", Expression: "delta(holder_count, 5) > 0", Severity: "ALERT"},
+		"bad severity":   {Name: "r", Expression: "xscore > 1", Severity: "PANIC"},
+		"bad name":       {Name: "High Score", Expression: "xscore > 1", Severity: "ALERT"},
+		"bad template":   {Name: "r", Expression: "xscore > 1", Severity: "ALERT", Message: "{{.symbol"},
+		"unknown wallet": {Name: "r", Expression: "wallets.whales > 1", Severity: "ALERT"},
+	}
+	for name, rule := range cases {
+		if err := ValidateRule(&rule); !errors.Is(err, ErrInvalidRule) {
+			t.Errorf("%s: expected ErrInvalidRule, got %v", name, err)
+		}
+	}
+
+	rule := models.AlertRule{Name: "momentum", Expression: `state == "HYPED" && delta(holder_count, 1h) > 0.2`, Severity: "urgent"}
+	if err := ValidateRule(&rule); err != nil {
+		t.Fatalf("valid rule rejected: %v", err)
+	}
+	if rule.Severity != "URGENT" || rule.AlertType == "" || rule.Message == "" {
+		t.Errorf("rule not normalized: %+v", rule)
+	}
+}
+
+func TestRuleSetEvaluatesDeltasOverSnapshots(t *testing.T) {
+	rs, err := NewRuleSet([]models.AlertRule{{
+		Name:       "holders",
+		Expression: "xscore > 70 && delta(holder_count, 1h) > 0.2",
+		Severity:   "URGENT",
+		AlertType:  "MOMENTUM",
+		Message:    `{{.symbol}} {{printf "%.0f" .holder_count}} holders`,
+		Enabled:    true,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	input := func(at time.Duration, holders float64) RuleInput {
+		return RuleInput{
+			TokenAddress: "tok",
+			TokenSymbol:  "TOK",
+			XScore:       75,
+			Metrics:      map[string]float64{"holder_count": holders},
+			At:           start.Add(at),
+		}
+	}
+
+	if matches := rs.Evaluate(input(0, 100)).Matches; len(matches) != 0 {
+		t.Fatalf("matched without history: %+v", matches)
+	}
+	if matches := rs.Evaluate(input(30*time.Minute, 200)).Matches; len(matches) != 0 {
+		t.Fatalf("matched before the window elapsed: %+v", matches)
+	}
+
+	matches := rs.Evaluate(input(time.Hour, 130)).Matches
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+	if matches[0].Message != "TOK 130 holders" || matches[0].Rule.AlertType != "MOMENTUM" {
+		t.Errorf("unexpected match: %+v", matches[0])
+	}
+
+	// Base une heure plus tôt: 200 holders, soit une baisse
+	if matches := rs.Evaluate(input(90*time.Minute, 210)).Matches; len(matches) != 0 {
+		t.Errorf("matched against the wrong baseline: %+v", matches)
+	}
+}
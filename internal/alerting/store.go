@@ -0,0 +1,211 @@
+package alerting
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// Store persiste les alertes. La base de données (table token_alerts) l'implémente.
+type Store interface {
+	SaveTokenAlert(alert *models.TokenAlert) error
+	// GetTokenAlert retourne nil si l'alerte n'existe pas
+	GetTokenAlert(id string) (*models.TokenAlert, error)
+	// ConfirmTokenAlert retourne l'alerte confirmée, nil si elle n'existe pas
+	ConfirmTokenAlert(id string) (*models.TokenAlert, error)
+	// QueryTokenAlerts retourne les alertes par date de détection puis ID décroissants
+	QueryTokenAlerts(filter models.AlertFilter) ([]models.TokenAlert, error)
+	DeleteTokenAlertsBefore(before time.Time) (int64, error)
+	SaveAlertStateChange(change *models.AlertStateChange) error
+	// GetAlertStateChanges retourne l'historique d'une alerte, du plus ancien au plus récent
+	GetAlertStateChanges(alertID string) ([]models.AlertStateChange, error)
+}
+
+// MemoryStore conserve les alertes en mémoire, pour les tests et le mode démo
+type MemoryStore struct {
+	mu       sync.RWMutex
+	alerts   map[string]models.TokenAlert
+	history  map[string][]models.AlertStateChange
+	outcomes map[string]models.AlertOutcome
+}
+
+// NewMemoryStore crée un store d'alertes en mémoire
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		alerts:   make(map[string]models.TokenAlert),
+		history:  make(map[string][]models.AlertStateChange),
+		outcomes: make(map[string]models.AlertOutcome),
+	}
+}
+
+// SaveTokenAlert enregistre une alerte
+func (s *MemoryStore) SaveTokenAlert(alert *models.TokenAlert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts[alert.ID] = copyAlert(*alert)
+	return nil
+}
+
+// GetTokenAlert récupère une alerte par son ID
+func (s *MemoryStore) GetTokenAlert(id string) (*models.TokenAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	alert, ok := s.alerts[id]
+	if !ok {
+		return nil, nil
+	}
+	alert = copyAlert(alert)
+	return &alert, nil
+}
+
+// ConfirmTokenAlert incrémente le compteur de confirmations d'une alerte
+func (s *MemoryStore) ConfirmTokenAlert(id string) (*models.TokenAlert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alert, ok := s.alerts[id]
+	if !ok {
+		return nil, nil
+	}
+	alert.ConfirmationCount++
+	alert.IsConfirmed = true
+	s.alerts[id] = alert
+
+	alert = copyAlert(alert)
+	return &alert, nil
+}
+
+// QueryTokenAlerts récupère les alertes correspondant à un filtre
+func (s *MemoryStore) QueryTokenAlerts(filter models.AlertFilter) ([]models.TokenAlert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := make([]models.TokenAlert, 0)
+	for _, alert := range s.alerts {
+		if matchesFilter(alert, filter) {
+			alerts = append(alerts, copyAlert(alert))
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool { return alertBefore(alerts[j], alerts[i]) })
+	if filter.Limit > 0 && len(alerts) > filter.Limit {
+		alerts = alerts[:filter.Limit]
+	}
+	return alerts, nil
+}
+
+// DeleteTokenAlertsBefore supprime les alertes détectées avant une date
+func (s *MemoryStore) DeleteTokenAlertsBefore(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for id, alert := range s.alerts {
+		if alert.DetectedAt.Before(before) {
+			delete(s.alerts, id)
+			delete(s.history, id)
+			delete(s.outcomes, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// SaveAlertStateChange ajoute une étape à l'historique d'une alerte
+func (s *MemoryStore) SaveAlertStateChange(change *models.AlertStateChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history[change.AlertID] = append(s.history[change.AlertID], *change)
+	return nil
+}
+
+// GetAlertStateChanges récupère l'historique d'une alerte
+func (s *MemoryStore) GetAlertStateChanges(alertID string) ([]models.AlertStateChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.AlertStateChange{}, s.history[alertID]...), nil
+}
+
+// SaveAlertOutcome enregistre le suivi d'une alerte
+func (s *MemoryStore) SaveAlertOutcome(outcome *models.AlertOutcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outcomes[outcome.AlertID] = *outcome
+	return nil
+}
+
+// GetAlertOutcome récupère le suivi d'une alerte
+func (s *MemoryStore) GetAlertOutcome(alertID string) (*models.AlertOutcome, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	outcome, ok := s.outcomes[alertID]
+	if !ok {
+		return nil, nil
+	}
+	return &outcome, nil
+}
+
+// QueryAlertOutcomes récupère les suivis des alertes détectées depuis since
+func (s *MemoryStore) QueryAlertOutcomes(since time.Time) ([]models.AlertOutcome, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	outcomes := make([]models.AlertOutcome, 0)
+	for _, outcome := range s.outcomes {
+		if !outcome.DetectedAt.Before(since) {
+			outcomes = append(outcomes, outcome)
+		}
+	}
+	return outcomes, nil
+}
+
+// ListTrackingAlertOutcomes récupère les suivis en cours
+func (s *MemoryStore) ListTrackingAlertOutcomes() ([]models.AlertOutcome, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	outcomes := make([]models.AlertOutcome, 0)
+	for _, outcome := range s.outcomes {
+		if outcome.Status == OutcomeTracking {
+			outcomes = append(outcomes, outcome)
+		}
+	}
+	return outcomes, nil
+}
+
+// matchesFilter indique si une alerte satisfait un filtre
+func matchesFilter(alert models.TokenAlert, filter models.AlertFilter) bool {
+	switch {
+	case filter.TokenAddress != "" && alert.TokenAddress != filter.TokenAddress:
+		return false
+	case filter.AlertType != "" && alert.AlertType != filter.AlertType:
+		return false
+	case filter.Severity != "" && alert.Severity != filter.Severity:
+		return false
+	case !filter.From.IsZero() && alert.DetectedAt.Before(filter.From):
+		return false
+	case !filter.To.IsZero() && !alert.DetectedAt.Before(filter.To):
+		return false
+	case filter.Confirmed != nil && alert.IsConfirmed != *filter.Confirmed:
+		return false
+	case filter.Status != "" && alert.Status != filter.Status:
+		return false
+	case !filter.BeforeTime.IsZero() && !alertBefore(alert, models.TokenAlert{ID: filter.BeforeID, DetectedAt: filter.BeforeTime}):
+		return false
+	}
+	return true
+}
+
+// alertBefore ordonne les alertes par date de détection puis par ID
+func alertBefore(a, b models.TokenAlert) bool {
+	if !a.DetectedAt.Equal(b.DetectedAt) {
+		return a.DetectedAt.Before(b.DetectedAt)
+	}
+	return a.ID < b.ID
+}
+
+// copyAlert copie une alerte, ses wallets liés et son historique
+func copyAlert(alert models.TokenAlert) models.TokenAlert {
+	alert.RelatedWallets = append([]string(nil), alert.RelatedWallets...)
+	alert.History = append([]models.AlertStateChange(nil), alert.History...)
+	return alert
+}
@@ -0,0 +1,195 @@
+package alerting
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidSubscriber est retournée pour un abonné mal défini
+	ErrInvalidSubscriber = errors.New("invalid subscriber")
+	// ErrSubscriberNotFound est retournée pour un abonné inconnu
+	ErrSubscriberNotFound = errors.New("subscriber not found")
+)
+
+// subscriberIDPattern contraint les identifiants d'abonnés
+var subscriberIDPattern = regexp.MustCompile(`^[a-z0-9_][a-z0-9_-]*$`)
+
+// subscriberChannelTypes sont les canaux qu'un abonné peut utiliser
+var subscriberChannelTypes = []string{"telegram", "discord", "slack", "webhook"}
+
+// SubscriberStore persiste les abonnés. La base de données (table alert_subscribers) l'implémente.
+type SubscriberStore interface {
+	ListAlertSubscribers() ([]models.Subscriber, error)
+	SaveAlertSubscriber(sub *models.Subscriber) error
+	DeleteAlertSubscriber(id string) (bool, error)
+}
+
+// ValidateSubscriber normalise un abonné (sévérité et types en majuscules, noms de canaux
+// par défaut) et vérifie ses filtres et ses canaux
+func ValidateSubscriber(sub *models.Subscriber) error {
+	if !subscriberIDPattern.MatchString(sub.ID) {
+		return fmt.Errorf("%w: id %q must be lowercase letters, digits, _ or -", ErrInvalidSubscriber, sub.ID)
+	}
+	if sub.Name == "" {
+		sub.Name = sub.ID
+	}
+
+	sub.MinSeverity = strings.ToUpper(strings.TrimSpace(sub.MinSeverity))
+	if sub.MinSeverity != "" && SeverityRank(sub.MinSeverity) < 0 {
+		return fmt.Errorf("%w: %s: min_severity %q must be one of %s", ErrInvalidSubscriber, sub.ID, sub.MinSeverity, strings.Join(Severities, ", "))
+	}
+	if sub.MinXScore < 0 || sub.MinXScore > 100 {
+		return fmt.Errorf("%w: %s: min_xscore must be between 0 and 100", ErrInvalidSubscriber, sub.ID)
+	}
+	for i := range sub.AlertTypes {
+		sub.AlertTypes[i] = strings.ToUpper(strings.TrimSpace(sub.AlertTypes[i]))
+	}
+	for i := range sub.States {
+		sub.States[i] = strings.ToUpper(strings.TrimSpace(sub.States[i]))
+	}
+
+	if q := sub.QuietHours; q != nil {
+		if _, _, _, err := parseQuietHours(q); err != nil {
+			return fmt.Errorf("%w: %s: quiet_hours: %v", ErrInvalidSubscriber, sub.ID, err)
+		}
+	}
+
+	if len(sub.Channels) == 0 {
+		return fmt.Errorf("%w: %s: at least one channel is required", ErrInvalidSubscriber, sub.ID)
+	}
+	names := make(map[string]bool, len(sub.Channels))
+	for i := range sub.Channels {
+		channel := &sub.Channels[i]
+		channel.Type = strings.ToLower(channel.Type)
+		if !containsFold(subscriberChannelTypes, channel.Type) {
+			return fmt.Errorf("%w: %s: channel type %q must be one of %s", ErrInvalidSubscriber, sub.ID, channel.Type, strings.Join(subscriberChannelTypes, ", "))
+		}
+		if channel.Name == "" {
+			channel.Name = channel.Type
+		}
+		if names[channel.Name] {
+			return fmt.Errorf("%w: %s: duplicate channel name %q", ErrInvalidSubscriber, sub.ID, channel.Name)
+		}
+		names[channel.Name] = true
+
+		if _, err := NotifierFromConfig(channelConfig(*sub, *channel), http.DefaultClient, nil); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidSubscriber, sub.ID, err)
+		}
+	}
+	return nil
+}
+
+// channelConfig convertit un canal d'abonné en configuration de canal
+func channelConfig(sub models.Subscriber, channel models.SubscriberChannel) config.AlertChannelConfig {
+	cfg := config.AlertChannelConfig{
+		Name:    sub.ID + "/" + channel.Name,
+		Type:    channel.Type,
+		Enabled: true,
+		Token:   channel.Token,
+		ChatID:  channel.ChatID,
+		URL:     channel.URL,
+		Headers: channel.Headers,
+	}
+	// L'URL de l'API Telegram n'est pas modifiable par un abonné
+	if channel.Type == "telegram" {
+		cfg.URL = ""
+	}
+	return cfg
+}
+
+// subscriberRoutes construit les routes des canaux d'un abonné validé
+func subscriberRoutes(sub models.Subscriber, client *http.Client, logger *logrus.Logger) ([]Route, error) {
+	if !sub.Enabled {
+		return nil, nil
+	}
+
+	routes := make([]Route, 0, len(sub.Channels))
+	for _, channel := range sub.Channels {
+		notifier, err := NotifierFromConfig(channelConfig(sub, channel), client, logger)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, Route{
+			Notifier:   notifier,
+			Subscriber: sub.ID,
+			Accept: func(alert models.TokenAlert) bool {
+				return SubscriberAccepts(sub, alert, time.Now())
+			},
+		})
+	}
+	return routes, nil
+}
+
+// SubscriberAccepts indique si une alerte doit être envoyée à un abonné à l'instant now.
+// Une alerte sans X-Score ou sans état n'est pas écartée par ces filtres.
+func SubscriberAccepts(sub models.Subscriber, alert models.TokenAlert, now time.Time) bool {
+	switch {
+	case !sub.Enabled:
+		return false
+	case sub.MinSeverity != "" && SeverityRank(alert.Severity) < SeverityRank(sub.MinSeverity):
+		return false
+	case sub.MinXScore > 0 && alert.XScore > 0 && alert.XScore < sub.MinXScore:
+		return false
+	case !containsFold(sub.AlertTypes, alert.AlertType):
+		return false
+	case alert.TokenState != "" && !containsFold(sub.States, alert.TokenState):
+		return false
+	case len(sub.Watchlist) > 0 && !onWatchlist(sub.Watchlist, alert):
+		return false
+	case sub.QuietHours != nil && inQuietHours(sub.QuietHours, now):
+		return sub.QuietHours.AllowCritical && strings.EqualFold(alert.Severity, "CRITICAL")
+	}
+	return true
+}
+
+// onWatchlist indique si le token de l'alerte figure dans la liste, par adresse ou par symbole
+func onWatchlist(watchlist []string, alert models.TokenAlert) bool {
+	for _, entry := range watchlist {
+		if entry == alert.TokenAddress || (alert.TokenSymbol != "" && strings.EqualFold(entry, alert.TokenSymbol)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseQuietHours lit les bornes d'une plage calme, en minutes depuis minuit, et son fuseau
+func parseQuietHours(q *models.QuietHours) (int, int, *time.Location, error) {
+	start, err := time.Parse("15:04", q.Start)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("start %q must be HH:MM", q.Start)
+	}
+	end, err := time.Parse("15:04", q.End)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("end %q must be HH:MM", q.End)
+	}
+	location := time.UTC
+	if q.Timezone != "" {
+		if location, err = time.LoadLocation(q.Timezone); err != nil {
+			return 0, 0, nil, fmt.Errorf("unknown timezone %q", q.Timezone)
+		}
+	}
+	return start.Hour()*60 + start.Minute(), end.Hour()*60 + end.Minute(), location, nil
+}
+
+// inQuietHours indique si now tombe dans la plage calme; la plage peut passer minuit
+func inQuietHours(q *models.QuietHours, now time.Time) bool {
+	start, end, location, err := parseQuietHours(q)
+	if err != nil || start == end {
+		return false
+	}
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
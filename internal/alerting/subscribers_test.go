@@ -0,0 +1,111 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+func TestSubscriberAcceptsFilters(t *testing.T) {
+	sub := models.Subscriber{
+		ID:          "alice",
+		Enabled:     true,
+		MinXScore:   80,
+		MinSeverity: "URGENT",
+		States:      []string{"HYPED"},
+		Watchlist:   []string{"TOK"},
+		QuietHours:  &models.QuietHours{Start: "22:00", End: "07:00", AllowCritical: true},
+		Channels:    []models.SubscriberChannel{{Type: "slack", URL: "http://localhost/hook"}},
+	}
+	if err := ValidateSubscriber(&sub); err != nil {
+		t.Fatal(err)
+	}
+
+	noon := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)
+	base := models.TokenAlert{TokenAddress: "addr", TokenSymbol: "tok", AlertType: "HIGH_SCORE", Severity: "URGENT", XScore: 85, TokenState: "HYPED"}
+
+	cases := []struct {
+		name   string
+		change func(a *models.TokenAlert)
+		at     time.Time
+		want   bool
+	}{
+		{"matching", func(a *models.TokenAlert) {}, noon, true},
+		{"low score", func(a *models.TokenAlert) { a.XScore = 70 }, noon, false},
+		{"unknown score", func(a *models.TokenAlert) { a.XScore = 0 }, noon, true},
+		{"low severity", func(a *models.TokenAlert) { a.Severity = "ALERT" }, noon, false},
+		{"other state", func(a *models.TokenAlert) { a.TokenState = "SLEEP_MODE" }, noon, false},
+		{"not watched", func(a *models.TokenAlert) { a.TokenSymbol = "OTHER" }, noon, false},
+		{"quiet hours", func(a *models.TokenAlert) {}, night, false},
+		{"critical in quiet hours", func(a *models.TokenAlert) { a.Severity = "CRITICAL" }, night, true},
+	}
+	for _, c := range cases {
+		alert := base
+		c.change(&alert)
+		if got := SubscriberAccepts(sub, alert, c.at); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+
+	invalid := models.Subscriber{ID: "bob", Channels: []models.SubscriberChannel{{Type: "console"}}}
+	if err := ValidateSubscriber(&invalid); !errors.Is(err, ErrInvalidSubscriber) {
+		t.Errorf("console channel accepted: %v", err)
+	}
+}
+
+func TestManagerFansOutToMatchingSubscribers(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path]++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	m := NewManager(testLogger())
+	subscribers := []models.Subscriber{
+		{ID: "dumps", Enabled: true, AlertTypes: []string{"DUMP_DETECTED"}, Channels: []models.SubscriberChannel{{Type: "slack", URL: server.URL + "/dumps"}}},
+		{ID: "scores", Enabled: true, MinXScore: 80, Channels: []models.SubscriberChannel{{Type: "webhook", URL: server.URL + "/scores"}}},
+		{ID: "muted", Enabled: false, Channels: []models.SubscriberChannel{{Type: "slack", URL: server.URL + "/muted"}}},
+	}
+	for _, sub := range subscribers {
+		if _, err := m.SaveSubscriber(sub); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.dispatcher.Start()
+
+	raise := func(token, alertType string, xscore float64) {
+		t.Helper()
+		_, err := m.Raise(AlertSignal{TokenAddress: token, AlertType: alertType, Severity: "URGENT", XScore: xscore, Driver: math.NaN()})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	raise("a", "HIGH_SCORE", 90)
+	raise("b", "HIGH_SCORE", 60)
+	raise("c", "DUMP_DETECTED", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	m.dispatcher.Stop(ctx)
+
+	want := map[string]int{"/dumps": 1, "/scores": 2}
+	for path, count := range want {
+		if received[path] != count {
+			t.Errorf("%s received %d alerts, want %d (%v)", path, received[path], count, received)
+		}
+	}
+	if received["/muted"] != 0 {
+		t.Errorf("disabled subscriber received alerts")
+	}
+}
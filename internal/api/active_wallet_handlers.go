@@ -58,7 +58,7 @@ func (h *ActiveWalletHandler) GetTokenActiveWallets(w http.ResponseWriter, r *ht
 		h.logger.Error("Échec de la récupération des wallets actifs", err, map[string]interface{}{
 			"token_address": tokenAddress,
 		})
-		http.Error(w, "Erreur lors de la récupération des wallets actifs", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération des wallets actifs")
 		return
 	}
 
@@ -82,7 +82,7 @@ func (h *ActiveWalletHandler) GetTokenActiveWalletsCount(w http.ResponseWriter,
 		h.logger.Error("Échec du comptage des wallets actifs", err, map[string]interface{}{
 			"token_address": tokenAddress,
 		})
-		http.Error(w, "Erreur lors du comptage des wallets actifs", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors du comptage des wallets actifs")
 		return
 	}
 
@@ -125,7 +125,7 @@ func (h *ActiveWalletHandler) GetTrustedActiveWallets(w http.ResponseWriter, r *
 			"token_address": tokenAddress,
 			"min_score":     minScore,
 		})
-		http.Error(w, "Erreur lors de la récupération des wallets actifs de confiance", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération des wallets actifs de confiance")
 		return
 	}
 
@@ -190,7 +190,7 @@ func (h *ActiveWalletHandler) SearchActiveWallets(w http.ResponseWriter, r *http
 		h.logger.Error("Échec de la récupération des wallets actifs pour la recherche", err, map[string]interface{}{
 			"token_address": tokenAddress,
 		})
-		http.Error(w, "Erreur lors de la recherche des wallets actifs", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la recherche des wallets actifs")
 		return
 	}
 
@@ -278,7 +278,7 @@ func (h *ActiveWalletHandler) GetRecentActiveWallets(w http.ResponseWriter, r *h
 			"token_address": tokenAddress,
 			"hours":        hours,
 		})
-		http.Error(w, "Erreur lors de la récupération des wallets récemment actifs", http.StatusInternalServerError)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération des wallets récemment actifs")
 		return
 	}
 	
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// failingActiveWallets échoue sur toutes les lectures des wallets actifs
+type failingActiveWallets struct {
+	memory.MemoryOfTrust
+}
+
+func (failingActiveWallets) GetTokenActiveWallets(tokenAddress string, minTrustScore float64, limit int) ([]models.ActiveWallet, error) {
+	return nil, errors.New("database unreachable")
+}
+
+func (failingActiveWallets) GetActiveWalletsCount(tokenAddress string) (int, error) {
+	return 0, errors.New("database unreachable")
+}
+
+func TestActiveWalletErrorsUseEnvelope(t *testing.T) {
+	router := mux.NewRouter()
+	NewActiveWalletHandler(failingActiveWallets{}, logger.NewLogger("error")).RegisterRoutes(router)
+
+	for _, route := range []string{"", "/count", "/trusted", "/search", "/recent"} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/tokens/aaa/active-wallets"+route, nil))
+		var envelope ErrorResponse
+		if err := json.NewDecoder(rec.Body).Decode(&envelope); err != nil || rec.Code != http.StatusInternalServerError || envelope.Error.Code != ErrCodeInternal {
+			t.Errorf("%s: status %d, envelope %+v (%v)", route, rec.Code, envelope, err)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// maxAlertPageSize borne le nombre d'alertes retournées par page
+const maxAlertPageSize = 500
+
+// AlertHandler gère les requêtes API relatives aux alertes
+type AlertHandler struct {
+	manager *alerting.Manager
+	logger  *logger.Logger
+}
+
+// NewAlertHandler crée un nouveau gestionnaire pour les alertes
+func NewAlertHandler(manager *alerting.Manager, logger *logger.Logger) *AlertHandler {
+	return &AlertHandler{
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de l'API pour les alertes
+func (h *AlertHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/alerts", h.ListAlerts).Methods("GET")
+	router.HandleFunc("/api/alerts/deliveries", h.ListDeliveries).Methods("GET")
+	router.HandleFunc("/api/alerts/stats", h.GetAlertStats).Methods("GET")
+	router.HandleFunc("/api/alerts/rules", h.ListRules).Methods("GET")
+	router.HandleFunc("/api/alerts/rules", h.CreateRule).Methods("POST")
+	router.HandleFunc("/api/alerts/rules/validate", h.ValidateRule).Methods("POST")
+	router.HandleFunc("/api/alerts/rules/{name}", h.GetRule).Methods("GET")
+	router.HandleFunc("/api/alerts/rules/{name}", h.UpdateRule).Methods("PUT")
+	router.HandleFunc("/api/alerts/rules/{name}", h.DeleteRule).Methods("DELETE")
+	router.HandleFunc("/api/alerts/{id}", h.GetAlert).Methods("GET")
+	router.HandleFunc("/api/alerts/{id}/confirm", h.ConfirmAlert).Methods("POST")
+	router.HandleFunc("/api/alerts/{id}/resolve", h.ResolveAlert).Methods("POST")
+	router.HandleFunc("/api/alerts/{id}/history", h.GetAlertHistory).Methods("GET")
+	router.HandleFunc("/api/alerts/{id}/outcome", h.GetAlertOutcome).Methods("GET")
+	router.HandleFunc("/api/alerts/{id}/deliveries", h.GetAlertDeliveries).Methods("GET")
+}
+
+// ListAlerts retourne une page d'alertes filtrées, des plus récentes aux plus anciennes.
+// Filtres: token, type, severity, status, from et to (RFC 3339), confirmed; pagination: cursor, limit.
+func (h *AlertHandler) ListAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := models.AlertFilter{
+		TokenAddress: query.Get("token"),
+		AlertType:    query.Get("type"),
+		Severity:     query.Get("severity"),
+		Status:       strings.ToUpper(query.Get("status")),
+		Limit:        alerting.DefaultAlertPageSize,
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 || parsedLimit > maxAlertPageSize {
+			http.Error(w, "Paramètre limit invalide", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = parsedLimit
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		http.Error(w, "Paramètre from invalide (RFC 3339 attendu)", http.StatusBadRequest)
+		return
+	}
+	if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+		http.Error(w, "Paramètre to invalide (RFC 3339 attendu)", http.StatusBadRequest)
+		return
+	}
+
+	if confirmedStr := query.Get("confirmed"); confirmedStr != "" {
+		confirmed, err := strconv.ParseBool(confirmedStr)
+		if err != nil {
+			http.Error(w, "Paramètre confirmed invalide", http.StatusBadRequest)
+			return
+		}
+		filter.Confirmed = &confirmed
+	}
+
+	page, err := h.manager.QueryAlerts(filter, query.Get("cursor"))
+	if errors.Is(err, alerting.ErrInvalidCursor) {
+		http.Error(w, "Curseur de pagination invalide", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération des alertes", err, nil)
+		http.Error(w, "Erreur lors de la récupération des alertes", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// GetAlert retourne une alerte
+func (h *AlertHandler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	alertID := mux.Vars(r)["id"]
+
+	alert, err := h.manager.GetAlert(alertID)
+	if errors.Is(err, alerting.ErrAlertNotFound) {
+		http.Error(w, "Alerte introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération de l'alerte", err, map[string]interface{}{
+			"alert_id": alertID,
+		})
+		http.Error(w, "Erreur lors de la récupération de l'alerte", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alert)
+}
+
+// ConfirmAlert confirme une alerte
+func (h *AlertHandler) ConfirmAlert(w http.ResponseWriter, r *http.Request) {
+	alertID := mux.Vars(r)["id"]
+
+	err := h.manager.ConfirmAlert(alertID)
+	if errors.Is(err, alerting.ErrAlertNotFound) {
+		http.Error(w, "Alerte introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la confirmation de l'alerte", err, map[string]interface{}{
+			"alert_id": alertID,
+		})
+		http.Error(w, "Erreur lors de la confirmation de l'alerte", http.StatusInternalServerError)
+		return
+	}
+
+	h.GetAlert(w, r)
+}
+
+// ResolveAlert résout manuellement une alerte ouverte; le corps peut donner un motif {"reason": "..."}
+func (h *AlertHandler) ResolveAlert(w http.ResponseWriter, r *http.Request) {
+	alertID := mux.Vars(r)["id"]
+
+	var request struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+			return
+		}
+	}
+
+	_, err := h.manager.ResolveAlert(alertID, request.Reason)
+	if errors.Is(err, alerting.ErrAlertNotFound) {
+		http.Error(w, "Alerte introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la résolution de l'alerte", err, map[string]interface{}{
+			"alert_id": alertID,
+		})
+		http.Error(w, "Erreur lors de la résolution de l'alerte", http.StatusInternalServerError)
+		return
+	}
+
+	h.GetAlert(w, r)
+}
+
+// GetAlertHistory retourne l'historique des états d'une alerte: ouverture, escalades, résolution
+func (h *AlertHandler) GetAlertHistory(w http.ResponseWriter, r *http.Request) {
+	alertID := mux.Vars(r)["id"]
+
+	alert, err := h.manager.GetAlert(alertID)
+	if errors.Is(err, alerting.ErrAlertNotFound) {
+		http.Error(w, "Alerte introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération de l'historique de l'alerte", err, map[string]interface{}{
+			"alert_id": alertID,
+		})
+		http.Error(w, "Erreur lors de la récupération de l'historique", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"alert_id": alert.ID,
+		"status":   alert.Status,
+		"severity": alert.Severity,
+		"history":  alert.History,
+	})
+}
+
+// GetAlertOutcome retourne le suivi du marché après une alerte
+func (h *AlertHandler) GetAlertOutcome(w http.ResponseWriter, r *http.Request) {
+	alertID := mux.Vars(r)["id"]
+
+	outcome, err := h.manager.AlertOutcome(alertID)
+	if errors.Is(err, alerting.ErrAlertNotFound) {
+		http.Error(w, "Suivi de l'alerte introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération du suivi de l'alerte", err, map[string]interface{}{
+			"alert_id": alertID,
+		})
+		http.Error(w, "Erreur lors de la récupération du suivi de l'alerte", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(outcome)
+}
+
+// GetAlertStats retourne les performances des alertes par type.
+// Paramètres: since (RFC 3339, 30 jours par défaut), type.
+func (h *AlertHandler) GetAlertStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	since, err := parseTimeParam(query.Get("since"))
+	if err != nil {
+		http.Error(w, "Paramètre since invalide (RFC 3339 attendu)", http.StatusBadRequest)
+		return
+	}
+	if since.IsZero() {
+		since = time.Now().AddDate(0, 0, -30)
+	}
+
+	stats, err := h.manager.OutcomeStats(since, query.Get("type"))
+	if err != nil {
+		h.logger.Error("Échec du calcul des statistiques d'alertes", err, nil)
+		http.Error(w, "Erreur lors du calcul des statistiques", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"since": since,
+		"stats": stats,
+	})
+}
+
+// ListDeliveries retourne les derniers envois de notifications, filtrés par statut
+func (h *AlertHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+
+	limit := 100 // Valeur par défaut
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	deliveries := h.manager.RecentDeliveries(status, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// GetAlertDeliveries retourne l'état des envois d'une alerte sur chaque canal
+func (h *AlertHandler) GetAlertDeliveries(w http.ResponseWriter, r *http.Request) {
+	alertID := mux.Vars(r)["id"]
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"alert_id":   alertID,
+		"deliveries": h.manager.Deliveries(alertID),
+	})
+}
+
+// ListRules retourne les règles d'alerte
+func (h *AlertHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules := h.manager.Rules()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// GetRule retourne une règle d'alerte
+func (h *AlertHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := h.manager.GetRule(mux.Vars(r)["name"])
+	if err != nil {
+		http.Error(w, "Règle introuvable", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rule)
+}
+
+// CreateRule crée ou remplace une règle d'alerte
+func (h *AlertHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+		return
+	}
+	h.saveRule(w, rule, http.StatusCreated)
+}
+
+// UpdateRule remplace une règle d'alerte; le nom est celui du chemin
+func (h *AlertHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+		return
+	}
+	rule.Name = mux.Vars(r)["name"]
+	h.saveRule(w, rule, http.StatusOK)
+}
+
+// saveRule enregistre une règle et retourne la version normalisée
+func (h *AlertHandler) saveRule(w http.ResponseWriter, rule models.AlertRule, status int) {
+	saved, err := h.manager.SaveRule(rule)
+	if errors.Is(err, alerting.ErrInvalidRule) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de l'enregistrement de la règle d'alerte", err, map[string]interface{}{
+			"rule": rule.Name,
+		})
+		http.Error(w, "Erreur lors de l'enregistrement de la règle", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// ValidateRule vérifie une règle sans l'enregistrer
+func (h *AlertHandler) ValidateRule(w http.ResponseWriter, r *http.Request) {
+	var rule models.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+		return
+	}
+
+	response := map[string]interface{}{"valid": true, "rule": rule}
+	if err := alerting.ValidateRule(&rule); err != nil {
+		response = map[string]interface{}{"valid": false, "error": err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteRule supprime une règle d'alerte
+func (h *AlertHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	err := h.manager.DeleteRule(name)
+	if errors.Is(err, alerting.ErrRuleNotFound) {
+		http.Error(w, "Règle introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la suppression de la règle d'alerte", err, map[string]interface{}{
+			"rule": name,
+		})
+		http.Error(w, "Erreur lors de la suppression de la règle", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseTimeParam lit une date RFC 3339 optionnelle
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/gorilla/mux"
+)
+
+// publicRoutes sont accessibles sans clé d'API
+var publicRoutes = map[string]bool{
+	"/api/health":       true,
+	"/api/openapi.json": true,
+}
+
+// requiredScope retourne le scope exigé par une route, "" si elle est publique.
+// Les écritures et les routes d'administration (pipeline, abonnés, clés) exigent admin.
+func requiredScope(method, template string) string {
+	switch {
+	case publicRoutes[template]:
+		return ""
+	case strings.HasPrefix(template, "/api/stream/"):
+		return models.ScopeStream
+	case method != http.MethodGet:
+		return models.ScopeAdmin
+	case strings.HasPrefix(template, "/api/wallets"),
+		strings.HasPrefix(template, "/api/graph"),
+		strings.Contains(template, "/active-wallets"):
+		return models.ScopeReadWallets
+	case strings.HasPrefix(template, "/api/tokens"),
+		strings.HasPrefix(template, "/api/alerts"),
+		strings.HasPrefix(template, "/api/digests"):
+		return models.ScopeReadTokens
+	}
+	return models.ScopeAdmin
+}
+
+// apiKeyFromRequest lit la clé présentée: en-tête Authorization: Bearer ou X-API-Key.
+// Le paramètre api_key n'est accepté que sur les flux, EventSource et WebSocket ne
+// permettant pas d'ajouter d'en-tête dans un navigateur.
+func apiKeyFromRequest(r *http.Request, scope string) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if scope == models.ScopeStream {
+		return r.URL.Query().Get("api_key")
+	}
+	return ""
+}
+
+// authMiddleware vérifie la clé d'API, son scope et son débit, puis journalise son utilisation
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		template := r.URL.Path
+		if route := mux.CurrentRoute(r); route != nil {
+			if t, err := route.GetPathTemplate(); err == nil {
+				template = t
+			}
+		}
+		scope := requiredScope(r.Method, template)
+
+		// Sans authentification, seules les lectures sont servies: les écritures et les routes
+		// d'administration seraient sinon ouvertes à tous
+		if s.auth == nil || !s.auth.Enabled() {
+			if scope == models.ScopeAdmin {
+				writeError(w, http.StatusForbidden, ErrCodeForbidden,
+					"Routes d'administration désactivées tant que api.auth.enabled est faux")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if scope == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		usage := models.APIKeyUsage{
+			Method:     r.Method,
+			Path:       template,
+			RemoteAddr: r.RemoteAddr,
+		}
+		deny := func(status int, code, reason, message string) {
+			usage.Status = status
+			usage.Reason = reason
+			s.auth.Record(usage)
+			writeError(w, status, code, message)
+		}
+
+		raw := apiKeyFromRequest(r, scope)
+		if raw == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="crypto-oracle"`)
+			writeError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Clé d'API requise")
+			return
+		}
+		usage.Prefix, _ = auth.ParsePrefix(raw)
+
+		key, err := s.auth.Authenticate(raw)
+		if key != nil {
+			usage.KeyID = key.ID
+		}
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="crypto-oracle", error="invalid_token"`)
+			message := "Clé d'API invalide"
+			switch {
+			case errors.Is(err, auth.ErrKeyRevoked):
+				message = "Clé d'API révoquée"
+			case errors.Is(err, auth.ErrKeyExpired):
+				message = "Clé d'API expirée"
+			}
+			deny(http.StatusUnauthorized, ErrCodeUnauthorized, err.Error(), message)
+			return
+		}
+		usage.Prefix = key.Prefix
+
+		if !key.HasScope(scope) {
+			deny(http.StatusForbidden, ErrCodeForbidden, "missing scope "+scope,
+				fmt.Sprintf("Scope %s requis", scope))
+			return
+		}
+
+		decision := s.auth.Allow(key)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		if !decision.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+			deny(http.StatusTooManyRequests, ErrCodeRateLimited, "rate limited", "Limite de requêtes atteinte")
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(auth.WithKey(r.Context(), key)))
+
+		usage.Status = recorder.status
+		s.auth.Record(usage)
+	})
+}
+
+// statusRecorder retient le statut de la réponse. Il expose Flush et Hijack pour que
+// le SSE et le WebSocket fonctionnent derrière le middleware.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader retient le statut avant de l'écrire
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush vide le tampon de la réponse si le ResponseWriter le permet
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack rend la connexion au handler, pour le WebSocket
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	r.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Unwrap donne accès au ResponseWriter d'origine à http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const testBootstrapKey = "test-bootstrap-key-0123456789abcdef"
+
+func newAuthServer(t *testing.T) (*Server, *auth.Manager) {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	manager := auth.NewManager(log)
+	if err := manager.ApplyConfig(&config.AuthConfig{Enabled: true, BootstrapKey: testBootstrapKey, DefaultRateLimit: 60, DefaultBurst: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	server := NewServer(&config.APIConfig{}, nil, logger.NewLogger("error"))
+	server.SetAuth(manager)
+	server.SetWallets(stubWalletTrust{scores: map[string]float64{"w1": 50}}, nil)
+	return server, manager
+}
+
+func doRequest(server *Server, method, path, key string, body interface{}) *httptest.ResponseRecorder {
+	var payload io.Reader
+	if body != nil {
+		data, _ := json.Marshal(body)
+		payload = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, payload)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	server, manager := newAuthServer(t)
+
+	if rec := doRequest(server, "GET", "/api/health", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("health: status %d", rec.Code)
+	}
+	rec := doRequest(server, "GET", "/api/wallets/w1/trust", "", nil)
+	var errBody ErrorResponse
+	json.NewDecoder(rec.Body).Decode(&errBody)
+	if rec.Code != http.StatusUnauthorized || errBody.Error.Code != ErrCodeUnauthorized || rec.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("missing key: status %d, body %+v", rec.Code, errBody)
+	}
+
+	// La clé bootstrap émet une clé limitée à la lecture des tokens
+	rec = doRequest(server, "POST", "/api/keys", testBootstrapKey, auth.IssueRequest{Name: "reader", Scopes: []string{models.ScopeReadTokens}})
+	var issued issuedKey
+	json.NewDecoder(rec.Body).Decode(&issued)
+	if rec.Code != http.StatusCreated || issued.Key == "" || issued.APIKey == nil {
+		t.Fatalf("issue: status %d, body %+v", rec.Code, issued)
+	}
+
+	if rec := doRequest(server, "GET", "/api/wallets/w1/trust", issued.Key, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("wrong scope: status %d", rec.Code)
+	}
+	if rec := doRequest(server, "GET", "/api/keys", issued.Key, nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("admin route: status %d", rec.Code)
+	}
+
+	rec = doRequest(server, "GET", "/api/wallets/w1/trust", testBootstrapKey, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "60" {
+		t.Fatalf("admin key: status %d, headers %v", rec.Code, rec.Header())
+	}
+
+	// Le seau de la clé bootstrap contient deux requêtes, dont deux déjà consommées
+	rec = doRequest(server, "GET", "/api/keys", testBootstrapKey, nil)
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("rate limit: status %d, headers %v", rec.Code, rec.Header())
+	}
+
+	if _, err := manager.Revoke(issued.APIKey.ID); err != nil {
+		t.Fatal(err)
+	}
+	if rec := doRequest(server, "GET", "/api/wallets/w1/trust", issued.Key, nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("revoked key: status %d", rec.Code)
+	}
+
+	usage, _ := manager.Usage(issued.APIKey.ID, 10)
+	if len(usage) != 3 || usage[0].Reason != auth.ErrKeyRevoked.Error() || usage[2].Path != "/api/wallets/{walletAddress}/trust" {
+		t.Fatalf("audit log %+v", usage)
+	}
+}
+
+func TestAdminRoutesClosedWithoutAuth(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	server := NewServer(&config.APIConfig{}, nil, logger.NewLogger("error"))
+	server.SetAuth(auth.NewManager(log))
+	server.SetWallets(stubWalletTrust{scores: map[string]float64{"w1": 50}}, nil)
+
+	if rec := doRequest(server, "GET", "/api/wallets/w1/trust", "", nil); rec.Code != http.StatusOK {
+		t.Fatalf("read route: status %d", rec.Code)
+	}
+	for _, method := range []string{"GET", "POST"} {
+		if rec := doRequest(server, method, "/api/keys", "", nil); rec.Code != http.StatusForbidden {
+			t.Errorf("%s /api/keys: status %d", method, rec.Code)
+		}
+	}
+}
+
+func TestRequiredScope(t *testing.T) {
+	cases := []struct {
+		method, template, scope string
+	}{
+		{"GET", "/api/health", ""},
+		{"GET", "/api/stream/events", models.ScopeStream},
+		{"GET", "/api/tokens/{tokenAddress}", models.ScopeReadTokens},
+		{"GET", "/api/alerts", models.ScopeReadTokens},
+		{"GET", "/api/tokens/{tokenAddress}/active-wallets", models.ScopeReadWallets},
+		{"GET", "/api/wallets/trusted", models.ScopeReadWallets},
+		{"GET", "/api/graph/paths", models.ScopeReadWallets},
+		{"POST", "/api/alerts/{id}/resolve", models.ScopeAdmin},
+		{"GET", "/api/pipeline/stats", models.ScopeAdmin},
+		{"GET", "/api/keys", models.ScopeAdmin},
+		{"GET", "/api/maintenance/jobs/{id}", models.ScopeAdmin},
+	}
+	for _, c := range cases {
+		if got := requiredScope(c.method, c.template); got != c.scope {
+			t.Errorf("%s %s: got %q, want %q", c.method, c.template, got, c.scope)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// setPrivateCache rend une réponse cacheable par le seul client: les routes sont authentifiées,
+// un cache partagé ne doit pas servir la réponse d'une clé à une autre
+func setPrivateCache(w http.ResponseWriter, maxAge time.Duration) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	w.Header().Set("Vary", "Authorization, X-API-Key")
+}
+
+// writeCachedJSON écrit une réponse JSON cacheable: Cache-Control privé avec la durée donnée et un
+// ETag calculé sur le corps. Une requête If-None-Match correspondante reçoit un 304 sans corps.
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, maxAge time.Duration, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de l'encodage de la réponse")
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	setPrivateCache(w, maxAge)
+	w.Header().Set("ETag", etag)
+	if match := r.Header.Get("If-None-Match"); match != "" && (match == etag || match == "*") {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/digest"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// DigestHandler gère les requêtes API relatives aux digests
+type DigestHandler struct {
+	generator *digest.Generator
+	logger    *logger.Logger
+}
+
+// NewDigestHandler crée un nouveau gestionnaire pour les digests
+func NewDigestHandler(generator *digest.Generator, logger *logger.Logger) *DigestHandler {
+	return &DigestHandler{
+		generator: generator,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de l'API pour les digests
+func (h *DigestHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/digests", h.ListDigests).Methods("GET")
+	router.HandleFunc("/api/digests", h.GenerateDigest).Methods("POST")
+	router.HandleFunc("/api/digests/latest", h.GetLatestDigest).Methods("GET")
+	router.HandleFunc("/api/digests/{id}", h.GetDigest).Methods("GET")
+	router.HandleFunc("/api/digests/{id}/deliveries", h.GetDigestDeliveries).Methods("GET")
+}
+
+// digestSummary décrit un digest dans une liste, sans son contenu
+type digestSummary struct {
+	ID          string    `json:"id"`
+	Period      string    `json:"period"`
+	Title       string    `json:"title"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// ListDigests retourne les derniers digests, filtrés par période
+func (h *DigestHandler) ListDigests(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+
+	limit := 50 // Valeur par défaut
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 || parsedLimit > 500 {
+			http.Error(w, "Paramètre limit invalide", http.StatusBadRequest)
+			return
+		}
+		limit = parsedLimit
+	}
+
+	digests, err := h.generator.Digests(period, limit)
+	if err != nil {
+		h.logger.Error("Échec de la récupération des digests", err, nil)
+		http.Error(w, "Erreur lors de la récupération des digests", http.StatusInternalServerError)
+		return
+	}
+
+	summaries := make([]digestSummary, 0, len(digests))
+	for _, d := range digests {
+		summaries = append(summaries, digestSummary{
+			ID:          d.ID,
+			Period:      d.Period,
+			Title:       d.Title,
+			From:        d.From,
+			To:          d.To,
+			GeneratedAt: d.GeneratedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"digests": summaries,
+		"count":   len(summaries),
+	})
+}
+
+// GetDigest retourne un digest en JSON, ou son rendu avec format=markdown ou format=html
+func (h *DigestHandler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	d, err := h.generator.Digest(id)
+	if errors.Is(err, digest.ErrDigestNotFound) {
+		http.Error(w, "Digest introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération du digest", err, map[string]interface{}{
+			"digest": id,
+		})
+		http.Error(w, "Erreur lors de la récupération du digest", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeDigest(w, r, d)
+}
+
+// GetLatestDigest retourne le digest le plus récent d'une période
+func (h *DigestHandler) GetLatestDigest(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		period = models.DigestPeriodDaily
+	}
+
+	digests, err := h.generator.Digests(period, 1)
+	if err != nil {
+		h.logger.Error("Échec de la récupération des digests", err, nil)
+		http.Error(w, "Erreur lors de la récupération des digests", http.StatusInternalServerError)
+		return
+	}
+	if len(digests) == 0 {
+		http.Error(w, "Aucun digest pour cette période", http.StatusNotFound)
+		return
+	}
+
+	h.writeDigest(w, r, &digests[0])
+}
+
+// writeDigest écrit un digest au format demandé par le paramètre format
+func (h *DigestHandler) writeDigest(w http.ResponseWriter, r *http.Request, d *models.Digest) {
+	switch r.URL.Query().Get("format") {
+	case "markdown", "md":
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(d.Markdown))
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(d.HTML))
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d)
+	default:
+		http.Error(w, "Paramètre format invalide (json, markdown ou html)", http.StatusBadRequest)
+	}
+}
+
+// GenerateDigest construit le digest de la dernière période complète avant at (maintenant par défaut),
+// et l'envoie sur les canaux si deliver est vrai
+func (h *DigestHandler) GenerateDigest(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Period  string    `json:"period"`
+		At      time.Time `json:"at"`
+		Deliver bool      `json:"deliver"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+		return
+	}
+	if req.At.IsZero() {
+		req.At = time.Now()
+	}
+
+	d, err := h.generator.Generate(req.Period, req.At, req.Deliver)
+	if errors.Is(err, digest.ErrUnknownPeriod) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la génération du digest", err, map[string]interface{}{
+			"period": req.Period,
+		})
+		http.Error(w, "Erreur lors de la génération du digest", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+// GetDigestDeliveries retourne l'état des envois d'un digest sur chaque canal
+func (h *DigestHandler) GetDigestDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	deliveries := h.generator.Deliveries(id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"digest":     id,
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Codes d'erreur de l'enveloppe JSON
+const (
+	ErrCodeInvalidParameter = "invalid_parameter"
+	ErrCodeNotFound         = "not_found"
+	ErrCodeUpstream         = "upstream_error"
+	ErrCodeInternal         = "internal_error"
+	ErrCodeUnavailable      = "unavailable"
+	ErrCodeLagging          = "lagging"
+	ErrCodeUnauthorized     = "unauthorized"
+	ErrCodeForbidden        = "forbidden"
+	ErrCodeRateLimited      = "rate_limited"
+	ErrCodeConflict         = "conflict"
+)
+
+// ErrorResponse est l'enveloppe JSON commune des erreurs de l'API
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody décrit une erreur: code stable pour les clients et message lisible
+type ErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError écrit une erreur dans l'enveloppe JSON commune
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, ErrorResponse{Error: ErrorBody{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}})
+}
+
+// writeJSON écrit une réponse JSON avec le statut donné
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// graphMaxAge est la durée de cache des requêtes sur le graphe, mis à jour à chaque interaction
+const graphMaxAge = time.Minute
+
+// Formats de sortie des requêtes sur le graphe
+const (
+	graphFormatJSON      = "json"
+	graphFormatCytoscape = "cytoscape"
+	graphFormatDOT       = "dot"
+)
+
+// TrustGraphQuery regroupe les requêtes sur le graphe de confiance (implémenté par memory.TrustNetwork)
+type TrustGraphQuery interface {
+	Neighborhood(kind, address string, hops, maxNodes int) (*models.TrustSubgraph, error)
+	ShortestWalletPath(from, to string, maxHops int) (*models.TrustPath, error)
+	EarlyBuyersSubgraph(tokenAddress string, buyers, maxNodes int) (*models.TrustSubgraph, error)
+}
+
+var _ TrustGraphQuery = (*memory.TrustNetwork)(nil)
+
+// GraphHandler gère les requêtes sur le graphe de confiance wallets-tokens
+type GraphHandler struct {
+	graph  TrustGraphQuery
+	logger *logger.Logger
+}
+
+// NewGraphHandler crée un nouveau gestionnaire pour le graphe de confiance
+func NewGraphHandler(graph TrustGraphQuery, logger *logger.Logger) *GraphHandler {
+	return &GraphHandler{
+		graph:  graph,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de requête sur le graphe de confiance
+func (h *GraphHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/graph/wallets/{walletAddress}/neighborhood", h.GetWalletNeighborhood).Methods("GET")
+	router.HandleFunc("/api/graph/tokens/{tokenAddress}/neighborhood", h.GetTokenNeighborhood).Methods("GET")
+	router.HandleFunc("/api/graph/tokens/{tokenAddress}/early-buyers", h.GetEarlyBuyers).Methods("GET")
+	router.HandleFunc("/api/graph/paths", h.GetShortestPath).Methods("GET")
+}
+
+// GetWalletNeighborhood retourne le voisinage d'un wallet: ses tokens, leurs autres wallets, etc.
+func (h *GraphHandler) GetWalletNeighborhood(w http.ResponseWriter, r *http.Request) {
+	h.neighborhood(w, r, models.GraphNodeWallet, mux.Vars(r)["walletAddress"])
+}
+
+// GetTokenNeighborhood retourne le voisinage d'un token: ses wallets, leurs autres tokens, etc.
+func (h *GraphHandler) GetTokenNeighborhood(w http.ResponseWriter, r *http.Request) {
+	h.neighborhood(w, r, models.GraphNodeToken, mux.Vars(r)["tokenAddress"])
+}
+
+// neighborhood répond avec les nœuds à au plus hops liens du nœud demandé
+func (h *GraphHandler) neighborhood(w http.ResponseWriter, r *http.Request, kind, address string) {
+	format, ok := graphFormat(w, r)
+	if !ok || !h.requireGraph(w) {
+		return
+	}
+	hops, ok := intParam(w, r, "hops", 2, 1, 4)
+	if !ok {
+		return
+	}
+	maxNodes, ok := intParam(w, r, "max_nodes", 200, 1, 2000)
+	if !ok {
+		return
+	}
+
+	subgraph, err := h.graph.Neighborhood(kind, address, hops, maxNodes)
+	if errors.Is(err, memory.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Nœud absent du graphe de confiance")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec du parcours du graphe de confiance", err, map[string]interface{}{
+			"kind":    kind,
+			"address": address,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de parcourir le graphe de confiance")
+		return
+	}
+
+	writeGraph(w, r, format, subgraph.Root, subgraph.Nodes, subgraph.Edges, subgraph.Truncated, subgraph)
+}
+
+// GetEarlyBuyers retourne le sous-graphe des premiers acheteurs d'un token et des tokens
+// qu'ils ont en commun. Paramètres: buyers (transactions lues), max_nodes, format.
+func (h *GraphHandler) GetEarlyBuyers(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+	format, ok := graphFormat(w, r)
+	if !ok || !h.requireGraph(w) {
+		return
+	}
+	buyers, ok := intParam(w, r, "buyers", 50, 1, 500)
+	if !ok {
+		return
+	}
+	maxNodes, ok := intParam(w, r, "max_nodes", 200, 1, 2000)
+	if !ok {
+		return
+	}
+
+	subgraph, err := h.graph.EarlyBuyersSubgraph(tokenAddress, buyers, maxNodes)
+	if errors.Is(err, memory.ErrNodeNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Token absent du graphe de confiance")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération des premiers acheteurs", err, map[string]interface{}{
+			"token_address": tokenAddress,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de récupérer les premiers acheteurs")
+		return
+	}
+
+	writeGraph(w, r, format, subgraph.Root, subgraph.Nodes, subgraph.Edges, subgraph.Truncated, subgraph)
+}
+
+// GetShortestPath retourne un plus court chemin entre deux wallets via des tokens partagés.
+// Paramètres: from, to, max_hops (tokens traversés), format.
+func (h *GraphHandler) GetShortestPath(w http.ResponseWriter, r *http.Request) {
+	format, ok := graphFormat(w, r)
+	if !ok || !h.requireGraph(w) {
+		return
+	}
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Paramètres from et to requis")
+		return
+	}
+	maxHops, ok := intParam(w, r, "max_hops", 3, 1, 6)
+	if !ok {
+		return
+	}
+
+	path, err := h.graph.ShortestWalletPath(from, to, maxHops)
+	switch {
+	case errors.Is(err, memory.ErrNodeNotFound):
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Wallet absent du graphe de confiance")
+		return
+	case errors.Is(err, memory.ErrNoPath):
+		writeError(w, http.StatusNotFound, ErrCodeNotFound,
+			fmt.Sprintf("Aucun chemin entre ces wallets en %d tokens partagés au plus", maxHops))
+		return
+	case err != nil:
+		h.logger.Error("Échec de la recherche de chemin dans le graphe de confiance", err, map[string]interface{}{
+			"from": from,
+			"to":   to,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de rechercher un chemin")
+		return
+	}
+
+	root := ""
+	if len(path.Nodes) > 0 {
+		root = path.Nodes[0].ID
+	}
+	writeGraph(w, r, format, root, path.Nodes, path.Edges, false, path)
+}
+
+// requireGraph répond 503 si aucun graphe de confiance n'est configuré
+func (h *GraphHandler) requireGraph(w http.ResponseWriter) bool {
+	if h.graph == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Graphe de confiance indisponible")
+		return false
+	}
+	return true
+}
+
+// graphFormat lit le format de sortie demandé: json (par défaut), cytoscape ou dot
+func graphFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	switch format {
+	case "":
+		return graphFormatJSON, true
+	case graphFormatJSON, graphFormatCytoscape, graphFormatDOT:
+		return format, true
+	}
+	writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Paramètre format invalide (json, cytoscape ou dot)")
+	return "", false
+}
+
+// cytoscapeGraph est un graphe au format d'éléments de Cytoscape.js
+type cytoscapeGraph struct {
+	Root      string            `json:"root"`
+	Truncated bool              `json:"truncated"`
+	Elements  cytoscapeElements `json:"elements"`
+}
+
+// cytoscapeElements regroupe les nœuds et les liens d'un graphe Cytoscape.js
+type cytoscapeElements struct {
+	Nodes []cytoscapeNode `json:"nodes"`
+	Edges []cytoscapeEdge `json:"edges"`
+}
+
+// cytoscapeNode est un nœud Cytoscape.js
+type cytoscapeNode struct {
+	Data models.GraphNode `json:"data"`
+}
+
+// cytoscapeEdge est un lien Cytoscape.js
+type cytoscapeEdge struct {
+	Data cytoscapeEdgeData `json:"data"`
+}
+
+// cytoscapeEdgeData décrit un lien Cytoscape.js, qui exige un ID
+type cytoscapeEdgeData struct {
+	ID           string `json:"id"`
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Interactions int    `json:"interactions"`
+}
+
+// writeGraph écrit un graphe dans le format demandé; body est la réponse au format json
+func writeGraph(w http.ResponseWriter, r *http.Request, format, root string, nodes []models.GraphNode, edges []models.GraphEdge, truncated bool, body interface{}) {
+	switch format {
+	case graphFormatCytoscape:
+		graph := cytoscapeGraph{
+			Root:      root,
+			Truncated: truncated,
+			Elements: cytoscapeElements{
+				Nodes: make([]cytoscapeNode, 0, len(nodes)),
+				Edges: make([]cytoscapeEdge, 0, len(edges)),
+			},
+		}
+		for _, node := range nodes {
+			graph.Elements.Nodes = append(graph.Elements.Nodes, cytoscapeNode{Data: node})
+		}
+		for _, edge := range edges {
+			graph.Elements.Edges = append(graph.Elements.Edges, cytoscapeEdge{Data: cytoscapeEdgeData{
+				ID:           edge.Source + "|" + edge.Target,
+				Source:       edge.Source,
+				Target:       edge.Target,
+				Interactions: edge.Interactions,
+			}})
+		}
+		writeCachedJSON(w, r, graphMaxAge, graph)
+
+	case graphFormatDOT:
+		var b strings.Builder
+		b.WriteString("graph trust {\n")
+		for _, node := range nodes {
+			shape := "ellipse"
+			if node.Kind == models.GraphNodeToken {
+				shape = "box"
+			}
+			style := ""
+			if node.ID == root {
+				style = ", style=bold"
+			}
+			fmt.Fprintf(&b, "  %s [label=%s, shape=%s%s];\n", strconv.Quote(node.ID), strconv.Quote(node.Address), shape, style)
+		}
+		for _, edge := range edges {
+			fmt.Fprintf(&b, "  %s -- %s [weight=%d];\n", strconv.Quote(edge.Source), strconv.Quote(edge.Target), edge.Interactions)
+		}
+		b.WriteString("}\n")
+
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		setPrivateCache(w, graphMaxAge)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(b.String()))
+
+	default:
+		writeCachedJSON(w, r, graphMaxAge, body)
+	}
+}
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+type stubTrustGraph struct {
+	hops int
+}
+
+func (s *stubTrustGraph) Neighborhood(kind, address string, hops, maxNodes int) (*models.TrustSubgraph, error) {
+	if address != "w1" {
+		return nil, fmt.Errorf("%w: %s", memory.ErrNodeNotFound, address)
+	}
+	s.hops = hops
+	return &models.TrustSubgraph{
+		Root: "wallet:w1",
+		Nodes: []models.GraphNode{
+			{ID: "wallet:w1", Kind: models.GraphNodeWallet, Address: "w1", TrustScore: 70, Degree: 1},
+			{ID: "token:T1", Kind: models.GraphNodeToken, Address: "T1", Degree: 1, Depth: 1},
+		},
+		Edges: []models.GraphEdge{{Source: "wallet:w1", Target: "token:T1", Interactions: 3}},
+	}, nil
+}
+
+func (s *stubTrustGraph) ShortestWalletPath(from, to string, maxHops int) (*models.TrustPath, error) {
+	return nil, fmt.Errorf("%w: %s and %s", memory.ErrNoPath, from, to)
+}
+
+func (s *stubTrustGraph) EarlyBuyersSubgraph(tokenAddress string, buyers, maxNodes int) (*models.TrustSubgraph, error) {
+	return &models.TrustSubgraph{Root: "token:" + tokenAddress, Nodes: []models.GraphNode{}, Edges: []models.GraphEdge{}}, nil
+}
+
+func TestGraphHandlerFormats(t *testing.T) {
+	graph := &stubTrustGraph{}
+	router := mux.NewRouter()
+	NewGraphHandler(graph, logger.NewLogger("error")).RegisterRoutes(router)
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	rec := get("/api/graph/wallets/w1/neighborhood?hops=3")
+	var subgraph models.TrustSubgraph
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &subgraph) != nil || len(subgraph.Edges) != 1 || graph.hops != 3 {
+		t.Fatalf("json: status %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	rec = get("/api/graph/wallets/w1/neighborhood?format=cytoscape")
+	var cy cytoscapeGraph
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &cy) != nil {
+		t.Fatalf("cytoscape: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	if len(cy.Elements.Nodes) != 2 || cy.Elements.Nodes[0].Data.ID != "wallet:w1" ||
+		len(cy.Elements.Edges) != 1 || cy.Elements.Edges[0].Data.ID != "wallet:w1|token:T1" {
+		t.Fatalf("cytoscape elements %+v", cy.Elements)
+	}
+
+	rec = get("/api/graph/wallets/w1/neighborhood?format=dot")
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/vnd.graphviz") ||
+		!strings.Contains(body, `"wallet:w1" -- "token:T1" [weight=3];`) || !strings.Contains(body, `"token:T1" [label="T1", shape=box];`) {
+		t.Fatalf("dot: status %d, body %s", rec.Code, body)
+	}
+
+	for target, status := range map[string]int{
+		"/api/graph/wallets/w1/neighborhood?format=svg": http.StatusBadRequest,
+		"/api/graph/wallets/w1/neighborhood?hops=9":     http.StatusBadRequest,
+		"/api/graph/wallets/w2/neighborhood":            http.StatusNotFound,
+		"/api/graph/paths?from=w1":                      http.StatusBadRequest,
+		"/api/graph/paths?from=w1&to=w2":                http.StatusNotFound,
+		"/api/graph/tokens/T1/early-buyers?buyers=20":   http.StatusOK,
+	} {
+		if rec := get(target); rec.Code != status {
+			t.Errorf("%s: status %d, want %d", target, rec.Code, status)
+		}
+	}
+}
+
+func TestGraphHandlerUnavailable(t *testing.T) {
+	router := mux.NewRouter()
+	NewGraphHandler(nil, logger.NewLogger("error")).RegisterRoutes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/graph/paths?from=a&to=b", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d", rec.Code)
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// KeyHandler gère l'administration des clés d'API: émission, rotation, révocation et audit
+type KeyHandler struct {
+	manager *auth.Manager
+	logger  *logger.Logger
+}
+
+// NewKeyHandler crée un nouveau gestionnaire pour les clés d'API
+func NewKeyHandler(manager *auth.Manager, logger *logger.Logger) *KeyHandler {
+	return &KeyHandler{
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes d'administration des clés
+func (h *KeyHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/keys", h.ListKeys).Methods("GET")
+	router.HandleFunc("/api/keys", h.IssueKey).Methods("POST")
+	router.HandleFunc("/api/keys/usage", h.GetUsage).Methods("GET")
+	router.HandleFunc("/api/keys/{id}", h.GetKey).Methods("GET")
+	router.HandleFunc("/api/keys/{id}", h.RevokeKey).Methods("DELETE")
+	router.HandleFunc("/api/keys/{id}/rotate", h.RotateKey).Methods("POST")
+	router.HandleFunc("/api/keys/{id}/usage", h.GetKeyUsage).Methods("GET")
+}
+
+// issuedKey est la réponse à l'émission d'une clé: la clé complète n'est jamais renvoyée ensuite
+type issuedKey struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// ListKeys retourne les clés émises, révoquées comprises
+func (h *KeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys := h.manager.Keys()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys":  keys,
+		"count": len(keys),
+	})
+}
+
+// GetKey retourne une clé
+func (h *KeyHandler) GetKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.manager.Key(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Clé d'API introuvable")
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// IssueKey émet une clé à partir de son nom, ses scopes, sa limite de débit et son expiration
+func (h *KeyHandler) IssueKey(w http.ResponseWriter, r *http.Request) {
+	var req auth.IssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Corps de requête invalide")
+		return
+	}
+
+	raw, key, err := h.manager.Issue(req)
+	if errors.Is(err, auth.ErrInvalidKeyRequest) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de l'émission de la clé d'API", err, map[string]interface{}{
+			"name": req.Name,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de l'émission de la clé")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, issuedKey{Key: raw, APIKey: key})
+}
+
+// RotateKey remplace une clé par une nouvelle aux mêmes droits. L'ancienne reste valide
+// pendant grace_seconds (0 par défaut).
+func (h *KeyHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		GraceSeconds int `json:"grace_seconds"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Corps de requête invalide")
+			return
+		}
+	}
+
+	id := mux.Vars(r)["id"]
+	raw, key, err := h.manager.Rotate(id, time.Duration(body.GraceSeconds)*time.Second)
+	switch {
+	case errors.Is(err, auth.ErrKeyNotFound):
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Clé d'API introuvable")
+		return
+	case errors.Is(err, auth.ErrInvalidKeyRequest):
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	case err != nil:
+		h.logger.Error("Échec de la rotation de la clé d'API", err, map[string]interface{}{
+			"key_id": id,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la rotation de la clé")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, issuedKey{Key: raw, APIKey: key})
+}
+
+// RevokeKey révoque immédiatement une clé; elle reste listée avec sa date de révocation
+func (h *KeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	key, err := h.manager.Revoke(id)
+	if errors.Is(err, auth.ErrKeyNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Clé d'API introuvable")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la révocation de la clé d'API", err, map[string]interface{}{
+			"key_id": id,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la révocation de la clé")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, key)
+}
+
+// GetUsage retourne le journal d'audit de toutes les clés
+func (h *KeyHandler) GetUsage(w http.ResponseWriter, r *http.Request) {
+	h.writeUsage(w, r, "")
+}
+
+// GetKeyUsage retourne le journal d'audit d'une clé
+func (h *KeyHandler) GetKeyUsage(w http.ResponseWriter, r *http.Request) {
+	h.writeUsage(w, r, mux.Vars(r)["id"])
+}
+
+// writeUsage écrit les dernières entrées d'audit d'une clé, ou de toutes si id est vide
+func (h *KeyHandler) writeUsage(w http.ResponseWriter, r *http.Request, id string) {
+	limit, ok := intParam(w, r, "limit", 100, 1, 1000)
+	if !ok {
+		return
+	}
+
+	entries, err := h.manager.Usage(id, limit)
+	if errors.Is(err, auth.ErrKeyNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Clé d'API introuvable")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la lecture de l'audit des clés d'API", err, map[string]interface{}{
+			"key_id": id,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la lecture de l'audit")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"key_id": id,
+		"usage":  entries,
+		"count":  len(entries),
+	})
+}
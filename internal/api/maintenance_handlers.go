@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/internal/maintenance"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// MaintenanceJobs lance et suit les jobs de maintenance (implémenté par maintenance.Manager)
+type MaintenanceJobs interface {
+	Submit(kind, target, requestedBy string) (*models.MaintenanceJob, error)
+	Cancel(id, requestedBy string) (*models.MaintenanceJob, error)
+	Job(id string) (*models.MaintenanceJob, error)
+	Jobs(kind, status string, limit int) []models.MaintenanceJob
+}
+
+var _ MaintenanceJobs = (*maintenance.Manager)(nil)
+
+// MaintenanceHandler gère les jobs de maintenance du Memory of Trust: reconstruction du graphe,
+// similarités, caches, purge de wallets et réinitialisation de tokens
+type MaintenanceHandler struct {
+	jobs   MaintenanceJobs
+	logger *logger.Logger
+}
+
+// NewMaintenanceHandler crée un nouveau gestionnaire pour les jobs de maintenance
+func NewMaintenanceHandler(jobs MaintenanceJobs, logger *logger.Logger) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		jobs:   jobs,
+		logger: logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes des jobs de maintenance
+func (h *MaintenanceHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/maintenance/jobs", h.ListJobs).Methods("GET")
+	router.HandleFunc("/api/maintenance/jobs", h.SubmitJob).Methods("POST")
+	router.HandleFunc("/api/maintenance/jobs/{id}", h.GetJob).Methods("GET")
+	router.HandleFunc("/api/maintenance/jobs/{id}/cancel", h.CancelJob).Methods("POST")
+}
+
+// jobRequest décrit un job à lancer; target est l'adresse visée par purge_wallet et
+// reset_token_trust_metrics
+type jobRequest struct {
+	Kind   string `json:"kind"`
+	Target string `json:"target"`
+}
+
+// ListJobs retourne les derniers jobs. Paramètres: kind, status, limit.
+func (h *MaintenanceHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJobs(w) {
+		return
+	}
+	limit, ok := intParam(w, r, "limit", 50, 1, 200)
+	if !ok {
+		return
+	}
+
+	jobs := h.jobs.Jobs(r.URL.Query().Get("kind"), r.URL.Query().Get("status"), limit)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// SubmitJob lance un job en tâche de fond et répond 202 avec son état initial. Un job qui
+// tient déjà le même verrou donne 409.
+func (h *MaintenanceHandler) SubmitJob(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJobs(w) {
+		return
+	}
+	var req jobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Corps de requête invalide")
+		return
+	}
+
+	job, err := h.jobs.Submit(req.Kind, req.Target, requesterID(r))
+	switch {
+	case errors.Is(err, maintenance.ErrInvalidJob):
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return
+	case errors.Is(err, maintenance.ErrJobConflict):
+		writeError(w, http.StatusConflict, ErrCodeConflict, err.Error())
+		return
+	case errors.Is(err, maintenance.ErrUnavailable):
+		writeError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Jobs de maintenance indisponibles")
+		return
+	case err != nil:
+		h.logger.Error("Échec du lancement du job de maintenance", err, map[string]interface{}{
+			"kind":   req.Kind,
+			"target": req.Target,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors du lancement du job")
+		return
+	}
+
+	w.Header().Set("Location", "/api/maintenance/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// GetJob retourne l'état d'un job: statut, avancement et historique
+func (h *MaintenanceHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJobs(w) {
+		return
+	}
+	job, err := h.jobs.Job(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Job de maintenance introuvable")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// CancelJob demande l'annulation d'un job et répond 202: le job passe à cancelling puis
+// à cancelled quand l'opération s'arrête
+func (h *MaintenanceHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	if !h.requireJobs(w) {
+		return
+	}
+	job, err := h.jobs.Cancel(mux.Vars(r)["id"], requesterID(r))
+	switch {
+	case errors.Is(err, maintenance.ErrJobNotFound):
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Job de maintenance introuvable")
+		return
+	case errors.Is(err, maintenance.ErrJobFinished):
+		writeError(w, http.StatusConflict, ErrCodeConflict, "Job de maintenance déjà terminé")
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de l'annulation du job")
+		return
+	}
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// requireJobs répond 503 si aucun gestionnaire de jobs n'est configuré
+func (h *MaintenanceHandler) requireJobs(w http.ResponseWriter) bool {
+	if h.jobs == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Jobs de maintenance indisponibles")
+		return false
+	}
+	return true
+}
+
+// requesterID retourne l'ID de la clé d'API de la requête, vide sans authentification
+func requesterID(r *http.Request) string {
+	if key := auth.KeyFromContext(r.Context()); key != nil {
+		return key.ID
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/internal/maintenance"
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// stubMaintenanceOps bloque la reconstruction du graphe jusqu'à son annulation
+type stubMaintenanceOps struct{}
+
+func (stubMaintenanceOps) RebuildTrustGraphContext(ctx context.Context, progress memory.ProgressFunc) error {
+	progress(10, 100)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (stubMaintenanceOps) UpdateWalletSimilaritiesContext(ctx context.Context, progress memory.ProgressFunc) error {
+	return nil
+}
+
+func (stubMaintenanceOps) CleanObsoleteCaches(ctx context.Context, progress memory.ProgressFunc) error {
+	return nil
+}
+
+func (stubMaintenanceOps) PurgeWallet(walletAddress string) error { return nil }
+
+func (stubMaintenanceOps) ResetTokenTrustMetrics(tokenAddress string) error { return nil }
+
+func TestMaintenanceJobs(t *testing.T) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	jobs := maintenance.NewManager(log)
+	jobs.SetOperations(stubMaintenanceOps{})
+	if err := jobs.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer jobs.Shutdown(context.Background())
+
+	server, keys := newAuthServer(t)
+	server.SetMaintenance(jobs)
+	adminKey, admin, err := keys.Issue(auth.IssueRequest{Name: "ops", Scopes: []string{models.ScopeAdmin}, RateLimit: 6000, Burst: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodeJob := func(rec *httptest.ResponseRecorder) models.MaintenanceJob {
+		t.Helper()
+		var job models.MaintenanceJob
+		if err := json.Unmarshal(rec.Body.Bytes(), &job); err != nil {
+			t.Fatalf("decode %s: %v", rec.Body.String(), err)
+		}
+		return job
+	}
+
+	rec := doRequest(server, "POST", "/api/maintenance/jobs", adminKey, map[string]string{"kind": models.JobRebuildTrustGraph})
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("submit: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	job := decodeJob(rec)
+	if rec.Header().Get("Location") != "/api/maintenance/jobs/"+job.ID || job.RequestedBy != admin.ID {
+		t.Fatalf("submitted job %+v, location %s", job, rec.Header().Get("Location"))
+	}
+
+	rec = doRequest(server, "POST", "/api/maintenance/jobs", adminKey, map[string]string{"kind": models.JobUpdateSimilarities})
+	var body ErrorResponse
+	if rec.Code != http.StatusConflict || json.Unmarshal(rec.Body.Bytes(), &body) != nil || body.Error.Code != ErrCodeConflict {
+		t.Fatalf("conflicting job: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	rec = doRequest(server, "POST", "/api/maintenance/jobs", adminKey, map[string]string{"kind": models.JobPurgeWallet})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("purge without target: status %d", rec.Code)
+	}
+
+	rec = doRequest(server, "POST", "/api/maintenance/jobs/"+job.ID+"/cancel", adminKey, nil)
+	if rec.Code != http.StatusAccepted || decodeJob(rec).Status != models.JobCancelling {
+		t.Fatalf("cancel: status %d, body %s", rec.Code, rec.Body.String())
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		rec = doRequest(server, "GET", "/api/maintenance/jobs/"+job.ID, adminKey, nil)
+		if got := decodeJob(rec); got.Status == models.JobCancelled {
+			if got.History[len(got.History)-1].Message != "cancelled by "+admin.ID {
+				t.Fatalf("cancelled job %+v", got)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job not cancelled: %s", rec.Body.String())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	for target, status := range map[string]int{
+		"/api/maintenance/jobs/" + job.ID + "/cancel": http.StatusConflict,
+		"/api/maintenance/jobs/job_unknown/cancel":    http.StatusNotFound,
+	} {
+		if rec := doRequest(server, "POST", target, adminKey, nil); rec.Code != status {
+			t.Errorf("%s: status %d, want %d", target, rec.Code, status)
+		}
+	}
+
+	rec = doRequest(server, "GET", "/api/maintenance/jobs?status=cancelled", adminKey, nil)
+	var list struct {
+		Jobs  []models.MaintenanceJob `json:"jobs"`
+		Count int                     `json:"count"`
+	}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &list) != nil || list.Count != 1 || list.Jobs[0].ID != job.ID {
+		t.Fatalf("list: status %d, body %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMaintenanceJobsUnavailable(t *testing.T) {
+	router := mux.NewRouter()
+	NewMaintenanceHandler(nil, logger.NewLogger("error")).RegisterRoutes(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/maintenance/jobs", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d", rec.Code)
+	}
+}
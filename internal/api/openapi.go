@@ -0,0 +1,20 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec est la description OpenAPI 3 de toutes les routes de l'API. Le test de
+// contrat échoue si elle diverge des routes enregistrées.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// GetOpenAPI retourne la description OpenAPI de l'API
+func (s *Server) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	w.Write(openAPISpec)
+}
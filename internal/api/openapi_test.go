@@ -0,0 +1,245 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/internal/stream"
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// specRoots sont les types décrits par les schémas de la spec; les types imbriqués sont
+// retrouvés par réflexion
+var specRoots = []interface{}{
+	ErrorResponse{}, tokenSummary{}, tokenDetails{}, walletClass{}, wsFrame{}, digestSummary{}, issuedKey{},
+	models.TokenMetrics{}, models.XScoreResult{}, models.WalletProfile{}, models.WalletTrustScore{},
+	models.TrustScorePoint{}, models.WalletSimilarity{}, models.WalletRiskFactors{}, models.WalletToken{},
+	models.TokenAlert{}, models.AlertTypeStats{}, models.AlertRule{}, models.Subscriber{}, models.Digest{},
+	models.APIKey{}, models.APIKeyUsage{}, models.ActiveWallet{}, models.TrustSubgraph{}, models.TrustPath{},
+	models.MaintenanceJob{}, cytoscapeGraph{},
+	token.StateTransition{}, token.TokenReadModel{}, alerting.AlertPage{}, alerting.DeliveryRecord{}, stream.Event{},
+}
+
+type specParameter struct {
+	Ref  string `json:"$ref"`
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+type specOperation struct {
+	OperationID   string          `json:"operationId"`
+	Parameters    []specParameter `json:"parameters"`
+	RequiredScope *string         `json:"x-required-scope"`
+}
+
+type specSchema struct {
+	GoType     string                     `json:"x-go-type"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+type openAPIDocument struct {
+	Paths      map[string]map[string]specOperation `json:"paths"`
+	Components struct {
+		Parameters map[string]specParameter `json:"parameters"`
+		Schemas    map[string]specSchema    `json:"schemas"`
+	} `json:"components"`
+}
+
+// newFullServer crée un serveur avec toutes les routes enregistrées
+func newFullServer() *Server {
+	log := logrus.New()
+	server := NewServer(&config.APIConfig{}, nil, logger.NewLogger("error"))
+	server.SetPipeline(nil)
+	server.SetTokens(nil, nil)
+	server.SetWallets(nil, nil)
+	server.SetTrustGraph(nil)
+	server.SetStream(nil)
+	server.SetTokenHistory(nil, nil)
+	server.SetAlerts(nil)
+	server.SetDigests(nil)
+	server.SetMaintenance(nil)
+	server.SetAuth(auth.NewManager(log))
+	return server
+}
+
+func loadSpec(t *testing.T) openAPIDocument {
+	t.Helper()
+	var doc openAPIDocument
+	if err := json.Unmarshal(openAPISpec, &doc); err != nil {
+		t.Fatalf("invalid openapi.json: %v", err)
+	}
+	return doc
+}
+
+var pathParamPattern = regexp.MustCompile(`\{([^}]+)\}`)
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	doc := loadSpec(t)
+	server := newFullServer()
+
+	routes := make(map[string]bool)
+	server.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			routes[method+" "+template] = true
+		}
+		return nil
+	})
+
+	documented := make(map[string]bool)
+	operationIDs := make(map[string]string)
+	for path, operations := range doc.Paths {
+		for method, op := range operations {
+			key := strings.ToUpper(method) + " " + path
+			documented[key] = true
+			if !routes[key] {
+				t.Errorf("%s is documented but not served", key)
+			}
+
+			if op.OperationID == "" {
+				t.Errorf("%s has no operationId", key)
+			} else if other, ok := operationIDs[op.OperationID]; ok {
+				t.Errorf("operationId %s used by %s and %s", op.OperationID, other, key)
+			}
+			operationIDs[op.OperationID] = key
+
+			if op.RequiredScope == nil {
+				t.Errorf("%s has no x-required-scope", key)
+			} else if want := requiredScope(strings.ToUpper(method), path); *op.RequiredScope != want {
+				t.Errorf("%s: x-required-scope %q, middleware requires %q", key, *op.RequiredScope, want)
+			}
+
+			var want, got []string
+			for _, match := range pathParamPattern.FindAllStringSubmatch(path, -1) {
+				want = append(want, match[1])
+			}
+			for _, param := range op.Parameters {
+				if param.Ref != "" {
+					param = doc.Components.Parameters[strings.TrimPrefix(param.Ref, "#/components/parameters/")]
+				}
+				if param.In == "path" {
+					got = append(got, param.Name)
+				}
+			}
+			sort.Strings(want)
+			sort.Strings(got)
+			if strings.Join(want, ",") != strings.Join(got, ",") {
+				t.Errorf("%s: path parameters %v, route has %v", key, got, want)
+			}
+		}
+	}
+
+	for key := range routes {
+		if !documented[key] {
+			t.Errorf("%s is served but missing from openapi.json", key)
+		}
+	}
+}
+
+func TestOpenAPISchemasMatchTypes(t *testing.T) {
+	doc := loadSpec(t)
+
+	types := make(map[string]reflect.Type)
+	var collect func(reflect.Type)
+	collect = func(t reflect.Type) {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Map {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		name := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:] + "." + t.Name()
+		if _, ok := types[name]; ok {
+			return
+		}
+		types[name] = t
+		for _, field := range jsonFields(t) {
+			collect(field)
+		}
+	}
+	for _, root := range specRoots {
+		collect(reflect.TypeOf(root))
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		if schema.GoType == "" {
+			continue
+		}
+		typ, ok := types[schema.GoType]
+		if !ok {
+			t.Errorf("schema %s: unknown x-go-type %s", name, schema.GoType)
+			continue
+		}
+		fields := jsonFields(typ)
+		for field := range fields {
+			if _, ok := schema.Properties[field]; !ok {
+				t.Errorf("schema %s: field %s of %s is not documented", name, field, schema.GoType)
+			}
+		}
+		for property := range schema.Properties {
+			if _, ok := fields[property]; !ok {
+				t.Errorf("schema %s: property %s does not exist in %s", name, property, schema.GoType)
+			}
+		}
+	}
+
+	// Toutes les références pointent vers un schéma défini
+	for _, match := range regexp.MustCompile(`"#/components/schemas/([^"]+)"`).FindAllStringSubmatch(string(openAPISpec), -1) {
+		if _, ok := doc.Components.Schemas[match[1]]; !ok {
+			t.Errorf("reference to undefined schema %s", match[1])
+		}
+	}
+}
+
+// jsonFields retourne les champs JSON d'une structure et leur type, champs embarqués compris
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			for name, typ := range jsonFields(field.Type) {
+				fields[name] = typ
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+func TestOpenAPIServedWithoutKey(t *testing.T) {
+	server, _ := newAuthServer(t)
+
+	rec := httptest.NewRecorder()
+	server.router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/openapi.json", nil))
+	var doc map[string]interface{}
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &doc) != nil || doc["openapi"] == nil {
+		t.Fatalf("status %d, body %.100s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// PipelineHandler gère les requêtes API relatives au pipeline de traitement
+type PipelineHandler struct {
+	pipeline *pipeline.Pipeline
+	logger   *logger.Logger
+}
+
+// NewPipelineHandler crée un nouveau gestionnaire pour le pipeline
+func NewPipelineHandler(p *pipeline.Pipeline, logger *logger.Logger) *PipelineHandler {
+	return &PipelineHandler{
+		pipeline: p,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de l'API pour le pipeline
+func (h *PipelineHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/pipeline/topology", h.GetTopology).Methods("GET")
+	router.HandleFunc("/api/pipeline/stats", h.GetStats).Methods("GET")
+	router.HandleFunc("/api/pipeline/latency", h.GetLatency).Methods("GET")
+	router.HandleFunc("/api/pipeline/coalescing", h.GetCoalescing).Methods("GET")
+	router.HandleFunc("/api/pipeline/scheduled/{key}", h.GetScheduled).Methods("GET")
+	router.HandleFunc("/api/pipeline/scheduled/{key}", h.CancelScheduled).Methods("DELETE")
+	router.HandleFunc("/api/pipeline/streams/{stream}/events", h.ReadEvents).Methods("GET")
+	router.HandleFunc("/api/pipeline/streams/{stream}/dead-letters", h.ListDeadLetters).Methods("GET")
+	router.HandleFunc("/api/pipeline/streams/{stream}/dead-letters/{id}", h.GetDeadLetter).Methods("GET")
+	router.HandleFunc("/api/pipeline/streams/{stream}/dead-letters/{id}/replay", h.ReplayDeadLetter).Methods("POST")
+	router.HandleFunc("/api/pipeline/streams/{stream}/dead-letters/{id}", h.DropDeadLetter).Methods("DELETE")
+}
+
+// GetTopology retourne le routage streams -> groupes -> processeurs
+func (h *PipelineHandler) GetTopology(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"streams": h.pipeline.Topology(),
+	})
+}
+
+// GetStats retourne le retard et le débit de chaque groupe de consommateurs
+func (h *PipelineHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"consumer_id": h.pipeline.ConsumerID(),
+		"groups":      h.pipeline.Stats(),
+	})
+}
+
+// GetLatency retourne les histogrammes de latence par processeur et type d'événement
+func (h *PipelineHandler) GetLatency(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"processors": h.pipeline.Latency(),
+	})
+}
+
+// GetCoalescing retourne les compteurs d'événements fusionnés par type
+func (h *PipelineHandler) GetCoalescing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"event_types": h.pipeline.CoalesceStats(),
+	})
+}
+
+// GetScheduled retourne une publication programmée
+func (h *PipelineHandler) GetScheduled(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	msg, err := h.pipeline.GetScheduled(key)
+	if err != nil {
+		h.logger.Error("Échec de la lecture du message programmé", err, map[string]interface{}{
+			"key": key,
+		})
+		http.Error(w, "Erreur lors de la lecture du message programmé", http.StatusInternalServerError)
+		return
+	}
+	if msg == nil {
+		http.Error(w, "Message programmé introuvable", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
+
+// CancelScheduled annule une publication programmée
+func (h *PipelineHandler) CancelScheduled(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+
+	cancelled, err := h.pipeline.CancelScheduled(key)
+	if err != nil {
+		h.logger.Error("Échec de l'annulation du message programmé", err, map[string]interface{}{
+			"key": key,
+		})
+		http.Error(w, "Erreur lors de l'annulation du message programmé", http.StatusInternalServerError)
+		return
+	}
+	if !cancelled {
+		http.Error(w, "Message programmé introuvable", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key":       key,
+		"cancelled": true,
+	})
+}
+
+// ReadEvents retourne une page du journal d'un stream à partir d'un offset exclusif
+func (h *PipelineHandler) ReadEvents(w http.ResponseWriter, r *http.Request) {
+	stream := mux.Vars(r)["stream"]
+	after := r.URL.Query().Get("after")
+
+	limit := 100 // Valeur par défaut
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
+			limit = parsedLimit
+		}
+	}
+
+	page, err := h.pipeline.ReadEvents(stream, after, limit)
+	if err != nil {
+		h.logger.Error("Échec de la lecture du journal", err, map[string]interface{}{
+			"stream": stream,
+			"after":  after,
+		})
+		http.Error(w, "Erreur lors de la lecture du journal", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// ListDeadLetters retourne les messages en dead-letter d'un stream
+func (h *PipelineHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	stream := mux.Vars(r)["stream"]
+
+	limit := 100 // Valeur par défaut
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	letters, err := h.pipeline.ListDeadLetters(stream, limit)
+	if err != nil {
+		h.logger.Error("Échec de la lecture du dead-letter", err, map[string]interface{}{
+			"stream": stream,
+		})
+		http.Error(w, "Erreur lors de la lecture du dead-letter", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stream":       stream,
+		"dlq_stream":   pipeline.DeadLetterStream(stream),
+		"dead_letters": letters,
+		"count":        len(letters),
+	})
+}
+
+// GetDeadLetter retourne un message en dead-letter
+func (h *PipelineHandler) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	letter, err := h.pipeline.GetDeadLetter(vars["stream"], vars["id"])
+	if err != nil {
+		http.Error(w, "Message introuvable dans le dead-letter", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letter)
+}
+
+// ReplayDeadLetter republie un message en dead-letter dans son stream d'origine
+func (h *PipelineHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if _, err := h.pipeline.GetDeadLetter(vars["stream"], vars["id"]); err != nil {
+		http.Error(w, "Message introuvable dans le dead-letter", http.StatusNotFound)
+		return
+	}
+
+	if err := h.pipeline.ReplayDeadLetter(vars["stream"], vars["id"]); err != nil {
+		h.logger.Error("Échec du rejeu du dead-letter", err, map[string]interface{}{
+			"stream": vars["stream"],
+			"id":     vars["id"],
+		})
+		http.Error(w, "Erreur lors du rejeu du message", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stream":   vars["stream"],
+		"id":       vars["id"],
+		"replayed": true,
+	})
+}
+
+// DropDeadLetter supprime définitivement un message en dead-letter
+func (h *PipelineHandler) DropDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if _, err := h.pipeline.GetDeadLetter(vars["stream"], vars["id"]); err != nil {
+		http.Error(w, "Message introuvable dans le dead-letter", http.StatusNotFound)
+		return
+	}
+
+	if err := h.pipeline.DropDeadLetter(vars["stream"], vars["id"]); err != nil {
+		h.logger.Error("Échec de la suppression du dead-letter", err, map[string]interface{}{
+			"stream": vars["stream"],
+			"id":     vars["id"],
+		})
+		http.Error(w, "Erreur lors de la suppression du message", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
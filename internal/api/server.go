@@ -8,7 +8,13 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/internal/auth"
+	"github.com/franky69420/crypto-oracle/internal/digest"
 	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/internal/stream"
+	"github.com/franky69420/crypto-oracle/internal/token"
 	"github.com/franky69420/crypto-oracle/pkg/utils/config"
 	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
 )
@@ -20,6 +26,7 @@ type Server struct {
 	httpServer  *http.Server
 	logger      *logger.Logger
 	trustNetwork memory.MemoryOfTrust
+	auth        *auth.Manager
 }
 
 // NewServer crée un nouveau serveur API
@@ -42,24 +49,112 @@ func NewServer(config *config.APIConfig, trustNetwork memory.MemoryOfTrust, logg
 // initializeRoutes configure toutes les routes de l'API
 func (s *Server) initializeRoutes() {
 	// Configurer CORS
-	corsMiddleware := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization"},
-		AllowCredentials: true,
-		MaxAge:           300,
-	})
+	corsMiddleware := newCORS(s.config.AllowedOrigins)
 	
 	// Routes de base
 	s.router.HandleFunc("/api/health", s.HealthCheck).Methods("GET")
+	s.router.HandleFunc("/api/openapi.json", s.GetOpenAPI).Methods("GET")
 	
 	// Enregistrer les gestionnaires d'API
 	activeWalletHandler := NewActiveWalletHandler(s.trustNetwork, s.logger)
 	activeWalletHandler.RegisterRoutes(s.router)
 	
 	// Appliquer le middleware CORS
-	s.router.Use(corsMiddleware.Handler)
+	if corsMiddleware != nil {
+		s.router.Use(corsMiddleware.Handler)
+	}
 	s.router.Use(s.loggingMiddleware)
+	s.router.Use(s.authMiddleware)
+}
+
+// newCORS crée le middleware CORS des origines autorisées. Sans origine configurée, il n'y en a
+// pas: les navigateurs refusent alors toute requête cross-origin. Les requêtes avec
+// identifiants ne sont acceptées que d'origines nommées, jamais avec "*".
+func newCORS(allowedOrigins []string) *cors.Cors {
+	if len(allowedOrigins) == 0 {
+		return nil
+	}
+	credentials := true
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			credentials = false
+		}
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins:   allowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization", "X-API-Key", "Last-Event-ID"},
+		ExposedHeaders:   []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "Retry-After", "ETag"},
+		AllowCredentials: credentials,
+		MaxAge:           300,
+	})
+}
+
+// SetAuth active l'authentification par clé d'API et enregistre les routes d'administration des clés
+func (s *Server) SetAuth(manager *auth.Manager) {
+	s.auth = manager
+	keyHandler := NewKeyHandler(manager, s.logger)
+	keyHandler.RegisterRoutes(s.router)
+}
+
+// SetPipeline enregistre les routes d'administration du pipeline
+func (s *Server) SetPipeline(p *pipeline.Pipeline) {
+	pipelineHandler := NewPipelineHandler(p, s.logger)
+	pipelineHandler.RegisterRoutes(s.router)
+}
+
+// SetTokens enregistre les routes de consultation des tokens: détails, métriques, X-Score,
+// cycle de vie et analyse anti-dump
+func (s *Server) SetTokens(engine *token.Engine, projector *token.Projector) {
+	tokenHandler := NewTokenHandler(engine, projector, s.logger)
+	tokenHandler.RegisterRoutes(s.router)
+}
+
+// SetWallets enregistre les routes de consultation des wallets. Le profil et la classification
+// répondent 503 si analyzer est nil.
+func (s *Server) SetWallets(trust WalletTrust, analyzer WalletAnalyzer) {
+	walletHandler := NewWalletHandler(trust, analyzer, s.logger)
+	walletHandler.RegisterRoutes(s.router)
+}
+
+// SetTrustGraph enregistre les routes de requête sur le graphe de confiance: voisinages,
+// plus courts chemins entre wallets et premiers acheteurs d'un token
+func (s *Server) SetTrustGraph(graph TrustGraphQuery) {
+	graphHandler := NewGraphHandler(graph, s.logger)
+	graphHandler.RegisterRoutes(s.router)
+}
+
+// SetMaintenance enregistre les routes des jobs de maintenance du Memory of Trust
+func (s *Server) SetMaintenance(jobs MaintenanceJobs) {
+	maintenanceHandler := NewMaintenanceHandler(jobs, s.logger)
+	maintenanceHandler.RegisterRoutes(s.router)
+}
+
+// SetStream enregistre les routes du flux d'événements en direct (WebSocket et SSE)
+func (s *Server) SetStream(hub *stream.Hub) {
+	streamHandler := NewStreamHandler(hub, s.config.AllowedOrigins, s.logger)
+	streamHandler.RegisterRoutes(s.router)
+}
+
+// SetTokenHistory enregistre les routes d'historique des tokens issues du journal d'événements
+func (s *Server) SetTokenHistory(projector *token.Projector, p *pipeline.Pipeline) {
+	historyHandler := NewTokenHistoryHandler(projector, p, s.logger)
+	historyHandler.RegisterRoutes(s.router)
+}
+
+// SetAlerts enregistre les routes de consultation des alertes
+func (s *Server) SetAlerts(manager *alerting.Manager) {
+	alertHandler := NewAlertHandler(manager, s.logger)
+	alertHandler.RegisterRoutes(s.router)
+
+	subscriberHandler := NewSubscriberHandler(manager, s.logger)
+	subscriberHandler.RegisterRoutes(s.router)
+}
+
+// SetDigests enregistre les routes de consultation des digests
+func (s *Server) SetDigests(generator *digest.Generator) {
+	digestHandler := NewDigestHandler(generator, s.logger)
+	digestHandler.RegisterRoutes(s.router)
 }
 
 // HealthCheck est un endpoint pour vérifier l'état du serveur
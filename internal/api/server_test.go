@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+)
+
+func TestCORSOrigins(t *testing.T) {
+	cases := []struct {
+		origins            []string
+		allowOrigin, creds string
+	}{
+		{nil, "", ""},
+		{[]string{"*"}, "*", ""},
+		{[]string{"https://dashboard.example"}, "https://dashboard.example", "true"},
+		{[]string{"https://other.example"}, "", ""},
+	}
+	for _, c := range cases {
+		server := NewServer(&config.APIConfig{AllowedOrigins: c.origins}, nil, logger.NewLogger("error"))
+		req := httptest.NewRequest("GET", "/api/health", nil)
+		req.Header.Set("Origin", "https://dashboard.example")
+		rec := httptest.NewRecorder()
+		server.router.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != c.allowOrigin {
+			t.Errorf("origins %v: Access-Control-Allow-Origin %q, want %q", c.origins, got, c.allowOrigin)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != c.creds {
+			t.Errorf("origins %v: Access-Control-Allow-Credentials %q, want %q", c.origins, got, c.creds)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/stream"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+// sseRetryMs est le délai de reconnexion suggéré aux clients SSE
+const sseRetryMs = 3000
+
+// StreamHandler diffuse les événements du pipeline en direct, en WebSocket ou en SSE
+type StreamHandler struct {
+	hub            *stream.Hub
+	allowedOrigins []string // Origines autorisées à ouvrir une connexion WebSocket
+	logger         *logger.Logger
+}
+
+// NewStreamHandler crée un nouveau gestionnaire de flux d'événements
+func NewStreamHandler(hub *stream.Hub, allowedOrigins []string, logger *logger.Logger) *StreamHandler {
+	return &StreamHandler{
+		hub:            hub,
+		allowedOrigins: allowedOrigins,
+		logger:         logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes du flux d'événements
+func (h *StreamHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/stream/events", h.StreamSSE).Methods("GET")
+	router.HandleFunc("/api/stream/ws", h.StreamWebSocket).Methods("GET")
+	router.HandleFunc("/api/stream/stats", h.GetStreamStats).Methods("GET")
+}
+
+// wsFrame est un message envoyé aux clients WebSocket
+type wsFrame struct {
+	Kind      string        `json:"kind"` // "event", "heartbeat" ou "error"
+	Event     *stream.Event `json:"event,omitempty"`
+	Error     *ErrorBody    `json:"error,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// subscribe lit le filtre et l'ID de reprise de la requête puis abonne le client.
+// Il répond avec l'erreur adaptée et retourne nil si l'abonnement est refusé.
+func (h *StreamHandler) subscribe(w http.ResponseWriter, r *http.Request) *stream.Subscription {
+	query := r.URL.Query()
+	filter := stream.Filter{
+		Tokens:      listParam(query["token"]),
+		Types:       listParam(query["type"]),
+		MinSeverity: strings.ToUpper(query.Get("min_severity")),
+	}
+
+	// EventSource renvoie Last-Event-ID à la reconnexion; le paramètre sert aux clients WebSocket
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("last_event_id")
+	}
+
+	sub, err := h.hub.Subscribe(filter, lastEventID)
+	switch {
+	case errors.Is(err, stream.ErrInvalidFilter), errors.Is(err, stream.ErrInvalidEventID):
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+		return nil
+	case err != nil:
+		writeError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Flux d'événements indisponible")
+		return nil
+	}
+	return sub
+}
+
+// StreamSSE diffuse les événements en Server-Sent Events. L'ID de chaque événement est
+// son offset dans le stream, renvoyé par le navigateur dans Last-Event-ID à la reconnexion.
+func (h *StreamHandler) StreamSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Streaming non supporté")
+		return
+	}
+
+	sub := h.subscribe(w, r)
+	if sub == nil {
+		return
+	}
+	defer sub.Close()
+
+	// Le flux reste ouvert au-delà du WriteTimeout du serveur
+	http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMs)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.hub.Options().Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprintf(w, ": heartbeat %s\n\n", time.Now().UTC().Format(time.RFC3339))
+		case event, ok := <-sub.Events():
+			if !ok {
+				if body := streamError(sub.Err()); body != nil {
+					data, _ := json.Marshal(ErrorResponse{Error: *body})
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+					flusher.Flush()
+				}
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// StreamWebSocket diffuse les événements sur une connexion WebSocket. Pour reprendre
+// après une déconnexion, le client passe l'ID du dernier événement reçu dans last_event_id.
+func (h *StreamHandler) StreamWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Les navigateurs n'appliquent pas CORS à l'ouverture d'un WebSocket: l'origine est
+	// vérifiée ici, avant l'abonnement
+	if !h.originAllowed(r) {
+		writeError(w, http.StatusForbidden, ErrCodeForbidden, "Origine non autorisée")
+		return
+	}
+
+	sub := h.subscribe(w, r)
+	if sub == nil {
+		return
+	}
+	defer sub.Close()
+
+	server := websocket.Server{
+		// L'origine a déjà été vérifiée par originAllowed
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.pumpWebSocket(ws, sub)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// originAllowed indique si la page à l'origine de la requête peut ouvrir un WebSocket: même
+// hôte que l'API, ou origine listée dans api.allowed_origins ("*" les accepte toutes). Les
+// clients hors navigateur n'envoient pas d'Origin et sont acceptés.
+func (h *StreamHandler) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Host == "" {
+		return false
+	}
+	if strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+	for _, allowed := range h.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// pumpWebSocket envoie les événements de l'abonnement jusqu'à la déconnexion du client
+func (h *StreamHandler) pumpWebSocket(ws *websocket.Conn, sub *stream.Subscription) {
+	// Le flux reste ouvert au-delà des délais du serveur
+	ws.SetDeadline(time.Time{})
+
+	// Les messages du client sont ignorés; une erreur de lecture signale la déconnexion
+	go func() {
+		var message string
+		for websocket.Message.Receive(ws, &message) == nil {
+		}
+		sub.Close()
+	}()
+
+	heartbeat := time.NewTicker(h.hub.Options().Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var frame wsFrame
+		select {
+		case <-heartbeat.C:
+			frame = wsFrame{Kind: "heartbeat"}
+		case event, ok := <-sub.Events():
+			if !ok {
+				if body := streamError(sub.Err()); body != nil {
+					websocket.JSON.Send(ws, wsFrame{Kind: "error", Error: body, Timestamp: time.Now()})
+				}
+				return
+			}
+			frame = wsFrame{Kind: "event", Event: &event}
+		}
+		frame.Timestamp = time.Now()
+		if err := websocket.JSON.Send(ws, frame); err != nil {
+			return
+		}
+	}
+}
+
+// GetStreamStats retourne l'état du flux: stream suivi, dernier offset diffusé et clients connectés
+func (h *StreamHandler) GetStreamStats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"stream":      h.hub.Options().Stream,
+		"offset":      h.hub.Offset(),
+		"subscribers": h.hub.Subscribers(),
+	})
+}
+
+// streamError décrit la fin d'un abonnement imposée par le serveur, nil si le client l'a fermé
+func streamError(err error) *ErrorBody {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, stream.ErrLagging):
+		return &ErrorBody{Status: http.StatusServiceUnavailable, Code: ErrCodeLagging, Message: "Client trop lent, reconnexion nécessaire avec le dernier ID reçu"}
+	case errors.Is(err, stream.ErrClosed):
+		return &ErrorBody{Status: http.StatusServiceUnavailable, Code: ErrCodeUnavailable, Message: "Flux d'événements arrêté"}
+	}
+	return &ErrorBody{Status: http.StatusInternalServerError, Code: ErrCodeInternal, Message: "Erreur lors de la lecture du flux"}
+}
+
+// listParam découpe les valeurs d'un paramètre répété ou séparé par des virgules
+func listParam(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+func TestStreamWebSocketOrigin(t *testing.T) {
+	h := NewStreamHandler(nil, []string{"https://dashboard.example"}, logger.NewLogger("error"))
+	for origin, allowed := range map[string]bool{
+		"":                          true, // Client hors navigateur
+		"http://api.example:8080":   true, // Même hôte que l'API
+		"https://dashboard.example": true,
+		"https://DASHBOARD.example": true,
+		"https://evil.example":      false,
+		"null":                      false,
+	} {
+		req := httptest.NewRequest("GET", "http://api.example:8080/api/stream/ws", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if got := h.originAllowed(req); got != allowed {
+			t.Errorf("origin %q: allowed %v, want %v", origin, got, allowed)
+		}
+	}
+
+	// Une origine refusée n'atteint pas l'abonnement au flux
+	router := mux.NewRouter()
+	h.RegisterRoutes(router)
+	req := httptest.NewRequest("GET", "/api/stream/ws", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("cross-site upgrade: status %d", rec.Code)
+	}
+
+	if !NewStreamHandler(nil, []string{"*"}, logger.NewLogger("error")).originAllowed(req) {
+		t.Fatal("wildcard origin refused")
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// SubscriberHandler gère les requêtes API relatives aux abonnés aux alertes
+type SubscriberHandler struct {
+	manager *alerting.Manager
+	logger  *logger.Logger
+}
+
+// NewSubscriberHandler crée un nouveau gestionnaire pour les abonnés
+func NewSubscriberHandler(manager *alerting.Manager, logger *logger.Logger) *SubscriberHandler {
+	return &SubscriberHandler{
+		manager: manager,
+		logger:  logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de l'API pour les abonnés
+func (h *SubscriberHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/subscribers", h.ListSubscribers).Methods("GET")
+	router.HandleFunc("/api/subscribers", h.CreateSubscriber).Methods("POST")
+	router.HandleFunc("/api/subscribers/{id}", h.GetSubscriber).Methods("GET")
+	router.HandleFunc("/api/subscribers/{id}", h.UpdateSubscriber).Methods("PUT")
+	router.HandleFunc("/api/subscribers/{id}", h.DeleteSubscriber).Methods("DELETE")
+	router.HandleFunc("/api/subscribers/{id}/deliveries", h.GetSubscriberDeliveries).Methods("GET")
+}
+
+// ListSubscribers retourne les abonnés
+func (h *SubscriberHandler) ListSubscribers(w http.ResponseWriter, r *http.Request) {
+	subscribers := h.manager.Subscribers()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"subscribers": subscribers,
+		"count":       len(subscribers),
+	})
+}
+
+// GetSubscriber retourne un abonné
+func (h *SubscriberHandler) GetSubscriber(w http.ResponseWriter, r *http.Request) {
+	sub, err := h.manager.GetSubscriber(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Abonné introuvable", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sub)
+}
+
+// CreateSubscriber crée un abonné; un identifiant est attribué s'il est absent
+func (h *SubscriberHandler) CreateSubscriber(w http.ResponseWriter, r *http.Request) {
+	sub := models.Subscriber{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+		return
+	}
+	if sub.ID == "" {
+		sub.ID = fmt.Sprintf("sub_%d", time.Now().UnixNano())
+	}
+	if _, err := h.manager.GetSubscriber(sub.ID); err == nil {
+		http.Error(w, "Un abonné avec cet identifiant existe déjà", http.StatusConflict)
+		return
+	}
+	h.saveSubscriber(w, sub, http.StatusCreated)
+}
+
+// UpdateSubscriber remplace un abonné existant; l'identifiant est celui du chemin
+func (h *SubscriberHandler) UpdateSubscriber(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := h.manager.GetSubscriber(id); err != nil {
+		http.Error(w, "Abonné introuvable", http.StatusNotFound)
+		return
+	}
+
+	sub := models.Subscriber{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "Corps de requête invalide", http.StatusBadRequest)
+		return
+	}
+	sub.ID = id
+	h.saveSubscriber(w, sub, http.StatusOK)
+}
+
+// saveSubscriber enregistre un abonné et retourne sa version normalisée
+func (h *SubscriberHandler) saveSubscriber(w http.ResponseWriter, sub models.Subscriber, status int) {
+	saved, err := h.manager.SaveSubscriber(sub)
+	if errors.Is(err, alerting.ErrInvalidSubscriber) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de l'enregistrement de l'abonné", err, map[string]interface{}{
+			"subscriber": sub.ID,
+		})
+		http.Error(w, "Erreur lors de l'enregistrement de l'abonné", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// DeleteSubscriber supprime un abonné
+func (h *SubscriberHandler) DeleteSubscriber(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	err := h.manager.DeleteSubscriber(id)
+	if errors.Is(err, alerting.ErrSubscriberNotFound) {
+		http.Error(w, "Abonné introuvable", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la suppression de l'abonné", err, map[string]interface{}{
+			"subscriber": id,
+		})
+		http.Error(w, "Erreur lors de la suppression de l'abonné", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetSubscriberDeliveries retourne les derniers envois vers les canaux d'un abonné
+func (h *SubscriberHandler) GetSubscriberDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if _, err := h.manager.GetSubscriber(id); err != nil {
+		http.Error(w, "Abonné introuvable", http.StatusNotFound)
+		return
+	}
+
+	deliveries := make([]alerting.DeliveryRecord, 0)
+	for _, record := range h.manager.RecentDeliveries(r.URL.Query().Get("status"), 0) {
+		if record.Subscriber == id {
+			deliveries = append(deliveries, record)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"subscriber": id,
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
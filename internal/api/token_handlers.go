@@ -0,0 +1,230 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// TokenHandler gère les requêtes API relatives aux tokens
+type TokenHandler struct {
+	engine    *token.Engine
+	projector *token.Projector
+	logger    *logger.Logger
+}
+
+// NewTokenHandler crée un nouveau gestionnaire pour les tokens
+func NewTokenHandler(engine *token.Engine, projector *token.Projector, logger *logger.Logger) *TokenHandler {
+	return &TokenHandler{
+		engine:    engine,
+		projector: projector,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de l'API pour les tokens
+func (h *TokenHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/tokens", h.ListTokens).Methods("GET")
+	router.HandleFunc("/api/tokens/{tokenAddress}", h.GetToken).Methods("GET")
+	router.HandleFunc("/api/tokens/{tokenAddress}/metrics", h.GetTokenMetrics).Methods("GET")
+	router.HandleFunc("/api/tokens/{tokenAddress}/xscore", h.GetTokenXScore).Methods("GET")
+	router.HandleFunc("/api/tokens/{tokenAddress}/state", h.GetTokenState).Methods("GET")
+	router.HandleFunc("/api/tokens/{tokenAddress}/anti-dump", h.GetTokenAntiDump).Methods("GET")
+}
+
+// tokenSummary décrit un token dans une liste
+type tokenSummary struct {
+	Address     string    `json:"address"`
+	Symbol      string    `json:"symbol"`
+	State       string    `json:"state"`
+	XScore      float64   `json:"xscore"`
+	Price       float64   `json:"price"`
+	MarketCap   float64   `json:"market_cap"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastEventAt time.Time `json:"last_event_at"`
+}
+
+// tokenDetails regroupe les métadonnées d'un token et son état courant
+type tokenDetails struct {
+	Token       *models.Token `json:"token"`
+	State       string        `json:"state"`
+	XScore      float64       `json:"xscore"`
+	FirstSeenAt *time.Time    `json:"first_seen_at,omitempty"`
+	LastEventAt *time.Time    `json:"last_event_at,omitempty"`
+}
+
+// ListTokens retourne les tokens suivis, filtrés par états (state=HYPED,REACTIVATED)
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	var states []string
+	for _, value := range r.URL.Query()["state"] {
+		for _, state := range strings.Split(value, ",") {
+			state = strings.ToUpper(strings.TrimSpace(state))
+			if state == "" {
+				continue
+			}
+			if !models.IsLifecycleState(state) {
+				writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "État de cycle de vie inconnu: "+state)
+				return
+			}
+			states = append(states, state)
+		}
+	}
+
+	limit := 100 // Valeur par défaut
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsedLimit, err := strconv.Atoi(limitStr)
+		if err != nil || parsedLimit <= 0 || parsedLimit > 1000 {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Paramètre limit invalide")
+			return
+		}
+		limit = parsedLimit
+	}
+
+	projected := h.projector.Tokens(states...)
+	total := len(projected)
+	if len(projected) > limit {
+		projected = projected[:limit]
+	}
+
+	tokens := make([]tokenSummary, 0, len(projected))
+	for _, m := range projected {
+		tokens = append(tokens, tokenSummary{
+			Address:     m.Address,
+			Symbol:      m.Symbol,
+			State:       m.State,
+			XScore:      m.XScore,
+			Price:       m.Price,
+			MarketCap:   m.MarketCap,
+			FirstSeenAt: m.FirstSeenAt,
+			LastEventAt: m.LastEventAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"tokens": tokens,
+		"count":  len(tokens),
+		"total":  total,
+	})
+}
+
+// GetToken retourne les métadonnées d'un token avec son état de cycle de vie courant
+func (h *TokenHandler) GetToken(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+
+	t, err := h.engine.GetToken(tokenAddress)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Token introuvable")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération du token", err, map[string]interface{}{
+			"token_address": tokenAddress,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de récupérer le token")
+		return
+	}
+
+	details := tokenDetails{
+		Token: t,
+		State: h.engine.GetTokenState(tokenAddress),
+	}
+	if model := h.projector.Token(tokenAddress); model != nil {
+		if details.State == "" {
+			details.State = model.State
+		}
+		details.XScore = model.XScore
+		details.FirstSeenAt = &model.FirstSeenAt
+		details.LastEventAt = &model.LastEventAt
+	}
+
+	writeJSON(w, http.StatusOK, details)
+}
+
+// GetTokenMetrics retourne les métriques en direct d'un token, enrichies par le Memory of Trust
+func (h *TokenHandler) GetTokenMetrics(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+
+	metrics, err := h.engine.GetTokenMetrics(tokenAddress)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Token introuvable")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec de la récupération des métriques du token", err, map[string]interface{}{
+			"token_address": tokenAddress,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de récupérer les métriques du token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, metrics)
+}
+
+// GetTokenXScore calcule le X-Score d'un token à la demande, avec le détail des facteurs
+// et les poids appliqués. Le score n'est ni publié dans le journal d'événements ni historisé.
+func (h *TokenHandler) GetTokenXScore(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+
+	result, err := h.engine.ComputeXScore(tokenAddress, nil)
+	if errors.Is(err, token.ErrTokenNotFound) {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Token introuvable")
+		return
+	}
+	if err != nil {
+		h.logger.Error("Échec du calcul du X-Score", err, map[string]interface{}{
+			"token_address": tokenAddress,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de calculer le X-Score du token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"xscore":  result,
+		"weights": h.engine.GetWeights(),
+	})
+}
+
+// GetTokenState retourne l'état de cycle de vie courant d'un token et l'historique de ses transitions
+func (h *TokenHandler) GetTokenState(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+
+	state := h.engine.GetTokenState(tokenAddress)
+	history := []token.StateTransition{}
+	if model := h.projector.Token(tokenAddress); model != nil {
+		if state == "" {
+			state = model.State
+		}
+		if model.StateHistory != nil {
+			history = model.StateHistory
+		}
+	}
+	if state == "" {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Aucun état connu pour ce token")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token_address": tokenAddress,
+		"state":         state,
+		"history":       history,
+	})
+}
+
+// GetTokenAntiDump retourne l'analyse des ventes coordonnées d'un token sur les dernières 24h
+func (h *TokenHandler) GetTokenAntiDump(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+
+	result := h.engine.CheckAntiDump(tokenAddress)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token_address": tokenAddress,
+		"anti_dump":     result,
+	})
+}
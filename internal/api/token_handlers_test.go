@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func newTokenRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+
+	projector := token.NewProjector(quiet)
+	at := time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC)
+	for _, event := range []pipeline.Event{
+		&pipeline.TokenDetected{TokenAddress: "aaa", TokenSymbol: "AAA"},
+		&pipeline.TokenDetected{TokenAddress: "bbb", TokenSymbol: "BBB"},
+		&pipeline.StateChange{TokenAddress: "bbb", NewState: models.LifecycleStateHyped, Reason: "volume"},
+	} {
+		projector.Apply(pipeline.Message{Timestamp: at, Event: event})
+	}
+
+	router := mux.NewRouter()
+	NewTokenHandler(token.NewEngine(nil, nil, nil, quiet), projector, logger.NewLogger("error")).RegisterRoutes(router)
+	return router
+}
+
+func TestListTokensByState(t *testing.T) {
+	router := newTokenRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/tokens?state=hyped", nil))
+	var body struct {
+		Tokens []tokenSummary `json:"tokens"`
+		Total  int            `json:"total"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d (%v)", rec.Code, err)
+	}
+	if body.Total != 1 || body.Tokens[0].Address != "bbb" {
+		t.Fatalf("tokens = %+v", body.Tokens)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/tokens?state=MOONING", nil))
+	var envelope ErrorResponse
+	json.NewDecoder(rec.Body).Decode(&envelope)
+	if rec.Code != http.StatusBadRequest || envelope.Error.Code != ErrCodeInvalidParameter || envelope.Error.Status != http.StatusBadRequest {
+		t.Fatalf("status %d, envelope %+v", rec.Code, envelope)
+	}
+}
+
+func TestGetTokenState(t *testing.T) {
+	router := newTokenRouter(t)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/tokens/bbb/state", nil))
+	var body struct {
+		State   string                  `json:"state"`
+		History []token.StateTransition `json:"history"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusOK || body.State != models.LifecycleStateHyped || len(body.History) == 0 {
+		t.Fatalf("status %d, body %+v", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/tokens/zzz/state", nil))
+	var envelope ErrorResponse
+	json.NewDecoder(rec.Body).Decode(&envelope)
+	if rec.Code != http.StatusNotFound || envelope.Error.Code != ErrCodeNotFound {
+		t.Fatalf("status %d, envelope %+v", rec.Code, envelope)
+	}
+}
+
+// stubTokenSource répond pour "aaa", ne connaît pas "zzz" et échoue pour les autres tokens
+type stubTokenSource struct{}
+
+func (stubTokenSource) lookup(tokenAddress string) error {
+	switch tokenAddress {
+	case "aaa":
+		return nil
+	case "zzz":
+		return fmt.Errorf("token_stat %s: %w", tokenAddress, token.ErrTokenNotFound)
+	}
+	return errors.New("gmgn unreachable")
+}
+
+func (s stubTokenSource) GetTokenInfo(tokenAddress string) (*models.Token, error) {
+	if err := s.lookup(tokenAddress); err != nil {
+		return nil, err
+	}
+	return &models.Token{Address: tokenAddress, Symbol: "AAA", HolderCount: 300}, nil
+}
+
+func (s stubTokenSource) GetTokenStats(tokenAddress string) (*models.TokenStats, error) {
+	if err := s.lookup(tokenAddress); err != nil {
+		return nil, err
+	}
+	return &models.TokenStats{HolderCount: 300, Price: 0.02, MarketCap: 50000}, nil
+}
+
+func (stubTokenSource) GetTokenTrades(tokenAddress string, limit int) ([]models.TokenTrade, error) {
+	return nil, nil
+}
+
+func (stubTokenSource) GetTokenPrice(tokenAddress string) (*models.TokenPrice, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (stubTokenSource) GetWalletTokenTrades(walletAddress, tokenAddress string, limit int) ([]models.TokenTrade, error) {
+	return nil, nil
+}
+
+// stubTokenTrust n'a aucune métrique de confiance
+type stubTokenTrust struct {
+	memory.MemoryOfTrust
+}
+
+func (stubTokenTrust) GetTokenTrustMetrics(tokenAddress string) (*models.TokenTrustMetrics, error) {
+	return nil, errors.New("no trust metrics")
+}
+
+// recordingXScoreHistory compte les X-Scores historisés
+type recordingXScoreHistory struct {
+	saved int
+}
+
+func (h *recordingXScoreHistory) SaveXScoreHistory(result *models.XScoreResult) error {
+	h.saved++
+	return nil
+}
+
+func TestTokenLookupErrors(t *testing.T) {
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	engine := token.NewEngine(stubTokenSource{}, stubTokenTrust{}, nil, quiet)
+	history := &recordingXScoreHistory{}
+	engine.SetXScoreHistoryStore(history)
+
+	router := mux.NewRouter()
+	NewTokenHandler(engine, token.NewProjector(quiet), logger.NewLogger("error")).RegisterRoutes(router)
+
+	for _, route := range []string{"", "/metrics", "/xscore"} {
+		for address, want := range map[string]struct {
+			status int
+			code   string
+		}{
+			"aaa":  {http.StatusOK, ""},
+			"zzz":  {http.StatusNotFound, ErrCodeNotFound},
+			"down": {http.StatusBadGateway, ErrCodeUpstream},
+		} {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/tokens/"+address+route, nil))
+			var envelope ErrorResponse
+			json.NewDecoder(rec.Body).Decode(&envelope)
+			if rec.Code != want.status || envelope.Error.Code != want.code {
+				t.Errorf("%s%s: status %d, envelope %+v", address, route, rec.Code, envelope)
+			}
+		}
+	}
+
+	// Le X-Score calculé à la demande n'est pas historisé
+	if history.saved != 0 {
+		t.Fatalf("on-demand X-Score saved %d times", history.saved)
+	}
+	if _, err := engine.CalculateXScore("aaa", nil); err != nil {
+		t.Fatalf("CalculateXScore: %v", err)
+	}
+	// L'historisation est asynchrone: Shutdown écrit les scores en file
+	if err := engine.Shutdown(context.Background()); err != nil || history.saved != 1 {
+		t.Fatalf("Shutdown: %v, saved %d", err, history.saved)
+	}
+}
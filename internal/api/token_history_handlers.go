@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// TokenHistoryHandler expose l'état des tokens reconstruit à partir du journal d'événements
+type TokenHistoryHandler struct {
+	projector *token.Projector
+	pipeline  *pipeline.Pipeline
+	logger    *logger.Logger
+}
+
+// NewTokenHistoryHandler crée un nouveau gestionnaire d'historique des tokens
+func NewTokenHistoryHandler(projector *token.Projector, p *pipeline.Pipeline, logger *logger.Logger) *TokenHistoryHandler {
+	return &TokenHistoryHandler{
+		projector: projector,
+		pipeline:  p,
+		logger:    logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de l'API d'historique des tokens
+func (h *TokenHistoryHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/tokens/{tokenAddress}/history", h.GetTokenHistory).Methods("GET")
+	router.HandleFunc("/api/tokens/{tokenAddress}/events", h.GetTokenEvents).Methods("GET")
+}
+
+// GetTokenHistory retourne l'état projeté d'un token: cycle de vie, X-Scores et alertes
+func (h *TokenHistoryHandler) GetTokenHistory(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+
+	model := h.projector.Token(tokenAddress)
+	if model == nil {
+		writeError(w, http.StatusNotFound, ErrCodeNotFound, "Token absent du journal")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, model)
+}
+
+// GetTokenEvents retourne tous les événements journalisés d'un token, pour audit
+func (h *TokenHistoryHandler) GetTokenEvents(w http.ResponseWriter, r *http.Request) {
+	tokenAddress := mux.Vars(r)["tokenAddress"]
+
+	events, err := h.pipeline.TokenEvents(pipeline.StreamTokenEvents, tokenAddress)
+	if err != nil {
+		h.logger.Error("Échec de la lecture du journal du token", err, map[string]interface{}{
+			"token_address": tokenAddress,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la lecture du journal")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"token_address": tokenAddress,
+		"events":        events,
+		"count":         len(events),
+	})
+}
@@ -0,0 +1,294 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+// Durées de cache des réponses wallet, selon la fréquence de mise à jour des données
+const (
+	walletTrustMaxAge   = 30 * time.Second
+	walletProfileMaxAge = time.Minute
+	walletTokensMaxAge  = time.Minute
+	walletTrendMaxAge   = 5 * time.Minute
+	walletGraphMaxAge   = 5 * time.Minute
+)
+
+// WalletTrust regroupe les lectures du Memory of Trust exposées par l'API wallet
+type WalletTrust interface {
+	GetWalletTrustScore(walletAddress string) (float64, error)
+	GetWalletTrustTrend(walletAddress string, days int) ([]models.TrustScorePoint, error)
+	GetSimilarWallets(walletAddress string, minSimilarity float64, limit int) ([]models.WalletSimilarity, error)
+	GetMostTrustedWallets(limit int) ([]models.WalletTrustScore, error)
+	GetWalletRiskFactors(walletAddress string) (*models.WalletRiskFactors, error)
+	GetWalletTokens(walletAddress string, limit int) ([]models.WalletToken, error)
+}
+
+var _ WalletTrust = (*memory.TrustNetwork)(nil)
+
+// WalletAnalyzer regroupe les analyses de wallets basées sur GMGN (implémenté par wallet.Analyzer)
+type WalletAnalyzer interface {
+	GetWalletProfile(walletAddress string) (*models.WalletProfile, error)
+	IsSniperWallet(walletAddress string) (bool, float64, error)
+	IsSmartMoneyWallet(walletAddress string) (bool, float64, error)
+}
+
+// WalletHandler gère les requêtes API relatives aux wallets
+type WalletHandler struct {
+	trust    WalletTrust
+	analyzer WalletAnalyzer
+	logger   *logger.Logger
+}
+
+// NewWalletHandler crée un nouveau gestionnaire pour les wallets
+func NewWalletHandler(trust WalletTrust, analyzer WalletAnalyzer, logger *logger.Logger) *WalletHandler {
+	return &WalletHandler{
+		trust:    trust,
+		analyzer: analyzer,
+		logger:   logger,
+	}
+}
+
+// RegisterRoutes enregistre les routes de l'API pour les wallets
+func (h *WalletHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/wallets/trusted", h.GetMostTrustedWallets).Methods("GET")
+	router.HandleFunc("/api/wallets/{walletAddress}", h.GetWalletProfile).Methods("GET")
+	router.HandleFunc("/api/wallets/{walletAddress}/trust", h.GetWalletTrustScore).Methods("GET")
+	router.HandleFunc("/api/wallets/{walletAddress}/trust/trend", h.GetWalletTrustTrend).Methods("GET")
+	router.HandleFunc("/api/wallets/{walletAddress}/similar", h.GetSimilarWallets).Methods("GET")
+	router.HandleFunc("/api/wallets/{walletAddress}/risk", h.GetWalletRiskFactors).Methods("GET")
+	router.HandleFunc("/api/wallets/{walletAddress}/tokens", h.GetWalletTokens).Methods("GET")
+	router.HandleFunc("/api/wallets/{walletAddress}/classification", h.GetWalletClassification).Methods("GET")
+}
+
+// walletClass décrit le résultat d'une classification de wallet
+type walletClass struct {
+	Detected bool    `json:"detected"`
+	Score    float64 `json:"score"`
+}
+
+// GetMostTrustedWallets retourne les wallets avec les meilleurs scores de confiance
+func (h *WalletHandler) GetMostTrustedWallets(w http.ResponseWriter, r *http.Request) {
+	limit, ok := intParam(w, r, "limit", 50, 1, 500)
+	if !ok {
+		return
+	}
+
+	wallets, err := h.trust.GetMostTrustedWallets(limit)
+	if err != nil {
+		h.logger.Error("Échec de la récupération des wallets de confiance", err, nil)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération des wallets de confiance")
+		return
+	}
+	if wallets == nil {
+		wallets = []models.WalletTrustScore{}
+	}
+
+	writeCachedJSON(w, r, walletTrustMaxAge, map[string]interface{}{
+		"wallets": wallets,
+		"count":   len(wallets),
+	})
+}
+
+// GetWalletProfile retourne le profil complet d'un wallet: tags, statistiques, holdings et score de confiance
+func (h *WalletHandler) GetWalletProfile(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["walletAddress"]
+	if !h.requireAnalyzer(w) {
+		return
+	}
+
+	profile, err := h.analyzer.GetWalletProfile(walletAddress)
+	if err != nil {
+		h.logger.Error("Échec de la récupération du profil du wallet", err, map[string]interface{}{
+			"wallet_address": walletAddress,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de récupérer le profil du wallet")
+		return
+	}
+
+	writeCachedJSON(w, r, walletProfileMaxAge, profile)
+}
+
+// GetWalletTrustScore retourne le score de confiance courant d'un wallet
+func (h *WalletHandler) GetWalletTrustScore(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["walletAddress"]
+
+	score, err := h.trust.GetWalletTrustScore(walletAddress)
+	if err != nil {
+		h.logger.Error("Échec de la récupération du score de confiance", err, map[string]interface{}{
+			"wallet_address": walletAddress,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération du score de confiance")
+		return
+	}
+
+	writeCachedJSON(w, r, walletTrustMaxAge, map[string]interface{}{
+		"wallet_address": walletAddress,
+		"trust_score":    score,
+	})
+}
+
+// GetWalletTrustTrend retourne l'évolution journalière du score de confiance d'un wallet
+func (h *WalletHandler) GetWalletTrustTrend(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["walletAddress"]
+	days, ok := intParam(w, r, "days", 30, 1, 365)
+	if !ok {
+		return
+	}
+
+	points, err := h.trust.GetWalletTrustTrend(walletAddress, days)
+	if err != nil {
+		h.logger.Error("Échec de la récupération de l'évolution du score de confiance", err, map[string]interface{}{
+			"wallet_address": walletAddress,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération de l'évolution du score de confiance")
+		return
+	}
+	if points == nil {
+		points = []models.TrustScorePoint{}
+	}
+
+	writeCachedJSON(w, r, walletTrendMaxAge, map[string]interface{}{
+		"wallet_address": walletAddress,
+		"days":           days,
+		"points":         points,
+	})
+}
+
+// GetSimilarWallets retourne les wallets au comportement proche (tokens communs, timing, positions)
+func (h *WalletHandler) GetSimilarWallets(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["walletAddress"]
+	limit, ok := intParam(w, r, "limit", 20, 1, 200)
+	if !ok {
+		return
+	}
+
+	minSimilarity := 0.5 // Valeur par défaut
+	if minStr := r.URL.Query().Get("min_similarity"); minStr != "" {
+		parsed, err := strconv.ParseFloat(minStr, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Paramètre min_similarity invalide (entre 0 et 1)")
+			return
+		}
+		minSimilarity = parsed
+	}
+
+	similar, err := h.trust.GetSimilarWallets(walletAddress, minSimilarity, limit)
+	if err != nil {
+		h.logger.Error("Échec de la récupération des wallets similaires", err, map[string]interface{}{
+			"wallet_address": walletAddress,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération des wallets similaires")
+		return
+	}
+	if similar == nil {
+		similar = []models.WalletSimilarity{}
+	}
+
+	writeCachedJSON(w, r, walletGraphMaxAge, map[string]interface{}{
+		"wallet_address": walletAddress,
+		"similar":        similar,
+		"count":          len(similar),
+	})
+}
+
+// GetWalletRiskFactors retourne les facteurs de risque d'un wallet
+func (h *WalletHandler) GetWalletRiskFactors(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["walletAddress"]
+
+	risk, err := h.trust.GetWalletRiskFactors(walletAddress)
+	if err != nil {
+		h.logger.Error("Échec de la récupération des facteurs de risque", err, map[string]interface{}{
+			"wallet_address": walletAddress,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération des facteurs de risque")
+		return
+	}
+
+	writeCachedJSON(w, r, walletGraphMaxAge, risk)
+}
+
+// GetWalletTokens retourne les tokens tradés par un wallet avec ses métriques d'interaction
+func (h *WalletHandler) GetWalletTokens(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["walletAddress"]
+	limit, ok := intParam(w, r, "limit", 50, 1, 500)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.trust.GetWalletTokens(walletAddress, limit)
+	if err != nil {
+		h.logger.Error("Échec de la récupération des tokens du wallet", err, map[string]interface{}{
+			"wallet_address": walletAddress,
+		})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Erreur lors de la récupération des tokens du wallet")
+		return
+	}
+	if tokens == nil {
+		tokens = []models.WalletToken{}
+	}
+
+	writeCachedJSON(w, r, walletTokensMaxAge, map[string]interface{}{
+		"wallet_address": walletAddress,
+		"tokens":         tokens,
+		"count":          len(tokens),
+	})
+}
+
+// GetWalletClassification indique si un wallet se comporte en sniper et en smart money, avec les scores
+func (h *WalletHandler) GetWalletClassification(w http.ResponseWriter, r *http.Request) {
+	walletAddress := mux.Vars(r)["walletAddress"]
+	if !h.requireAnalyzer(w) {
+		return
+	}
+
+	var sniper, smartMoney walletClass
+	var err error
+	sniper.Detected, sniper.Score, err = h.analyzer.IsSniperWallet(walletAddress)
+	if err == nil {
+		smartMoney.Detected, smartMoney.Score, err = h.analyzer.IsSmartMoneyWallet(walletAddress)
+	}
+	if err != nil {
+		h.logger.Error("Échec de la classification du wallet", err, map[string]interface{}{
+			"wallet_address": walletAddress,
+		})
+		writeError(w, http.StatusBadGateway, ErrCodeUpstream, "Impossible de classifier le wallet")
+		return
+	}
+
+	writeCachedJSON(w, r, walletGraphMaxAge, map[string]interface{}{
+		"wallet_address": walletAddress,
+		"sniper":         sniper,
+		"smart_money":    smartMoney,
+	})
+}
+
+// requireAnalyzer répond 503 si aucun analyseur de wallets n'est configuré
+func (h *WalletHandler) requireAnalyzer(w http.ResponseWriter) bool {
+	if h.analyzer == nil {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeUnavailable, "Analyse de wallets indisponible")
+		return false
+	}
+	return true
+}
+
+// intParam lit un paramètre entier borné de la requête, avec une valeur par défaut.
+// Il répond 400 et retourne false si la valeur est invalide.
+func intParam(w http.ResponseWriter, r *http.Request, name string, def, min, max int) (int, bool) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return def, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min || value > max {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidParameter, "Paramètre "+name+" invalide")
+		return 0, false
+	}
+	return value, true
+}
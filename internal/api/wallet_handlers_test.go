@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+	"github.com/gorilla/mux"
+)
+
+type stubWalletTrust struct {
+	WalletTrust
+	scores map[string]float64
+}
+
+func (s stubWalletTrust) GetWalletTrustScore(walletAddress string) (float64, error) {
+	return s.scores[walletAddress], nil
+}
+
+func (s stubWalletTrust) GetSimilarWallets(walletAddress string, minSimilarity float64, limit int) ([]models.WalletSimilarity, error) {
+	return nil, nil
+}
+
+type stubWalletAnalyzer struct {
+	WalletAnalyzer
+}
+
+func (stubWalletAnalyzer) IsSniperWallet(walletAddress string) (bool, float64, error) {
+	return true, 72, nil
+}
+
+func (stubWalletAnalyzer) IsSmartMoneyWallet(walletAddress string) (bool, float64, error) {
+	return false, 20, nil
+}
+
+func newWalletRouter(analyzer WalletAnalyzer) *mux.Router {
+	router := mux.NewRouter()
+	trust := stubWalletTrust{scores: map[string]float64{"w1": 81.5}}
+	NewWalletHandler(trust, analyzer, logger.NewLogger("error")).RegisterRoutes(router)
+	return router
+}
+
+func TestWalletTrustCaching(t *testing.T) {
+	router := newWalletRouter(stubWalletAnalyzer{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/wallets/w1/trust", nil))
+	var body struct {
+		TrustScore float64 `json:"trust_score"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || body.TrustScore != 81.5 || etag == "" || rec.Header().Get("Cache-Control") != "private, max-age=30" || rec.Header().Get("Vary") != "Authorization, X-API-Key" {
+		t.Fatalf("status %d, body %+v, headers %v", rec.Code, body, rec.Header())
+	}
+
+	req := httptest.NewRequest("GET", "/api/wallets/w1/trust", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("revalidation status %d, body %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/wallets/w1/similar?min_similarity=2", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("invalid min_similarity status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/wallets/w1/similar", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Cache-Control") != "private, max-age=300" {
+		t.Fatalf("similar status %d, headers %v", rec.Code, rec.Header())
+	}
+}
+
+func TestWalletClassification(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newWalletRouter(stubWalletAnalyzer{}).ServeHTTP(rec, httptest.NewRequest("GET", "/api/wallets/w1/classification", nil))
+	var body struct {
+		Sniper     walletClass `json:"sniper"`
+		SmartMoney walletClass `json:"smart_money"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if rec.Code != http.StatusOK || !body.Sniper.Detected || body.Sniper.Score != 72 || body.SmartMoney.Detected {
+		t.Fatalf("status %d, body %+v", rec.Code, body)
+	}
+
+	rec = httptest.NewRecorder()
+	newWalletRouter(nil).ServeHTTP(rec, httptest.NewRequest("GET", "/api/wallets/w1/classification", nil))
+	var envelope ErrorResponse
+	json.NewDecoder(rec.Body).Decode(&envelope)
+	if rec.Code != http.StatusServiceUnavailable || envelope.Error.Code != ErrCodeUnavailable {
+		t.Fatalf("status %d, envelope %+v", rec.Code, envelope)
+	}
+}
@@ -0,0 +1,603 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidKey est retournée pour une clé absente, mal formée ou inconnue
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrKeyRevoked est retournée pour une clé révoquée
+	ErrKeyRevoked = errors.New("API key revoked")
+	// ErrKeyExpired est retournée pour une clé expirée
+	ErrKeyExpired = errors.New("API key expired")
+	// ErrKeyNotFound est retournée pour un identifiant de clé inconnu
+	ErrKeyNotFound = errors.New("API key not found")
+	// ErrInvalidKeyRequest est retournée pour une demande d'émission mal définie
+	ErrInvalidKeyRequest = errors.New("invalid API key request")
+)
+
+// keyPrefix préfixe toutes les clés émises, pour les repérer dans un dépôt ou un log
+const keyPrefix = "co_"
+
+// bootstrapKeyID identifie la clé d'administration issue de la configuration
+const bootstrapKeyID = "bootstrap"
+
+// Options règle l'authentification par clé d'API
+type Options struct {
+	Enabled          bool
+	DefaultRateLimit int           // Requêtes par minute des clés sans limite propre
+	DefaultBurst     int           // Requêtes consécutives tolérées des clés sans limite propre
+	ReloadInterval   time.Duration // Relecture des clés, pour voir les révocations faites par une autre instance
+	AuditBufferSize  int           // Entrées d'audit en attente avant abandon
+	AuditFlush       time.Duration // Intervalle d'écriture du journal d'audit
+}
+
+// DefaultOptions sont les réglages utilisés sans configuration
+var DefaultOptions = Options{
+	DefaultRateLimit: 120,
+	DefaultBurst:     20,
+	ReloadInterval:   time.Minute,
+	AuditBufferSize:  1024,
+	AuditFlush:       2 * time.Second,
+}
+
+// IssueRequest décrit une clé à émettre
+type IssueRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	RateLimit int        `json:"rate_limit"`
+	Burst     int        `json:"burst"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Decision est le résultat du contrôle de débit d'une requête
+type Decision struct {
+	Allowed    bool
+	Limit      int // Requêtes par minute
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Manager émet, vérifie et révoque les clés d'API, limite leur débit et tient le journal
+// d'audit de leur utilisation. Les clés sont gardées en mémoire, indexées par préfixe;
+// le Manager peut être utilisé depuis plusieurs goroutines.
+type Manager struct {
+	logger  *logrus.Logger
+	store   Store
+	options Options
+
+	mu        sync.RWMutex
+	keys      map[string]*models.APIKey // Par préfixe
+	bootstrap *models.APIKey
+
+	bucketMu sync.Mutex
+	buckets  map[string]*tokenBucket // Par ID de clé
+
+	audit     chan models.APIKeyUsage
+	auditStop chan struct{}
+	dropped   atomic.Int64
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager crée un gestionnaire de clés, avec un store en mémoire
+func NewManager(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger:  logger,
+		store:   NewMemoryStore(),
+		options: DefaultOptions,
+		keys:    make(map[string]*models.APIKey),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// SetStore remplace le store des clés, par exemple par la base de données
+func (m *Manager) SetStore(store Store) {
+	m.store = store
+}
+
+// ApplyConfig applique la configuration de l'authentification. Doit être appelée avant Start.
+func (m *Manager) ApplyConfig(cfg *config.AuthConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	m.options.Enabled = cfg.Enabled
+	if cfg.DefaultRateLimit > 0 {
+		m.options.DefaultRateLimit = cfg.DefaultRateLimit
+	}
+	if cfg.DefaultBurst > 0 {
+		m.options.DefaultBurst = cfg.DefaultBurst
+	}
+	if cfg.ReloadIntervalSeconds > 0 {
+		m.options.ReloadInterval = time.Duration(cfg.ReloadIntervalSeconds) * time.Second
+	}
+	if cfg.AuditBufferSize > 0 {
+		m.options.AuditBufferSize = cfg.AuditBufferSize
+	}
+	if cfg.AuditFlushMs > 0 {
+		m.options.AuditFlush = time.Duration(cfg.AuditFlushMs) * time.Millisecond
+	}
+
+	if cfg.BootstrapKey != "" {
+		if len(cfg.BootstrapKey) < 32 {
+			return fmt.Errorf("%w: bootstrap key must be at least 32 characters", ErrInvalidKeyRequest)
+		}
+		m.mu.Lock()
+		m.bootstrap = &models.APIKey{
+			ID:     bootstrapKeyID,
+			Name:   "Bootstrap admin key",
+			Prefix: bootstrapKeyID,
+			Hash:   hashKey(cfg.BootstrapKey),
+			Scopes: []string{models.ScopeAdmin},
+		}
+		m.mu.Unlock()
+	}
+	return nil
+}
+
+// Enabled indique si les requêtes doivent présenter une clé
+func (m *Manager) Enabled() bool {
+	return m.options.Enabled
+}
+
+// Start charge les clés et démarre l'écriture du journal d'audit
+func (m *Manager) Start(ctx context.Context) error {
+	m.logger.Info("Starting API key manager")
+
+	if err := m.Load(); err != nil {
+		return err
+	}
+	if m.options.Enabled && len(m.Keys()) == 0 && m.bootstrap == nil {
+		m.logger.Warn("API authentication enabled without any key: set api.auth.bootstrap_key to issue the first keys")
+	}
+
+	// Le journal d'audit s'arrête avec Shutdown et non avec ctx, pour garder les requêtes
+	// servies pendant l'arrêt du serveur API
+	m.audit = make(chan models.APIKeyUsage, m.options.AuditBufferSize)
+	m.auditStop = make(chan struct{})
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.wg.Add(2)
+	go m.runAudit()
+	go m.runReload(ctx)
+	return nil
+}
+
+// Shutdown arrête le gestionnaire après avoir écrit les entrées d'audit en attente;
+// l'attente est bornée par l'échéance de ctx
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.logger.Info("Shutting down API key manager")
+
+	if m.cancel != nil {
+		m.cancel()
+		close(m.auditStop)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("API key manager shutdown: %w", ctx.Err())
+	}
+}
+
+// Load recharge les clés depuis le store
+func (m *Manager) Load() error {
+	stored, err := m.store.ListAPIKeys()
+	if err != nil {
+		return fmt.Errorf("failed to load API keys: %w", err)
+	}
+
+	keys := make(map[string]*models.APIKey, len(stored))
+	for i := range stored {
+		keys[stored[i].Prefix] = &stored[i]
+	}
+
+	m.mu.Lock()
+	// La dernière utilisation connue en mémoire est plus récente que celle du store
+	for prefix, key := range keys {
+		if current, ok := m.keys[prefix]; ok && current.LastUsedAt != nil &&
+			(key.LastUsedAt == nil || key.LastUsedAt.Before(*current.LastUsedAt)) {
+			key.LastUsedAt = current.LastUsedAt
+		}
+	}
+	m.keys = keys
+	m.mu.Unlock()
+	return nil
+}
+
+// runReload relit périodiquement les clés du store
+func (m *Manager) runReload(ctx context.Context) {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.options.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Load(); err != nil {
+				m.logger.WithError(err).Error("Failed to reload API keys")
+			}
+		}
+	}
+}
+
+// Issue émet une nouvelle clé. La clé complète est retournée une seule fois: seule son
+// empreinte est conservée.
+func (m *Manager) Issue(req IssueRequest) (string, *models.APIKey, error) {
+	if err := validateIssueRequest(&req); err != nil {
+		return "", nil, err
+	}
+	return m.issue(req, "")
+}
+
+// issue génère et enregistre une clé validée
+func (m *Manager) issue(req IssueRequest, rotatedFrom string) (string, *models.APIKey, error) {
+	prefix, err := randomToken(6)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+	raw := keyPrefix + prefix + "_" + secret
+
+	key := &models.APIKey{
+		ID:          fmt.Sprintf("key_%d", time.Now().UnixNano()),
+		Name:        req.Name,
+		Prefix:      prefix,
+		Hash:        hashKey(raw),
+		Scopes:      req.Scopes,
+		RateLimit:   req.RateLimit,
+		Burst:       req.Burst,
+		RotatedFrom: rotatedFrom,
+		CreatedAt:   time.Now(),
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if err := m.store.SaveAPIKey(key); err != nil {
+		return "", nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+
+	m.mu.Lock()
+	m.keys[prefix] = key
+	m.mu.Unlock()
+
+	m.logger.WithFields(logrus.Fields{
+		"key_id":       key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       key.Scopes,
+		"rotated_from": rotatedFrom,
+	}).Info("API key issued")
+
+	copied := *key
+	return raw, &copied, nil
+}
+
+// Rotate émet une clé de remplacement avec les mêmes droits. L'ancienne clé reste valide
+// pendant grace pour laisser le temps de déployer la nouvelle, puis est révoquée.
+func (m *Manager) Rotate(id string, grace time.Duration) (string, *models.APIKey, error) {
+	old, err := m.Key(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if !old.Active(time.Now()) {
+		return "", nil, fmt.Errorf("%w: key %s is no longer active", ErrInvalidKeyRequest, id)
+	}
+	if grace < 0 {
+		return "", nil, fmt.Errorf("%w: grace period must be positive", ErrInvalidKeyRequest)
+	}
+
+	raw, key, err := m.issue(IssueRequest{
+		Name:      old.Name,
+		Scopes:    old.Scopes,
+		RateLimit: old.RateLimit,
+		Burst:     old.Burst,
+		ExpiresAt: old.ExpiresAt,
+	}, old.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := m.revoke(id, time.Now().Add(grace)); err != nil {
+		return "", nil, err
+	}
+	return raw, key, nil
+}
+
+// Revoke révoque immédiatement une clé
+func (m *Manager) Revoke(id string) (*models.APIKey, error) {
+	return m.revoke(id, time.Now())
+}
+
+// revoke révoque une clé à partir de at
+func (m *Manager) revoke(id string, at time.Time) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.findLocked(id)
+	if current == nil {
+		return nil, ErrKeyNotFound
+	}
+	if current.RevokedAt != nil && !current.RevokedAt.After(at) {
+		copied := *current
+		return &copied, nil
+	}
+
+	key := *current
+	key.RevokedAt = &at
+	if err := m.store.SaveAPIKey(&key); err != nil {
+		return nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+	m.keys[key.Prefix] = &key
+
+	m.logger.WithFields(logrus.Fields{
+		"key_id":     key.ID,
+		"prefix":     key.Prefix,
+		"revoked_at": at,
+	}).Info("API key revoked")
+
+	copied := key
+	return &copied, nil
+}
+
+// findLocked retourne la clé d'identifiant id; m.mu doit être tenu
+func (m *Manager) findLocked(id string) *models.APIKey {
+	for _, key := range m.keys {
+		if key.ID == id {
+			return key
+		}
+	}
+	return nil
+}
+
+// Keys retourne les clés émises, des plus anciennes aux plus récentes
+func (m *Manager) Keys() []models.APIKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	keys := make([]models.APIKey, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, *key)
+	}
+	sortKeys(keys)
+	return keys
+}
+
+// Key retourne une clé par son identifiant
+func (m *Manager) Key(id string) (*models.APIKey, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key := m.findLocked(id)
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+// Authenticate vérifie une clé présentée par un client et retourne la clé correspondante.
+// Pour une clé révoquée ou expirée, la clé est retournée avec l'erreur pour l'audit.
+func (m *Manager) Authenticate(raw string) (*models.APIKey, error) {
+	hash := hashKey(raw)
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var key *models.APIKey
+	if m.bootstrap != nil && subtle.ConstantTimeCompare([]byte(hash), []byte(m.bootstrap.Hash)) == 1 {
+		key = m.bootstrap
+	} else if prefix, ok := ParsePrefix(raw); ok {
+		if candidate := m.keys[prefix]; candidate != nil &&
+			subtle.ConstantTimeCompare([]byte(hash), []byte(candidate.Hash)) == 1 {
+			key = candidate
+		}
+	}
+
+	if key == nil {
+		return nil, ErrInvalidKey
+	}
+	copied := *key
+	switch {
+	case key.RevokedAt != nil && !now.Before(*key.RevokedAt):
+		return &copied, ErrKeyRevoked
+	case key.ExpiresAt != nil && !now.Before(*key.ExpiresAt):
+		return &copied, ErrKeyExpired
+	}
+
+	key.LastUsedAt = &now
+	copied.LastUsedAt = &now
+	return &copied, nil
+}
+
+// Allow consomme une requête dans le seau de la clé
+func (m *Manager) Allow(key *models.APIKey) Decision {
+	limit, burst := key.RateLimit, key.Burst
+	if limit <= 0 {
+		limit = m.options.DefaultRateLimit
+	}
+	if burst <= 0 {
+		burst = m.options.DefaultBurst
+	}
+
+	m.bucketMu.Lock()
+	bucket, ok := m.buckets[key.ID]
+	if !ok || bucket.capacity != float64(burst) || bucket.rate != float64(limit)/60 {
+		bucket = newTokenBucket(limit, burst, time.Now())
+		m.buckets[key.ID] = bucket
+	}
+	m.bucketMu.Unlock()
+
+	allowed, remaining, retryAfter := bucket.take(time.Now())
+	return Decision{Allowed: allowed, Limit: limit, Remaining: remaining, RetryAfter: retryAfter}
+}
+
+// Record ajoute une entrée au journal d'audit sans bloquer la requête. Les entrées sont
+// abandonnées si le journal ne suit pas.
+func (m *Manager) Record(entry models.APIKeyUsage) {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	if m.audit == nil {
+		if err := m.store.SaveAPIKeyUsage([]models.APIKeyUsage{entry}); err != nil {
+			m.logger.WithError(err).Error("Failed to save API key usage")
+		}
+		return
+	}
+
+	select {
+	case m.audit <- entry:
+	default:
+		if dropped := m.dropped.Add(1); dropped%100 == 1 {
+			m.logger.WithField("dropped", dropped).Warn("API key audit log is full, dropping entries")
+		}
+	}
+}
+
+// Usage retourne les dernières entrées d'audit d'une clé, ou de toutes les clés si id est vide
+func (m *Manager) Usage(id string, limit int) ([]models.APIKeyUsage, error) {
+	if id != "" && id != bootstrapKeyID {
+		if _, err := m.Key(id); err != nil {
+			return nil, err
+		}
+	}
+	return m.store.ListAPIKeyUsage(id, limit)
+}
+
+// runAudit écrit le journal d'audit par lots
+func (m *Manager) runAudit() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.options.AuditFlush)
+	defer ticker.Stop()
+
+	batch := make([]models.APIKeyUsage, 0, 64)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := m.store.SaveAPIKeyUsage(batch); err != nil {
+			m.logger.WithError(err).WithField("entries", len(batch)).Error("Failed to save API key usage")
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-m.auditStop:
+			// Vider les entrées déjà en file avant de s'arrêter
+			for {
+				select {
+				case entry := <-m.audit:
+					batch = append(batch, entry)
+				default:
+					flush()
+					return
+				}
+			}
+		case entry := <-m.audit:
+			batch = append(batch, entry)
+			if len(batch) >= cap(batch) {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// validateIssueRequest normalise et vérifie une demande d'émission
+func validateIssueRequest(req *IssueRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidKeyRequest)
+	}
+	if len(req.Scopes) == 0 {
+		return fmt.Errorf("%w: at least one scope is required", ErrInvalidKeyRequest)
+	}
+	for i, scope := range req.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !isScope(scope) {
+			return fmt.Errorf("%w: scope %q must be one of %s", ErrInvalidKeyRequest, scope, strings.Join(models.APIKeyScopes, ", "))
+		}
+		req.Scopes[i] = scope
+	}
+	if req.RateLimit < 0 || req.Burst < 0 {
+		return fmt.Errorf("%w: rate_limit and burst must be positive", ErrInvalidKeyRequest)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return fmt.Errorf("%w: expires_at must be in the future", ErrInvalidKeyRequest)
+	}
+	return nil
+}
+
+// isScope indique si scope est un scope connu
+func isScope(scope string) bool {
+	for _, known := range models.APIKeyScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// sortKeys trie les clés par date de création
+func sortKeys(keys []models.APIKey) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+}
+
+// ParsePrefix extrait le préfixe public d'une clé de la forme co_<préfixe>_<secret>
+func ParsePrefix(raw string) (string, bool) {
+	if !strings.HasPrefix(raw, keyPrefix) {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(strings.TrimPrefix(raw, keyPrefix), "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// hashKey calcule l'empreinte conservée d'une clé
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomToken retourne n octets aléatoires encodés en base64 URL, sans '_' pour ne pas
+// se confondre avec le séparateur des clés
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return strings.ReplaceAll(base64.RawURLEncoding.EncodeToString(buf), "_", "-"), nil
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestManager(t *testing.T) (*Manager, *MemoryStore) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	store := NewMemoryStore()
+	m := NewManager(logger)
+	m.SetStore(store)
+	return m, store
+}
+
+func TestIssueAndAuthenticate(t *testing.T) {
+	m, store := newTestManager(t)
+
+	raw, key, err := m.Issue(IssueRequest{Name: "dashboard", Scopes: []string{"READ:TOKENS"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prefix, ok := ParsePrefix(raw); !ok || prefix != key.Prefix {
+		t.Fatalf("key %q does not carry prefix %q", raw, key.Prefix)
+	}
+	stored, _ := store.ListAPIKeys()
+	if len(stored) != 1 || stored[0].Hash == "" || stored[0].Hash == raw {
+		t.Fatalf("stored keys %+v", stored)
+	}
+
+	got, err := m.Authenticate(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.HasScope(models.ScopeReadTokens) || got.HasScope(models.ScopeReadWallets) || got.LastUsedAt == nil {
+		t.Fatalf("unexpected key %+v", got)
+	}
+
+	for _, bad := range []string{"", "co_nope", raw + "x", "co_" + key.Prefix + "_wrong"} {
+		if _, err := m.Authenticate(bad); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("%q accepted: %v", bad, err)
+		}
+	}
+
+	// Les clés sont rechargées depuis le store, comme au redémarrage
+	reloaded := NewManager(m.logger)
+	reloaded.SetStore(store)
+	if err := reloaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloaded.Authenticate(raw); err != nil {
+		t.Fatalf("reloaded key rejected: %v", err)
+	}
+}
+
+func TestIssueValidation(t *testing.T) {
+	m, _ := newTestManager(t)
+	past := time.Now().Add(-time.Hour)
+
+	for _, req := range []IssueRequest{
+		{Scopes: []string{models.ScopeAdmin}},
+		{Name: "no scope"},
+		{Name: "bad scope", Scopes: []string{"write:everything"}},
+		{Name: "expired", Scopes: []string{models.ScopeStream}, ExpiresAt: &past},
+	} {
+		if _, _, err := m.Issue(req); !errors.Is(err, ErrInvalidKeyRequest) {
+			t.Fatalf("%+v accepted: %v", req, err)
+		}
+	}
+}
+
+func TestRotateAndRevoke(t *testing.T) {
+	m, _ := newTestManager(t)
+
+	oldRaw, old, _ := m.Issue(IssueRequest{Name: "bot", Scopes: []string{models.ScopeStream}, RateLimit: 30})
+	newRaw, rotated, err := m.Rotate(old.ID, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.RotatedFrom != old.ID || rotated.RateLimit != 30 || !rotated.HasScope(models.ScopeStream) {
+		t.Fatalf("unexpected rotated key %+v", rotated)
+	}
+
+	// L'ancienne clé reste valide pendant la période de grâce
+	if _, err := m.Authenticate(oldRaw); err != nil {
+		t.Fatalf("old key rejected during grace period: %v", err)
+	}
+	if _, err := m.Revoke(old.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Authenticate(oldRaw); !errors.Is(err, ErrKeyRevoked) {
+		t.Fatalf("revoked key accepted: %v", err)
+	}
+	if _, err := m.Authenticate(newRaw); err != nil {
+		t.Fatalf("rotated key rejected: %v", err)
+	}
+
+	if _, err := m.Revoke("key_unknown"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("unknown key revoked: %v", err)
+	}
+	if _, _, err := m.Rotate(old.ID, 0); !errors.Is(err, ErrInvalidKeyRequest) {
+		t.Fatalf("revoked key rotated: %v", err)
+	}
+}
+
+func TestBootstrapKey(t *testing.T) {
+	m, _ := newTestManager(t)
+	if err := m.ApplyConfig(&config.AuthConfig{Enabled: true, BootstrapKey: "short"}); !errors.Is(err, ErrInvalidKeyRequest) {
+		t.Fatalf("short bootstrap key accepted: %v", err)
+	}
+
+	bootstrap := "0123456789abcdef0123456789abcdef"
+	if err := m.ApplyConfig(&config.AuthConfig{Enabled: true, BootstrapKey: bootstrap}); err != nil {
+		t.Fatal(err)
+	}
+	key, err := m.Authenticate(bootstrap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.ID != bootstrapKeyID || !key.HasScope(models.ScopeReadWallets) {
+		t.Fatalf("unexpected bootstrap key %+v", key)
+	}
+	if len(m.Keys()) != 0 {
+		t.Fatal("bootstrap key listed with issued keys")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	m, _ := newTestManager(t)
+	_, key, _ := m.Issue(IssueRequest{Name: "client", Scopes: []string{models.ScopeReadTokens}, RateLimit: 60, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if decision := m.Allow(key); !decision.Allowed || decision.Remaining != 2-i {
+			t.Fatalf("request %d: %+v", i, decision)
+		}
+	}
+	decision := m.Allow(key)
+	if decision.Allowed || decision.Limit != 60 || decision.RetryAfter <= 0 || decision.RetryAfter > time.Second {
+		t.Fatalf("burst exceeded: %+v", decision)
+	}
+
+	bucket := newTokenBucket(60, 1, time.Unix(0, 0))
+	bucket.take(time.Unix(0, 0))
+	if ok, _, _ := bucket.take(time.Unix(0, 0).Add(500 * time.Millisecond)); ok {
+		t.Fatal("token refilled too early")
+	}
+	if ok, _, _ := bucket.take(time.Unix(1, 0)); !ok {
+		t.Fatal("token not refilled after one second")
+	}
+}
+
+func TestAuditFlushedOnShutdown(t *testing.T) {
+	m, store := newTestManager(t)
+	m.options.AuditFlush = time.Hour
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	_, key, _ := m.Issue(IssueRequest{Name: "client", Scopes: []string{models.ScopeReadTokens}})
+
+	m.Record(models.APIKeyUsage{KeyID: key.ID, Method: "GET", Path: "/api/tokens", Status: 200})
+	m.Record(models.APIKeyUsage{Prefix: "unknown", Method: "GET", Path: "/api/tokens", Status: 401, Reason: "invalid API key"})
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := m.Usage(key.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usage) != 1 || usage[0].Status != 200 || usage[0].At.IsZero() {
+		t.Fatalf("key usage %+v", usage)
+	}
+	all, _ := store.ListAPIKeyUsage("", 10)
+	if len(all) != 2 || all[0].Reason != "invalid API key" {
+		t.Fatalf("audit log %+v", all)
+	}
+	if _, err := m.Usage("key_unknown", 10); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("usage of unknown key: %v", err)
+	}
+}
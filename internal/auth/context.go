@@ -0,0 +1,20 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+type contextKey struct{}
+
+// WithKey attache la clé authentifiée au contexte d'une requête
+func WithKey(ctx context.Context, key *models.APIKey) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// KeyFromContext retourne la clé authentifiée de la requête, nil si aucune
+func KeyFromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(contextKey{}).(*models.APIKey)
+	return key
+}
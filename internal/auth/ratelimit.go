@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// tokenBucket limite le débit d'une clé: chaque requête consomme un jeton, les jetons
+// se régénèrent au rythme rate par seconde jusqu'à capacity
+type tokenBucket struct {
+	mu       sync.Mutex
+	capacity float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket crée un seau plein pour perMinute requêtes par minute et burst requêtes consécutives
+func newTokenBucket(perMinute, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(burst),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(burst),
+		last:     now,
+	}
+}
+
+// take consomme un jeton si possible. Retourne les jetons restants et, en cas de refus,
+// le délai avant qu'un jeton soit disponible.
+func (b *tokenBucket) take(now time.Time) (bool, int, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, int(b.tokens), 0
+	}
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	return false, 0, wait
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"sync"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// Store persiste les clés d'API et leur journal d'audit
+type Store interface {
+	ListAPIKeys() ([]models.APIKey, error)
+	SaveAPIKey(key *models.APIKey) error
+	SaveAPIKeyUsage(entries []models.APIKeyUsage) error
+	ListAPIKeyUsage(keyID string, limit int) ([]models.APIKeyUsage, error)
+}
+
+// MemoryStore est un Store en mémoire, pour les tests et le développement
+type MemoryStore struct {
+	mu    sync.Mutex
+	keys  map[string]models.APIKey
+	usage []models.APIKeyUsage
+}
+
+// NewMemoryStore crée un store en mémoire vide
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{keys: make(map[string]models.APIKey)}
+}
+
+// ListAPIKeys retourne les clés triées par date de création
+func (s *MemoryStore) ListAPIKeys() ([]models.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]models.APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, key)
+	}
+	sortKeys(keys)
+	return keys, nil
+}
+
+// SaveAPIKey enregistre ou remplace une clé
+func (s *MemoryStore) SaveAPIKey(key *models.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = *key
+	return nil
+}
+
+// SaveAPIKeyUsage ajoute des entrées au journal d'audit
+func (s *MemoryStore) SaveAPIKeyUsage(entries []models.APIKeyUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage = append(s.usage, entries...)
+	return nil
+}
+
+// ListAPIKeyUsage retourne les dernières entrées d'audit d'une clé (toutes si keyID est vide),
+// les plus récentes d'abord
+func (s *MemoryStore) ListAPIKeyUsage(keyID string, limit int) ([]models.APIKeyUsage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]models.APIKeyUsage, 0)
+	for i := len(s.usage) - 1; i >= 0 && len(result) < limit; i-- {
+		if keyID == "" || s.usage[i].KeyID == keyID {
+			result = append(result, s.usage[i])
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,80 @@
+// Package calibration ajuste hors ligne les poids du X-Score à partir des
+// résultats observés après chaque calcul.
+package calibration
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/token"
+)
+
+// Result regroupe le modèle ajusté, les poids candidats et le rapport holdout
+type Result struct {
+	Model   *Model
+	Weights token.Weights
+	Report  *Report
+}
+
+// Run ajuste le modèle sur la partie entraînement et l'évalue sur le holdout
+func Run(dataset *Dataset, holdoutRatio float64, opts Options, current token.Weights) (*Result, error) {
+	if holdoutRatio <= 0 || holdoutRatio >= 1 {
+		return nil, fmt.Errorf("holdout ratio must be in (0, 1), got %f", holdoutRatio)
+	}
+
+	train, holdout := dataset.Split(holdoutRatio)
+	if len(holdout.Samples) == 0 {
+		return nil, fmt.Errorf("holdout set is empty (%d samples)", len(dataset.Samples))
+	}
+
+	model, err := Fit(train, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fit model: %w", err)
+	}
+
+	weights, err := model.CandidateWeights(dataset.Outcome.IsDrawdown())
+	if err != nil {
+		return nil, err
+	}
+
+	coefficients := make(map[string]float64, len(model.Features))
+	for i, name := range model.Features {
+		coefficients[name] = model.Coefficients[i]
+	}
+
+	baseRate := float64(train.Positives()) / float64(len(train.Samples))
+	report := &Report{
+		Target:           dataset.Outcome.Name,
+		TrainSamples:     len(train.Samples),
+		SkippedSamples:   dataset.Skipped,
+		Iterations:       model.Iterations,
+		Model:            Evaluate(model, holdout),
+		Baseline:         EvaluateBaseline(baseRate, holdout),
+		CandidateAUC:     ScoreAUC(weights, holdout),
+		CurrentAUC:       ScoreAUC(current, holdout),
+		Coefficients:     coefficients,
+		CandidateWeights: weights,
+	}
+
+	return &Result{
+		Model:   model,
+		Weights: weights,
+		Report:  report,
+	}, nil
+}
+
+// WeightsFile construit le fichier de poids candidat chargeable par le moteur
+func (r *Result) WeightsFile() *token.WeightsFile {
+	return &token.WeightsFile{
+		Version:     token.WeightsFileVersion,
+		Target:      r.Report.Target,
+		GeneratedAt: time.Now(),
+		Weights:     r.Weights,
+		Evaluation: map[string]interface{}{
+			"holdout":       r.Report.Model,
+			"baseline":      r.Report.Baseline,
+			"candidate_auc": r.Report.CandidateAUC,
+			"current_auc":   r.Report.CurrentAUC,
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package calibration
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// syntheticObservations génère des observations dont le pump dépend surtout du trust_factor
+func syntheticObservations(n int) []Observation {
+	rng := rand.New(rand.NewSource(42))
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	observations := make([]Observation, 0, n)
+
+	for i := 0; i < n; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		factors := make(map[string]float64)
+		for _, name := range token.WeightedFactors {
+			factors[name] = rng.Float64() * 100
+		}
+
+		pumped := rng.Float64()*100 < factors[token.FactorTrust]*0.9
+		high := 1.2
+		if pumped {
+			high = 2.5
+		}
+
+		observations = append(observations, Observation{
+			TokenAddress: "token",
+			At:           at,
+			Price:        1.0,
+			Factors:      factors,
+			Prices: []models.TokenPricePoint{
+				{Timestamp: at.Add(time.Hour), High: high, Low: 0.9, Close: 1},
+				{Timestamp: at.Add(25 * time.Hour), High: 1, Low: 1, Close: 1},
+			},
+		})
+	}
+	return observations
+}
+
+func TestOutcomeLabel(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prices := []models.TokenPricePoint{
+		{Timestamp: at.Add(2 * time.Hour), High: 1.5, Low: 0.15},
+		{Timestamp: at.Add(30 * time.Hour), High: 3, Low: 1},
+	}
+
+	if label, ok := OutcomePump2x24h.Label(1, at, prices); !ok || label {
+		t.Fatalf("pump label = %v (covered %v), want false covered", label, ok)
+	}
+	if label, ok := OutcomeDump80.Label(1, at, prices); !ok || !label {
+		t.Fatalf("dump label = %v (covered %v), want true covered", label, ok)
+	}
+	if _, ok := OutcomePump2x24h.Label(1, at, prices[:1]); ok {
+		t.Fatal("expected uncovered horizon to be skipped")
+	}
+}
+
+func TestRunFavoursInformativeFactor(t *testing.T) {
+	dataset := BuildDataset(syntheticObservations(600), OutcomePump2x24h)
+	if len(dataset.Samples) != 600 {
+		t.Fatalf("samples = %d, want 600", len(dataset.Samples))
+	}
+
+	result, err := Run(dataset, 0.25, DefaultOptions(), token.DefaultWeights())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if err := result.Weights.Validate(); err != nil {
+		t.Fatalf("candidate weights invalid: %v", err)
+	}
+	for _, name := range token.WeightedFactors {
+		if name != token.FactorTrust && result.Weights[name] >= result.Weights[token.FactorTrust] {
+			t.Errorf("weight of %s (%.3f) should be below trust_factor (%.3f)",
+				name, result.Weights[name], result.Weights[token.FactorTrust])
+		}
+	}
+
+	if result.Report.Model.AUC < 0.7 {
+		t.Errorf("holdout AUC = %.3f, want >= 0.7", result.Report.Model.AUC)
+	}
+	if result.Report.Model.LogLoss >= result.Report.Baseline.LogLoss {
+		t.Errorf("model log loss %.4f should beat baseline %.4f",
+			result.Report.Model.LogLoss, result.Report.Baseline.LogLoss)
+	}
+	if result.Report.CandidateAUC <= result.Report.CurrentAUC {
+		t.Errorf("candidate AUC %.3f should beat current AUC %.3f",
+			result.Report.CandidateAUC, result.Report.CurrentAUC)
+	}
+}
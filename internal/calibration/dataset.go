@@ -0,0 +1,196 @@
+package calibration
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// Outcome définit un résultat futur servant d'étiquette (ex: "2x en 24h")
+type Outcome struct {
+	Name    string        `json:"name"`
+	Horizon time.Duration `json:"horizon"`
+	// Multiple du prix d'entrée à atteindre: 2.0 pour un 2x, 0.2 pour une baisse de 80%
+	Multiple float64 `json:"multiple"`
+}
+
+// Outcomes prédéfinis utilisables par la commande calibrate
+var (
+	OutcomePump2x24h = Outcome{Name: "pump_2x_24h", Horizon: 24 * time.Hour, Multiple: 2.0}
+	OutcomeDump80    = Outcome{Name: "dump_80_24h", Horizon: 24 * time.Hour, Multiple: 0.2}
+)
+
+// IsDrawdown indique si l'outcome mesure une baisse plutôt qu'une hausse
+func (o Outcome) IsDrawdown() bool {
+	return o.Multiple < 1.0
+}
+
+// Label calcule l'étiquette d'une observation à partir des prix futurs.
+// Le second retour est faux si la fenêtre d'observation n'est pas couverte.
+func (o Outcome) Label(entryPrice float64, at time.Time, prices []models.TokenPricePoint) (bool, bool) {
+	if entryPrice <= 0 || len(prices) == 0 {
+		return false, false
+	}
+
+	end := at.Add(o.Horizon)
+	covered := false
+	for _, point := range prices {
+		if point.Timestamp.Before(at) {
+			continue
+		}
+		if point.Timestamp.After(end) {
+			covered = true
+			break
+		}
+		if o.IsDrawdown() {
+			if point.Low > 0 && point.Low/entryPrice <= o.Multiple {
+				return true, true
+			}
+		} else if point.High/entryPrice >= o.Multiple {
+			return true, true
+		}
+		if !point.Timestamp.Before(end) {
+			covered = true
+		}
+	}
+
+	// Sans données au-delà de l'horizon, on ne peut pas conclure à un échec
+	return false, covered
+}
+
+// Observation est un X-Score historisé ou rejoué accompagné des prix qui ont suivi
+type Observation struct {
+	TokenAddress string                   `json:"token_address"`
+	At           time.Time                `json:"at"`
+	Price        float64                  `json:"price"`
+	Factors      map[string]float64       `json:"factors"`
+	Prices       []models.TokenPricePoint `json:"prices"`
+}
+
+// ObservationFromXScore construit une observation à partir d'un X-Score historisé
+func ObservationFromXScore(result models.XScoreResult, prices []models.TokenPricePoint) Observation {
+	return Observation{
+		TokenAddress: result.TokenAddress,
+		At:           result.CalculatedAt,
+		Price:        result.Price,
+		Factors:      result.Factors,
+		Prices:       prices,
+	}
+}
+
+// LoadObservationsFile charge des observations rejouées au format JSON Lines
+func LoadObservationsFile(path string) ([]Observation, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open observations file: %w", err)
+	}
+	defer file.Close()
+
+	var observations []Observation
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var obs Observation
+		if err := json.Unmarshal(scanner.Bytes(), &obs); err != nil {
+			return nil, fmt.Errorf("invalid observation at line %d: %w", line, err)
+		}
+		observations = append(observations, obs)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read observations file: %w", err)
+	}
+
+	return observations, nil
+}
+
+// Sample est une ligne du jeu de données: facteurs normalisés et étiquette
+type Sample struct {
+	TokenAddress string
+	At           time.Time
+	Features     []float64
+	Label        float64
+}
+
+// Dataset est un jeu de données étiqueté, trié chronologiquement
+type Dataset struct {
+	Outcome  Outcome
+	Features []string
+	Samples  []Sample
+	Skipped  int
+}
+
+// BuildDataset étiquette les observations selon l'outcome choisi.
+// Les facteurs (0-100) sont ramenés dans [0, 1].
+func BuildDataset(observations []Observation, outcome Outcome) *Dataset {
+	dataset := &Dataset{
+		Outcome:  outcome,
+		Features: token.WeightedFactors,
+	}
+
+	for _, obs := range observations {
+		label, ok := outcome.Label(obs.Price, obs.At, obs.Prices)
+		if !ok || len(obs.Factors) == 0 {
+			dataset.Skipped++
+			continue
+		}
+
+		features := make([]float64, len(dataset.Features))
+		for i, name := range dataset.Features {
+			features[i] = obs.Factors[name] / 100
+		}
+
+		sample := Sample{
+			TokenAddress: obs.TokenAddress,
+			At:           obs.At,
+			Features:     features,
+		}
+		if label {
+			sample.Label = 1
+		}
+		dataset.Samples = append(dataset.Samples, sample)
+	}
+
+	sort.SliceStable(dataset.Samples, func(i, j int) bool {
+		return dataset.Samples[i].At.Before(dataset.Samples[j].At)
+	})
+
+	return dataset
+}
+
+// Positives retourne le nombre d'échantillons positifs
+func (d *Dataset) Positives() int {
+	count := 0
+	for _, sample := range d.Samples {
+		if sample.Label > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Split sépare le jeu de données en entraînement et holdout.
+// La séparation est chronologique pour éviter toute fuite d'information future.
+func (d *Dataset) Split(holdoutRatio float64) (*Dataset, *Dataset) {
+	cut := int(float64(len(d.Samples)) * (1 - holdoutRatio))
+	if cut < 0 {
+		cut = 0
+	}
+	if cut > len(d.Samples) {
+		cut = len(d.Samples)
+	}
+
+	train := &Dataset{Outcome: d.Outcome, Features: d.Features, Samples: d.Samples[:cut]}
+	holdout := &Dataset{Outcome: d.Outcome, Features: d.Features, Samples: d.Samples[cut:]}
+	return train, holdout
+}
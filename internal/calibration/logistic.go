@@ -0,0 +1,136 @@
+package calibration
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/franky69420/crypto-oracle/internal/token"
+)
+
+// Options contrôle l'ajustement de la régression logistique
+type Options struct {
+	L2           float64 // Coefficient de régularisation L2 (l'intercept n'est pas pénalisé)
+	LearningRate float64
+	Iterations   int
+	Tolerance    float64 // Arrêt anticipé quand la norme du gradient passe sous ce seuil
+}
+
+// DefaultOptions retourne des options raisonnables pour des facteurs dans [0, 1]
+func DefaultOptions() Options {
+	return Options{
+		L2:           0.01,
+		LearningRate: 0.5,
+		Iterations:   5000,
+		Tolerance:    1e-6,
+	}
+}
+
+// Model est une régression logistique ajustée
+type Model struct {
+	Features     []string
+	Intercept    float64
+	Coefficients []float64
+	Iterations   int
+}
+
+// Fit ajuste une régression logistique régularisée par descente de gradient
+func Fit(dataset *Dataset, opts Options) (*Model, error) {
+	if len(dataset.Samples) == 0 {
+		return nil, fmt.Errorf("empty dataset")
+	}
+
+	positives := dataset.Positives()
+	if positives == 0 || positives == len(dataset.Samples) {
+		return nil, fmt.Errorf("dataset needs both positive and negative samples (%d/%d positive)",
+			positives, len(dataset.Samples))
+	}
+
+	n := float64(len(dataset.Samples))
+	dims := len(dataset.Features)
+	model := &Model{
+		Features:     dataset.Features,
+		Coefficients: make([]float64, dims),
+	}
+
+	// Initialiser l'intercept sur le taux de base pour accélérer la convergence
+	baseRate := float64(positives) / n
+	model.Intercept = math.Log(baseRate / (1 - baseRate))
+
+	gradient := make([]float64, dims)
+	for iter := 0; iter < opts.Iterations; iter++ {
+		for i := range gradient {
+			gradient[i] = 0
+		}
+		gradIntercept := 0.0
+
+		for _, sample := range dataset.Samples {
+			diff := model.Predict(sample.Features) - sample.Label
+			gradIntercept += diff
+			for i, x := range sample.Features {
+				gradient[i] += diff * x
+			}
+		}
+
+		norm := 0.0
+		gradIntercept /= n
+		model.Intercept -= opts.LearningRate * gradIntercept
+		norm += gradIntercept * gradIntercept
+		for i := range gradient {
+			g := gradient[i]/n + opts.L2*model.Coefficients[i]
+			model.Coefficients[i] -= opts.LearningRate * g
+			norm += g * g
+		}
+
+		model.Iterations = iter + 1
+		if math.Sqrt(norm) < opts.Tolerance {
+			break
+		}
+	}
+
+	return model, nil
+}
+
+// Predict retourne la probabilité estimée de l'outcome
+func (m *Model) Predict(features []float64) float64 {
+	z := m.Intercept
+	for i, x := range features {
+		z += m.Coefficients[i] * x
+	}
+	return sigmoid(z)
+}
+
+// sigmoid est la fonction logistique, stable numériquement
+func sigmoid(z float64) float64 {
+	if z >= 0 {
+		return 1 / (1 + math.Exp(-z))
+	}
+	ez := math.Exp(z)
+	return ez / (1 + ez)
+}
+
+// CandidateWeights convertit les coefficients en poids X-Score sommant à 1.
+// Pour un outcome de baisse, ce sont les facteurs qui réduisent le risque
+// (coefficients négatifs) qui reçoivent du poids.
+func (m *Model) CandidateWeights(drawdown bool) (token.Weights, error) {
+	weights := make(token.Weights, len(m.Features))
+	total := 0.0
+	for i, name := range m.Features {
+		coef := m.Coefficients[i]
+		if drawdown {
+			coef = -coef
+		}
+		weight := math.Max(0, coef)
+		weights[name] = weight
+		total += weight
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("no factor carries a useful signal for this outcome")
+	}
+
+	for name := range weights {
+		weights[name] /= total
+	}
+
+	return weights, nil
+}
@@ -0,0 +1,155 @@
+package calibration
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/franky69420/crypto-oracle/internal/token"
+)
+
+// Metrics regroupe les métriques d'évaluation sur un jeu de données
+type Metrics struct {
+	Samples   int     `json:"samples"`
+	Positives int     `json:"positives"`
+	LogLoss   float64 `json:"log_loss"`
+	Brier     float64 `json:"brier"`
+	Accuracy  float64 `json:"accuracy"`
+	AUC       float64 `json:"auc"`
+}
+
+// Report est le rapport d'évaluation produit par la calibration
+type Report struct {
+	Target           string             `json:"target"`
+	TrainSamples     int                `json:"train_samples"`
+	SkippedSamples   int                `json:"skipped_samples"`
+	Iterations       int                `json:"iterations"`
+	Model            Metrics            `json:"model"`
+	Baseline         Metrics            `json:"baseline"`      // Prédiction constante au taux de base
+	CandidateAUC     float64            `json:"candidate_auc"` // Pouvoir de classement du X-Score avec les nouveaux poids
+	CurrentAUC       float64            `json:"current_auc"`   // Pouvoir de classement du X-Score avec les poids actuels
+	Coefficients     map[string]float64 `json:"coefficients"`
+	CandidateWeights token.Weights      `json:"candidate_weights"`
+}
+
+// Evaluate calcule les métriques d'un modèle sur un jeu de données
+func Evaluate(model *Model, dataset *Dataset) Metrics {
+	return evaluatePredictions(dataset, func(sample Sample) float64 {
+		return model.Predict(sample.Features)
+	})
+}
+
+// EvaluateBaseline évalue une prédiction constante égale au taux de base
+func EvaluateBaseline(rate float64, dataset *Dataset) Metrics {
+	return evaluatePredictions(dataset, func(Sample) float64 {
+		return rate
+	})
+}
+
+// ScoreAUC mesure le pouvoir de classement d'un jeu de poids X-Score sur l'outcome
+func ScoreAUC(weights token.Weights, dataset *Dataset) float64 {
+	scores := make([]float64, len(dataset.Samples))
+	labels := make([]float64, len(dataset.Samples))
+	for i, sample := range dataset.Samples {
+		for j, name := range dataset.Features {
+			scores[i] += weights[name] * sample.Features[j]
+		}
+		labels[i] = sample.Label
+		if dataset.Outcome.IsDrawdown() {
+			// Un X-Score élevé doit signaler un faible risque de baisse
+			labels[i] = 1 - sample.Label
+		}
+	}
+	return auc(scores, labels)
+}
+
+// evaluatePredictions applique une fonction de prédiction et agrège les métriques
+func evaluatePredictions(dataset *Dataset, predict func(Sample) float64) Metrics {
+	metrics := Metrics{Samples: len(dataset.Samples)}
+	if metrics.Samples == 0 {
+		return metrics
+	}
+
+	const eps = 1e-12
+	scores := make([]float64, len(dataset.Samples))
+	labels := make([]float64, len(dataset.Samples))
+	correct := 0
+	for i, sample := range dataset.Samples {
+		p := predict(sample)
+		scores[i] = p
+		labels[i] = sample.Label
+		if sample.Label > 0 {
+			metrics.Positives++
+		}
+
+		pc := math.Min(1-eps, math.Max(eps, p))
+		metrics.LogLoss -= sample.Label*math.Log(pc) + (1-sample.Label)*math.Log(1-pc)
+		metrics.Brier += (p - sample.Label) * (p - sample.Label)
+		if (p >= 0.5) == (sample.Label > 0) {
+			correct++
+		}
+	}
+
+	n := float64(metrics.Samples)
+	metrics.LogLoss /= n
+	metrics.Brier /= n
+	metrics.Accuracy = float64(correct) / n
+	metrics.AUC = auc(scores, labels)
+	return metrics
+}
+
+// auc calcule l'aire sous la courbe ROC (statistique de Mann-Whitney, ex-aequo comptés pour moitié)
+func auc(scores, labels []float64) float64 {
+	type pair struct {
+		score float64
+		label float64
+	}
+	pairs := make([]pair, len(scores))
+	for i := range scores {
+		pairs[i] = pair{scores[i], labels[i]}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].score < pairs[j].score })
+
+	positives, negatives := 0.0, 0.0
+	rankSum := 0.0
+	for i := 0; i < len(pairs); {
+		j := i
+		for j < len(pairs) && pairs[j].score == pairs[i].score {
+			j++
+		}
+		// Rang moyen pour les ex-aequo (rangs 1-indexés)
+		avgRank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if pairs[k].label > 0 {
+				positives++
+				rankSum += avgRank
+			} else {
+				negatives++
+			}
+		}
+		i = j
+	}
+
+	if positives == 0 || negatives == 0 {
+		return 0.5
+	}
+	return (rankSum - positives*(positives+1)/2) / (positives * negatives)
+}
+
+// String formate le rapport pour la console
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Calibration target: %s\n", r.Target)
+	fmt.Fprintf(&b, "Train samples: %d (skipped: %d), iterations: %d\n", r.TrainSamples, r.SkippedSamples, r.Iterations)
+	fmt.Fprintf(&b, "Holdout: %d samples, %d positive\n", r.Model.Samples, r.Model.Positives)
+	fmt.Fprintf(&b, "%-10s %10s %10s %10s %10s\n", "", "log_loss", "brier", "accuracy", "auc")
+	fmt.Fprintf(&b, "%-10s %10.4f %10.4f %10.4f %10.4f\n", "model", r.Model.LogLoss, r.Model.Brier, r.Model.Accuracy, r.Model.AUC)
+	fmt.Fprintf(&b, "%-10s %10.4f %10.4f %10.4f %10.4f\n", "baseline", r.Baseline.LogLoss, r.Baseline.Brier, r.Baseline.Accuracy, r.Baseline.AUC)
+	fmt.Fprintf(&b, "X-Score AUC on holdout: current %.4f, candidate %.4f\n", r.CurrentAUC, r.CandidateAUC)
+	fmt.Fprintf(&b, "%-20s %12s %10s\n", "factor", "coefficient", "weight")
+	for _, name := range token.WeightedFactors {
+		fmt.Fprintf(&b, "%-20s %12.4f %10.4f\n", name, r.Coefficients[name], r.CandidateWeights[name])
+	}
+	return b.String()
+}
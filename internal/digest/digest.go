@@ -0,0 +1,648 @@
+// Package digest produit les rapports périodiques (horaires et quotidiens) des tokens
+// et des alertes, et les envoie sur les canaux de notification des alertes.
+package digest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrDigestNotFound est retournée pour un digest inconnu
+	ErrDigestNotFound = errors.New("digest not found")
+	// ErrUnknownPeriod est retournée pour une période autre que hourly ou daily
+	ErrUnknownPeriod = errors.New("unknown digest period")
+)
+
+// Periods sont les périodes de digest connues
+var Periods = []string{models.DigestPeriodHourly, models.DigestPeriodDaily}
+
+// Options règle le contenu des digests
+type Options struct {
+	States            []string // États dont les nouveaux tokens sont listés
+	TopMovers         int
+	TopWallets        int
+	MinWalletTrust    float64
+	MaxItems          int // Éléments par section
+	ReactivationTypes []string
+	RugTypes          []string
+}
+
+// DefaultOptions sont les options utilisées sans configuration
+var DefaultOptions = Options{
+	States: []string{
+		models.LifecycleStateDiscovered,
+		models.LifecycleStateValidated,
+		models.LifecycleStateHyped,
+	},
+	TopMovers:         10,
+	TopWallets:        10,
+	MinWalletTrust:    70,
+	MaxItems:          25,
+	ReactivationTypes: []string{"REACTIVATION"},
+	RugTypes:          []string{"DUMP_DETECTED"},
+}
+
+// maxWalletTokens borne le nombre de tokens actifs inspectés pour trouver les wallets de confiance
+const maxWalletTokens = 50
+
+// TokenSource fournit l'état des tokens. Le projecteur de tokens l'implémente.
+type TokenSource interface {
+	Tokens(states ...string) []token.TokenReadModel
+}
+
+// WalletSource fournit les wallets actifs d'un token. La mémoire de confiance l'implémente.
+type WalletSource interface {
+	GetTokenActiveWallets(tokenAddress string, minTrustScore float64, limit int) ([]models.ActiveWallet, error)
+}
+
+// Generator construit, conserve et envoie les digests
+type Generator struct {
+	logger   *logrus.Logger
+	tokens   TokenSource
+	alerts   *alerting.Manager
+	wallets  WalletSource
+	store    Store
+	renderer *Renderer
+
+	options  Options
+	periods  []string
+	channels []string
+	location *time.Location
+
+	mu     sync.Mutex // Sérialise les générations
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewGenerator crée un générateur de digests avec un store en mémoire, les modèles intégrés
+// et aucune période planifiée
+func NewGenerator(tokens TokenSource, alerts *alerting.Manager, logger *logrus.Logger) *Generator {
+	renderer, err := NewRenderer(DefaultMarkdownTemplate, DefaultHTMLTemplate)
+	if err != nil {
+		panic(err) // Les modèles intégrés sont valides
+	}
+	return &Generator{
+		logger:   logger,
+		tokens:   tokens,
+		alerts:   alerts,
+		store:    NewMemoryStore(),
+		renderer: renderer,
+		options:  DefaultOptions,
+		location: time.UTC,
+	}
+}
+
+// SetStore remplace le store des digests, par exemple par la base de données
+func (g *Generator) SetStore(store Store) {
+	g.store = store
+}
+
+// SetWalletSource active la section des wallets de confiance actifs
+func (g *Generator) SetWalletSource(wallets WalletSource) {
+	g.wallets = wallets
+}
+
+// ApplyConfig applique la configuration des digests: périodes, canaux, contenu et modèles.
+// Doit être appelée avant Start.
+func (g *Generator) ApplyConfig(cfg *config.DigestsConfig) error {
+	if cfg == nil {
+		return nil
+	}
+
+	for _, period := range cfg.Periods {
+		period = strings.ToLower(period)
+		if !isPeriod(period) {
+			return fmt.Errorf("%w: %q", ErrUnknownPeriod, period)
+		}
+		g.periods = append(g.periods, period)
+	}
+	if cfg.Timezone != "" {
+		location, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return fmt.Errorf("unknown digest timezone %q", cfg.Timezone)
+		}
+		g.location = location
+	}
+	g.channels = cfg.Channels
+
+	if len(cfg.States) > 0 {
+		g.options.States = cfg.States
+	}
+	if cfg.TopMovers > 0 {
+		g.options.TopMovers = cfg.TopMovers
+	}
+	if cfg.TopWallets > 0 {
+		g.options.TopWallets = cfg.TopWallets
+	}
+	if cfg.MinWalletTrust > 0 {
+		g.options.MinWalletTrust = cfg.MinWalletTrust
+	}
+	if cfg.MaxItems > 0 {
+		g.options.MaxItems = cfg.MaxItems
+	}
+	if len(cfg.ReactivationTypes) > 0 {
+		g.options.ReactivationTypes = cfg.ReactivationTypes
+	}
+	if len(cfg.RugTypes) > 0 {
+		g.options.RugTypes = cfg.RugTypes
+	}
+
+	renderer, err := LoadRenderer(cfg.MarkdownTemplate, cfg.HTMLTemplate)
+	if err != nil {
+		return err
+	}
+	g.renderer = renderer
+	return nil
+}
+
+// isPeriod indique si period est une période de digest connue
+func isPeriod(period string) bool {
+	for _, p := range Periods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+// Window retourne la dernière période complète terminée au plus tard à now: [from, to)
+func (g *Generator) Window(period string, now time.Time) (time.Time, time.Time, error) {
+	local := now.In(g.location)
+	switch period {
+	case models.DigestPeriodHourly:
+		to := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, g.location)
+		return to.Add(-time.Hour), to, nil
+	case models.DigestPeriodDaily:
+		to := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, g.location)
+		return to.AddDate(0, 0, -1), to, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: %q", ErrUnknownPeriod, period)
+}
+
+// DigestID retourne l'identifiant du digest d'une période commençant à from
+func DigestID(period string, from time.Time) string {
+	return period + "-" + from.UTC().Format("20060102T1504Z")
+}
+
+// Start planifie les digests des périodes configurées, envoyés à la fin de chaque période
+func (g *Generator) Start(ctx context.Context) error {
+	if len(g.periods) == 0 {
+		return nil
+	}
+
+	ctx, g.cancel = context.WithCancel(ctx)
+	for _, period := range g.periods {
+		g.wg.Add(1)
+		go g.run(ctx, period)
+	}
+	g.logger.WithFields(logrus.Fields{
+		"periods":  g.periods,
+		"timezone": g.location.String(),
+		"channels": g.channels,
+	}).Info("Digests scheduled")
+	return nil
+}
+
+// Shutdown arrête la planification et attend la génération en cours, au plus jusqu'à l'échéance de ctx
+func (g *Generator) Shutdown(ctx context.Context) error {
+	if g.cancel != nil {
+		g.cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("digest shutdown: %w", ctx.Err())
+	}
+}
+
+// run génère le digest d'une période à chaque fin de période
+func (g *Generator) run(ctx context.Context, period string) {
+	defer g.wg.Done()
+
+	for {
+		// La prochaine fin de période est la fin de la période en cours
+		_, next, err := g.Window(period, g.nextBoundary(period, time.Now()))
+		if err != nil {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if _, err := g.Generate(period, next, true); err != nil {
+			g.logger.WithError(err).WithField("period", period).Error("Failed to generate digest")
+		}
+	}
+}
+
+// nextBoundary retourne un instant de la période suivante, dont la fin de la période
+// précédente est la prochaine échéance
+func (g *Generator) nextBoundary(period string, now time.Time) time.Time {
+	if period == models.DigestPeriodDaily {
+		return now.In(g.location).AddDate(0, 0, 1)
+	}
+	return now.Add(time.Hour)
+}
+
+// Generate construit, enregistre et, si deliver est vrai, envoie le digest de la dernière
+// période complète terminée au plus tard à now. Un digest existant de même période est remplacé.
+func (g *Generator) Generate(period string, now time.Time, deliver bool) (*models.Digest, error) {
+	from, to, err := g.Window(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	digest, err := g.Build(period, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.store.SaveDigest(digest); err != nil {
+		return nil, fmt.Errorf("failed to save digest: %w", err)
+	}
+
+	queued := 0
+	if deliver {
+		queued = g.alerts.DispatchReport(alerting.Report{
+			ID:       digest.ID,
+			Title:    digest.Title,
+			Markdown: digest.Markdown,
+			HTML:     digest.HTML,
+			Data:     digest.Content,
+		}, g.channels)
+	}
+
+	g.logger.WithFields(logrus.Fields{
+		"digest":     digest.ID,
+		"new_tokens": countTokens(digest.Content.NewTokens),
+		"movers":     len(digest.Content.Movers),
+		"rugs":       len(digest.Content.Rugs),
+		"deliveries": queued,
+	}).Info("Digest generated")
+	return digest, nil
+}
+
+// Build construit et rend le digest de la période [from, to) sans l'enregistrer
+func (g *Generator) Build(period string, from, to time.Time) (*models.Digest, error) {
+	tokens := g.tokens.Tokens()
+
+	content := models.DigestContent{
+		NewTokens:      g.newTokens(tokens, from, to),
+		Movers:         g.movers(tokens, from, to),
+		Reactivations:  g.reactivatedTokens(tokens, from, to),
+		TrustedWallets: g.trustedWallets(tokens, from, to),
+	}
+
+	outcomes, err := g.alerts.DecidedOutcomes(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alert outcomes: %w", err)
+	}
+	content.OutcomeStats = alerting.OutcomeStats(outcomes)
+	if len(outcomes) > g.options.MaxItems {
+		outcomes = outcomes[len(outcomes)-g.options.MaxItems:]
+	}
+	content.Outcomes = outcomes
+
+	reactivations, err := g.queryAlerts(g.options.ReactivationTypes, from, to)
+	if err != nil {
+		return nil, err
+	}
+	content.Reactivations = mergeReactivations(content.Reactivations, reactivations, tokens, to, g.options.MaxItems)
+
+	if content.Rugs, err = g.queryAlerts(g.options.RugTypes, from, to); err != nil {
+		return nil, err
+	}
+
+	digest := &models.Digest{
+		ID:          DigestID(period, from),
+		Period:      period,
+		Title:       title(period, from),
+		From:        from,
+		To:          to,
+		Content:     content,
+		GeneratedAt: time.Now().UTC(),
+	}
+	if err := g.renderer.Render(digest); err != nil {
+		return nil, err
+	}
+	return digest, nil
+}
+
+// title retourne le titre d'un digest
+func title(period string, from time.Time) string {
+	if period == models.DigestPeriodDaily {
+		return "Daily digest " + from.Format("2006-01-02")
+	}
+	return "Hourly digest " + from.Format("2006-01-02 15:04 MST")
+}
+
+// Digest retourne un digest enregistré
+func (g *Generator) Digest(id string) (*models.Digest, error) {
+	digest, err := g.store.GetDigest(id)
+	if err != nil {
+		return nil, err
+	}
+	if digest == nil {
+		return nil, fmt.Errorf("%w: %s", ErrDigestNotFound, id)
+	}
+	return digest, nil
+}
+
+// Digests retourne les derniers digests, limités à une période si period n'est pas vide
+func (g *Generator) Digests(period string, limit int) ([]models.Digest, error) {
+	return g.store.ListDigests(period, limit)
+}
+
+// Deliveries retourne les envois d'un digest sur les canaux
+func (g *Generator) Deliveries(id string) []alerting.DeliveryRecord {
+	return g.alerts.ReportDeliveries(id)
+}
+
+// inWindow indique si at appartient à [from, to)
+func inWindow(at, from, to time.Time) bool {
+	return !at.Before(from) && at.Before(to)
+}
+
+// scoreAt retourne le dernier X-Score calculé avant to, 0 si aucun
+func scoreAt(model token.TokenReadModel, to time.Time) float64 {
+	score := 0.0
+	for _, point := range model.XScoreHistory {
+		if !point.At.Before(to) {
+			break
+		}
+		score = point.XScore
+	}
+	return score
+}
+
+// newTokens liste, par état, les tokens entrés dans cet état pendant la période
+func (g *Generator) newTokens(tokens []token.TokenReadModel, from, to time.Time) []models.DigestTokenGroup {
+	groups := make([]models.DigestTokenGroup, 0, len(g.options.States))
+	for _, state := range g.options.States {
+		group := models.DigestTokenGroup{State: state, Tokens: make([]models.DigestToken, 0)}
+		for _, model := range tokens {
+			if transition, ok := lastTransition(model, state, from, to); ok {
+				group.Tokens = append(group.Tokens, models.DigestToken{
+					Address: model.Address,
+					Symbol:  model.Symbol,
+					State:   state,
+					XScore:  scoreAt(model, to),
+					Reason:  transition.Reason,
+					At:      transition.At,
+				})
+			}
+		}
+		if len(group.Tokens) == 0 {
+			continue
+		}
+		sort.Slice(group.Tokens, func(i, j int) bool { return group.Tokens[i].XScore > group.Tokens[j].XScore })
+		if len(group.Tokens) > g.options.MaxItems {
+			group.Tokens = group.Tokens[:g.options.MaxItems]
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// lastTransition retourne le dernier passage du token dans l'état pendant la période
+func lastTransition(model token.TokenReadModel, state string, from, to time.Time) (token.StateTransition, bool) {
+	for i := len(model.StateHistory) - 1; i >= 0; i-- {
+		transition := model.StateHistory[i]
+		if transition.To == state && inWindow(transition.At, from, to) {
+			return transition, true
+		}
+	}
+	return token.StateTransition{}, false
+}
+
+// movers retourne les plus fortes variations du X-Score pendant la période. La variation part
+// du dernier X-Score connu avant la période, ou du premier de la période.
+func (g *Generator) movers(tokens []token.TokenReadModel, from, to time.Time) []models.DigestMover {
+	movers := make([]models.DigestMover, 0)
+	for _, model := range tokens {
+		var start, end *token.XScorePoint
+		for i := range model.XScoreHistory {
+			point := &model.XScoreHistory[i]
+			if !point.At.Before(to) {
+				break
+			}
+			if point.At.Before(from) || start == nil {
+				start = point
+			}
+			if inWindow(point.At, from, to) {
+				end = point
+			}
+		}
+		if end == nil || start == end || end.XScore == start.XScore {
+			continue
+		}
+		movers = append(movers, models.DigestMover{
+			Address: model.Address,
+			Symbol:  model.Symbol,
+			Start:   start.XScore,
+			End:     end.XScore,
+			Change:  end.XScore - start.XScore,
+		})
+	}
+
+	sort.Slice(movers, func(i, j int) bool {
+		a, b := math.Abs(movers[i].Change), math.Abs(movers[j].Change)
+		if a != b {
+			return a > b
+		}
+		return movers[i].Address < movers[j].Address
+	})
+	if len(movers) > g.options.TopMovers {
+		movers = movers[:g.options.TopMovers]
+	}
+	return movers
+}
+
+// reactivatedTokens liste les tokens passés à l'état REACTIVATED pendant la période
+func (g *Generator) reactivatedTokens(tokens []token.TokenReadModel, from, to time.Time) []models.DigestToken {
+	reactivated := make([]models.DigestToken, 0)
+	for _, model := range tokens {
+		if transition, ok := lastTransition(model, models.LifecycleStateReactivated, from, to); ok {
+			reactivated = append(reactivated, models.DigestToken{
+				Address: model.Address,
+				Symbol:  model.Symbol,
+				State:   models.LifecycleStateReactivated,
+				XScore:  scoreAt(model, to),
+				Reason:  transition.Reason,
+				At:      transition.At,
+			})
+		}
+	}
+	return reactivated
+}
+
+// mergeReactivations ajoute aux tokens réactivés ceux des alertes de réactivation, un seul par token,
+// du plus récent au plus ancien
+func mergeReactivations(reactivated []models.DigestToken, alerts []models.DigestAlert, tokens []token.TokenReadModel, to time.Time, max int) []models.DigestToken {
+	byAddress := make(map[string]token.TokenReadModel, len(tokens))
+	for _, model := range tokens {
+		byAddress[model.Address] = model
+	}
+	seen := make(map[string]bool, len(reactivated))
+	for _, t := range reactivated {
+		seen[t.Address] = true
+	}
+
+	for _, alert := range alerts {
+		if seen[alert.Address] {
+			continue
+		}
+		seen[alert.Address] = true
+		model := byAddress[alert.Address]
+		reactivated = append(reactivated, models.DigestToken{
+			Address: alert.Address,
+			Symbol:  alert.Symbol,
+			State:   model.State,
+			XScore:  scoreAt(model, to),
+			Reason:  alert.Message,
+			At:      alert.DetectedAt,
+		})
+	}
+
+	sort.Slice(reactivated, func(i, j int) bool { return reactivated[i].At.After(reactivated[j].At) })
+	if len(reactivated) > max {
+		reactivated = reactivated[:max]
+	}
+	return reactivated
+}
+
+// queryAlerts retourne les alertes des types donnés détectées pendant la période, des plus récentes
+// aux plus anciennes
+func (g *Generator) queryAlerts(alertTypes []string, from, to time.Time) ([]models.DigestAlert, error) {
+	alerts := make([]models.DigestAlert, 0)
+	for _, alertType := range alertTypes {
+		page, err := g.alerts.QueryAlerts(models.AlertFilter{
+			AlertType: alertType,
+			From:      from,
+			To:        to,
+			Limit:     g.options.MaxItems,
+		}, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s alerts: %w", alertType, err)
+		}
+		for _, alert := range page.Alerts {
+			alerts = append(alerts, models.DigestAlert{
+				ID:         alert.ID,
+				Address:    alert.TokenAddress,
+				Symbol:     alert.TokenSymbol,
+				AlertType:  alert.AlertType,
+				Severity:   alert.Severity,
+				Message:    alert.Message,
+				DetectedAt: alert.DetectedAt,
+			})
+		}
+	}
+
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].DetectedAt.After(alerts[j].DetectedAt) })
+	if len(alerts) > g.options.MaxItems {
+		alerts = alerts[:g.options.MaxItems]
+	}
+	return alerts, nil
+}
+
+// trustedWallets retourne les wallets de confiance actifs pendant la période sur les tokens
+// ayant reçu des événements, par score de confiance décroissant
+func (g *Generator) trustedWallets(tokens []token.TokenReadModel, from, to time.Time) []models.DigestWallet {
+	wallets := make([]models.DigestWallet, 0)
+	if g.wallets == nil {
+		return wallets
+	}
+
+	active := make([]token.TokenReadModel, 0)
+	for _, model := range tokens {
+		if !model.LastEventAt.Before(from) {
+			active = append(active, model)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].LastEventAt.After(active[j].LastEventAt) })
+	if len(active) > maxWalletTokens {
+		active = active[:maxWalletTokens]
+	}
+
+	byAddress := make(map[string]*models.DigestWallet)
+	for _, model := range active {
+		activeWallets, err := g.wallets.GetTokenActiveWallets(model.Address, g.options.MinWalletTrust, g.options.MaxItems)
+		if err != nil {
+			g.logger.WithError(err).WithField("token_address", model.Address).Warn("Failed to load active wallets for digest")
+			continue
+		}
+		for _, w := range activeWallets {
+			if !inWindow(w.LastActivity, from, to) || w.TrustScore < g.options.MinWalletTrust {
+				continue
+			}
+			wallet, ok := byAddress[w.Address]
+			if !ok {
+				wallet = &models.DigestWallet{Address: w.Address, Tokens: make([]string, 0)}
+				byAddress[w.Address] = wallet
+			}
+			wallet.TrustScore = math.Max(wallet.TrustScore, w.TrustScore)
+			wallet.Tokens = append(wallet.Tokens, tokenLabel(model))
+			if w.LastActivity.After(wallet.LastActivity) {
+				wallet.LastActivity = w.LastActivity
+			}
+		}
+	}
+
+	for _, wallet := range byAddress {
+		wallets = append(wallets, *wallet)
+	}
+	sort.Slice(wallets, func(i, j int) bool {
+		if wallets[i].TrustScore != wallets[j].TrustScore {
+			return wallets[i].TrustScore > wallets[j].TrustScore
+		}
+		return wallets[i].Address < wallets[j].Address
+	})
+	if len(wallets) > g.options.TopWallets {
+		wallets = wallets[:g.options.TopWallets]
+	}
+	return wallets
+}
+
+// tokenLabel retourne le symbole d'un token, ou son adresse abrégée
+func tokenLabel(model token.TokenReadModel) string {
+	if model.Symbol != "" {
+		return model.Symbol
+	}
+	return shortAddress(model.Address)
+}
+
+// countTokens compte les tokens de tous les groupes
+func countTokens(groups []models.DigestTokenGroup) int {
+	count := 0
+	for _, group := range groups {
+		count += len(group.Tokens)
+	}
+	return count
+}
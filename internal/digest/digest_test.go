@@ -0,0 +1,144 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/internal/token"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+type stubWallets map[string][]models.ActiveWallet
+
+func (s stubWallets) GetTokenActiveWallets(tokenAddress string, minTrustScore float64, limit int) ([]models.ActiveWallet, error) {
+	return s[tokenAddress], nil
+}
+
+func TestWindow(t *testing.T) {
+	g := NewGenerator(token.NewProjector(testLogger()), alerting.NewManager(testLogger()), testLogger())
+	now := time.Date(2024, 3, 10, 14, 25, 0, 0, time.UTC)
+
+	from, to, err := g.Window(models.DigestPeriodHourly, now)
+	if err != nil || !from.Equal(now.Add(-85*time.Minute)) || !to.Equal(now.Add(-25*time.Minute)) {
+		t.Fatalf("hourly window = %v - %v (%v)", from, to, err)
+	}
+	from, to, _ = g.Window(models.DigestPeriodDaily, now)
+	if from.Day() != 9 || to.Day() != 10 || to.Hour() != 0 {
+		t.Fatalf("daily window = %v - %v", from, to)
+	}
+	if DigestID(models.DigestPeriodDaily, from) != "daily-20240309T0000Z" {
+		t.Fatalf("unexpected id %s", DigestID(models.DigestPeriodDaily, from))
+	}
+	if _, _, err := g.Window("weekly", now); err == nil {
+		t.Fatal("weekly period accepted")
+	}
+}
+
+func TestGenerateDigest(t *testing.T) {
+	from := time.Date(2024, 3, 10, 14, 0, 0, 0, time.UTC)
+	before, during := from.Add(-30*time.Minute), from.Add(20*time.Minute)
+
+	projector := token.NewProjector(testLogger())
+	apply := func(at time.Time, event pipeline.Event) {
+		projector.Apply(pipeline.Message{Timestamp: at, Event: event})
+	}
+	apply(before, &pipeline.TokenDetected{TokenAddress: "old", TokenSymbol: "OLD"})
+	apply(before, &pipeline.XScoreCalculated{TokenAddress: "old", XScore: 40, CalculatedAt: before})
+	apply(during, &pipeline.XScoreCalculated{TokenAddress: "old", XScore: 75, CalculatedAt: during})
+	apply(during, &pipeline.StateChange{TokenAddress: "old", NewState: models.LifecycleStateHyped, Reason: "volume"})
+	apply(during, &pipeline.TokenDetected{TokenAddress: "new", TokenSymbol: "NEW"})
+	apply(during, &pipeline.XScoreCalculated{TokenAddress: "new", XScore: 55, CalculatedAt: during})
+
+	manager := alerting.NewManager(testLogger())
+	var mu sync.Mutex
+	var reports []alerting.Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var report alerting.Report
+		json.NewDecoder(r.Body).Decode(&report)
+		mu.Lock()
+		reports = append(reports, report)
+		mu.Unlock()
+	}))
+	defer server.Close()
+	// Le canal ne reçoit aucune alerte, seulement les rapports
+	manager.AddChannel(alerting.Route{
+		Notifier:   alerting.NewWebhookNotifier("hook", server.URL, nil, server.Client()),
+		AlertTypes: []string{"NONE"},
+	})
+
+	for _, signal := range []alerting.AlertSignal{
+		{TokenAddress: "old", TokenSymbol: "OLD", AlertType: "DUMP_DETECTED", Severity: "CRITICAL", Message: "dump", At: during},
+		{TokenAddress: "new", TokenSymbol: "NEW", AlertType: "REACTIVATION", Severity: "ALERT", Message: "back", At: during},
+		{TokenAddress: "new", TokenSymbol: "NEW", AlertType: "DUMP_DETECTED", Severity: "HIGH", Message: "late", At: from.Add(2 * time.Hour)},
+	} {
+		signal.Driver = math.NaN()
+		if _, err := manager.Raise(signal); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := NewGenerator(projector, manager, testLogger())
+	g.SetWalletSource(stubWallets{
+		"old": {
+			{Address: "trusted", TrustScore: 90, LastActivity: during},
+			{Address: "idle", TrustScore: 95, LastActivity: before},
+		},
+	})
+
+	digest, err := g.Generate(models.DigestPeriodHourly, from.Add(time.Hour+time.Minute), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := digest.Content
+
+	if len(content.NewTokens) != 2 || content.NewTokens[0].State != models.LifecycleStateDiscovered ||
+		content.NewTokens[0].Tokens[0].Address != "new" || content.NewTokens[1].Tokens[0].Address != "old" {
+		t.Errorf("new tokens = %+v", content.NewTokens)
+	}
+	if len(content.Movers) != 1 || content.Movers[0].Change != 35 {
+		t.Errorf("movers = %+v", content.Movers)
+	}
+	if len(content.Rugs) != 1 || content.Rugs[0].Message != "dump" {
+		t.Errorf("rugs = %+v", content.Rugs)
+	}
+	if len(content.Reactivations) != 1 || content.Reactivations[0].XScore != 55 {
+		t.Errorf("reactivations = %+v", content.Reactivations)
+	}
+	if len(content.TrustedWallets) != 1 || content.TrustedWallets[0].Address != "trusted" {
+		t.Errorf("trusted wallets = %+v", content.TrustedWallets)
+	}
+	if !strings.Contains(digest.Markdown, "OLD: 40.0 → 75.0 (+35.0)") || !strings.Contains(digest.HTML, "<td>OLD</td>") {
+		t.Errorf("unexpected rendering:\n%s", digest.Markdown)
+	}
+
+	stored, err := g.Digest("hourly-20240310T1400Z")
+	if err != nil || stored.Markdown != digest.Markdown {
+		t.Fatalf("stored digest = %v (%v)", stored, err)
+	}
+
+	manager.Start(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	manager.Shutdown(ctx)
+
+	if len(reports) != 1 || reports[0].ID != digest.ID || reports[0].Markdown == "" {
+		t.Fatalf("reports = %+v", reports)
+	}
+}
@@ -0,0 +1,170 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// DefaultMarkdownTemplate est le modèle Markdown des digests
+const DefaultMarkdownTemplate = `# {{.Title}}
+{{date .From}} → {{date .To}}
+
+## New tokens
+{{range .Content.NewTokens}}**{{.State}}** ({{len .Tokens}})
+{{range .Tokens}}- {{label .Symbol .Address}}{{if .XScore}} · X-Score {{score .XScore}}{{end}}
+{{end}}{{else}}No new token.
+{{end}}
+## X-Score movers
+{{range .Content.Movers}}- {{label .Symbol .Address}}: {{score .Start}} → {{score .End}} ({{signed .Change}})
+{{else}}No X-Score change.
+{{end}}
+## Alert outcomes
+{{range .Content.OutcomeStats}}- {{.AlertType}}: {{.Confirmed}} confirmed, {{.Failed}} failed, {{.Expired}} expired · hit rate {{percent .HitRate}}
+{{else}}No alert decided.
+{{end}}
+## Reactivations
+{{range .Content.Reactivations}}- {{label .Symbol .Address}}{{if .XScore}} · X-Score {{score .XScore}}{{end}}{{if .Reason}} · {{.Reason}}{{end}}
+{{else}}No reactivation.
+{{end}}
+## Trusted wallets active
+{{range .Content.TrustedWallets}}- {{short .Address}} · trust {{score .TrustScore}} · {{join .Tokens}}
+{{else}}No trusted wallet active.
+{{end}}
+## Rug detections
+{{range .Content.Rugs}}- [{{.Severity}}] {{label .Symbol .Address}}: {{.Message}}
+{{else}}No rug detected.
+{{end}}`
+
+// DefaultHTMLTemplate est le modèle HTML des digests
+const DefaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{date .From}} → {{date .To}}</p>
+
+<h2>New tokens</h2>
+{{range .Content.NewTokens}}<h3>{{.State}} ({{len .Tokens}})</h3>
+<ul>
+{{range .Tokens}}<li>{{label .Symbol .Address}}{{if .XScore}} · X-Score {{score .XScore}}{{end}}</li>
+{{end}}</ul>
+{{else}}<p>No new token.</p>
+{{end}}
+<h2>X-Score movers</h2>
+{{if .Content.Movers}}<table>
+<tr><th>Token</th><th>Start</th><th>End</th><th>Change</th></tr>
+{{range .Content.Movers}}<tr><td>{{label .Symbol .Address}}</td><td>{{score .Start}}</td><td>{{score .End}}</td><td>{{signed .Change}}</td></tr>
+{{end}}</table>
+{{else}}<p>No X-Score change.</p>
+{{end}}
+<h2>Alert outcomes</h2>
+{{if .Content.OutcomeStats}}<table>
+<tr><th>Type</th><th>Confirmed</th><th>Failed</th><th>Expired</th><th>Hit rate</th></tr>
+{{range .Content.OutcomeStats}}<tr><td>{{.AlertType}}</td><td>{{.Confirmed}}</td><td>{{.Failed}}</td><td>{{.Expired}}</td><td>{{percent .HitRate}}</td></tr>
+{{end}}</table>
+{{else}}<p>No alert decided.</p>
+{{end}}
+<h2>Reactivations</h2>
+{{if .Content.Reactivations}}<ul>
+{{range .Content.Reactivations}}<li>{{label .Symbol .Address}}{{if .XScore}} · X-Score {{score .XScore}}{{end}}{{if .Reason}} · {{.Reason}}{{end}}</li>
+{{end}}</ul>
+{{else}}<p>No reactivation.</p>
+{{end}}
+<h2>Trusted wallets active</h2>
+{{if .Content.TrustedWallets}}<ul>
+{{range .Content.TrustedWallets}}<li><code>{{short .Address}}</code> · trust {{score .TrustScore}} · {{join .Tokens}}</li>
+{{end}}</ul>
+{{else}}<p>No trusted wallet active.</p>
+{{end}}
+<h2>Rug detections</h2>
+{{if .Content.Rugs}}<ul>
+{{range .Content.Rugs}}<li>[{{.Severity}}] {{label .Symbol .Address}}: {{.Message}}</li>
+{{end}}</ul>
+{{else}}<p>No rug detected.</p>
+{{end}}
+</body>
+</html>
+`
+
+// templateFuncs sont les fonctions disponibles dans les modèles
+var templateFuncs = map[string]interface{}{
+	"score":   func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"signed":  func(v float64) string { return fmt.Sprintf("%+.1f", v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+	"date":    func(t time.Time) string { return t.Format("2006-01-02 15:04 MST") },
+	"short":   shortAddress,
+	"label": func(symbol, address string) string {
+		if symbol != "" {
+			return symbol
+		}
+		return shortAddress(address)
+	},
+	"join": func(values []string) string { return strings.Join(values, ", ") },
+}
+
+// shortAddress abrège une adresse en gardant son début et sa fin
+func shortAddress(address string) string {
+	if len(address) <= 12 {
+		return address
+	}
+	return address[:4] + "…" + address[len(address)-4:]
+}
+
+// Renderer rend les digests en Markdown et en HTML
+type Renderer struct {
+	markdown *texttemplate.Template
+	html     *htmltemplate.Template
+}
+
+// NewRenderer compile les modèles Markdown (text/template) et HTML (html/template)
+func NewRenderer(markdown, html string) (*Renderer, error) {
+	md, err := texttemplate.New("markdown").Funcs(templateFuncs).Parse(markdown)
+	if err != nil {
+		return nil, fmt.Errorf("invalid markdown template: %w", err)
+	}
+	page, err := htmltemplate.New("html").Funcs(templateFuncs).Parse(html)
+	if err != nil {
+		return nil, fmt.Errorf("invalid html template: %w", err)
+	}
+	return &Renderer{markdown: md, html: page}, nil
+}
+
+// LoadRenderer lit les modèles depuis des fichiers; un chemin vide garde le modèle intégré
+func LoadRenderer(markdownFile, htmlFile string) (*Renderer, error) {
+	markdown, html := DefaultMarkdownTemplate, DefaultHTMLTemplate
+	if markdownFile != "" {
+		data, err := os.ReadFile(markdownFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read markdown template: %w", err)
+		}
+		markdown = string(data)
+	}
+	if htmlFile != "" {
+		data, err := os.ReadFile(htmlFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read html template: %w", err)
+		}
+		html = string(data)
+	}
+	return NewRenderer(markdown, html)
+}
+
+// Render remplit les champs Markdown et HTML du digest
+func (r *Renderer) Render(digest *models.Digest) error {
+	var md, page bytes.Buffer
+	if err := r.markdown.Execute(&md, digest); err != nil {
+		return fmt.Errorf("failed to render markdown: %w", err)
+	}
+	if err := r.html.Execute(&page, digest); err != nil {
+		return fmt.Errorf("failed to render html: %w", err)
+	}
+	digest.Markdown, digest.HTML = md.String(), page.String()
+	return nil
+}
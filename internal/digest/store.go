@@ -0,0 +1,72 @@
+package digest
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// Store persiste les digests. La base de données (table digests) l'implémente.
+type Store interface {
+	// SaveDigest enregistre un digest, en remplaçant celui de même ID
+	SaveDigest(digest *models.Digest) error
+	// GetDigest retourne nil si le digest n'existe pas
+	GetDigest(id string) (*models.Digest, error)
+	// ListDigests retourne au plus limit digests, du plus récent au plus ancien,
+	// limités à une période si period n'est pas vide
+	ListDigests(period string, limit int) ([]models.Digest, error)
+}
+
+// MemoryStore conserve les digests en mémoire, pour les tests et le mode démo
+type MemoryStore struct {
+	mu      sync.RWMutex
+	digests map[string]models.Digest
+}
+
+// NewMemoryStore crée un store de digests en mémoire
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{digests: make(map[string]models.Digest)}
+}
+
+// SaveDigest enregistre un digest
+func (s *MemoryStore) SaveDigest(digest *models.Digest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.digests[digest.ID] = *digest
+	return nil
+}
+
+// GetDigest récupère un digest par son ID
+func (s *MemoryStore) GetDigest(id string) (*models.Digest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	digest, ok := s.digests[id]
+	if !ok {
+		return nil, nil
+	}
+	return &digest, nil
+}
+
+// ListDigests récupère les derniers digests
+func (s *MemoryStore) ListDigests(period string, limit int) ([]models.Digest, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	digests := make([]models.Digest, 0)
+	for _, digest := range s.digests {
+		if period == "" || digest.Period == period {
+			digests = append(digests, digest)
+		}
+	}
+	sort.Slice(digests, func(i, j int) bool {
+		if !digests[i].From.Equal(digests[j].From) {
+			return digests[i].From.After(digests[j].From)
+		}
+		return digests[i].ID > digests[j].ID
+	})
+	if limit > 0 && len(digests) > limit {
+		digests = digests[:limit]
+	}
+	return digests, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/franky69420/crypto-oracle/internal/token"
 	"github.com/franky69420/crypto-oracle/pkg/models"
 )
 
@@ -37,6 +38,9 @@ func (a *Adapter) GetTokenInfo(tokenAddress string) (*models.Token, error) {
 	if err != nil {
 		return nil, err
 	}
+	if stats.Address == "" {
+		return nil, token.ErrTokenNotFound
+	}
 
 	return &models.Token{
 		Address:     tokenAddress,
@@ -140,6 +144,9 @@ func (a *Adapter) GetTokenStats(tokenAddress string) (*models.TokenStats, error)
 	if err != nil {
 		return nil, err
 	}
+	if stats.Address == "" {
+		return nil, token.ErrTokenNotFound
+	}
 
 	// Map GMGN stats to our internal model
 	return &models.TokenStats{
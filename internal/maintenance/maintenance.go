@@ -0,0 +1,399 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrJobNotFound est retournée pour un identifiant de job inconnu
+	ErrJobNotFound = errors.New("maintenance job not found")
+	// ErrInvalidJob est retournée pour un type de job inconnu ou une cible manquante
+	ErrInvalidJob = errors.New("invalid maintenance job")
+	// ErrJobConflict est retournée quand un job tient déjà le verrou demandé
+	ErrJobConflict = errors.New("maintenance job already running")
+	// ErrJobFinished est retournée pour l'annulation d'un job terminé
+	ErrJobFinished = errors.New("maintenance job already finished")
+	// ErrUnavailable est retournée tant que le gestionnaire n'est pas démarré avec ses opérations
+	ErrUnavailable = errors.New("maintenance jobs unavailable")
+)
+
+// historyLimit est le nombre de jobs gardés en mémoire et rechargés au démarrage
+const historyLimit = 200
+
+// Operations regroupe les opérations de maintenance du Memory of Trust
+type Operations interface {
+	RebuildTrustGraphContext(ctx context.Context, progress memory.ProgressFunc) error
+	UpdateWalletSimilaritiesContext(ctx context.Context, progress memory.ProgressFunc) error
+	CleanObsoleteCaches(ctx context.Context, progress memory.ProgressFunc) error
+	PurgeWallet(walletAddress string) error
+	ResetTokenTrustMetrics(tokenAddress string) error
+}
+
+var _ Operations = (*memory.TrustNetwork)(nil)
+
+// job est un job suivi par le gestionnaire, avec de quoi l'annuler
+type job struct {
+	models.MaintenanceJob
+	lock         string
+	cancel       context.CancelFunc
+	cancelReason string
+}
+
+// Manager exécute les opérations de maintenance en tâche de fond. Chaque job prend un verrou
+// exclusif sur ce qu'il modifie: la reconstruction du graphe et le calcul des similarités
+// partagent le verrou du graphe, la purge d'un wallet ne bloque que ce wallet. Les verrous
+// sont propres à l'instance. L'avancement n'est gardé qu'en mémoire; le store reçoit
+// chaque changement de statut.
+type Manager struct {
+	logger *logrus.Logger
+	store  Store
+	ops    Operations
+
+	mu    sync.Mutex
+	jobs  map[string]*job
+	locks map[string]string // Clé de verrou -> ID du job qui la tient
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewManager crée un gestionnaire de jobs de maintenance, avec un store en mémoire
+func NewManager(logger *logrus.Logger) *Manager {
+	return &Manager{
+		logger: logger,
+		store:  NewMemoryStore(),
+		jobs:   make(map[string]*job),
+		locks:  make(map[string]string),
+	}
+}
+
+// SetStore remplace le store des jobs, par exemple par la base de données
+func (m *Manager) SetStore(store Store) {
+	m.store = store
+}
+
+// SetOperations définit les opérations exécutées par les jobs
+func (m *Manager) SetOperations(ops Operations) {
+	m.ops = ops
+}
+
+// Start recharge l'historique des jobs. Les jobs restés en cours lors du dernier arrêt
+// sont marqués en échec: leur opération n'a pas survécu au processus.
+func (m *Manager) Start(ctx context.Context) error {
+	m.logger.Info("Starting maintenance job manager")
+
+	stored, err := m.store.ListMaintenanceJobs(historyLimit)
+	if err != nil {
+		return fmt.Errorf("failed to load maintenance jobs: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range stored {
+		j := &job{MaintenanceJob: s}
+		m.jobs[j.ID] = j
+		if !j.Finished() {
+			j.Error = "interrupted by restart"
+			m.finish(j, models.JobFailed, j.Error)
+		}
+	}
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	return nil
+}
+
+// Shutdown annule les jobs en cours et attend leur fin; un job qui ignore l'annulation
+// n'est plus attendu après l'échéance de ctx
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.logger.Info("Shutting down maintenance job manager")
+
+	m.mu.Lock()
+	if m.cancel == nil {
+		m.mu.Unlock()
+		return nil
+	}
+	for _, j := range m.jobs {
+		if !j.Finished() && j.cancelReason == "" {
+			j.cancelReason = "cancelled by shutdown"
+		}
+	}
+	m.cancel()
+	m.ctx = nil
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("maintenance job shutdown: %w", ctx.Err())
+	}
+}
+
+// Submit lance un job en tâche de fond et retourne son état initial. requestedBy identifie
+// le demandeur (ID de clé d'API), vide si inconnu.
+func (m *Manager) Submit(kind, target, requestedBy string) (*models.MaintenanceJob, error) {
+	lock, err := lockKey(kind, target)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx == nil || m.ops == nil {
+		return nil, ErrUnavailable
+	}
+	if holder, busy := m.locks[lock]; busy {
+		return nil, fmt.Errorf("%w: %s holds the %s lock", ErrJobConflict, holder, lock)
+	}
+
+	now := time.Now()
+	j := &job{
+		MaintenanceJob: models.MaintenanceJob{
+			ID:          fmt.Sprintf("job_%d", now.UnixNano()),
+			Kind:        kind,
+			Target:      target,
+			RequestedBy: requestedBy,
+			CreatedAt:   now,
+		},
+		lock: lock,
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	j.cancel = cancel
+	m.jobs[j.ID] = j
+	m.locks[lock] = j.ID
+	m.setStatus(j, models.JobQueued, "")
+	m.prune()
+
+	m.logger.WithFields(logrus.Fields{
+		"job_id": j.ID,
+		"kind":   kind,
+		"target": target,
+	}).Info("Maintenance job submitted")
+
+	m.wg.Add(1)
+	go m.run(ctx, j)
+
+	snapshot := j.snapshot()
+	return &snapshot, nil
+}
+
+// Cancel demande l'annulation d'un job. Le job passe à cancelling puis à cancelled quand
+// l'opération s'arrête; une opération qui ne peut être interrompue va à son terme.
+func (m *Manager) Cancel(id, requestedBy string) (*models.MaintenanceJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	if j.Finished() {
+		return nil, fmt.Errorf("%w: %s is %s", ErrJobFinished, id, j.Status)
+	}
+	if j.Status != models.JobCancelling {
+		j.cancelReason = "cancelled"
+		if requestedBy != "" {
+			j.cancelReason = "cancelled by " + requestedBy
+		}
+		m.setStatus(j, models.JobCancelling, j.cancelReason)
+		j.cancel()
+	}
+
+	snapshot := j.snapshot()
+	return &snapshot, nil
+}
+
+// Job retourne l'état d'un job
+func (m *Manager) Job(id string) (*models.MaintenanceJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	snapshot := j.snapshot()
+	return &snapshot, nil
+}
+
+// Jobs retourne les derniers jobs, les plus récents d'abord, filtrés par type et statut
+// quand ils sont renseignés
+func (m *Manager) Jobs(kind, status string, limit int) []models.MaintenanceJob {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]models.MaintenanceJob, 0)
+	for _, j := range m.jobs {
+		if (kind == "" || j.Kind == kind) && (status == "" || j.Status == status) {
+			result = append(result, j.snapshot())
+		}
+	}
+	sortJobs(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// run exécute l'opération d'un job puis enregistre son issue et libère son verrou
+func (m *Manager) run(ctx context.Context, j *job) {
+	defer m.wg.Done()
+	defer j.cancel()
+
+	m.mu.Lock()
+	if j.Status == models.JobQueued {
+		now := time.Now()
+		j.StartedAt = &now
+		m.setStatus(j, models.JobRunning, "")
+	}
+	m.mu.Unlock()
+
+	err := ctx.Err()
+	if err == nil {
+		err = m.execute(ctx, j)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fields := logrus.Fields{"job_id": j.ID, "kind": j.Kind, "target": j.Target}
+	switch {
+	case err == nil:
+		j.Progress = 1
+		m.finish(j, models.JobSucceeded, "")
+		m.logger.WithFields(fields).Info("Maintenance job succeeded")
+	case ctx.Err() != nil && errors.Is(err, context.Canceled):
+		reason := j.cancelReason
+		if reason == "" {
+			reason = "cancelled by shutdown"
+		}
+		m.finish(j, models.JobCancelled, reason)
+		m.logger.WithFields(fields).Info("Maintenance job cancelled")
+	default:
+		j.Error = err.Error()
+		m.finish(j, models.JobFailed, j.Error)
+		m.logger.WithFields(fields).WithError(err).Error("Maintenance job failed")
+	}
+}
+
+// execute appelle l'opération correspondant au type du job
+func (m *Manager) execute(ctx context.Context, j *job) error {
+	progress := func(done, total int) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		j.Done, j.Total = done, total
+		if total > 0 {
+			j.Progress = float64(done) / float64(total)
+		}
+	}
+
+	switch j.Kind {
+	case models.JobRebuildTrustGraph:
+		return m.ops.RebuildTrustGraphContext(ctx, progress)
+	case models.JobUpdateSimilarities:
+		return m.ops.UpdateWalletSimilaritiesContext(ctx, progress)
+	case models.JobCleanCaches:
+		return m.ops.CleanObsoleteCaches(ctx, progress)
+	case models.JobPurgeWallet:
+		return m.ops.PurgeWallet(j.Target)
+	case models.JobResetTokenMetrics:
+		return m.ops.ResetTokenTrustMetrics(j.Target)
+	}
+	return fmt.Errorf("%w: unknown job kind %q", ErrInvalidJob, j.Kind)
+}
+
+// finish termine un job et libère son verrou. m.mu doit être tenu.
+func (m *Manager) finish(j *job, status, message string) {
+	now := time.Now()
+	j.FinishedAt = &now
+	if m.locks[j.lock] == j.ID {
+		delete(m.locks, j.lock)
+	}
+	m.setStatus(j, status, message)
+}
+
+// setStatus change le statut d'un job, l'ajoute à son historique et l'enregistre. m.mu doit
+// être tenu, pour que le store reçoive les statuts dans l'ordre.
+func (m *Manager) setStatus(j *job, status, message string) {
+	j.Status = status
+	j.History = append(j.History, models.MaintenanceJobStatus{
+		Status:  status,
+		Message: message,
+		At:      time.Now(),
+	})
+
+	snapshot := j.snapshot()
+	if err := m.store.SaveMaintenanceJob(&snapshot); err != nil {
+		m.logger.WithError(err).WithField("job_id", j.ID).Warn("Failed to save maintenance job")
+	}
+}
+
+// prune oublie les jobs terminés les plus anciens au-delà de historyLimit. m.mu doit être tenu.
+func (m *Manager) prune() {
+	if len(m.jobs) <= historyLimit {
+		return
+	}
+	finished := make([]models.MaintenanceJob, 0, len(m.jobs))
+	for _, j := range m.jobs {
+		if j.Finished() {
+			finished = append(finished, j.MaintenanceJob)
+		}
+	}
+	sortJobs(finished)
+	for i := len(finished) - 1; i >= 0 && len(m.jobs) > historyLimit; i-- {
+		delete(m.jobs, finished[i].ID)
+	}
+}
+
+// snapshot copie l'état du job, historique compris
+func (j *job) snapshot() models.MaintenanceJob {
+	s := j.MaintenanceJob
+	s.History = append([]models.MaintenanceJobStatus(nil), j.History...)
+	return s
+}
+
+// lockKey valide un job et retourne le verrou qu'il doit prendre
+func lockKey(kind, target string) (string, error) {
+	switch kind {
+	case models.JobRebuildTrustGraph, models.JobUpdateSimilarities, models.JobCleanCaches:
+		if target != "" {
+			return "", fmt.Errorf("%w: %s takes no target", ErrInvalidJob, kind)
+		}
+		if kind == models.JobCleanCaches {
+			return "caches", nil
+		}
+		return "trust_graph", nil
+	case models.JobPurgeWallet, models.JobResetTokenMetrics:
+		if target == "" {
+			return "", fmt.Errorf("%w: %s requires a target address", ErrInvalidJob, kind)
+		}
+		if kind == models.JobPurgeWallet {
+			return "wallet:" + target, nil
+		}
+		return "token:" + target, nil
+	}
+	return "", fmt.Errorf("%w: unknown job kind %q", ErrInvalidJob, kind)
+}
+
+// sortJobs trie les jobs du plus récent au plus ancien
+func sortJobs(jobs []models.MaintenanceJob) {
+	sort.Slice(jobs, func(a, b int) bool {
+		if !jobs[a].CreatedAt.Equal(jobs[b].CreatedAt) {
+			return jobs[a].CreatedAt.After(jobs[b].CreatedAt)
+		}
+		return jobs[a].ID > jobs[b].ID
+	})
+}
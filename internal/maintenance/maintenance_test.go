@@ -0,0 +1,262 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// blockingOps bloque la reconstruction du graphe jusqu'à release ou l'annulation du job
+type blockingOps struct {
+	started chan struct{}
+	release chan struct{}
+	purged  []string
+}
+
+func newBlockingOps() *blockingOps {
+	return &blockingOps{started: make(chan struct{}, 1), release: make(chan struct{})}
+}
+
+func (o *blockingOps) RebuildTrustGraphContext(ctx context.Context, progress memory.ProgressFunc) error {
+	progress(1, 4)
+	o.started <- struct{}{}
+	select {
+	case <-o.release:
+		progress(4, 4)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (o *blockingOps) UpdateWalletSimilaritiesContext(ctx context.Context, progress memory.ProgressFunc) error {
+	return errors.New("database unreachable")
+}
+
+func (o *blockingOps) CleanObsoleteCaches(ctx context.Context, progress memory.ProgressFunc) error {
+	return nil
+}
+
+func (o *blockingOps) PurgeWallet(walletAddress string) error {
+	o.purged = append(o.purged, walletAddress)
+	return nil
+}
+
+func (o *blockingOps) ResetTokenTrustMetrics(tokenAddress string) error {
+	return nil
+}
+
+func newTestManager(t *testing.T, ops Operations, store Store) *Manager {
+	t.Helper()
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	m := NewManager(log)
+	m.SetOperations(ops)
+	if store != nil {
+		m.SetStore(store)
+	}
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Shutdown(context.Background()) })
+	return m
+}
+
+// waitStatus attend qu'un job atteigne un statut
+func waitStatus(t *testing.T, m *Manager, id, status string) *models.MaintenanceJob {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		job, err := m.Job(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, job.Status, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func statuses(job *models.MaintenanceJob) []string {
+	result := make([]string, 0, len(job.History))
+	for _, h := range job.History {
+		result = append(result, h.Status)
+	}
+	return result
+}
+
+func TestJobLifecycle(t *testing.T) {
+	ops := newBlockingOps()
+	m := newTestManager(t, ops, nil)
+
+	job, err := m.Submit(models.JobRebuildTrustGraph, "", "key_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-ops.started
+	running := waitStatus(t, m, job.ID, models.JobRunning)
+	if running.Progress != 0.25 || running.Done != 1 || running.Total != 4 || running.StartedAt == nil {
+		t.Fatalf("running job %+v", running)
+	}
+
+	// Le calcul des similarités partage le verrou du graphe, la purge non
+	if _, err := m.Submit(models.JobUpdateSimilarities, "", ""); !errors.Is(err, ErrJobConflict) {
+		t.Fatalf("concurrent similarities: %v", err)
+	}
+	if _, err := m.Submit(models.JobRebuildTrustGraph, "", ""); !errors.Is(err, ErrJobConflict) {
+		t.Fatalf("concurrent rebuild: %v", err)
+	}
+	purge, err := m.Submit(models.JobPurgeWallet, "w1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitStatus(t, m, purge.ID, models.JobSucceeded)
+
+	close(ops.release)
+	done := waitStatus(t, m, job.ID, models.JobSucceeded)
+	if done.Progress != 1 || done.FinishedAt == nil || done.RequestedBy != "key_1" {
+		t.Fatalf("finished job %+v", done)
+	}
+	if got := statuses(done); len(got) != 3 || got[0] != models.JobQueued || got[1] != models.JobRunning {
+		t.Fatalf("history %v", got)
+	}
+
+	// Le verrou est libéré
+	again, err := m.Submit(models.JobUpdateSimilarities, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := waitStatus(t, m, again.ID, models.JobFailed)
+	if failed.Error != "database unreachable" {
+		t.Fatalf("failed job %+v", failed)
+	}
+
+	if jobs := m.Jobs("", "", 10); len(jobs) != 3 || jobs[0].ID != again.ID {
+		t.Fatalf("jobs %+v", jobs)
+	}
+	if jobs := m.Jobs(models.JobPurgeWallet, models.JobSucceeded, 10); len(jobs) != 1 || len(ops.purged) != 1 {
+		t.Fatalf("purge jobs %+v, purged %v", jobs, ops.purged)
+	}
+}
+
+func TestJobCancel(t *testing.T) {
+	ops := newBlockingOps()
+	m := newTestManager(t, ops, nil)
+
+	job, _ := m.Submit(models.JobRebuildTrustGraph, "", "")
+	<-ops.started
+	waitStatus(t, m, job.ID, models.JobRunning)
+
+	cancelling, err := m.Cancel(job.ID, "key_2")
+	if err != nil || cancelling.Status != models.JobCancelling {
+		t.Fatalf("cancel: %+v, %v", cancelling, err)
+	}
+	cancelled := waitStatus(t, m, job.ID, models.JobCancelled)
+	last := cancelled.History[len(cancelled.History)-1]
+	if last.Message != "cancelled by key_2" || cancelled.Error != "" {
+		t.Fatalf("cancelled job %+v", cancelled)
+	}
+
+	if _, err := m.Cancel(job.ID, ""); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("cancel finished job: %v", err)
+	}
+	if _, err := m.Cancel("job_unknown", ""); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("cancel unknown job: %v", err)
+	}
+	if _, err := m.Submit(models.JobRebuildTrustGraph, "", ""); err != nil {
+		t.Fatalf("lock not released after cancel: %v", err)
+	}
+}
+
+func TestSubmitValidation(t *testing.T) {
+	m := newTestManager(t, newBlockingOps(), nil)
+
+	for _, req := range [][2]string{
+		{"defragment", ""},
+		{models.JobPurgeWallet, ""},
+		{models.JobRebuildTrustGraph, "w1"},
+	} {
+		if _, err := m.Submit(req[0], req[1], ""); !errors.Is(err, ErrInvalidJob) {
+			t.Errorf("%v: %v", req, err)
+		}
+	}
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	if _, err := NewManager(log).Submit(models.JobCleanCaches, "", ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("manager not started: %v", err)
+	}
+}
+
+func TestShutdownCancelsAndRestartFailsStaleJobs(t *testing.T) {
+	store := NewMemoryStore()
+	ops := newBlockingOps()
+	m := newTestManager(t, ops, store)
+
+	job, _ := m.Submit(models.JobRebuildTrustGraph, "", "")
+	<-ops.started
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := store.ListMaintenanceJobs(10)
+	if len(stored) != 1 || stored[0].Status != models.JobCancelled ||
+		stored[0].History[len(stored[0].History)-1].Message != "cancelled by shutdown" {
+		t.Fatalf("stored after shutdown %+v", stored)
+	}
+	if _, err := m.Submit(models.JobCleanCaches, "", ""); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("submit after shutdown: %v", err)
+	}
+
+	// Un job resté en cours (processus tué) est marqué en échec au redémarrage
+	stale := stored[0]
+	stale.ID, stale.Status = "job_stale", models.JobRunning
+	store.SaveMaintenanceJob(&stale)
+	restarted := newTestManager(t, ops, store)
+	failed, err := restarted.Job("job_stale")
+	if err != nil || failed.Status != models.JobFailed || failed.Error != "interrupted by restart" {
+		t.Fatalf("stale job %+v, %v", failed, err)
+	}
+	if previous, err := restarted.Job(job.ID); err != nil || previous.Status != models.JobCancelled {
+		t.Fatalf("previous job %+v, %v", previous, err)
+	}
+}
+
+// stubbornOps ignore l'annulation du job et ne rend la main qu'à release
+type stubbornOps struct {
+	*blockingOps
+}
+
+func (o stubbornOps) RebuildTrustGraphContext(ctx context.Context, progress memory.ProgressFunc) error {
+	o.started <- struct{}{}
+	<-o.release
+	return nil
+}
+
+func TestShutdownStopsWaitingAtDeadline(t *testing.T) {
+	ops := stubbornOps{newBlockingOps()}
+	m := newTestManager(t, ops, nil)
+	defer close(ops.release)
+
+	m.Submit(models.JobRebuildTrustGraph, "", "")
+	<-ops.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("shutdown past deadline: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("shutdown waited %v", elapsed)
+	}
+}
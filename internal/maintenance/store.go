@@ -0,0 +1,48 @@
+package maintenance
+
+import (
+	"sync"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// Store persiste l'historique des jobs de maintenance
+type Store interface {
+	ListMaintenanceJobs(limit int) ([]models.MaintenanceJob, error)
+	SaveMaintenanceJob(job *models.MaintenanceJob) error
+}
+
+// MemoryStore est un Store en mémoire, pour les tests et le développement
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]models.MaintenanceJob
+}
+
+// NewMemoryStore crée un store en mémoire vide
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]models.MaintenanceJob)}
+}
+
+// ListMaintenanceJobs retourne les derniers jobs, les plus récents d'abord
+func (s *MemoryStore) ListMaintenanceJobs(limit int) ([]models.MaintenanceJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]models.MaintenanceJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs)
+	if len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+	return jobs, nil
+}
+
+// SaveMaintenanceJob enregistre ou remplace un job
+func (s *MemoryStore) SaveMaintenanceJob(job *models.MaintenanceJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = *job
+	return nil
+}
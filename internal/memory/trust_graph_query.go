@@ -0,0 +1,361 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+var (
+	// ErrNodeNotFound est retourné quand le wallet ou le token n'est pas dans le graphe de confiance
+	ErrNodeNotFound = errors.New("graph node not found")
+	// ErrNoPath est retourné quand aucun chemin ne relie deux wallets dans la limite de sauts
+	ErrNoPath = errors.New("no path between wallets")
+)
+
+// Neighborhood retourne les nœuds à au plus hops liens d'un wallet ou d'un token, et tous
+// les liens entre eux. Un wallet est à deux liens des autres acheteurs de ses tokens.
+func (m *TrustNetwork) Neighborhood(kind, address string, hops, maxNodes int) (*models.TrustSubgraph, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.trustGraph.neighborhood(kind, address, hops, maxNodes)
+}
+
+// ShortestWalletPath retourne un plus court chemin entre deux wallets via des tokens partagés,
+// en au plus maxHops tokens
+func (m *TrustNetwork) ShortestWalletPath(from, to string, maxHops int) (*models.TrustPath, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.trustGraph.shortestWalletPath(from, to, maxHops)
+}
+
+// EarlyBuyersSubgraph retourne le sous-graphe des premiers acheteurs d'un token, lus dans
+// ses buyers premières transactions, avec les autres tokens qu'au moins deux d'entre eux ont tradés
+func (m *TrustNetwork) EarlyBuyersSubgraph(tokenAddress string, buyers, maxNodes int) (*models.TrustSubgraph, error) {
+	wallets, err := m.getEarlyWallets(tokenAddress, buyers)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des premiers acheteurs: %w", err)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.trustGraph.earlyBuyersSubgraph(tokenAddress, wallets, maxNodes)
+}
+
+// graphNodeID retourne l'ID d'un nœud du graphe
+func graphNodeID(kind, address string) string {
+	return kind + ":" + address
+}
+
+// graphIndex est une vue en listes d'adjacence du graphe biparti wallets-tokens
+type graphIndex struct {
+	graph        *TrustGraph
+	walletTokens map[string][]string
+	interactions map[string]map[string]int // token -> wallet -> nombre d'interactions
+}
+
+// index construit les listes d'adjacence du graphe; l'appelant détient le verrou du réseau
+func (g *TrustGraph) index() *graphIndex {
+	idx := &graphIndex{
+		graph:        g,
+		walletTokens: make(map[string][]string),
+		interactions: make(map[string]map[string]int, len(g.Tokens)),
+	}
+	for tokenAddr, node := range g.Tokens {
+		for _, walletAddr := range node.Wallets {
+			idx.walletTokens[walletAddr] = append(idx.walletTokens[walletAddr], tokenAddr)
+		}
+
+		// Les IDs d'interaction ont la forme <tx>:<wallet>:<token>
+		counts := make(map[string]int)
+		for _, id := range node.Interactions {
+			parts := strings.Split(id, ":")
+			if len(parts) >= 3 {
+				counts[parts[len(parts)-2]]++
+			}
+		}
+		idx.interactions[tokenAddr] = counts
+	}
+	for _, tokens := range idx.walletTokens {
+		sort.Strings(tokens)
+	}
+	return idx
+}
+
+// has indique si le nœud existe dans le graphe
+func (idx *graphIndex) has(kind, address string) bool {
+	switch kind {
+	case models.GraphNodeWallet:
+		_, known := idx.graph.Wallets[address]
+		return known || len(idx.walletTokens[address]) > 0
+	case models.GraphNodeToken:
+		_, known := idx.graph.Tokens[address]
+		return known
+	}
+	return false
+}
+
+// neighbors retourne les voisins d'un nœud, triés: les tokens d'un wallet ou les wallets d'un token
+func (idx *graphIndex) neighbors(kind, address string) []string {
+	if kind == models.GraphNodeWallet {
+		return idx.walletTokens[address]
+	}
+	node, ok := idx.graph.Tokens[address]
+	if !ok {
+		return nil
+	}
+	wallets := append([]string(nil), node.Wallets...)
+	sort.Strings(wallets)
+	return wallets
+}
+
+// node décrit un nœud pour la réponse
+func (idx *graphIndex) node(kind, address string, depth int) models.GraphNode {
+	node := models.GraphNode{
+		ID:      graphNodeID(kind, address),
+		Kind:    kind,
+		Address: address,
+		Degree:  len(idx.neighbors(kind, address)),
+		Depth:   depth,
+	}
+	if kind == models.GraphNodeWallet {
+		if wallet, ok := idx.graph.Wallets[address]; ok {
+			node.TrustScore = wallet.TrustScore
+		}
+	}
+	return node
+}
+
+// edge décrit le lien entre un wallet et un token
+func (idx *graphIndex) edge(walletAddr, tokenAddr string) models.GraphEdge {
+	return models.GraphEdge{
+		Source:       graphNodeID(models.GraphNodeWallet, walletAddr),
+		Target:       graphNodeID(models.GraphNodeToken, tokenAddr),
+		Interactions: idx.interactions[tokenAddr][walletAddr],
+	}
+}
+
+// graphRef désigne un nœud pendant un parcours
+type graphRef struct {
+	kind    string
+	address string
+}
+
+// other retourne le type des voisins d'un nœud
+func (r graphRef) other() string {
+	if r.kind == models.GraphNodeWallet {
+		return models.GraphNodeToken
+	}
+	return models.GraphNodeWallet
+}
+
+// subgraph construit le sous-graphe induit par les nœuds donnés, dans leur ordre
+func (idx *graphIndex) subgraph(root string, refs []graphRef, depths map[graphRef]int, truncated bool) *models.TrustSubgraph {
+	included := make(map[graphRef]bool, len(refs))
+	for _, ref := range refs {
+		included[ref] = true
+	}
+
+	result := &models.TrustSubgraph{
+		Root:      root,
+		Nodes:     make([]models.GraphNode, 0, len(refs)),
+		Edges:     []models.GraphEdge{},
+		Truncated: truncated,
+	}
+	for _, ref := range refs {
+		result.Nodes = append(result.Nodes, idx.node(ref.kind, ref.address, depths[ref]))
+		if ref.kind != models.GraphNodeWallet {
+			continue
+		}
+		for _, tokenAddr := range idx.walletTokens[ref.address] {
+			if included[graphRef{models.GraphNodeToken, tokenAddr}] {
+				result.Edges = append(result.Edges, idx.edge(ref.address, tokenAddr))
+			}
+		}
+	}
+	return result
+}
+
+// neighborhood parcourt le graphe en largeur depuis un nœud jusqu'à hops liens ou maxNodes nœuds
+func (g *TrustGraph) neighborhood(kind, address string, hops, maxNodes int) (*models.TrustSubgraph, error) {
+	idx := g.index()
+	if !idx.has(kind, address) {
+		return nil, fmt.Errorf("%w: %s %s", ErrNodeNotFound, kind, address)
+	}
+
+	root := graphRef{kind, address}
+	depths := map[graphRef]int{root: 0}
+	refs := []graphRef{root}
+	queue := []graphRef{root}
+	truncated := false
+
+	for len(queue) > 0 && !truncated {
+		current := queue[0]
+		queue = queue[1:]
+		if depths[current] >= hops {
+			continue
+		}
+		for _, neighbor := range idx.neighbors(current.kind, current.address) {
+			next := graphRef{current.other(), neighbor}
+			if _, seen := depths[next]; seen {
+				continue
+			}
+			if len(refs) >= maxNodes {
+				truncated = true
+				break
+			}
+			depths[next] = depths[current] + 1
+			refs = append(refs, next)
+			queue = append(queue, next)
+		}
+	}
+
+	return idx.subgraph(graphNodeID(kind, address), refs, depths, truncated), nil
+}
+
+// shortestWalletPath cherche en largeur le chemin le plus court entre deux wallets
+func (g *TrustGraph) shortestWalletPath(from, to string, maxHops int) (*models.TrustPath, error) {
+	idx := g.index()
+	for _, address := range []string{from, to} {
+		if !idx.has(models.GraphNodeWallet, address) {
+			return nil, fmt.Errorf("%w: wallet %s", ErrNodeNotFound, address)
+		}
+	}
+
+	start := graphRef{models.GraphNodeWallet, from}
+	target := graphRef{models.GraphNodeWallet, to}
+	parents := map[graphRef]graphRef{start: start}
+	depths := map[graphRef]int{start: 0}
+	queue := []graphRef{start}
+
+	for len(queue) > 0 && from != to {
+		current := queue[0]
+		queue = queue[1:]
+		if depths[current] >= 2*maxHops {
+			continue
+		}
+		for _, neighbor := range idx.neighbors(current.kind, current.address) {
+			next := graphRef{current.other(), neighbor}
+			if _, seen := depths[next]; seen {
+				continue
+			}
+			parents[next] = current
+			depths[next] = depths[current] + 1
+			if next == target {
+				queue = nil
+				break
+			}
+			queue = append(queue, next)
+		}
+	}
+
+	if _, found := depths[target]; !found {
+		return nil, fmt.Errorf("%w: %s and %s within %d hops", ErrNoPath, from, to, maxHops)
+	}
+
+	// Remonter le chemin depuis la cible
+	var refs []graphRef
+	for ref := target; ; ref = parents[ref] {
+		refs = append([]graphRef{ref}, refs...)
+		if ref == start {
+			break
+		}
+	}
+
+	path := &models.TrustPath{
+		From:  from,
+		To:    to,
+		Hops:  len(refs) / 2,
+		Nodes: make([]models.GraphNode, 0, len(refs)),
+		Edges: make([]models.GraphEdge, 0, len(refs)-1),
+	}
+	for i, ref := range refs {
+		path.Nodes = append(path.Nodes, idx.node(ref.kind, ref.address, i))
+		if i == 0 {
+			continue
+		}
+		// Les liens vont toujours du wallet vers le token
+		walletAddr, tokenAddr := refs[i-1].address, ref.address
+		if ref.kind == models.GraphNodeWallet {
+			walletAddr, tokenAddr = ref.address, refs[i-1].address
+		}
+		path.Edges = append(path.Edges, idx.edge(walletAddr, tokenAddr))
+	}
+	return path, nil
+}
+
+// earlyBuyersSubgraph construit le sous-graphe d'un token, de ses premiers acheteurs et des
+// tokens partagés par au moins deux d'entre eux, les plus partagés d'abord
+func (g *TrustGraph) earlyBuyersSubgraph(tokenAddress string, buyers []string, maxNodes int) (*models.TrustSubgraph, error) {
+	idx := g.index()
+	if len(buyers) == 0 && !idx.has(models.GraphNodeToken, tokenAddress) {
+		return nil, fmt.Errorf("%w: token %s", ErrNodeNotFound, tokenAddress)
+	}
+
+	root := graphRef{models.GraphNodeToken, tokenAddress}
+	depths := map[graphRef]int{root: 0}
+	refs := []graphRef{root}
+	truncated := false
+
+	wallets := append([]string(nil), buyers...)
+	sort.Strings(wallets)
+	shared := make(map[string]int)
+	for _, walletAddr := range wallets {
+		ref := graphRef{models.GraphNodeWallet, walletAddr}
+		if _, seen := depths[ref]; seen {
+			continue
+		}
+		if len(refs) >= maxNodes {
+			truncated = true
+			break
+		}
+		depths[ref] = 1
+		refs = append(refs, ref)
+		for _, tokenAddr := range idx.walletTokens[walletAddr] {
+			if tokenAddr != tokenAddress {
+				shared[tokenAddr]++
+			}
+		}
+	}
+
+	tokens := make([]string, 0, len(shared))
+	for tokenAddr, count := range shared {
+		if count >= 2 {
+			tokens = append(tokens, tokenAddr)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if shared[tokens[i]] != shared[tokens[j]] {
+			return shared[tokens[i]] > shared[tokens[j]]
+		}
+		return tokens[i] < tokens[j]
+	})
+	for _, tokenAddr := range tokens {
+		if len(refs) >= maxNodes {
+			truncated = true
+			break
+		}
+		ref := graphRef{models.GraphNodeToken, tokenAddr}
+		depths[ref] = 2
+		refs = append(refs, ref)
+	}
+
+	result := idx.subgraph(graphNodeID(models.GraphNodeToken, tokenAddress), refs, depths, truncated)
+
+	// Les achats lus en base relient les acheteurs au token même hors de la fenêtre du graphe
+	linked := make(map[string]bool)
+	for _, edge := range result.Edges {
+		if edge.Target == result.Root {
+			linked[edge.Source] = true
+		}
+	}
+	for _, ref := range refs[1:] {
+		if id := graphNodeID(ref.kind, ref.address); ref.kind == models.GraphNodeWallet && !linked[id] {
+			result.Edges = append(result.Edges, idx.edge(ref.address, tokenAddress))
+		}
+	}
+	return result, nil
+}
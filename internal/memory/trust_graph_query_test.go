@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// testGraph construit un graphe à partir des achats wallet -> tokens
+func testGraph(trades map[string][]string) *TrustGraph {
+	g := &TrustGraph{Wallets: make(map[string]*WalletNode), Tokens: make(map[string]*TokenNode)}
+	for walletAddr, tokens := range trades {
+		g.Wallets[walletAddr] = &WalletNode{Address: walletAddr, TrustScore: 60}
+		for i, tokenAddr := range tokens {
+			node, ok := g.Tokens[tokenAddr]
+			if !ok {
+				node = &TokenNode{Address: tokenAddr}
+				g.Tokens[tokenAddr] = node
+			}
+			node.Wallets = append(node.Wallets, walletAddr)
+			node.Interactions = append(node.Interactions, fmt.Sprintf("tx%d:%s:%s", i, walletAddr, tokenAddr))
+		}
+	}
+	return g
+}
+
+func nodeIDs(nodes []models.GraphNode) map[string]int {
+	ids := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		ids[node.ID] = node.Depth
+	}
+	return ids
+}
+
+// w1 -T1- w2 -T2- w3 -T3- w4, et w5 isolé sur T4
+var chain = map[string][]string{
+	"w1": {"T1"},
+	"w2": {"T1", "T2"},
+	"w3": {"T2", "T3"},
+	"w4": {"T3"},
+	"w5": {"T4"},
+}
+
+func TestNeighborhood(t *testing.T) {
+	g := testGraph(chain)
+
+	sub, err := g.neighborhood(models.GraphNodeWallet, "w2", 2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := nodeIDs(sub.Nodes)
+	want := map[string]int{"wallet:w2": 0, "token:T1": 1, "token:T2": 1, "wallet:w1": 2, "wallet:w3": 2}
+	if len(ids) != len(want) || sub.Truncated || sub.Root != "wallet:w2" {
+		t.Fatalf("nodes %v, truncated %v", ids, sub.Truncated)
+	}
+	for id, depth := range want {
+		if ids[id] != depth {
+			t.Errorf("%s depth %d, want %d (nodes %v)", id, ids[id], depth, ids)
+		}
+	}
+	// Liens induits: w1-T1, w2-T1, w2-T2, w3-T2 (w3-T3 est hors du voisinage)
+	if len(sub.Edges) != 4 {
+		t.Errorf("edges %+v", sub.Edges)
+	}
+	for _, edge := range sub.Edges {
+		if edge.Interactions != 1 {
+			t.Errorf("edge %+v: interactions %d", edge, edge.Interactions)
+		}
+	}
+
+	sub, err = g.neighborhood(models.GraphNodeToken, "T2", 1, 100)
+	if err != nil || len(sub.Nodes) != 3 || len(sub.Edges) != 2 {
+		t.Fatalf("token neighborhood %+v, %v", sub, err)
+	}
+
+	sub, _ = g.neighborhood(models.GraphNodeWallet, "w2", 4, 3)
+	if len(sub.Nodes) != 3 || !sub.Truncated {
+		t.Fatalf("limited neighborhood: %d nodes, truncated %v", len(sub.Nodes), sub.Truncated)
+	}
+
+	if _, err := g.neighborhood(models.GraphNodeWallet, "unknown", 2, 100); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown wallet: %v", err)
+	}
+}
+
+func TestShortestWalletPath(t *testing.T) {
+	g := testGraph(chain)
+
+	path, err := g.shortestWalletPath("w1", "w4", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, node := range path.Nodes {
+		ids = append(ids, node.ID)
+	}
+	if fmt.Sprint(ids) != "[wallet:w1 token:T1 wallet:w2 token:T2 wallet:w3 token:T3 wallet:w4]" || path.Hops != 3 {
+		t.Fatalf("path %v, hops %d", ids, path.Hops)
+	}
+	for i, edge := range path.Edges {
+		if edge.Source[:7] != "wallet:" || edge.Target[:6] != "token:" {
+			t.Errorf("edge %d not oriented wallet -> token: %+v", i, edge)
+		}
+	}
+
+	if _, err := g.shortestWalletPath("w1", "w4", 2); !errors.Is(err, ErrNoPath) {
+		t.Fatalf("path beyond max hops: %v", err)
+	}
+	if _, err := g.shortestWalletPath("w1", "w5", 10); !errors.Is(err, ErrNoPath) {
+		t.Fatalf("disconnected wallets: %v", err)
+	}
+	if path, err := g.shortestWalletPath("w3", "w3", 1); err != nil || path.Hops != 0 || len(path.Nodes) != 1 {
+		t.Fatalf("same wallet: %+v, %v", path, err)
+	}
+}
+
+func TestEarlyBuyersSubgraph(t *testing.T) {
+	g := testGraph(map[string][]string{
+		"a": {"LAUNCH", "X", "Y"},
+		"b": {"LAUNCH", "X", "Y"},
+		"c": {"LAUNCH", "X"},
+		"d": {"Z"}, // Acheteur précoce hors de la fenêtre du graphe pour LAUNCH
+	})
+
+	sub, err := g.earlyBuyersSubgraph("LAUNCH", []string{"c", "a", "b", "d", "a"}, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, node := range sub.Nodes {
+		ids = append(ids, node.ID)
+	}
+	// Z n'est tradé que par un acheteur; X est plus partagé que Y
+	if fmt.Sprint(ids) != "[token:LAUNCH wallet:a wallet:b wallet:c wallet:d token:X token:Y]" {
+		t.Fatalf("nodes %v", ids)
+	}
+	// a, b, c, d vers LAUNCH; a, b, c vers X; a, b vers Y
+	if len(sub.Edges) != 9 {
+		t.Fatalf("edges %+v", sub.Edges)
+	}
+
+	sub, _ = g.earlyBuyersSubgraph("LAUNCH", []string{"a", "b", "c"}, 3)
+	if len(sub.Nodes) != 3 || !sub.Truncated {
+		t.Fatalf("limited subgraph: %d nodes, truncated %v", len(sub.Nodes), sub.Truncated)
+	}
+
+	if _, err := g.earlyBuyersSubgraph("UNKNOWN", nil, 100); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("unknown token: %v", err)
+	}
+}
@@ -24,10 +24,20 @@ type TrustNetwork struct {
 	trustGraph  *TrustGraph
 	mutex       sync.RWMutex
 	maintenanceInterval time.Duration
+	jobs        JobSubmitter
 	stopCh      chan struct{}
 	wg          sync.WaitGroup
 }
 
+// JobSubmitter lance un job de maintenance sous les verrous du gestionnaire de jobs
+// (implémenté par maintenance.Manager)
+type JobSubmitter interface {
+	Submit(kind, target, requestedBy string) (*models.MaintenanceJob, error)
+}
+
+// scheduledJobRequester identifie les jobs lancés par la routine de maintenance
+const scheduledJobRequester = "scheduler"
+
 // TrustGraph est la structure de données principale maintenant les relations entre wallets et tokens
 type TrustGraph struct {
 	Wallets     map[string]*WalletNode
@@ -67,12 +77,18 @@ func NewTrustNetwork(db *db.Connection, cache *cache.Client, logger *logger.Logg
 	}
 }
 
+// SetMaintenanceJobs fait passer les tâches périodiques par le gestionnaire de jobs, pour
+// qu'elles prennent les mêmes verrous que les jobs lancés par l'API
+func (m *TrustNetwork) SetMaintenanceJobs(jobs JobSubmitter) {
+	m.jobs = jobs
+}
+
 // Start initialise et démarre le Memory of Trust
 func (m *TrustNetwork) Start(ctx context.Context) error {
 	m.logger.Info("Démarrage du Memory of Trust")
 	
 	// Charger les données du graphe de confiance depuis la base de données
-	if err := m.loadTrustGraph(); err != nil {
+	if err := m.loadTrustGraph(ctx, nil); err != nil {
 		m.logger.Error("Échec du chargement du graphe de confiance", err)
 		// Continuer avec un graphe vide plutôt que d'échouer complètement
 	}
@@ -105,13 +121,19 @@ func (m *TrustNetwork) Stop() error {
 	return nil
 }
 
-// loadTrustGraph charge le graphe de confiance depuis la base de données
-func (m *TrustNetwork) loadTrustGraph() error {
+// loadTrustGraph charge le graphe de confiance depuis la base de données. Le nouveau graphe
+// ne remplace l'actuel qu'une fois complet: une annulation ou une erreur le laisse intact.
+func (m *TrustNetwork) loadTrustGraph(ctx context.Context, progress ProgressFunc) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	
 	m.logger.Info("Chargement du graphe de confiance")
 	
+	graph := &TrustGraph{
+		Wallets: make(map[string]*WalletNode),
+		Tokens:  make(map[string]*TokenNode),
+	}
+	
 	// Récupérer les scores de confiance des wallets
 	walletScores, err := m.db.GetAllWalletTrustScores()
 	if err != nil {
@@ -120,7 +142,7 @@ func (m *TrustNetwork) loadTrustGraph() error {
 	
 	// Ajouter les wallets au graphe
 	for _, ws := range walletScores {
-		m.trustGraph.Wallets[ws.Address] = &WalletNode{
+		graph.Wallets[ws.Address] = &WalletNode{
 			Address:      ws.Address,
 			TrustScore:   ws.TrustScore,
 			Interactions: []string{},
@@ -136,16 +158,23 @@ func (m *TrustNetwork) loadTrustGraph() error {
 	}
 	
 	// Traiter les interactions pour construire le graphe
-	for _, interaction := range interactions {
+	for i, interaction := range interactions {
+		if i%loadProgressStep == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			progress.report(i, len(interactions))
+		}
+		
 		// Ajouter le wallet s'il n'existe pas déjà
-		if _, exists := m.trustGraph.Wallets[interaction.WalletAddress]; !exists {
+		if _, exists := graph.Wallets[interaction.WalletAddress]; !exists {
 			// Récupérer le score de confiance ou utiliser une valeur par défaut
 			trustScore, err := m.db.GetWalletTrustScore(interaction.WalletAddress)
 			if err != nil {
 				trustScore = 50.0 // Score par défaut
 			}
 			
-			m.trustGraph.Wallets[interaction.WalletAddress] = &WalletNode{
+			graph.Wallets[interaction.WalletAddress] = &WalletNode{
 				Address:      interaction.WalletAddress,
 				TrustScore:   trustScore,
 				Interactions: []string{},
@@ -154,8 +183,8 @@ func (m *TrustNetwork) loadTrustGraph() error {
 		}
 		
 		// Ajouter le token s'il n'existe pas déjà
-		if _, exists := m.trustGraph.Tokens[interaction.TokenAddress]; !exists {
-			m.trustGraph.Tokens[interaction.TokenAddress] = &TokenNode{
+		if _, exists := graph.Tokens[interaction.TokenAddress]; !exists {
+			graph.Tokens[interaction.TokenAddress] = &TokenNode{
 				Address:      interaction.TokenAddress,
 				Wallets:      []string{},
 				Interactions: []string{},
@@ -167,11 +196,11 @@ func (m *TrustNetwork) loadTrustGraph() error {
 		interactionID := fmt.Sprintf("%s:%s:%s", interaction.TxHash, interaction.WalletAddress, interaction.TokenAddress)
 		
 		// Ajouter l'interaction au wallet
-		walletNode := m.trustGraph.Wallets[interaction.WalletAddress]
+		walletNode := graph.Wallets[interaction.WalletAddress]
 		walletNode.Interactions = append(walletNode.Interactions, interactionID)
 		
 		// Ajouter le wallet au token s'il n'y est pas déjà
-		tokenNode := m.trustGraph.Tokens[interaction.TokenAddress]
+		tokenNode := graph.Tokens[interaction.TokenAddress]
 		// Vérifier si le wallet existe déjà dans la liste du token
 		walletExists := false
 		for _, addr := range tokenNode.Wallets {
@@ -188,10 +217,12 @@ func (m *TrustNetwork) loadTrustGraph() error {
 		tokenNode.Interactions = append(tokenNode.Interactions, interactionID)
 	}
 	
-	m.trustGraph.LastUpdated = time.Now()
+	graph.LastUpdated = time.Now()
+	m.trustGraph = graph
+	progress.report(len(interactions), len(interactions))
 	m.logger.Info("Graphe de confiance chargé avec succès", map[string]interface{}{
-		"wallets_count": len(m.trustGraph.Wallets),
-		"tokens_count":  len(m.trustGraph.Tokens),
+		"wallets_count": len(graph.Wallets),
+		"tokens_count":  len(graph.Tokens),
 	})
 	
 	return nil
@@ -656,13 +687,8 @@ func (m *TrustNetwork) maintenanceRoutine(ctx context.Context) {
 				m.logger.Error("Échec de la sauvegarde du graphe de confiance", err)
 			}
 			
-			// 2. Mettre à jour les similarités entre wallets
-			if err := m.UpdateWalletSimilarities(); err != nil {
-				m.logger.Error("Échec de la mise à jour des similarités", err)
-			}
-			
-			// 3. Nettoyer les caches obsolètes
-			m.cleanObsoleteCaches()
+			// 2 et 3. Mettre à jour les similarités et nettoyer les caches obsolètes
+			m.runScheduledTasks(ctx)
 			
 			// 4. Optimiser les index pour les requêtes futures
 			if err := m.db.OptimizeIndexes(); err != nil {
@@ -674,6 +700,29 @@ func (m *TrustNetwork) maintenanceRoutine(ctx context.Context) {
 	}
 }
 
+// runScheduledTasks met à jour les similarités et nettoie les caches. Avec un gestionnaire de
+// jobs, les tâches sont soumises comme jobs et sautées si un job tient déjà leur verrou.
+func (m *TrustNetwork) runScheduledTasks(ctx context.Context) {
+	if m.jobs != nil {
+		for _, kind := range []string{models.JobUpdateSimilarities, models.JobCleanCaches} {
+			if _, err := m.jobs.Submit(kind, "", scheduledJobRequester); err != nil {
+				m.logger.Warning("Tâche de maintenance périodique non lancée", map[string]interface{}{
+					"kind":  kind,
+					"error": err.Error(),
+				})
+			}
+		}
+		return
+	}
+
+	if err := m.UpdateWalletSimilarities(); err != nil {
+		m.logger.Error("Échec de la mise à jour des similarités", err)
+	}
+	if err := m.CleanObsoleteCaches(ctx, nil); err != nil {
+		m.logger.Error("Échec du nettoyage des caches", err)
+	}
+}
+
 // abs retourne la valeur absolue d'un float64
 func abs(x float64) float64 {
 	if x < 0 {
@@ -0,0 +1,37 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/franky69420/crypto-oracle/pkg/utils/logger"
+)
+
+// stubJobSubmitter enregistre les jobs soumis et refuse le calcul des similarités
+type stubJobSubmitter struct {
+	submitted []string
+}
+
+func (s *stubJobSubmitter) Submit(kind, target, requestedBy string) (*models.MaintenanceJob, error) {
+	s.submitted = append(s.submitted, kind+":"+requestedBy)
+	if kind == models.JobUpdateSimilarities {
+		return nil, errors.New("maintenance job already running")
+	}
+	return &models.MaintenanceJob{ID: "job_1", Kind: kind}, nil
+}
+
+func TestScheduledTasksGoThroughJobs(t *testing.T) {
+	jobs := &stubJobSubmitter{}
+	m := NewTrustNetwork(nil, nil, logger.NewLogger("error"))
+	m.SetMaintenanceJobs(jobs)
+
+	// Sans base ni cache, un appel direct paniquerait: les tâches doivent passer par les jobs
+	m.runScheduledTasks(context.Background())
+
+	want := []string{models.JobUpdateSimilarities + ":scheduler", models.JobCleanCaches + ":scheduler"}
+	if len(jobs.submitted) != len(want) || jobs.submitted[0] != want[0] || jobs.submitted[1] != want[1] {
+		t.Fatalf("submitted %v, want %v", jobs.submitted, want)
+	}
+}
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"context"
 	"fmt"
 	"time"
 	"sort"
@@ -10,49 +11,74 @@ import (
 	"github.com/franky69420/crypto-oracle/pkg/models"
 )
 
-// cleanObsoleteCaches nettoie les caches obsolètes
-func (m *TrustNetwork) cleanObsoleteCaches() {
+// ProgressFunc reçoit l'avancement d'une opération de maintenance: done éléments traités sur total
+type ProgressFunc func(done, total int)
+
+// loadProgressStep est le nombre d'interactions chargées entre deux points d'avancement
+const loadProgressStep = 100
+
+// report transmet l'avancement s'il est suivi
+func (p ProgressFunc) report(done, total int) {
+	if p != nil {
+		p(done, total)
+	}
+}
+
+// CleanObsoleteCaches nettoie les caches obsolètes, en s'interrompant si ctx est annulé
+func (m *TrustNetwork) CleanObsoleteCaches(ctx context.Context, progress ProgressFunc) error {
 	// Purge les clés cache avec un pattern spécifique
 	if err := m.cache.PurgePattern("trust:*:temp:*"); err != nil {
 		m.logger.Error("Failed to purge temporary cache keys", err)
 	}
 	
+	// Une clé illisible laisse son groupe de caches en place jusqu'au prochain passage
+	walletKeys, _ := m.cache.Keys("trust:wallet:*")
+	tokenKeys, _ := m.cache.Keys("trust:token:*")
+	total := len(walletKeys) + len(tokenKeys)
+	
 	// Purger les scores de confiance qui n'ont pas été mis à jour depuis trop longtemps
-	walletKeys, err := m.cache.Keys("trust:wallet:*")
-	if err == nil {
-		for _, key := range walletKeys {
-			// Vérifier la date de mise à jour du wallet
-			parts := strings.Split(key, ":")
-			if len(parts) == 3 {
-				walletAddr := parts[2]
-				
-				m.mutex.RLock()
-				walletNode, exists := m.trustGraph.Wallets[walletAddr]
-				updateTime := time.Time{}
-				if exists {
-					updateTime = walletNode.LastUpdated
-				}
-				m.mutex.RUnlock()
-				
-				// Si le cache est plus vieux que 24h, le supprimer
-				if exists && time.Since(updateTime) > 24*time.Hour {
-					m.cache.Delete(key)
-				}
+	for i, key := range walletKeys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress.report(i, total)
+		
+		// Vérifier la date de mise à jour du wallet
+		parts := strings.Split(key, ":")
+		if len(parts) == 3 {
+			walletAddr := parts[2]
+			
+			m.mutex.RLock()
+			walletNode, exists := m.trustGraph.Wallets[walletAddr]
+			updateTime := time.Time{}
+			if exists {
+				updateTime = walletNode.LastUpdated
+			}
+			m.mutex.RUnlock()
+			
+			// Si le cache est plus vieux que 24h, le supprimer
+			if exists && time.Since(updateTime) > 24*time.Hour {
+				m.cache.Delete(key)
 			}
 		}
 	}
 	
 	// Purger les métriques token qui n'ont pas été mises à jour depuis trop longtemps
-	tokenKeys, err := m.cache.Keys("trust:token:*")
-	if err == nil {
-		for _, key := range tokenKeys {
-			// Supprimer les métriques token de plus de 6h
-			ttl, err := m.cache.TTL(key)
-			if err != nil || ttl < 0 || ttl > 6*time.Hour {
-				m.cache.Delete(key)
-			}
+	for i, key := range tokenKeys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress.report(len(walletKeys)+i, total)
+		
+		// Supprimer les métriques token de plus de 6h
+		ttl, err := m.cache.TTL(key)
+		if err != nil || ttl < 0 || ttl > 6*time.Hour {
+			m.cache.Delete(key)
 		}
 	}
+	
+	progress.report(total, total)
+	return nil
 }
 
 // GenerateSystemMetrics génère des métriques sur l'état actuel du Memory of Trust
@@ -172,6 +198,12 @@ func (m *TrustNetwork) GenerateSystemMetrics() map[string]interface{} {
 
 // UpdateWalletSimilarities met à jour les similarités entre wallets
 func (m *TrustNetwork) UpdateWalletSimilarities() error {
+	return m.UpdateWalletSimilaritiesContext(context.Background(), nil)
+}
+
+// UpdateWalletSimilaritiesContext met à jour les similarités entre wallets en rapportant
+// l'avancement par wallet comparé. Une annulation n'enregistre aucune similarité.
+func (m *TrustNetwork) UpdateWalletSimilaritiesContext(ctx context.Context, progress ProgressFunc) error {
 	m.logger.Info("Updating wallet similarities")
 	
 	// Cette opération peut être lourde, donc on travaille sur une copie
@@ -223,6 +255,10 @@ func (m *TrustNetwork) UpdateWalletSimilarities() error {
 	similarities := make(map[string]map[string]float64)
 	
 	for i, addr1 := range wallets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		progress.report(i, len(wallets))
 		similarities[addr1] = make(map[string]float64)
 		
 		// Récupérer les tokens du wallet 1
@@ -283,6 +319,8 @@ func (m *TrustNetwork) UpdateWalletSimilarities() error {
 		}
 	}
 	
+	progress.report(len(wallets), len(wallets))
+	
 	// Sauvegarder les similarités en base
 	for addr1, sims := range similarities {
 		for addr2, score := range sims {
@@ -305,6 +343,12 @@ func (m *TrustNetwork) UpdateWalletSimilarities() error {
 
 // RebuildTrustGraph reconstruit complètement le graphe de confiance
 func (m *TrustNetwork) RebuildTrustGraph() error {
+	return m.RebuildTrustGraphContext(context.Background(), nil)
+}
+
+// RebuildTrustGraphContext reconstruit le graphe de confiance en rapportant l'avancement
+// par interaction chargée. Une annulation conserve le graphe actuel.
+func (m *TrustNetwork) RebuildTrustGraphContext(ctx context.Context, progress ProgressFunc) error {
 	m.logger.Info("Rebuilding trust graph")
 	
 	// Sauvegarde du graphe actuel avant de le reconstruire
@@ -314,7 +358,7 @@ func (m *TrustNetwork) RebuildTrustGraph() error {
 	}
 	
 	// Reconstruire le graphe
-	if err := m.loadTrustGraph(); err != nil {
+	if err := m.loadTrustGraph(ctx, progress); err != nil {
 		return fmt.Errorf("failed to rebuild trust graph: %w", err)
 	}
 	
@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"time"
+)
+
+// StreamMessage est une entrée d'un stream
+type StreamMessage struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+// PendingMessage est un message livré à un groupe mais pas encore acquitté
+type PendingMessage struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration // Temps écoulé depuis la dernière livraison
+	Deliveries int64         // Nombre de livraisons, y compris la première
+}
+
+// GroupInfo décrit l'état d'un groupe de consommateurs sur un stream
+type GroupInfo struct {
+	Name            string
+	Consumers       int64
+	Pending         int64
+	LastDeliveredID string
+}
+
+// Broker transporte les messages du pipeline avec une sémantique at-least-once:
+// un message lu par un groupe reste en attente jusqu'à son acquittement et peut être
+// repris par un autre consommateur du groupe.
+// Les IDs de messages sont de la forme <ms>-<seq> et croissent dans chaque stream.
+type Broker interface {
+	// Publish ajoute un message à un stream et retourne son ID
+	Publish(stream string, values map[string]interface{}) (string, error)
+	// CreateGroup crée un groupe positionné en fin de stream; sans effet s'il existe déjà
+	CreateGroup(stream, group string) error
+	// ReadGroup livre au consommateur les messages jamais livrés au groupe, en attendant au plus block
+	ReadGroup(stream, group, consumer string, count int, block time.Duration) ([]StreamMessage, error)
+	// Ack retire des messages de la liste d'attente du groupe
+	Ack(stream, group string, ids ...string) error
+	// Pending liste les messages en attente du groupe, par ID croissant
+	Pending(stream, group string, count int) ([]PendingMessage, error)
+	// Claim réattribue au consommateur les messages inactifs depuis minIdle et les relivre
+	Claim(stream, group, consumer string, minIdle time.Duration, ids ...string) ([]StreamMessage, error)
+	// Range lit les messages entre deux IDs inclus ("-" et "+" pour les extrémités)
+	Range(stream, start, stop string, count int) ([]StreamMessage, error)
+	// Delete supprime des messages d'un stream
+	Delete(stream string, ids ...string) error
+	// Groups décrit les groupes de consommateurs d'un stream
+	Groups(stream string) ([]GroupInfo, error)
+	// Trim supprime les messages les plus anciens: au-delà des maxLen derniers (0 = sans limite)
+	// et d'ID inférieur à minID ("" = sans limite). Retourne le nombre de messages supprimés.
+	Trim(stream string, maxLen int64, minID string) (int64, error)
+
+	// AcquireLease pose un bail expirant sur key si aucun autre détenteur ne l'a
+	AcquireLease(key, owner string, ttl time.Duration) (bool, error)
+	// RefreshLease prolonge un bail encore détenu par owner
+	RefreshLease(key, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLease libère un bail encore détenu par owner
+	ReleaseLease(key, owner string) error
+	// Heartbeat signale la présence de member et retourne le nombre de membres vus depuis ttl
+	Heartbeat(key, member string, ttl time.Duration) (int64, error)
+	// Members retourne le nombre de membres vus depuis ttl
+	Members(key string, ttl time.Duration) (int64, error)
+	// Leave retire member de l'ensemble
+	Leave(key, member string) error
+}
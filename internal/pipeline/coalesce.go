@@ -0,0 +1,311 @@
+package pipeline
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+// CoalescePolicy contrôle la fusion des événements répétés d'un même token.
+// Les événements d'un type listé sont retenus à la publication; chaque nouvel
+// événement du même type et du même token repousse l'émission de Window, sans
+// dépasser MaxWait après le premier. Un seul événement résumé est alors publié.
+type CoalescePolicy struct {
+	Window     time.Duration // Silence requis avant l'émission; 0 désactive la fusion
+	MaxWait    time.Duration // Retenue maximale après le premier événement; 0 = Window
+	EventTypes []string      // Types d'événements fusionnés
+}
+
+// DefaultCoalescePolicy retourne la politique par défaut: aucune fusion
+func DefaultCoalescePolicy() CoalescePolicy {
+	return CoalescePolicy{}
+}
+
+// CoalescePolicyFromConfig construit la politique de fusion à partir de la configuration
+func CoalescePolicyFromConfig(cfg *config.PipelineConfig) CoalescePolicy {
+	policy := DefaultCoalescePolicy()
+	if cfg == nil {
+		return policy
+	}
+	if cfg.CoalesceWindowMs > 0 {
+		policy.Window = time.Duration(cfg.CoalesceWindowMs) * time.Millisecond
+	}
+	if cfg.CoalesceMaxWaitMs > 0 {
+		policy.MaxWait = time.Duration(cfg.CoalesceMaxWaitMs) * time.Millisecond
+	}
+	if len(cfg.CoalesceEventTypes) > 0 {
+		policy.EventTypes = cfg.CoalesceEventTypes
+	}
+	return policy
+}
+
+// applies indique si un type d'événement est fusionné
+func (c CoalescePolicy) applies(eventType string) bool {
+	if c.Window <= 0 {
+		return false
+	}
+	for _, t := range c.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// maxWait retourne la retenue maximale effective
+func (c CoalescePolicy) maxWait() time.Duration {
+	if c.MaxWait < c.Window {
+		return c.Window
+	}
+	return c.MaxWait
+}
+
+// CoalesceSummary résume les événements fusionnés dans un événement émis
+type CoalesceSummary struct {
+	Count      int       `json:"count"`      // Événements reçus dans la fenêtre
+	Suppressed int       `json:"suppressed"` // Événements non publiés
+	FirstAt    time.Time `json:"first_at"`
+	LastAt     time.Time `json:"last_at"`
+	First      float64   `json:"first"` // Valeur suivie du premier événement
+	Last       float64   `json:"last"`  // Valeur suivie du dernier événement
+	Peak       float64   `json:"peak"`  // Valeur suivie maximale
+}
+
+// Coalescable est implémenté par les événements qui peuvent être fusionnés
+type Coalescable interface {
+	Event
+	// CoalesceValue retourne la valeur suivie (premier, dernier, pic) dans la fenêtre
+	CoalesceValue() float64
+	// Coalesce construit l'événement résumé; appelé sur le dernier événement de la fenêtre
+	Coalesce(first Event, summary *CoalesceSummary) Event
+}
+
+// CoalesceStats compte les événements fusionnés par type
+type CoalesceStats struct {
+	EventType  string `json:"event_type"`
+	Received   int64  `json:"received"`
+	Emitted    int64  `json:"emitted"`
+	Suppressed int64  `json:"suppressed"`
+	Pending    int    `json:"pending"` // Fenêtres ouvertes
+}
+
+// coalesceKey identifie une fenêtre de fusion
+type coalesceKey struct {
+	stream    string
+	eventType string
+	token     string
+}
+
+// coalesceWindow accumule les événements d'une fenêtre
+type coalesceWindow struct {
+	first   Coalescable
+	last    Coalescable
+	summary CoalesceSummary
+	timer   *time.Timer
+}
+
+// coalescer retient les événements fusionnables jusqu'à la fin de leur fenêtre
+type coalescer struct {
+	policy  CoalescePolicy
+	publish func(stream string, event Event) error
+	logger  *logrus.Logger
+
+	mu      sync.Mutex
+	windows map[coalesceKey]*coalesceWindow
+	stats   map[string]*CoalesceStats
+}
+
+// newCoalescer crée l'étage de fusion; publish émet les événements résumés
+func newCoalescer(policy CoalescePolicy, publish func(stream string, event Event) error, logger *logrus.Logger) *coalescer {
+	return &coalescer{
+		policy:  policy,
+		publish: publish,
+		logger:  logger,
+		windows: make(map[coalesceKey]*coalesceWindow),
+		stats:   make(map[string]*CoalesceStats),
+	}
+}
+
+// add retient un événement. Retourne false si l'événement doit être publié directement.
+func (c *coalescer) add(stream string, event Event) bool {
+	ev, ok := event.(Coalescable)
+	if !ok || !c.policy.applies(event.EventType()) {
+		return false
+	}
+
+	now := time.Now()
+	key := coalesceKey{stream: stream, eventType: event.EventType(), token: event.GetTokenAddress()}
+	value := ev.CoalesceValue()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.statsFor(key.eventType).Received++
+
+	w, ok := c.windows[key]
+	if !ok {
+		w = &coalesceWindow{
+			first: ev,
+			summary: CoalesceSummary{
+				FirstAt: now,
+				First:   value,
+				Peak:    value,
+			},
+		}
+		w.timer = time.AfterFunc(c.policy.Window, func() { c.flush(key) })
+		c.windows[key] = w
+	} else {
+		// Repousser l'émission sans dépasser la retenue maximale
+		delay := c.policy.Window
+		if remaining := c.policy.maxWait() - now.Sub(w.summary.FirstAt); remaining < delay {
+			delay = remaining
+		}
+		w.timer.Reset(delay)
+	}
+
+	w.last = ev
+	w.summary.Count++
+	w.summary.LastAt = now
+	w.summary.Last = value
+	w.summary.Peak = math.Max(w.summary.Peak, value)
+	return true
+}
+
+// flush publie l'événement résumé d'une fenêtre
+func (c *coalescer) flush(key coalesceKey) {
+	c.mu.Lock()
+	w, ok := c.windows[key]
+	if ok {
+		delete(c.windows, key)
+		w.timer.Stop()
+	}
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	summary := w.summary
+	summary.Suppressed = summary.Count - 1
+	event := w.last.Coalesce(w.first, &summary)
+
+	if err := c.publish(key.stream, event); err != nil {
+		c.logger.WithFields(logrus.Fields{
+			"stream":        key.stream,
+			"type":          key.eventType,
+			"token_address": key.token,
+			"count":         summary.Count,
+			"error":         err.Error(),
+		}).Error("Failed to publish coalesced event")
+		return
+	}
+
+	c.mu.Lock()
+	stats := c.statsFor(key.eventType)
+	stats.Emitted++
+	stats.Suppressed += int64(summary.Suppressed)
+	c.mu.Unlock()
+
+	if summary.Suppressed > 0 {
+		c.logger.WithFields(logrus.Fields{
+			"type":          key.eventType,
+			"token_address": key.token,
+			"count":         summary.Count,
+			"suppressed":    summary.Suppressed,
+		}).Debug("Coalesced events published")
+	}
+}
+
+// flushAll publie toutes les fenêtres ouvertes, à l'arrêt du pipeline
+func (c *coalescer) flushAll() {
+	c.mu.Lock()
+	keys := make([]coalesceKey, 0, len(c.windows))
+	for key := range c.windows {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	for _, key := range keys {
+		c.flush(key)
+	}
+}
+
+// statsFor retourne les compteurs d'un type; c.mu doit être détenu
+func (c *coalescer) statsFor(eventType string) *CoalesceStats {
+	s, ok := c.stats[eventType]
+	if !ok {
+		s = &CoalesceStats{EventType: eventType}
+		c.stats[eventType] = s
+	}
+	return s
+}
+
+// snapshot retourne les compteurs triés par type
+func (c *coalescer) snapshot() []CoalesceStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending := make(map[string]int)
+	for key := range c.windows {
+		pending[key.eventType]++
+	}
+
+	stats := make([]CoalesceStats, 0, len(c.stats))
+	for t, s := range c.stats {
+		st := *s
+		st.Pending = pending[t]
+		stats = append(stats, st)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].EventType < stats[j].EventType })
+	return stats
+}
+
+// SetCoalescePolicy remplace la politique de fusion des événements.
+// Les fenêtres ouvertes avec l'ancienne politique sont publiées.
+func (p *Pipeline) SetCoalescePolicy(policy CoalescePolicy) {
+	if p.coalescer != nil {
+		p.coalescer.flushAll()
+	}
+	p.coalescer = newCoalescer(policy, p.publishEvent, p.logger)
+}
+
+// CoalesceStats retourne les compteurs de fusion par type d'événement
+func (p *Pipeline) CoalesceStats() []CoalesceStats {
+	return p.coalescer.snapshot()
+}
+
+// CoalesceValue retourne le prix, dont le pic est suivi dans la fenêtre
+func (e *PriceChange) CoalesceValue() float64 { return e.Price }
+
+// Coalesce résume les variations de prix de la fenêtre depuis le prix précédant la première
+func (e *PriceChange) Coalesce(first Event, summary *CoalesceSummary) Event {
+	merged := *e
+	if f, ok := first.(*PriceChange); ok {
+		merged.PreviousPrice = f.PreviousPrice
+	}
+	if merged.PreviousPrice > 0 {
+		merged.PriceChange = (merged.Price - merged.PreviousPrice) / merged.PreviousPrice * 100
+		merged.IsPositive = merged.PriceChange > 0
+	}
+	merged.Coalesced = summary
+	return &merged
+}
+
+// CoalesceValue retourne le volume 24h, dont le pic est suivi dans la fenêtre
+func (e *VolumeSpike) CoalesceValue() float64 { return e.Volume24h }
+
+// Coalesce résume les hausses de volume de la fenêtre depuis le volume précédant la première
+func (e *VolumeSpike) Coalesce(first Event, summary *CoalesceSummary) Event {
+	merged := *e
+	if f, ok := first.(*VolumeSpike); ok {
+		merged.PreviousVolume24h = f.PreviousVolume24h
+	}
+	if merged.PreviousVolume24h > 0 {
+		merged.VolumeChange = (merged.Volume24h - merged.PreviousVolume24h) / merged.PreviousVolume24h * 100
+	}
+	merged.Coalesced = summary
+	return &merged
+}
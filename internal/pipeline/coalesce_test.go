@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPipelineCoalescesTokenEvents(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	p.SetCoalescePolicy(CoalescePolicy{
+		Window:     50 * time.Millisecond,
+		MaxWait:    time.Second,
+		EventTypes: []string{EventTypePriceChange},
+	})
+	proc := &recordingProcessor{
+		name:     "prices",
+		subs:     []Subscription{{Stream: StreamTokenEvents}},
+		received: make(chan Message, 10),
+	}
+	p.RegisterProcessor(proc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	prices := []float64{1.0, 1.2, 1.5, 1.3}
+	previous := 0.8
+	for _, price := range prices {
+		p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok", Price: price, PreviousPrice: previous})
+		previous = price
+	}
+	// Un autre token a sa propre fenêtre
+	p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "other", Price: 2, PreviousPrice: 1})
+
+	got := make(map[string]*PriceChange)
+	for len(got) < 2 {
+		select {
+		case msg := <-proc.received:
+			event := msg.Event.(*PriceChange)
+			got[event.TokenAddress] = event
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %d coalesced events, want 2", len(got))
+		}
+	}
+
+	event := got["tok"]
+	if event.Coalesced == nil {
+		t.Fatal("missing coalesce summary")
+	}
+	s := event.Coalesced
+	if s.Count != 4 || s.Suppressed != 3 || s.First != 1.0 || s.Last != 1.3 || s.Peak != 1.5 {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if event.PreviousPrice != 0.8 || event.Price != 1.3 || !event.IsPositive {
+		t.Errorf("unexpected merged event: %+v", event)
+	}
+
+	select {
+	case msg := <-proc.received:
+		t.Errorf("unexpected extra event: %+v", msg.Event)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	stats := p.CoalesceStats()
+	if len(stats) != 1 || stats[0].Received != 5 || stats[0].Emitted != 2 || stats[0].Suppressed != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
@@ -0,0 +1,286 @@
+package pipeline
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Champs réservés d'une entrée de stream contenant un événement typé
+const (
+	fieldEventID       = "event_id"
+	fieldType          = "type"
+	fieldSchemaVersion = "schema_version"
+	fieldTimestamp     = "timestamp"
+	fieldData          = "data"
+)
+
+// Envelope est la représentation sérialisée d'un événement dans un stream
+type Envelope struct {
+	ID        string
+	Type      string
+	Version   int
+	Timestamp time.Time
+	Data      json.RawMessage
+}
+
+// Values convertit l'enveloppe en champs de stream Redis.
+// Les données restent un document JSON unique pour garantir un aller-retour sans perte.
+func (e Envelope) Values() map[string]interface{} {
+	return map[string]interface{}{
+		fieldEventID:       e.ID,
+		fieldType:          e.Type,
+		fieldSchemaVersion: strconv.Itoa(e.Version),
+		fieldTimestamp:     e.Timestamp.UTC().Format(time.RFC3339Nano),
+		fieldData:          string(e.Data),
+	}
+}
+
+// EnvelopeFromValues reconstruit une enveloppe à partir des champs d'une entrée de stream.
+// Le second retour est faux si l'entrée n'a pas été publiée comme événement typé.
+func EnvelopeFromValues(values map[string]interface{}) (Envelope, bool) {
+	rawVersion, ok := values[fieldSchemaVersion]
+	if !ok {
+		return Envelope{}, false
+	}
+	rawData, ok := values[fieldData].(string)
+	if !ok {
+		return Envelope{}, false
+	}
+
+	version, err := strconv.Atoi(fmt.Sprint(rawVersion))
+	if err != nil {
+		return Envelope{}, false
+	}
+
+	env := Envelope{
+		Version: version,
+		Data:    json.RawMessage(rawData),
+	}
+	env.ID, _ = values[fieldEventID].(string)
+	env.Type, _ = values[fieldType].(string)
+	if ts, ok := values[fieldTimestamp].(string); ok {
+		if parsed, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			env.Timestamp = parsed
+		}
+	}
+
+	return env, true
+}
+
+// Upgrader convertit les données d'un événement d'une version vers la suivante
+type Upgrader func(data json.RawMessage) (json.RawMessage, error)
+
+// eventSchema décrit la version courante d'un type d'événement et ses migrations
+type eventSchema struct {
+	version   int
+	factory   func() Event
+	upgraders map[int]Upgrader // version source -> migration vers version source+1
+}
+
+// Registry associe chaque type d'événement à son schéma versionné
+type Registry struct {
+	mu      sync.RWMutex
+	schemas map[string]*eventSchema
+}
+
+// NewRegistry crée un registre de codecs vide
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas: make(map[string]*eventSchema),
+	}
+}
+
+// DefaultRegistry crée un registre contenant tous les événements du pipeline.
+// Les messages historiques non typés (version 0) sont migrés à la lecture.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(EventTypeTokenDetected, 1, func() Event { return &TokenDetected{} })
+	r.Register(EventTypePriceChange, 1, func() Event { return &PriceChange{} })
+	r.Register(EventTypeVolumeSpike, 1, func() Event { return &VolumeSpike{} })
+	r.Register(EventTypeStateChange, 1, func() Event { return &StateChange{} })
+	r.Register(EventTypeReactivation, 1, func() Event { return &Reactivation{} })
+	r.Register(EventTypeAlertRaised, 1, func() Event { return &AlertRaised{} })
+	r.Register(EventTypeXScore, 1, func() Event { return &XScoreCalculated{} })
+
+	for eventType, schema := range r.schemas {
+		r.RegisterUpgrade(eventType, 0, legacyUpgrader(schema.factory))
+	}
+
+	return r
+}
+
+// Register déclare la version courante d'un type d'événement
+func (r *Registry) Register(eventType string, version int, factory func() Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schema, ok := r.schemas[eventType]
+	if !ok {
+		schema = &eventSchema{upgraders: make(map[int]Upgrader)}
+		r.schemas[eventType] = schema
+	}
+	schema.version = version
+	schema.factory = factory
+}
+
+// RegisterUpgrade déclare la migration d'un type d'événement de fromVersion vers fromVersion+1
+func (r *Registry) RegisterUpgrade(eventType string, fromVersion int, upgrader Upgrader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	schema, ok := r.schemas[eventType]
+	if !ok {
+		schema = &eventSchema{upgraders: make(map[int]Upgrader)}
+		r.schemas[eventType] = schema
+	}
+	schema.upgraders[fromVersion] = upgrader
+}
+
+// Types retourne les types d'événements enregistrés
+func (r *Registry) Types() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]string, 0, len(r.schemas))
+	for eventType := range r.schemas {
+		types = append(types, eventType)
+	}
+	return types
+}
+
+// Version retourne la version courante d'un type d'événement
+func (r *Registry) Version(eventType string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.schemas[eventType]
+	if !ok || schema.factory == nil {
+		return 0, false
+	}
+	return schema.version, true
+}
+
+// Encode sérialise un événement dans une enveloppe à la version courante
+func (r *Registry) Encode(event Event) (Envelope, error) {
+	version, ok := r.Version(event.EventType())
+	if !ok {
+		return Envelope{}, fmt.Errorf("unregistered event type %q", event.EventType())
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal %s event: %w", event.EventType(), err)
+	}
+
+	return Envelope{
+		ID:        newEventID(),
+		Type:      event.EventType(),
+		Version:   version,
+		Timestamp: time.Now(),
+		Data:      data,
+	}, nil
+}
+
+// newEventID génère un ID d'événement unique entre instances
+func newEventID() string {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("evt_%d", time.Now().UnixNano())
+	}
+	return fmt.Sprintf("evt_%d_%s", time.Now().UnixNano(), hex.EncodeToString(suffix))
+}
+
+// Decode désérialise une enveloppe en appliquant les migrations nécessaires
+func (r *Registry) Decode(env Envelope) (Event, error) {
+	r.mu.RLock()
+	schema, ok := r.schemas[env.Type]
+	r.mu.RUnlock()
+	if !ok || schema.factory == nil {
+		return nil, fmt.Errorf("unregistered event type %q", env.Type)
+	}
+
+	if env.Version > schema.version {
+		return nil, fmt.Errorf("%s event version %d is newer than supported version %d",
+			env.Type, env.Version, schema.version)
+	}
+
+	data := env.Data
+	for version := env.Version; version < schema.version; version++ {
+		upgrader, ok := schema.upgraders[version]
+		if !ok {
+			return nil, fmt.Errorf("no upgrade path for %s event from version %d", env.Type, version)
+		}
+		upgraded, err := upgrader(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upgrade %s event from version %d: %w", env.Type, version, err)
+		}
+		data = upgraded
+	}
+
+	event := schema.factory()
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event: %w", env.Type, err)
+	}
+
+	return event, nil
+}
+
+// DecodeLegacy décode un payload publié avant l'introduction des schémas (version 0)
+func (r *Registry) DecodeLegacy(eventType string, payload map[string]interface{}) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal legacy payload: %w", err)
+	}
+	return r.Decode(Envelope{Type: eventType, Version: 0, Data: data})
+}
+
+// legacyUpgrader migre un payload de version 0 vers la version 1.
+// Redis renvoie les valeurs simples sous forme de chaînes: elles sont reconverties
+// selon le type du champ cible pour éviter les assertions qui échouent silencieusement.
+func legacyUpgrader(factory func() Event) Upgrader {
+	return func(data json.RawMessage) (json.RawMessage, error) {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return nil, err
+		}
+
+		target := reflect.TypeOf(factory()).Elem()
+		for i := 0; i < target.NumField(); i++ {
+			field := target.Field(i)
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			raw, ok := payload[name].(string)
+			if !ok || name == "" {
+				continue
+			}
+
+			switch field.Type.Kind() {
+			case reflect.Float32, reflect.Float64:
+				if v, err := strconv.ParseFloat(raw, 64); err == nil {
+					payload[name] = v
+				}
+			case reflect.Int, reflect.Int32, reflect.Int64:
+				if v, err := strconv.ParseFloat(raw, 64); err == nil {
+					payload[name] = int64(v)
+				}
+			case reflect.Bool:
+				if v, err := strconv.ParseBool(raw); err == nil {
+					payload[name] = v
+				}
+			case reflect.Map, reflect.Slice:
+				var nested interface{}
+				if err := json.Unmarshal([]byte(raw), &nested); err == nil {
+					payload[name] = nested
+				}
+			}
+		}
+
+		return json.Marshal(payload)
+	}
+}
@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// asRedisValues simule l'encodage Redis: toutes les valeurs reviennent en chaînes
+func asRedisValues(values map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		out[k] = fmt.Sprint(v)
+	}
+	return out
+}
+
+func TestRegistryRoundTrip(t *testing.T) {
+	registry := DefaultRegistry()
+	detectedAt := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.UTC)
+
+	events := []Event{
+		&TokenDetected{TokenAddress: "tok", TokenSymbol: "TOK", MarketCap: 123456.789, HolderCount: 42, DetectedAt: detectedAt},
+		&PriceChange{TokenAddress: "tok", PriceChange: 12.3456789012345, Price: 0.000001234, PreviousPrice: 0.0000011, IsPositive: true},
+		&VolumeSpike{TokenAddress: "tok", VolumeChange: 250.5, Volume24h: 1e9},
+		&StateChange{TokenAddress: "tok", OldState: "DISCOVERED", NewState: "VALIDATED"},
+		&Reactivation{TokenAddress: "tok", ReactivationScore: 81.25, Changes: map[string]float64{"volume": 5.2}, SmartReturns: true, DetectedAt: detectedAt},
+		&AlertRaised{AlertID: "a1", TokenAddress: "tok", AlertType: "HIGH_SCORE", Severity: "URGENT", Message: "m", DetectedAt: detectedAt},
+	}
+
+	for _, event := range events {
+		env, err := registry.Encode(event)
+		if err != nil {
+			t.Fatalf("Encode(%s): %v", event.EventType(), err)
+		}
+
+		decodedEnv, ok := EnvelopeFromValues(asRedisValues(env.Values()))
+		if !ok {
+			t.Fatalf("EnvelopeFromValues(%s) failed", event.EventType())
+		}
+		if decodedEnv.Version != env.Version || decodedEnv.ID != env.ID || !decodedEnv.Timestamp.Equal(env.Timestamp) {
+			t.Errorf("%s envelope mismatch: got %+v want %+v", event.EventType(), decodedEnv, env)
+		}
+
+		decoded, err := registry.Decode(decodedEnv)
+		if err != nil {
+			t.Fatalf("Decode(%s): %v", event.EventType(), err)
+		}
+		if !reflect.DeepEqual(decoded, event) {
+			t.Errorf("%s round trip mismatch:\n got %#v\nwant %#v", event.EventType(), decoded, event)
+		}
+	}
+}
+
+func TestRegistryUpgradesLegacyPayload(t *testing.T) {
+	registry := DefaultRegistry()
+
+	// Payload tel que relu depuis Redis avant l'introduction des schémas
+	event, err := registry.DecodeLegacy(EventTypePriceChange, map[string]interface{}{
+		"token_address": "tok",
+		"price_change":  "7.5",
+		"is_positive":   "true",
+	})
+	if err != nil {
+		t.Fatalf("DecodeLegacy: %v", err)
+	}
+
+	priceChange, ok := event.(*PriceChange)
+	if !ok {
+		t.Fatalf("decoded %T, want *PriceChange", event)
+	}
+	if priceChange.PriceChange != 7.5 || !priceChange.IsPositive {
+		t.Errorf("legacy upgrade lost values: %+v", priceChange)
+	}
+}
+
+func TestRegistryRejectsNewerVersion(t *testing.T) {
+	registry := DefaultRegistry()
+	_, err := registry.Decode(Envelope{Type: EventTypeStateChange, Version: 99, Data: []byte(`{}`)})
+	if err == nil {
+		t.Fatal("expected error for unsupported future version")
+	}
+}
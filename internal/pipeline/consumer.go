@@ -0,0 +1,710 @@
+package pipeline
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// lagScanLimit borne le nombre de messages parcourus pour mesurer le retard d'une partition
+const lagScanLimit = 1000
+
+// ConsumerOptions contrôle la consommation distribuée des streams
+type ConsumerOptions struct {
+	ConsumerID string        // Identité de l'instance dans les groupes de consommateurs
+	Workers    int           // Lots traités simultanément par processeur
+	Partitions int           // Partitions par stream, routées par token_address
+	BatchSize  int           // Messages lus par appel XREADGROUP
+	LeaseTTL   time.Duration // Durée de détention d'une partition sans renouvellement
+
+	ShutdownTimeout time.Duration // Attente maximale des lots en cours à l'arrêt
+	IdempotencyTTL  time.Duration // Rétention des clés des messages déjà traités
+
+	ScheduleInterval  time.Duration // Fréquence de publication des messages différés échus
+	RetentionInterval time.Duration // Fréquence d'application des politiques de rétention
+}
+
+// DefaultConsumerOptions retourne les options de consommation par défaut
+func DefaultConsumerOptions() ConsumerOptions {
+	return ConsumerOptions{
+		ConsumerID: NewConsumerID(),
+		Workers:    1,
+		Partitions: 1,
+		BatchSize:  10,
+		LeaseTTL:   15 * time.Second,
+
+		ShutdownTimeout: 10 * time.Second,
+		IdempotencyTTL:  24 * time.Hour,
+
+		ScheduleInterval:  time.Second,
+		RetentionInterval: 5 * time.Minute,
+	}
+}
+
+// ConsumerOptionsFromConfig construit les options de consommation à partir de la configuration
+func ConsumerOptionsFromConfig(cfg *config.PipelineConfig) ConsumerOptions {
+	opts := DefaultConsumerOptions()
+	if cfg == nil {
+		return opts
+	}
+	if cfg.ConsumerID != "" {
+		opts.ConsumerID = cfg.ConsumerID
+	}
+	if cfg.Workers > 0 {
+		opts.Workers = cfg.Workers
+	}
+	if cfg.Partitions > 0 {
+		opts.Partitions = cfg.Partitions
+	}
+	if cfg.BatchSize > 0 {
+		opts.BatchSize = cfg.BatchSize
+	}
+	if cfg.LeaseTTLMs > 0 {
+		opts.LeaseTTL = time.Duration(cfg.LeaseTTLMs) * time.Millisecond
+	}
+	if cfg.ShutdownTimeoutMs > 0 {
+		opts.ShutdownTimeout = time.Duration(cfg.ShutdownTimeoutMs) * time.Millisecond
+	}
+	if cfg.IdempotencyTTLMs > 0 {
+		opts.IdempotencyTTL = time.Duration(cfg.IdempotencyTTLMs) * time.Millisecond
+	}
+	if cfg.ScheduleIntervalMs > 0 {
+		opts.ScheduleInterval = time.Duration(cfg.ScheduleIntervalMs) * time.Millisecond
+	}
+	if cfg.RetentionIntervalMs > 0 {
+		opts.RetentionInterval = time.Duration(cfg.RetentionIntervalMs) * time.Millisecond
+	}
+	return opts
+}
+
+// NewConsumerID génère une identité unique pour l'instance: hostname-pid-aléatoire
+func NewConsumerID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "oracle"
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
+	}
+
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+// SetConsumerOptions remplace les options de consommation; à appeler avant Start
+func (p *Pipeline) SetConsumerOptions(opts ConsumerOptions) {
+	if opts.ConsumerID == "" {
+		opts.ConsumerID = NewConsumerID()
+	}
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.Partitions < 1 {
+		opts.Partitions = 1
+	}
+	if opts.BatchSize < 1 {
+		opts.BatchSize = 10
+	}
+	if opts.LeaseTTL <= 0 {
+		opts.LeaseTTL = 15 * time.Second
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 10 * time.Second
+	}
+	if opts.IdempotencyTTL <= 0 {
+		opts.IdempotencyTTL = 24 * time.Hour
+	}
+	if opts.ScheduleInterval <= 0 {
+		opts.ScheduleInterval = time.Second
+	}
+	if opts.RetentionInterval <= 0 {
+		opts.RetentionInterval = 5 * time.Minute
+	}
+	p.consumerOpts = opts
+}
+
+// ConsumerID retourne l'identité de l'instance dans les groupes de consommateurs
+func (p *Pipeline) ConsumerID() string {
+	return p.consumerOpts.ConsumerID
+}
+
+// PartitionFor retourne la partition d'un token. Tous les messages d'un même token
+// tombent dans la même partition, ce qui garantit leur ordre de traitement.
+func PartitionFor(tokenAddress string, partitions int) int {
+	if partitions <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(tokenAddress))
+	return int(h.Sum32() % uint32(partitions))
+}
+
+// PartitionStream retourne le nom du stream Redis d'une partition.
+// Sans partitionnement, le stream logique est utilisé tel quel.
+func PartitionStream(streamName string, partition, partitions int) string {
+	if partitions <= 1 {
+		return streamName
+	}
+	return fmt.Sprintf("%s:p%d", streamName, partition)
+}
+
+// routeStream retourne le stream de partition où publier un message d'un token
+func (p *Pipeline) routeStream(streamName, tokenAddress string) string {
+	n := p.consumerOpts.Partitions
+	return PartitionStream(streamName, PartitionFor(tokenAddress, n), n)
+}
+
+// partition identifie un stream de partition et son stream logique
+type partition struct {
+	stream  string
+	logical string
+	index   int
+}
+
+// partitionsOf liste les partitions d'un stream logique
+func (p *Pipeline) partitionsOf(streamName string) []partition {
+	n := p.consumerOpts.Partitions
+	parts := make([]partition, n)
+	for i := 0; i < n; i++ {
+		parts[i] = partition{
+			stream:  PartitionStream(streamName, i, n),
+			logical: streamName,
+			index:   i,
+		}
+	}
+	return parts
+}
+
+// tokenAddressFromValues extrait le token_address d'une entrée de stream, typée ou historique
+func tokenAddressFromValues(values map[string]interface{}) string {
+	if env, ok := EnvelopeFromValues(values); ok {
+		var data struct {
+			TokenAddress string `json:"token_address"`
+		}
+		if err := json.Unmarshal(env.Data, &data); err == nil {
+			return data.TokenAddress
+		}
+		return ""
+	}
+
+	if addr, ok := values["token_address"].(string); ok {
+		return addr
+	}
+	if raw, ok := values["payload"].(string); ok {
+		var payload map[string]interface{}
+		if err := json.Unmarshal([]byte(raw), &payload); err == nil {
+			addr, _ := payload["token_address"].(string)
+			return addr
+		}
+	}
+	return ""
+}
+
+// leaseKey retourne la clé du bail d'une partition pour un groupe
+func leaseKey(group, stream string) string {
+	return "pipeline:lease:" + group + ":" + stream
+}
+
+// membersKey retourne la clé des instances actives d'un groupe sur un stream
+func membersKey(stream, group string) string {
+	return "pipeline:consumers:" + stream + ":" + group
+}
+
+// ownedPartition est une partition détenue par l'instance
+type ownedPartition struct {
+	part      partition
+	cancel    context.CancelFunc
+	done      chan struct{}
+	releasing bool // Arrêt demandé, le bail est libéré une fois le lot en cours terminé
+}
+
+// consumerGroup répartit les partitions d'un stream entre les instances d'un groupe.
+// Chaque partition est détenue par une seule instance via un bail Redis et consommée
+// par une seule goroutine, ce qui préserve l'ordre des messages d'un même token.
+type consumerGroup struct {
+	pipeline  *Pipeline
+	stream    string
+	sub       Subscription
+	processor Processor
+	handler   Processor // processor enveloppé dans ses middlewares
+	parts     []partition
+	slots     chan struct{} // Partagé par les abonnements d'un processeur, limité au nombre de workers
+	counters  *groupCounters
+
+	mu    sync.RWMutex
+	owned map[int]*ownedPartition
+}
+
+// newConsumerGroup crée le gestionnaire de partitions d'un abonnement de processeur
+func newConsumerGroup(p *Pipeline, sub Subscription, processor, handler Processor, slots chan struct{}) *consumerGroup {
+	return &consumerGroup{
+		pipeline:  p,
+		stream:    sub.Stream,
+		sub:       sub,
+		processor: processor,
+		handler:   handler,
+		parts:     p.partitionsOf(sub.Stream),
+		slots:     slots,
+		counters:  newGroupCounters(),
+		owned:     make(map[int]*ownedPartition),
+	}
+}
+
+// run crée les groupes Redis puis maintient les baux jusqu'à l'arrêt du pipeline
+func (g *consumerGroup) run(ctx context.Context) {
+	p := g.pipeline
+	group := g.processor.GetName()
+
+	p.logger.WithFields(logrus.Fields{
+		"stream":      g.stream,
+		"processor":   group,
+		"consumer_id": p.consumerOpts.ConsumerID,
+		"partitions":  len(g.parts),
+		"workers":     p.consumerOpts.Workers,
+	}).Info("Starting consumer group")
+
+	// Créer les consumer groups si n'existent pas
+	for _, part := range g.parts {
+		if err := p.broker.CreateGroup(part.stream, group); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"stream":    part.stream,
+				"processor": group,
+				"error":     err.Error(),
+			}).Error("Failed to create consumer group")
+			return
+		}
+	}
+
+	ticker := time.NewTicker(p.consumerOpts.LeaseTTL / 3)
+	defer ticker.Stop()
+	statsTicker := time.NewTicker(time.Minute)
+	defer statsTicker.Stop()
+
+	g.rebalance(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			g.stopAll()
+			return
+		case <-ticker.C:
+			g.rebalance(ctx)
+		case <-statsTicker.C:
+			stats := p.groupStats(g)
+			p.logger.WithFields(logrus.Fields{
+				"stream":           stats.Stream,
+				"processor":        stats.Group,
+				"owned_partitions": stats.OwnedPartitions,
+				"consumers":        stats.Consumers,
+				"pending":          stats.Pending,
+				"lag":              stats.Lag,
+				"lag_seconds":      stats.LagSeconds,
+				"skipped":          stats.Skipped,
+				"throughput":       stats.Throughput,
+			}).Info("Consumer group stats")
+		}
+	}
+}
+
+// rebalance signale la présence de l'instance, renouvelle ses baux et ajuste le nombre
+// de partitions détenues à sa part équitable: ceil(partitions / instances actives)
+func (g *consumerGroup) rebalance(ctx context.Context) {
+	p := g.pipeline
+	group := g.processor.GetName()
+	consumerID := p.consumerOpts.ConsumerID
+	ttl := p.consumerOpts.LeaseTTL
+
+	// Heartbeat et purge des instances disparues
+	live, err := p.broker.Heartbeat(membersKey(g.stream, group), consumerID, ttl)
+	if err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"processor": group,
+			"error":     err.Error(),
+		}).Error("Failed to publish consumer heartbeat")
+	}
+	if live < 1 {
+		live = 1
+	}
+	target := (len(g.parts) + int(live) - 1) / int(live)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	active := make([]int, 0, len(g.owned))
+	for idx, o := range g.owned {
+		if o.releasing {
+			select {
+			case <-o.done:
+				p.broker.ReleaseLease(leaseKey(group, o.part.stream), consumerID)
+				delete(g.owned, idx)
+			default:
+				p.broker.RefreshLease(leaseKey(group, o.part.stream), consumerID, ttl)
+			}
+			continue
+		}
+
+		ok, err := p.broker.RefreshLease(leaseKey(group, o.part.stream), consumerID, ttl)
+		if err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"stream":    o.part.stream,
+				"processor": group,
+				"error":     err.Error(),
+			}).Error("Failed to refresh partition lease")
+			active = append(active, idx)
+			continue
+		}
+		if !ok {
+			// Bail expiré et repris par une autre instance
+			p.logger.WithFields(logrus.Fields{
+				"stream":    o.part.stream,
+				"processor": group,
+			}).Warn("Partition lease lost")
+			o.cancel()
+			o.releasing = true
+			continue
+		}
+		active = append(active, idx)
+	}
+
+	// Céder les partitions en excès, en commençant par les plus hautes
+	if len(active) > target {
+		sort.Ints(active)
+		for _, idx := range active[target:] {
+			o := g.owned[idx]
+			o.cancel()
+			o.releasing = true
+		}
+		return
+	}
+
+	// Acquérir les partitions libres, en partant d'un décalage propre à l'instance
+	held := len(active)
+	offset := PartitionFor(consumerID, len(g.parts))
+	for i := 0; i < len(g.parts) && held < target; i++ {
+		part := g.parts[(offset+i)%len(g.parts)]
+		if _, ok := g.owned[part.index]; ok {
+			continue
+		}
+
+		acquired, err := p.broker.AcquireLease(leaseKey(group, part.stream), consumerID, ttl)
+		if err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"stream":    part.stream,
+				"processor": group,
+				"error":     err.Error(),
+			}).Error("Failed to acquire partition lease")
+			continue
+		}
+		if !acquired {
+			continue
+		}
+
+		g.start(ctx, part)
+		held++
+	}
+}
+
+// start lance la consommation d'une partition acquise. Appelé sous g.mu.
+func (g *consumerGroup) start(ctx context.Context, part partition) {
+	pctx, cancel := context.WithCancel(ctx)
+	o := &ownedPartition{
+		part:   part,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	g.owned[part.index] = o
+
+	g.pipeline.wg.Add(1)
+	go func() {
+		defer g.pipeline.wg.Done()
+		defer close(o.done)
+		g.pipeline.consumePartition(pctx, part, g)
+	}()
+}
+
+// stopAll arrête toutes les partitions, libère les baux et retire l'instance du groupe
+func (g *consumerGroup) stopAll() {
+	p := g.pipeline
+	group := g.processor.GetName()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, o := range g.owned {
+		o.cancel()
+	}
+	for idx, o := range g.owned {
+		<-o.done
+		p.broker.ReleaseLease(leaseKey(group, o.part.stream), p.consumerOpts.ConsumerID)
+		delete(g.owned, idx)
+	}
+	p.broker.Leave(membersKey(g.stream, group), p.consumerOpts.ConsumerID)
+}
+
+// ownedIndexes retourne les partitions activement consommées par l'instance
+func (g *consumerGroup) ownedIndexes() []int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	indexes := make([]int, 0, len(g.owned))
+	for idx, o := range g.owned {
+		if !o.releasing {
+			indexes = append(indexes, idx)
+		}
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// consumePartition lit et traite séquentiellement les messages d'une partition détenue
+func (p *Pipeline) consumePartition(ctx context.Context, part partition, g *consumerGroup) {
+	group := g.processor.GetName()
+	consumerID := p.consumerOpts.ConsumerID
+
+	p.logger.WithFields(logrus.Fields{
+		"stream":      part.stream,
+		"processor":   group,
+		"consumer_id": consumerID,
+	}).Info("Partition acquired")
+
+	lastReclaim := time.Now()
+
+	// Ne pas bloquer la lecture plus longtemps que l'intervalle de reprise
+	block := 1 * time.Second
+	if p.retryPolicy.ReclaimInterval < block {
+		block = p.retryPolicy.ReclaimInterval
+	}
+
+	for {
+		// Le lot en cours est toujours terminé avant de rendre la partition
+		if ctx.Err() != nil {
+			p.logger.WithFields(logrus.Fields{
+				"stream":    part.stream,
+				"processor": group,
+			}).Info("Partition released")
+			return
+		}
+
+		// Lire les messages
+		messages, err := p.broker.ReadGroup(part.stream, group, consumerID, p.consumerOpts.BatchSize, block)
+		if err != nil {
+			// Ignorer les timeouts (cas normal quand pas de messages)
+			if !errors.Is(err, redis.Nil) {
+				p.logger.WithFields(logrus.Fields{
+					"stream":    part.stream,
+					"processor": group,
+					"error":     err.Error(),
+				}).Error("Error reading from stream")
+			}
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		// Traiter le lot dans l'ordre du stream
+		if len(messages) > 0 {
+			g.slots <- struct{}{}
+			for _, msg := range messages {
+				p.handleMessage(part, g, msg, 1)
+			}
+			<-g.slots
+		}
+
+		if time.Since(lastReclaim) >= p.retryPolicy.ReclaimInterval {
+			g.slots <- struct{}{}
+			p.reclaimPending(part, g)
+			<-g.slots
+			lastReclaim = time.Now()
+		}
+	}
+}
+
+// groupCounters accumule les compteurs de traitement d'un groupe sur cette instance
+type groupCounters struct {
+	processed    int64
+	skipped      int64 // Acquittés sans traitement, hors filtre de l'abonnement
+	duplicates   int64 // Relivraisons ignorées par un processeur idempotent
+	failed       int64
+	deadLettered int64
+	rate         *rateCounter
+}
+
+// newGroupCounters crée des compteurs vides
+func newGroupCounters() *groupCounters {
+	return &groupCounters{rate: &rateCounter{}}
+}
+
+// rateCounter mesure un débit sur une fenêtre glissante de 60 secondes
+type rateCounter struct {
+	mu      sync.Mutex
+	buckets [60]int64
+	seconds [60]int64
+}
+
+// add comptabilise n événements à la seconde courante
+func (r *rateCounter) add(n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sec := time.Now().Unix()
+	i := sec % 60
+	if r.seconds[i] != sec {
+		r.seconds[i] = sec
+		r.buckets[i] = 0
+	}
+	r.buckets[i] += n
+}
+
+// perSecond retourne le débit moyen sur la dernière minute
+func (r *rateCounter) perSecond() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now().Unix()
+	var total int64
+	for i := range r.buckets {
+		if now-r.seconds[i] < 60 {
+			total += r.buckets[i]
+		}
+	}
+	return float64(total) / 60
+}
+
+// GroupStats décrit l'état d'un groupe de consommateurs
+type GroupStats struct {
+	Group           string  `json:"group"`
+	Stream          string  `json:"stream"`
+	ConsumerID      string  `json:"consumer_id"`
+	Consumers       int64   `json:"consumers"` // Instances actives du groupe
+	Workers         int     `json:"workers"`
+	Partitions      int     `json:"partitions"`
+	OwnedPartitions []int   `json:"owned_partitions"`
+	Pending         int64   `json:"pending"`     // Livrés mais non acquittés, toutes instances
+	Lag             int64   `json:"lag"`         // Non encore livrés, plafonné par partition
+	LagSeconds      float64 `json:"lag_seconds"` // Âge du plus ancien message non livré
+	Processed       int64   `json:"processed"`   // Compteurs locaux à l'instance
+	Skipped         int64   `json:"skipped"`
+	Duplicates      int64   `json:"duplicates"`
+	Failed          int64   `json:"failed"`
+	DeadLettered    int64   `json:"dead_lettered"`
+	Throughput      float64 `json:"throughput"` // Messages/s traités sur la dernière minute
+}
+
+// Stats retourne le retard et le débit de chaque groupe de consommateurs
+func (p *Pipeline) Stats() []GroupStats {
+	p.groupsMu.RLock()
+	groups := make([]*consumerGroup, 0, len(p.groups))
+	for _, g := range p.groups {
+		groups = append(groups, g)
+	}
+	p.groupsMu.RUnlock()
+
+	stats := make([]GroupStats, 0, len(groups))
+	for _, g := range groups {
+		stats = append(stats, p.groupStats(g))
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Stream != stats[j].Stream {
+			return stats[i].Stream < stats[j].Stream
+		}
+		return stats[i].Group < stats[j].Group
+	})
+
+	return stats
+}
+
+// groupStats mesure l'état d'un groupe à partir de Redis et des compteurs locaux
+func (p *Pipeline) groupStats(g *consumerGroup) GroupStats {
+	group := g.processor.GetName()
+	c := g.counters
+
+	stats := GroupStats{
+		Group:           group,
+		Stream:          g.stream,
+		ConsumerID:      p.consumerOpts.ConsumerID,
+		Workers:         p.consumerOpts.Workers,
+		Partitions:      len(g.parts),
+		OwnedPartitions: g.ownedIndexes(),
+		Processed:       atomic.LoadInt64(&c.processed),
+		Skipped:         atomic.LoadInt64(&c.skipped),
+		Duplicates:      atomic.LoadInt64(&c.duplicates),
+		Failed:          atomic.LoadInt64(&c.failed),
+		DeadLettered:    atomic.LoadInt64(&c.deadLettered),
+		Throughput:      c.rate.perSecond(),
+	}
+
+	if live, err := p.broker.Members(membersKey(g.stream, group), p.consumerOpts.LeaseTTL); err == nil {
+		stats.Consumers = live
+	}
+
+	now := time.Now()
+	for _, part := range g.parts {
+		infos, err := p.broker.Groups(part.stream)
+		if err != nil {
+			continue
+		}
+
+		for _, info := range infos {
+			if info.Name != group {
+				continue
+			}
+			stats.Pending += info.Pending
+
+			lag, oldest := p.partitionLag(part.stream, info.LastDeliveredID)
+			stats.Lag += lag
+			if !oldest.IsZero() {
+				if age := now.Sub(oldest).Seconds(); age > stats.LagSeconds {
+					stats.LagSeconds = age
+				}
+			}
+		}
+	}
+
+	return stats
+}
+
+// partitionLag compte les messages postérieurs au dernier message livré au groupe
+// et retourne la date du plus ancien d'entre eux
+func (p *Pipeline) partitionLag(stream, lastDeliveredID string) (int64, time.Time) {
+	messages, err := p.broker.Range(stream, lastDeliveredID, "+", lagScanLimit+1)
+	if err != nil {
+		return 0, time.Time{}
+	}
+
+	// XRANGE inclut la borne de départ
+	if len(messages) > 0 && messages[0].ID == lastDeliveredID {
+		messages = messages[1:]
+	}
+	if len(messages) > lagScanLimit {
+		messages = messages[:lagScanLimit]
+	}
+	if len(messages) == 0 {
+		return 0, time.Time{}
+	}
+
+	return int64(len(messages)), streamIDTime(messages[0].ID)
+}
+
+// streamIDTime retourne la date encodée dans un ID de stream Redis (<ms>-<seq>)
+func streamIDTime(id string) time.Time {
+	ms, err := strconv.ParseInt(strings.SplitN(id, "-", 2)[0], 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
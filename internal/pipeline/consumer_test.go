@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"testing"
+)
+
+func TestPartitionForIsStable(t *testing.T) {
+	const partitions = 8
+	seen := make(map[int]bool)
+
+	for _, token := range []string{"tokA", "tokB", "tokC", "tokD", "tokE", "tokF", "tokG", "tokH"} {
+		first := PartitionFor(token, partitions)
+		if first < 0 || first >= partitions {
+			t.Fatalf("PartitionFor(%q) = %d, out of range", token, first)
+		}
+		if again := PartitionFor(token, partitions); again != first {
+			t.Errorf("PartitionFor(%q) not stable: %d then %d", token, first, again)
+		}
+		seen[first] = true
+	}
+
+	if len(seen) < 2 {
+		t.Errorf("tokens all routed to a single partition: %v", seen)
+	}
+	if got := PartitionStream("token_events", 3, 1); got != "token_events" {
+		t.Errorf("unpartitioned stream renamed to %q", got)
+	}
+	if got := PartitionStream("token_events", 3, 8); got != "token_events:p3" {
+		t.Errorf("PartitionStream = %q, want token_events:p3", got)
+	}
+}
+
+func TestTokenAddressFromValues(t *testing.T) {
+	env, err := DefaultRegistry().Encode(&PriceChange{TokenAddress: "typed"})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	cases := map[string]map[string]interface{}{
+		"typed":  asRedisValues(env.Values()),
+		"flat":   {"type": "price_change", "token_address": "flat"},
+		"nested": {"type": "price_change", "payload": `{"token_address":"nested"}`},
+	}
+	for want, values := range cases {
+		if got := tokenAddressFromValues(values); got != want {
+			t.Errorf("tokenAddressFromValues(%s) = %q", want, got)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+// retentionLeaseKey désigne l'instance qui applique les politiques de rétention
+const retentionLeaseKey = "pipeline:lease:retention"
+
+// replayBatchSize est le nombre de messages lus par partition et par page lors d'un rejeu
+const replayBatchSize = 500
+
+// RetentionPolicy borne la taille d'un stream. Les streams sans politique ne sont
+// jamais élagués: ils forment le journal complet à partir duquel l'état est reconstruit.
+type RetentionPolicy struct {
+	MaxLen int64         // Messages conservés par partition, 0 = sans limite
+	MaxAge time.Duration // Âge maximal des messages, 0 = sans limite
+}
+
+// RetentionPoliciesFromConfig construit les politiques de rétention par stream logique
+func RetentionPoliciesFromConfig(cfg *config.PipelineConfig) map[string]RetentionPolicy {
+	policies := make(map[string]RetentionPolicy)
+	if cfg == nil {
+		return policies
+	}
+	for stream, r := range cfg.Retention {
+		policies[stream] = RetentionPolicy{
+			MaxLen: r.MaxLen,
+			MaxAge: time.Duration(r.MaxAgeHours) * time.Hour,
+		}
+	}
+	return policies
+}
+
+// SetRetention définit la politique de rétention d'un stream logique et de ses partitions
+func (p *Pipeline) SetRetention(streamName string, policy RetentionPolicy) {
+	p.retentionMu.Lock()
+	defer p.retentionMu.Unlock()
+	p.retention[streamName] = policy
+}
+
+// ApplyRetention élague les streams selon leurs politiques et retourne le nombre de messages supprimés
+func (p *Pipeline) ApplyRetention() (int64, error) {
+	p.retentionMu.RLock()
+	policies := make(map[string]RetentionPolicy, len(p.retention))
+	for stream, policy := range p.retention {
+		policies[stream] = policy
+	}
+	p.retentionMu.RUnlock()
+
+	var total int64
+	for stream, policy := range policies {
+		minID := ""
+		if policy.MaxAge > 0 {
+			minID = strconv.FormatInt(time.Now().Add(-policy.MaxAge).UnixMilli(), 10) + "-0"
+		}
+		if minID == "" && policy.MaxLen <= 0 {
+			continue
+		}
+
+		for _, target := range p.physicalStreams(stream) {
+			n, err := p.broker.Trim(target, policy.MaxLen, minID)
+			if err != nil {
+				return total, fmt.Errorf("failed to trim %s: %w", target, err)
+			}
+			total += n
+			if n > 0 {
+				p.logger.WithFields(logrus.Fields{
+					"stream":  target,
+					"trimmed": n,
+				}).Info("Stream trimmed by retention policy")
+			}
+		}
+	}
+	return total, nil
+}
+
+// physicalStreams retourne les streams Redis d'un stream logique.
+// Les streams de dead-letter ne sont pas partitionnés.
+func (p *Pipeline) physicalStreams(streamName string) []string {
+	if strings.HasSuffix(streamName, deadLetterSuffix) {
+		return []string{streamName}
+	}
+	streams := make([]string, 0, p.consumerOpts.Partitions)
+	for _, part := range p.partitionsOf(streamName) {
+		streams = append(streams, part.stream)
+	}
+	return streams
+}
+
+// runRetention applique les politiques de rétention tant que l'instance détient leur bail
+func (p *Pipeline) runRetention(ctx context.Context) {
+	owner := p.consumerOpts.ConsumerID
+	interval := p.consumerOpts.RetentionInterval
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Le bail couvre l'intervalle: une seule instance élague par période
+		held, err := p.broker.AcquireLease(retentionLeaseKey, owner, interval)
+		if err != nil {
+			p.logger.WithField("error", err.Error()).Warn("Failed to acquire retention lease")
+			continue
+		}
+		if !held {
+			continue
+		}
+		if _, err := p.ApplyRetention(); err != nil {
+			p.logger.WithField("error", err.Error()).Error("Failed to apply retention policies")
+		}
+	}
+}
+
+// EventPage est une page du journal d'un stream logique, toutes partitions confondues
+type EventPage struct {
+	Stream string    `json:"stream"`
+	Events []Message `json:"events"`
+	Next   string    `json:"next"` // Offset à passer pour lire la page suivante
+}
+
+// ReadEvents lit au plus limit messages d'un stream logique d'offset strictement
+// supérieur à after ("" ou "0" pour le début du journal), par offset croissant.
+// L'offset d'un message est son ID de stream; les partitions sont fusionnées.
+func (p *Pipeline) ReadEvents(streamName, after string, limit int) (*EventPage, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	start, err := nextOffset(after)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &EventPage{Stream: streamName, Events: make([]Message, 0), Next: after}
+
+	merged := make([]Message, 0)
+	for _, part := range p.partitionsOf(streamName) {
+		messages, err := p.broker.Range(part.stream, start, "+", limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", part.stream, err)
+		}
+		for _, msg := range messages {
+			message := p.decodeMessage(msg)
+			message.Stream = part.stream
+			merged = append(merged, message)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return CompareOffsets(merged[i].ID, merged[j].ID) < 0
+	})
+
+	// Ne pas couper entre deux messages de même offset issus de partitions différentes
+	for i, message := range merged {
+		if i >= limit && message.ID != merged[i-1].ID {
+			break
+		}
+		page.Events = append(page.Events, message)
+		page.Next = message.ID
+	}
+	return page, nil
+}
+
+// Replay rejoue dans l'ordre des offsets les messages d'un stream logique postérieurs
+// à after et retourne le dernier offset traité. Le rejeu s'arrête à la première erreur.
+func (p *Pipeline) Replay(streamName, after string, handle func(message Message) error) (string, error) {
+	offset := after
+	for {
+		page, err := p.ReadEvents(streamName, offset, replayBatchSize)
+		if err != nil {
+			return offset, err
+		}
+		for _, message := range page.Events {
+			if err := handle(message); err != nil {
+				return offset, fmt.Errorf("replay stopped at %s: %w", message.ID, err)
+			}
+			offset = message.ID
+		}
+		if len(page.Events) == 0 {
+			return offset, nil
+		}
+	}
+}
+
+// TokenEvents retourne l'historique complet des messages d'un token dans un stream
+// logique, lu dans la seule partition qui les contient
+func (p *Pipeline) TokenEvents(streamName, tokenAddress string) ([]Message, error) {
+	target := p.routeStream(streamName, tokenAddress)
+	events := make([]Message, 0)
+
+	start := "-"
+	for {
+		messages, err := p.broker.Range(target, start, "+", replayBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", target, err)
+		}
+		for _, msg := range messages {
+			if tokenAddressFromValues(msg.Values) != tokenAddress {
+				continue
+			}
+			message := p.decodeMessage(msg)
+			message.Stream = target
+			events = append(events, message)
+		}
+		if len(messages) < replayBatchSize {
+			return events, nil
+		}
+		if start, err = nextOffset(messages[len(messages)-1].ID); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// CompareOffsets compare deux offsets de stream: -1, 0 ou 1.
+// Un offset vide précède tous les autres.
+func CompareOffsets(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return -1
+	}
+	if b == "" {
+		return 1
+	}
+	ida, erra := parseStreamID(a, false)
+	idb, errb := parseStreamID(b, false)
+	if erra != nil || errb != nil {
+		if a < b {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case ida.less(idb):
+		return -1
+	case idb.less(ida):
+		return 1
+	}
+	return 0
+}
+
+// OffsetBefore retourne un offset qui précède tous les messages publiés à partir de at,
+// pour lire un stream depuis un instant donné avec ReadEvents
+func OffsetBefore(at time.Time) string {
+	// La séquence maximale moins un: nextOffset l'incrémente sans déborder
+	return streamID{ms: at.UnixMilli() - 1, seq: 1<<63 - 2}.String()
+}
+
+// nextOffset retourne la borne de lecture inclusive suivant l'offset after
+func nextOffset(after string) (string, error) {
+	if after == "" || after == "0" || after == "-" {
+		return "-", nil
+	}
+	id, err := parseStreamID(after, false)
+	if err != nil {
+		return "", err
+	}
+	id.seq++
+	return id.String(), nil
+}
@@ -0,0 +1,76 @@
+package pipeline
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReadEventsMergesPartitionsInOffsetOrder(t *testing.T) {
+	p := newTestPipeline(t, 4)
+	for i := 0; i < 10; i++ {
+		token := fmt.Sprintf("tok%d", i%3)
+		if err := p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: token, Price: float64(i)}); err != nil {
+			t.Fatalf("PublishEvent: %v", err)
+		}
+	}
+
+	var prices []float64
+	offset := ""
+	for {
+		page, err := p.ReadEvents(StreamTokenEvents, offset, 3)
+		if err != nil {
+			t.Fatalf("ReadEvents: %v", err)
+		}
+		if len(page.Events) == 0 {
+			break
+		}
+		for _, message := range page.Events {
+			prices = append(prices, message.Event.(*PriceChange).Price)
+		}
+		offset = page.Next
+	}
+
+	if len(prices) != 10 {
+		t.Fatalf("read %d events, want 10", len(prices))
+	}
+	for i, price := range prices {
+		if price != float64(i) {
+			t.Fatalf("event %d out of order: %v", i, prices)
+		}
+	}
+
+	history, err := p.TokenEvents(StreamTokenEvents, "tok1")
+	if err != nil {
+		t.Fatalf("TokenEvents: %v", err)
+	}
+	if len(history) != 3 {
+		t.Errorf("tok1 has %d events, want 3", len(history))
+	}
+}
+
+func TestApplyRetentionTrimsOldMessages(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	for i := 0; i < 5; i++ {
+		p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok", Price: float64(i)})
+	}
+
+	p.SetRetention(StreamTokenEvents, RetentionPolicy{MaxLen: 2})
+	trimmed, err := p.ApplyRetention()
+	if err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if trimmed != 3 {
+		t.Errorf("trimmed %d messages, want 3", trimmed)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	p.SetRetention(StreamTokenEvents, RetentionPolicy{MaxAge: time.Millisecond})
+	if _, err := p.ApplyRetention(); err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	page, _ := p.ReadEvents(StreamTokenEvents, "", 10)
+	if len(page.Events) != 0 {
+		t.Errorf("%d messages left after age-based trim", len(page.Events))
+	}
+}
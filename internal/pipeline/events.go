@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"time"
+)
+
+// StreamTokenEvents est le stream où le moteur de tokens publie ses événements
+const StreamTokenEvents = "token_events"
+
+// Types d'événements publiés dans le pipeline
+const (
+	EventTypeTokenDetected = "token_detected"
+	EventTypePriceChange   = "price_change"
+	EventTypeVolumeSpike   = "volume_spike"
+	EventTypeStateChange   = "state_change"
+	EventTypeReactivation  = "reactivation"
+	EventTypeAlertRaised   = "alert_raised"
+	EventTypeXScore        = "xscore_calculated"
+)
+
+// Event est un événement typé et versionné du pipeline
+type Event interface {
+	EventType() string
+	GetTokenAddress() string
+}
+
+// TokenDetected est émis lorsqu'un nouveau token est détecté
+type TokenDetected struct {
+	TokenAddress string    `json:"token_address"`
+	TokenSymbol  string    `json:"token_symbol"`
+	TokenName    string    `json:"token_name,omitempty"`
+	MarketCap    float64   `json:"market_cap"`
+	HolderCount  int       `json:"holder_count"`
+	Source       string    `json:"source,omitempty"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// PriceChange est émis lors d'un mouvement de prix significatif
+type PriceChange struct {
+	TokenAddress  string  `json:"token_address"`
+	TokenSymbol   string  `json:"token_symbol"`
+	PriceChange   float64 `json:"price_change"` // En pourcentage
+	Price         float64 `json:"price"`
+	PreviousPrice float64 `json:"previous_price"`
+	IsPositive    bool    `json:"is_positive"`
+
+	Coalesced *CoalesceSummary `json:"coalesced,omitempty"` // Présent si l'événement résume une fenêtre
+}
+
+// VolumeSpike est émis lors d'une hausse de volume significative
+type VolumeSpike struct {
+	TokenAddress      string  `json:"token_address"`
+	TokenSymbol       string  `json:"token_symbol"`
+	VolumeChange      float64 `json:"volume_change"` // En pourcentage
+	Volume24h         float64 `json:"volume_24h"`
+	PreviousVolume24h float64 `json:"previous_volume_24h"`
+
+	Coalesced *CoalesceSummary `json:"coalesced,omitempty"` // Présent si l'événement résume une fenêtre
+}
+
+// StateChange est émis lors d'un changement d'état du cycle de vie
+type StateChange struct {
+	TokenAddress string `json:"token_address"`
+	TokenSymbol  string `json:"token_symbol"`
+	OldState     string `json:"old_state"`
+	NewState     string `json:"new_state"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// Reactivation est émis lorsqu'un token dormant se réactive
+type Reactivation struct {
+	TokenAddress      string             `json:"token_address"`
+	TokenSymbol       string             `json:"token_symbol"`
+	ReactivationScore float64            `json:"reactivation_score"`
+	Changes           map[string]float64 `json:"changes,omitempty"`
+	SmartReturns      bool               `json:"smart_returns"`
+	DetectedAt        time.Time          `json:"detected_at"`
+}
+
+// AlertRaised est émis lorsqu'une alerte est créée
+type AlertRaised struct {
+	AlertID      string    `json:"alert_id"`
+	TokenAddress string    `json:"token_address"`
+	TokenSymbol  string    `json:"token_symbol"`
+	AlertType    string    `json:"alert_type"`
+	Severity     string    `json:"severity"`
+	Message      string    `json:"message"`
+	DetectedAt   time.Time `json:"detected_at"`
+}
+
+// XScoreCalculated est émis à chaque calcul du X-Score d'un token
+type XScoreCalculated struct {
+	TokenAddress     string             `json:"token_address"`
+	TokenSymbol      string             `json:"token_symbol"`
+	XScore           float64            `json:"xscore"`
+	BaseScore        float64            `json:"base_score"`
+	Components       map[string]float64 `json:"components,omitempty"`
+	Factors          map[string]float64 `json:"factors,omitempty"`
+	Metrics          map[string]float64 `json:"metrics,omitempty"` // Métriques du token utilisées pour le calcul
+	Wallets          map[string]float64 `json:"wallets,omitempty"` // Ratios de l'analyse des wallets
+	Price            float64            `json:"price"`
+	MarketCap        float64            `json:"market_cap"`
+	AntiDumpDetected bool               `json:"anti_dump_detected"`
+	AntiDumpSeverity float64            `json:"anti_dump_severity,omitempty"`
+	CalculatedAt     time.Time          `json:"calculated_at"`
+}
+
+// EventType retourne le type de l'événement
+func (e *TokenDetected) EventType() string { return EventTypeTokenDetected }
+
+// EventType retourne le type de l'événement
+func (e *PriceChange) EventType() string { return EventTypePriceChange }
+
+// EventType retourne le type de l'événement
+func (e *VolumeSpike) EventType() string { return EventTypeVolumeSpike }
+
+// EventType retourne le type de l'événement
+func (e *StateChange) EventType() string { return EventTypeStateChange }
+
+// EventType retourne le type de l'événement
+func (e *Reactivation) EventType() string { return EventTypeReactivation }
+
+// EventType retourne le type de l'événement
+func (e *AlertRaised) EventType() string { return EventTypeAlertRaised }
+
+// EventType retourne le type de l'événement
+func (e *XScoreCalculated) EventType() string { return EventTypeXScore }
+
+// GetTokenAddress retourne l'adresse du token concerné
+func (e *TokenDetected) GetTokenAddress() string { return e.TokenAddress }
+
+// GetTokenAddress retourne l'adresse du token concerné
+func (e *PriceChange) GetTokenAddress() string { return e.TokenAddress }
+
+// GetTokenAddress retourne l'adresse du token concerné
+func (e *VolumeSpike) GetTokenAddress() string { return e.TokenAddress }
+
+// GetTokenAddress retourne l'adresse du token concerné
+func (e *StateChange) GetTokenAddress() string { return e.TokenAddress }
+
+// GetTokenAddress retourne l'adresse du token concerné
+func (e *Reactivation) GetTokenAddress() string { return e.TokenAddress }
+
+// GetTokenAddress retourne l'adresse du token concerné
+func (e *AlertRaised) GetTokenAddress() string { return e.TokenAddress }
+
+// GetTokenAddress retourne l'adresse du token concerné
+func (e *XScoreCalculated) GetTokenAddress() string { return e.TokenAddress }
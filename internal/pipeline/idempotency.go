@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/storage/cache"
+)
+
+// idempotencyPrefix préfixe les clés d'idempotence stockées dans Redis
+const idempotencyPrefix = "pipeline:processed:"
+
+// Idempotent est implémenté par les processeurs à effets de bord qui ne doivent pas
+// retraiter un message relivré. IdempotencyKey retourne la clé de déduplication
+// du message, vide pour le traiter sans contrôle.
+type Idempotent interface {
+	IdempotencyKey(message Message) string
+}
+
+// IdempotencyStore mémorise les messages déjà traités par un groupe
+type IdempotencyStore interface {
+	IsProcessed(key string) (bool, error)
+	MarkProcessed(key string, ttl time.Duration) error
+}
+
+// RedisIdempotencyStore stocke les clés d'idempotence dans Redis avec expiration
+type RedisIdempotencyStore struct {
+	redis *cache.Redis
+}
+
+// NewRedisIdempotencyStore crée un store d'idempotence Redis
+func NewRedisIdempotencyStore(redis *cache.Redis) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{redis: redis}
+}
+
+// IsProcessed indique si la clé a déjà été traitée
+func (s *RedisIdempotencyStore) IsProcessed(key string) (bool, error) {
+	return s.redis.Exists(idempotencyPrefix + key)
+}
+
+// MarkProcessed enregistre la clé comme traitée
+func (s *RedisIdempotencyStore) MarkProcessed(key string, ttl time.Duration) error {
+	return s.redis.Set(idempotencyPrefix+key, time.Now().UTC().Format(time.RFC3339), ttl)
+}
+
+// MemoryIdempotencyStore stocke les clés d'idempotence en mémoire
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewMemoryIdempotencyStore crée un store d'idempotence en mémoire
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{expires: make(map[string]time.Time)}
+}
+
+// IsProcessed indique si la clé a déjà été traitée et n'a pas expiré
+func (s *MemoryIdempotencyStore) IsProcessed(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expires, ok := s.expires[key]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(expires) {
+		delete(s.expires, key)
+		return false, nil
+	}
+	return true, nil
+}
+
+// MarkProcessed enregistre la clé comme traitée et purge les clés expirées
+func (s *MemoryIdempotencyStore) MarkProcessed(key string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, expires := range s.expires {
+		if now.After(expires) {
+			delete(s.expires, k)
+		}
+	}
+	s.expires[key] = now.Add(ttl)
+	return nil
+}
+
+// SetIdempotencyStore remplace le store des messages déjà traités
+func (p *Pipeline) SetIdempotencyStore(store IdempotencyStore) {
+	p.idempotency = store
+}
+
+// alreadyProcessed indique si un processeur idempotent a déjà traité le message.
+// En cas d'erreur du store, le message est traité: la livraison reste at-least-once.
+func (p *Pipeline) alreadyProcessed(group, key string) bool {
+	if key == "" || p.idempotency == nil {
+		return false
+	}
+
+	processed, err := p.idempotency.IsProcessed(group + ":" + key)
+	if err != nil {
+		p.logger.WithField("error", err.Error()).Warn("Failed to check idempotency key")
+		return false
+	}
+	return processed
+}
+
+// markProcessed enregistre le traitement réussi d'un message par un processeur idempotent
+func (p *Pipeline) markProcessed(group, key string) {
+	if key == "" || p.idempotency == nil {
+		return
+	}
+
+	if err := p.idempotency.MarkProcessed(group+":"+key, p.consumerOpts.IdempotencyTTL); err != nil {
+		p.logger.WithField("error", err.Error()).Warn("Failed to record idempotency key")
+	}
+}
@@ -0,0 +1,509 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryBroker implémente Broker en mémoire avec la même sémantique at-least-once
+// que Redis Streams. Destiné aux tests, aux démos et au scanner autonome: les
+// messages sont perdus à l'arrêt du processus.
+type MemoryBroker struct {
+	mu      sync.Mutex
+	streams map[string]*memoryStream
+	leases  map[string]memoryLease
+	members map[string]map[string]time.Time
+	notify  chan struct{} // Fermé puis remplacé à chaque publication pour réveiller les lecteurs
+	lastID  streamID
+}
+
+type memoryStream struct {
+	entries []StreamMessage // Triés par ID croissant
+	groups  map[string]*memoryGroup
+}
+
+type memoryGroup struct {
+	lastDelivered streamID
+	pending       map[string]*memoryPending
+	consumers     map[string]bool
+}
+
+type memoryPending struct {
+	consumer    string
+	deliveredAt time.Time
+	deliveries  int64
+}
+
+type memoryLease struct {
+	owner   string
+	expires time.Time
+}
+
+// NewMemoryBroker crée un broker en mémoire vide
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		streams: make(map[string]*memoryStream),
+		leases:  make(map[string]memoryLease),
+		members: make(map[string]map[string]time.Time),
+		notify:  make(chan struct{}),
+	}
+}
+
+// streamID est un ID de message décomposé <ms>-<seq>
+type streamID struct {
+	ms  int64
+	seq int64
+}
+
+func (id streamID) String() string {
+	return fmt.Sprintf("%d-%d", id.ms, id.seq)
+}
+
+func (id streamID) less(other streamID) bool {
+	return id.ms < other.ms || (id.ms == other.ms && id.seq < other.seq)
+}
+
+// parseStreamID décode un ID; upper complète un ID partiel vers la borne haute
+func parseStreamID(raw string, upper bool) (streamID, error) {
+	switch raw {
+	case "-":
+		return streamID{}, nil
+	case "+":
+		return streamID{ms: 1<<63 - 1, seq: 1<<63 - 1}, nil
+	}
+
+	parts := strings.SplitN(raw, "-", 2)
+	ms, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return streamID{}, fmt.Errorf("invalid stream ID %q", raw)
+	}
+	id := streamID{ms: ms}
+	if len(parts) == 2 {
+		if id.seq, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return streamID{}, fmt.Errorf("invalid stream ID %q", raw)
+		}
+	} else if upper {
+		id.seq = 1<<63 - 1
+	}
+	return id, nil
+}
+
+// mustParseID décode un ID produit par le broker
+func mustParseID(raw string) streamID {
+	id, _ := parseStreamID(raw, false)
+	return id
+}
+
+// nextID génère un ID strictement croissant. Appelé sous b.mu.
+func (b *MemoryBroker) nextID() streamID {
+	ms := time.Now().UnixMilli()
+	if ms > b.lastID.ms {
+		b.lastID = streamID{ms: ms}
+	} else {
+		b.lastID.seq++
+	}
+	return b.lastID
+}
+
+// stream retourne un stream en le créant si besoin. Appelé sous b.mu.
+func (b *MemoryBroker) stream(name string) *memoryStream {
+	s, ok := b.streams[name]
+	if !ok {
+		s = &memoryStream{groups: make(map[string]*memoryGroup)}
+		b.streams[name] = s
+	}
+	return s
+}
+
+// group retourne un groupe existant. Appelé sous b.mu.
+func (b *MemoryBroker) group(stream, group string) (*memoryStream, *memoryGroup, error) {
+	s, ok := b.streams[stream]
+	if !ok {
+		return nil, nil, fmt.Errorf("NOGROUP no such stream %q", stream)
+	}
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, nil, fmt.Errorf("NOGROUP no such consumer group %q for stream %q", group, stream)
+	}
+	return s, g, nil
+}
+
+// find retourne l'index d'un message, -1 s'il n'existe pas. Appelé sous b.mu.
+func (s *memoryStream) find(id string) int {
+	target := mustParseID(id)
+	i := sort.Search(len(s.entries), func(i int) bool {
+		return !mustParseID(s.entries[i].ID).less(target)
+	})
+	if i < len(s.entries) && s.entries[i].ID == id {
+		return i
+	}
+	return -1
+}
+
+// copyMessage isole l'appelant des mutations internes
+func copyMessage(m StreamMessage) StreamMessage {
+	values := make(map[string]interface{}, len(m.Values))
+	for k, v := range m.Values {
+		values[k] = v
+	}
+	return StreamMessage{ID: m.ID, Values: values}
+}
+
+// Publish ajoute un message à un stream
+func (b *MemoryBroker) Publish(stream string, values map[string]interface{}) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID().String()
+	s := b.stream(stream)
+	s.entries = append(s.entries, copyMessage(StreamMessage{ID: id, Values: values}))
+
+	close(b.notify)
+	b.notify = make(chan struct{})
+
+	return id, nil
+}
+
+// CreateGroup crée un groupe positionné après le dernier message du stream
+func (b *MemoryBroker) CreateGroup(stream, group string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.stream(stream)
+	if _, ok := s.groups[group]; ok {
+		return nil
+	}
+
+	g := &memoryGroup{
+		pending:   make(map[string]*memoryPending),
+		consumers: make(map[string]bool),
+	}
+	if n := len(s.entries); n > 0 {
+		g.lastDelivered = mustParseID(s.entries[n-1].ID)
+	}
+	s.groups[group] = g
+
+	return nil
+}
+
+// ReadGroup livre les messages jamais livrés au groupe, en attendant au plus block
+func (b *MemoryBroker) ReadGroup(stream, group, consumer string, count int, block time.Duration) ([]StreamMessage, error) {
+	deadline := time.Now().Add(block)
+
+	for {
+		b.mu.Lock()
+		s, g, err := b.group(stream, group)
+		if err != nil {
+			b.mu.Unlock()
+			return nil, err
+		}
+		g.consumers[consumer] = true
+
+		start := sort.Search(len(s.entries), func(i int) bool {
+			return g.lastDelivered.less(mustParseID(s.entries[i].ID))
+		})
+		end := len(s.entries)
+		if count > 0 && end-start > count {
+			end = start + count
+		}
+
+		if start < end {
+			now := time.Now()
+			messages := make([]StreamMessage, 0, end-start)
+			for _, entry := range s.entries[start:end] {
+				g.pending[entry.ID] = &memoryPending{
+					consumer:    consumer,
+					deliveredAt: now,
+					deliveries:  1,
+				}
+				messages = append(messages, copyMessage(entry))
+			}
+			g.lastDelivered = mustParseID(s.entries[end-1].ID)
+			b.mu.Unlock()
+			return messages, nil
+		}
+
+		notify := b.notify
+		b.mu.Unlock()
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return []StreamMessage{}, nil
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-notify:
+			timer.Stop()
+		case <-timer.C:
+			return []StreamMessage{}, nil
+		}
+	}
+}
+
+// Ack retire des messages de la liste d'attente du groupe
+func (b *MemoryBroker) Ack(stream, group string, ids ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, g, err := b.group(stream, group)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		delete(g.pending, id)
+	}
+	return nil
+}
+
+// Pending liste les messages en attente du groupe, par ID croissant
+func (b *MemoryBroker) Pending(stream, group string, count int) ([]PendingMessage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, g, err := b.group(stream, group)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	pending := make([]PendingMessage, 0, len(g.pending))
+	for id, p := range g.pending {
+		pending = append(pending, PendingMessage{
+			ID:         id,
+			Consumer:   p.consumer,
+			Idle:       now.Sub(p.deliveredAt),
+			Deliveries: p.deliveries,
+		})
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return mustParseID(pending[i].ID).less(mustParseID(pending[j].ID))
+	})
+	if count > 0 && len(pending) > count {
+		pending = pending[:count]
+	}
+
+	return pending, nil
+}
+
+// Claim réattribue au consommateur les messages inactifs depuis minIdle et les relivre.
+// Les messages supprimés du stream sont retirés de la liste d'attente.
+func (b *MemoryBroker) Claim(stream, group, consumer string, minIdle time.Duration, ids ...string) ([]StreamMessage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, g, err := b.group(stream, group)
+	if err != nil {
+		return nil, err
+	}
+	g.consumers[consumer] = true
+
+	now := time.Now()
+	messages := make([]StreamMessage, 0, len(ids))
+	for _, id := range ids {
+		p, ok := g.pending[id]
+		if !ok || now.Sub(p.deliveredAt) < minIdle {
+			continue
+		}
+
+		idx := s.find(id)
+		if idx < 0 {
+			delete(g.pending, id)
+			continue
+		}
+
+		p.consumer = consumer
+		p.deliveredAt = now
+		p.deliveries++
+		messages = append(messages, copyMessage(s.entries[idx]))
+	}
+
+	return messages, nil
+}
+
+// Range lit les messages entre deux IDs inclus
+func (b *MemoryBroker) Range(stream, start, stop string, count int) ([]StreamMessage, error) {
+	from, err := parseStreamID(start, false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseStreamID(stop, true)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.streams[stream]
+	if !ok {
+		return []StreamMessage{}, nil
+	}
+
+	messages := make([]StreamMessage, 0)
+	for _, entry := range s.entries {
+		id := mustParseID(entry.ID)
+		if id.less(from) {
+			continue
+		}
+		if to.less(id) {
+			break
+		}
+		messages = append(messages, copyMessage(entry))
+		if count > 0 && len(messages) >= count {
+			break
+		}
+	}
+
+	return messages, nil
+}
+
+// Delete supprime des messages d'un stream
+func (b *MemoryBroker) Delete(stream string, ids ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.streams[stream]
+	if !ok {
+		return nil
+	}
+	for _, id := range ids {
+		if idx := s.find(id); idx >= 0 {
+			s.entries = append(s.entries[:idx], s.entries[idx+1:]...)
+		}
+	}
+	return nil
+}
+
+// Trim supprime les messages les plus anciens d'un stream
+func (b *MemoryBroker) Trim(stream string, maxLen int64, minID string) (int64, error) {
+	var min streamID
+	if minID != "" {
+		var err error
+		if min, err = parseStreamID(minID, false); err != nil {
+			return 0, err
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.streams[stream]
+	if !ok {
+		return 0, nil
+	}
+
+	cut := 0
+	for cut < len(s.entries) && mustParseID(s.entries[cut].ID).less(min) {
+		cut++
+	}
+	if maxLen > 0 && int64(len(s.entries)-cut) > maxLen {
+		cut = len(s.entries) - int(maxLen)
+	}
+	s.entries = append([]StreamMessage(nil), s.entries[cut:]...)
+	return int64(cut), nil
+}
+
+// Groups décrit les groupes de consommateurs d'un stream
+func (b *MemoryBroker) Groups(stream string) ([]GroupInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.streams[stream]
+	if !ok {
+		return nil, fmt.Errorf("no such stream %q", stream)
+	}
+
+	groups := make([]GroupInfo, 0, len(s.groups))
+	for name, g := range s.groups {
+		groups = append(groups, GroupInfo{
+			Name:            name,
+			Consumers:       int64(len(g.consumers)),
+			Pending:         int64(len(g.pending)),
+			LastDeliveredID: g.lastDelivered.String(),
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+
+	return groups, nil
+}
+
+// AcquireLease pose un bail expirant si la clé est libre
+func (b *MemoryBroker) AcquireLease(key, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if lease, ok := b.leases[key]; ok && now.Before(lease.expires) {
+		return false, nil
+	}
+	b.leases[key] = memoryLease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// RefreshLease prolonge un bail encore détenu par owner
+func (b *MemoryBroker) RefreshLease(key, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	lease, ok := b.leases[key]
+	if !ok || lease.owner != owner || !now.Before(lease.expires) {
+		return false, nil
+	}
+	b.leases[key] = memoryLease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLease libère un bail encore détenu par owner
+func (b *MemoryBroker) ReleaseLease(key, owner string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if lease, ok := b.leases[key]; ok && lease.owner == owner {
+		delete(b.leases, key)
+	}
+	return nil
+}
+
+// Heartbeat signale la présence d'un membre
+func (b *MemoryBroker) Heartbeat(key, member string, ttl time.Duration) (int64, error) {
+	b.mu.Lock()
+	set, ok := b.members[key]
+	if !ok {
+		set = make(map[string]time.Time)
+		b.members[key] = set
+	}
+	set[member] = time.Now()
+	b.mu.Unlock()
+
+	return b.Members(key, ttl)
+}
+
+// Members compte les membres vus depuis ttl après purge des membres expirés
+func (b *MemoryBroker) Members(key string, ttl time.Duration) (int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	cutoff := time.Now().Add(-ttl)
+	set := b.members[key]
+	for member, seen := range set {
+		if seen.Before(cutoff) {
+			delete(set, member)
+		}
+	}
+	return int64(len(set)), nil
+}
+
+// Leave retire un membre
+func (b *MemoryBroker) Leave(key, member string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.members[key], member)
+	return nil
+}
@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryBrokerAtLeastOnce(t *testing.T) {
+	b := NewMemoryBroker()
+
+	if _, err := b.Publish("s", map[string]interface{}{"n": "before"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if err := b.CreateGroup("s", "g"); err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if err := b.CreateGroup("s", "g"); err != nil {
+		t.Fatalf("CreateGroup must be idempotent: %v", err)
+	}
+
+	// Le groupe démarre en fin de stream
+	id, _ := b.Publish("s", map[string]interface{}{"n": "after"})
+	messages, err := b.ReadGroup("s", "g", "c1", 10, 0)
+	if err != nil {
+		t.Fatalf("ReadGroup: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != id {
+		t.Fatalf("ReadGroup = %+v, want only %s", messages, id)
+	}
+
+	// Un message non acquitté n'est pas relivré par ReadGroup mais reste en attente
+	if again, _ := b.ReadGroup("s", "g", "c1", 10, 0); len(again) != 0 {
+		t.Fatalf("unacked message redelivered by ReadGroup: %+v", again)
+	}
+	pending, _ := b.Pending("s", "g", 10)
+	if len(pending) != 1 || pending[0].Consumer != "c1" || pending[0].Deliveries != 1 {
+		t.Fatalf("Pending = %+v", pending)
+	}
+
+	// Claim respecte minIdle puis transfère le message et incrémente les livraisons
+	if claimed, _ := b.Claim("s", "g", "c2", time.Hour, id); len(claimed) != 0 {
+		t.Fatalf("Claim ignored minIdle: %+v", claimed)
+	}
+	claimed, _ := b.Claim("s", "g", "c2", 0, id)
+	if len(claimed) != 1 {
+		t.Fatalf("Claim = %+v", claimed)
+	}
+	pending, _ = b.Pending("s", "g", 10)
+	if pending[0].Consumer != "c2" || pending[0].Deliveries != 2 {
+		t.Fatalf("Pending after claim = %+v", pending)
+	}
+
+	if err := b.Ack("s", "g", id); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+	if pending, _ = b.Pending("s", "g", 10); len(pending) != 0 {
+		t.Fatalf("Pending after ack = %+v", pending)
+	}
+}
+
+func TestMemoryBrokerReadGroupWakesOnPublish(t *testing.T) {
+	b := NewMemoryBroker()
+	b.CreateGroup("s", "g")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.Publish("s", map[string]interface{}{"n": "late"})
+	}()
+
+	start := time.Now()
+	messages, err := b.ReadGroup("s", "g", "c", 10, 5*time.Second)
+	if err != nil {
+		t.Fatalf("ReadGroup: %v", err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("ReadGroup = %+v", messages)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatal("ReadGroup did not wake up on publish")
+	}
+}
+
+func TestMemoryBrokerLeases(t *testing.T) {
+	b := NewMemoryBroker()
+
+	if ok, _ := b.AcquireLease("k", "a", time.Minute); !ok {
+		t.Fatal("first acquire failed")
+	}
+	if ok, _ := b.AcquireLease("k", "b", time.Minute); ok {
+		t.Fatal("lease acquired twice")
+	}
+	if ok, _ := b.RefreshLease("k", "b", time.Minute); ok {
+		t.Fatal("lease refreshed by non-owner")
+	}
+	b.ReleaseLease("k", "a")
+	if ok, _ := b.AcquireLease("k", "b", time.Minute); !ok {
+		t.Fatal("released lease not acquirable")
+	}
+}
@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryScheduleStore conserve les messages différés en mémoire.
+// Les messages sont perdus au redémarrage.
+type MemoryScheduleStore struct {
+	mu       sync.Mutex
+	messages map[string]ScheduledMessage
+}
+
+// NewMemoryScheduleStore crée un store de messages différés en mémoire
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{messages: make(map[string]ScheduledMessage)}
+}
+
+// Schedule enregistre un message
+func (s *MemoryScheduleStore) Schedule(msg ScheduledMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[msg.Key] = msg
+	return nil
+}
+
+// Cancel supprime le message d'une clé
+func (s *MemoryScheduleStore) Cancel(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.messages[key]
+	delete(s.messages, key)
+	return ok, nil
+}
+
+// Due retourne les messages échus par date croissante
+func (s *MemoryScheduleStore) Due(now time.Time, limit int) ([]ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make([]ScheduledMessage, 0)
+	for _, msg := range s.messages {
+		if !msg.DeliverAt.After(now) {
+			due = append(due, msg)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].DeliverAt.Before(due[j].DeliverAt) })
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Complete retire un message publié s'il n'a pas été reprogrammé
+func (s *MemoryScheduleStore) Complete(msg ScheduledMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.messages[msg.Key]; ok && current.DeliverAt.Equal(msg.DeliverAt) {
+		delete(s.messages, msg.Key)
+	}
+	return nil
+}
+
+// Get retourne le message programmé d'une clé
+func (s *MemoryScheduleStore) Get(key string) (*ScheduledMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	msg, ok := s.messages[key]
+	if !ok {
+		return nil, nil
+	}
+	return &msg, nil
+}
@@ -0,0 +1,336 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrInvalidMessage signale un message qu'aucune reprise ne rendra traitable.
+// Un processeur retournant une erreur qui l'enveloppe voit le message déplacé
+// immédiatement dans le stream de dead-letter.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Middleware enveloppe un processeur pour lui ajouter un comportement transverse
+type Middleware func(Processor) Processor
+
+// processorFunc adapte une fonction en processeur nommé
+type processorFunc struct {
+	name    string
+	process func(message Message) error
+}
+
+// ProcessorFunc crée un processeur à partir d'un nom et d'une fonction de traitement
+func ProcessorFunc(name string, process func(message Message) error) Processor {
+	return &processorFunc{name: name, process: process}
+}
+
+// Process traite le message
+func (f *processorFunc) Process(message Message) error {
+	return f.process(message)
+}
+
+// GetName retourne le nom du processeur
+func (f *processorFunc) GetName() string {
+	return f.name
+}
+
+// Chain enveloppe un processeur dans des middlewares. Le premier middleware
+// est le plus externe: il voit le message en premier et l'erreur en dernier.
+func Chain(processor Processor, middlewares ...Middleware) Processor {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		processor = middlewares[i](processor)
+	}
+	return processor
+}
+
+// Recover convertit la panique d'un processeur en erreur: le message est repris
+// comme un échec ordinaire et la goroutine de consommation survit.
+func Recover(logger *logrus.Logger) Middleware {
+	return func(next Processor) Processor {
+		return ProcessorFunc(next.GetName(), func(message Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.WithFields(logrus.Fields{
+						"processor": next.GetName(),
+						"msg_id":    message.ID,
+						"type":      message.Type,
+						"panic":     fmt.Sprint(r),
+						"stack":     string(debug.Stack()),
+					}).Error("Processor panicked")
+					err = fmt.Errorf("processor %s panicked: %v", next.GetName(), r)
+				}
+			}()
+			return next.Process(message)
+		})
+	}
+}
+
+// Dedup ignore les messages dont la clé a déjà été traitée avec succès par le processeur.
+// key retourne la clé de déduplication d'un message, vide pour le traiter sans contrôle;
+// nil utilise l'ID de l'événement.
+func Dedup(store IdempotencyStore, ttl time.Duration, key func(message Message) string) Middleware {
+	if key == nil {
+		key = func(message Message) string { return message.EventID }
+	}
+
+	return func(next Processor) Processor {
+		prefix := "dedup:" + next.GetName() + ":"
+		return ProcessorFunc(next.GetName(), func(message Message) error {
+			k := key(message)
+			if k == "" {
+				return next.Process(message)
+			}
+
+			// En cas d'erreur du store, le message est traité: la livraison reste at-least-once
+			if processed, err := store.IsProcessed(prefix + k); err == nil && processed {
+				return nil
+			}
+
+			if err := next.Process(message); err != nil {
+				return err
+			}
+			return store.MarkProcessed(prefix+k, ttl)
+		})
+	}
+}
+
+// Validator est implémenté par les événements qui vérifient leur propre contenu
+type Validator interface {
+	Validate() error
+}
+
+// Validate rejette les messages invalides avant traitement: type absent, événement
+// typé sans token ou refusé par son Validator, puis les contrôles fournis.
+// Les rejets enveloppent ErrInvalidMessage et partent directement en dead-letter.
+func Validate(checks ...func(message Message) error) Middleware {
+	return func(next Processor) Processor {
+		return ProcessorFunc(next.GetName(), func(message Message) error {
+			if err := validateMessage(message, checks); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+			}
+			return next.Process(message)
+		})
+	}
+}
+
+// validateMessage applique les contrôles de Validate
+func validateMessage(message Message, checks []func(message Message) error) error {
+	if message.Type == "" {
+		return errors.New("missing message type")
+	}
+
+	if message.Event != nil {
+		if message.Event.GetTokenAddress() == "" {
+			return fmt.Errorf("%s event without token address", message.Type)
+		}
+		if v, ok := message.Event.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, check := range checks {
+		if err := check(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DefaultLatencyBuckets sont les bornes supérieures par défaut des histogrammes de latence
+var DefaultLatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// LatencyHistogram compte les durées de traitement par processeur et type d'événement
+type LatencyHistogram struct {
+	buckets []time.Duration
+	mu      sync.Mutex
+	series  map[latencyKey]*latencySeries
+}
+
+type latencyKey struct {
+	processor string
+	eventType string
+}
+
+type latencySeries struct {
+	counts []int64 // Un compteur par borne, plus un pour les durées au-delà
+	total  time.Duration
+	max    time.Duration
+	errors int64
+}
+
+// LatencyBucket compte les traitements d'une durée inférieure ou égale à UpperBoundMs
+// et supérieure à la borne précédente. UpperBoundMs vaut -1 pour le dernier intervalle.
+type LatencyBucket struct {
+	UpperBoundMs float64 `json:"upper_bound_ms"`
+	Count        int64   `json:"count"`
+}
+
+// LatencyStats résume les latences d'un processeur pour un type d'événement
+type LatencyStats struct {
+	Processor string          `json:"processor"`
+	EventType string          `json:"event_type"`
+	Count     int64           `json:"count"`
+	Errors    int64           `json:"errors"`
+	MeanMs    float64         `json:"mean_ms"`
+	MaxMs     float64         `json:"max_ms"`
+	P50Ms     float64         `json:"p50_ms"`
+	P95Ms     float64         `json:"p95_ms"`
+	P99Ms     float64         `json:"p99_ms"`
+	Buckets   []LatencyBucket `json:"buckets"`
+}
+
+// NewLatencyHistogram crée un histogramme; sans bornes, DefaultLatencyBuckets est utilisé
+func NewLatencyHistogram(buckets ...time.Duration) *LatencyHistogram {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := append([]time.Duration(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	return &LatencyHistogram{
+		buckets: sorted,
+		series:  make(map[latencyKey]*latencySeries),
+	}
+}
+
+// Observe enregistre une durée de traitement
+func (h *LatencyHistogram) Observe(processor, eventType string, d time.Duration, failed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := latencyKey{processor: processor, eventType: eventType}
+	s, ok := h.series[k]
+	if !ok {
+		s = &latencySeries{counts: make([]int64, len(h.buckets)+1)}
+		h.series[k] = s
+	}
+
+	i := sort.Search(len(h.buckets), func(i int) bool { return d <= h.buckets[i] })
+	s.counts[i]++
+	s.total += d
+	if d > s.max {
+		s.max = d
+	}
+	if failed {
+		s.errors++
+	}
+}
+
+// Snapshot retourne les statistiques triées par processeur puis type d'événement
+func (h *LatencyHistogram) Snapshot() []LatencyStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	stats := make([]LatencyStats, 0, len(h.series))
+	for k, s := range h.series {
+		st := LatencyStats{
+			Processor: k.processor,
+			EventType: k.eventType,
+			Errors:    s.errors,
+			MaxMs:     durationMs(s.max),
+			Buckets:   make([]LatencyBucket, 0, len(s.counts)),
+		}
+		for i, c := range s.counts {
+			bound := -1.0
+			if i < len(h.buckets) {
+				bound = durationMs(h.buckets[i])
+			}
+			st.Buckets = append(st.Buckets, LatencyBucket{UpperBoundMs: bound, Count: c})
+			st.Count += c
+		}
+		if st.Count > 0 {
+			st.MeanMs = durationMs(s.total) / float64(st.Count)
+		}
+		st.P50Ms = h.quantile(s, st.Count, 0.50)
+		st.P95Ms = h.quantile(s, st.Count, 0.95)
+		st.P99Ms = h.quantile(s, st.Count, 0.99)
+		stats = append(stats, st)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Processor != stats[j].Processor {
+			return stats[i].Processor < stats[j].Processor
+		}
+		return stats[i].EventType < stats[j].EventType
+	})
+	return stats
+}
+
+// quantile estime un quantile par la borne supérieure de l'intervalle qui le contient.
+// Au-delà de la dernière borne, la durée maximale observée est retournée.
+func (h *LatencyHistogram) quantile(s *latencySeries, count int64, q float64) float64 {
+	if count == 0 {
+		return 0
+	}
+	rank := int64(math.Ceil(q * float64(count)))
+	var seen int64
+	for i, c := range s.counts {
+		seen += c
+		if seen >= rank {
+			if i < len(h.buckets) {
+				return math.Min(durationMs(h.buckets[i]), durationMs(s.max))
+			}
+			break
+		}
+	}
+	return durationMs(s.max)
+}
+
+// durationMs convertit une durée en millisecondes
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Timing mesure la durée de traitement de chaque message dans l'histogramme
+func Timing(h *LatencyHistogram) Middleware {
+	return func(next Processor) Processor {
+		return ProcessorFunc(next.GetName(), func(message Message) error {
+			start := time.Now()
+			err := next.Process(message)
+			h.Observe(next.GetName(), message.Type, time.Since(start), err != nil)
+			return err
+		})
+	}
+}
+
+// Use ajoute des middlewares appliqués à tous les processeurs, à l'intérieur de
+// la récupération de panique et de la mesure de latence installées par défaut.
+// Doit être appelé avant Start.
+func (p *Pipeline) Use(middlewares ...Middleware) {
+	p.middlewares = append(p.middlewares, middlewares...)
+}
+
+// Latency retourne les histogrammes de latence des processeurs
+func (p *Pipeline) Latency() []LatencyStats {
+	return p.latency.Snapshot()
+}
+
+// handlerFor construit la chaîne de traitement d'un processeur: middlewares communs
+// puis middlewares propres au processeur, du plus externe au plus interne
+func (p *Pipeline) handlerFor(name string, processor Processor) Processor {
+	middlewares := make([]Middleware, 0, len(p.middlewares)+len(p.chains[name]))
+	middlewares = append(middlewares, p.middlewares...)
+	middlewares = append(middlewares, p.chains[name]...)
+	return Chain(processor, middlewares...)
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Processor) Processor {
+			return ProcessorFunc(next.GetName(), func(message Message) error {
+				calls = append(calls, name)
+				return next.Process(message)
+			})
+		}
+	}
+
+	inner := ProcessorFunc("inner", func(message Message) error {
+		calls = append(calls, "inner")
+		return nil
+	})
+	chained := Chain(inner, trace("outer"), trace("middle"))
+
+	if err := chained.Process(Message{}); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if got := strings.Join(calls, ","); got != "outer,middle,inner" {
+		t.Errorf("call order = %s", got)
+	}
+	if chained.GetName() != "inner" {
+		t.Errorf("name = %q, want inner", chained.GetName())
+	}
+}
+
+func TestLatencyHistogramQuantiles(t *testing.T) {
+	h := NewLatencyHistogram(10*time.Millisecond, 100*time.Millisecond)
+	for i := 0; i < 9; i++ {
+		h.Observe("p", EventTypePriceChange, 5*time.Millisecond, false)
+	}
+	h.Observe("p", EventTypePriceChange, 300*time.Millisecond, true)
+
+	stats := h.Snapshot()
+	if len(stats) != 1 {
+		t.Fatalf("got %d series, want 1", len(stats))
+	}
+	s := stats[0]
+	if s.Count != 10 || s.Errors != 1 {
+		t.Errorf("count = %d, errors = %d", s.Count, s.Errors)
+	}
+	if s.P50Ms != 10 || s.P99Ms != 300 {
+		t.Errorf("p50 = %v, p99 = %v", s.P50Ms, s.P99Ms)
+	}
+	if len(s.Buckets) != 3 || s.Buckets[2].UpperBoundMs != -1 || s.Buckets[2].Count != 1 {
+		t.Errorf("unexpected buckets: %+v", s.Buckets)
+	}
+}
+
+func TestPipelineRecoversFromPanic(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	received := make(chan Message, 10)
+	proc := ProcessorFunc("panicky", func(message Message) error {
+		received <- message
+		if event, ok := message.Event.(*PriceChange); ok && event.PriceChange < 0 {
+			panic("negative price")
+		}
+		return nil
+	})
+	p.RegisterProcessor(&subscribed{proc, []Subscription{{Stream: StreamTokenEvents}}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok", PriceChange: -1})
+	p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok", PriceChange: 1})
+
+	// Le consommateur survit à la panique et traite le message suivant
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case msg := <-received:
+			if msg.Event.(*PriceChange).PriceChange == 1 {
+				stats := p.Stats()
+				if len(stats) != 1 || stats[0].Failed == 0 {
+					t.Errorf("panic not counted as failure: %+v", stats)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("consumer did not survive the panic")
+		}
+	}
+}
+
+func TestValidateDeadLettersImmediately(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	calls := 0
+	proc := ProcessorFunc("validated", func(message Message) error {
+		calls++
+		return nil
+	})
+	p.RegisterProcessor(&subscribed{proc, []Subscription{{Stream: StreamTokenEvents}}},
+		Validate(func(message Message) error {
+			if event, ok := message.Event.(*PriceChange); ok && event.Price < 0 {
+				return errors.New("negative price")
+			}
+			return nil
+		}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok", Price: -1})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		letters, err := p.ListDeadLetters(StreamTokenEvents, 10)
+		if err != nil {
+			t.Fatalf("ListDeadLetters: %v", err)
+		}
+		if len(letters) == 1 {
+			if letters[0].Attempts != 1 || !strings.Contains(letters[0].Error, "negative price") {
+				t.Errorf("unexpected dead letter: %+v", letters[0])
+			}
+			if calls != 0 {
+				t.Errorf("invalid message reached the processor %d times", calls)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("invalid message was not dead-lettered")
+}
+
+// subscribed ajoute des abonnements à un processeur
+type subscribed struct {
+	Processor
+	subs []Subscription
+}
+
+func (s *subscribed) Subscriptions() []Subscription { return s.subs }
@@ -3,8 +3,11 @@ package pipeline
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/franky69420/crypto-oracle/internal/storage/cache"
@@ -14,10 +17,26 @@ import (
 
 // Pipeline gère les flux de traitement des données
 type Pipeline struct {
-	cache      *cache.Redis
-	logger     *logrus.Logger
-	processors map[string]Processor
-	stopped    bool
+	broker       Broker
+	logger       *logrus.Logger
+	processors   map[string]Processor
+	registry     *Registry
+	retryPolicy  RetryPolicy
+	failures     *failureLog
+	consumerOpts ConsumerOptions
+	idempotency  IdempotencyStore
+	schedule     ScheduleStore
+	retention    map[string]RetentionPolicy
+	retentionMu  sync.RWMutex
+	middlewares  []Middleware            // Appliqués à tous les processeurs
+	chains       map[string][]Middleware // Middlewares propres à chaque processeur
+	latency      *LatencyHistogram
+	coalescer    *coalescer
+	groups       map[string]*consumerGroup
+	groupsMu     sync.RWMutex
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup // Goroutines de consommation, drainées par Shutdown
+	inFlight     int64          // Messages en cours de traitement
 }
 
 // Processor est une interface pour les processeurs de messages
@@ -28,49 +47,176 @@ type Processor interface {
 
 // Message représente un message à traiter dans le pipeline
 type Message struct {
-	ID        string                 `json:"id"`
-	Type      string                 `json:"type"`
-	Timestamp time.Time              `json:"timestamp"`
-	Payload   map[string]interface{} `json:"payload"`
+	ID            string                 `json:"id"`
+	EventID       string                 `json:"event_id,omitempty"` // ID de l'événement, stable entre relivraisons et rejeux
+	Stream        string                 `json:"stream,omitempty"`   // Partition d'où le message a été lu
+	Type          string                 `json:"type"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Payload       map[string]interface{} `json:"payload"`
+	SchemaVersion int                    `json:"-"`
+	Event         Event                  `json:"-"` // Événement typé décodé, nil si le type est inconnu
 }
 
-// NewPipeline crée un nouveau pipeline
-func NewPipeline(cache *cache.Redis, logger *logrus.Logger) *Pipeline {
-	return &Pipeline{
-		cache:      cache,
-		logger:     logger,
-		processors: make(map[string]Processor),
-		stopped:    true,
+// NewPipeline crée un nouveau pipeline sur Redis Streams
+func NewPipeline(redis *cache.Redis, logger *logrus.Logger) *Pipeline {
+	p := NewPipelineWithBroker(NewRedisBroker(redis), logger)
+	p.SetIdempotencyStore(NewRedisIdempotencyStore(redis))
+	p.SetScheduleStore(NewRedisScheduleStore(redis))
+	return p
+}
+
+// NewPipelineWithBroker crée un nouveau pipeline sur le broker fourni
+func NewPipelineWithBroker(broker Broker, logger *logrus.Logger) *Pipeline {
+	p := &Pipeline{
+		broker:       broker,
+		logger:       logger,
+		processors:   make(map[string]Processor),
+		registry:     DefaultRegistry(),
+		retryPolicy:  DefaultRetryPolicy(),
+		failures:     newFailureLog(),
+		consumerOpts: DefaultConsumerOptions(),
+		idempotency:  NewMemoryIdempotencyStore(),
+		schedule:     NewMemoryScheduleStore(),
+		retention:    make(map[string]RetentionPolicy),
+		chains:       make(map[string][]Middleware),
+		latency:      NewLatencyHistogram(),
+		groups:       make(map[string]*consumerGroup),
 	}
+	p.middlewares = []Middleware{Recover(logger), Timing(p.latency)}
+	p.coalescer = newCoalescer(DefaultCoalescePolicy(), p.publishEvent, logger)
+	return p
+}
+
+// GetRegistry retourne le registre des codecs d'événements
+func (p *Pipeline) GetRegistry() *Registry {
+	return p.registry
 }
 
-// Start démarre le pipeline
+// PublishEvent publie un événement typé et versionné dans un stream.
+// Les événements soumis à la politique de fusion sont retenus puis publiés résumés.
+func (p *Pipeline) PublishEvent(streamName string, event Event) error {
+	if p.coalescer.add(streamName, event) {
+		return nil
+	}
+	return p.publishEvent(streamName, event)
+}
+
+// publishEvent encode et publie un événement sans fusion
+func (p *Pipeline) publishEvent(streamName string, event Event) error {
+	env, err := p.registry.Encode(event)
+	if err != nil {
+		return err
+	}
+
+	target := p.routeStream(streamName, event.GetTokenAddress())
+	if _, err := p.broker.Publish(target, env.Values()); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"stream":         target,
+		"event_id":       env.ID,
+		"type":           env.Type,
+		"schema_version": env.Version,
+	}).Debug("Event published")
+
+	return nil
+}
+
+// Start démarre le pipeline. Les consommateurs s'arrêtent à l'annulation de ctx
+// ou à l'appel de Shutdown.
 func (p *Pipeline) Start(ctx context.Context) error {
 	p.logger.Info("Starting Pipeline")
-	p.stopped = false
 
-	// Démarrer les goroutines de consommation pour chaque processeur
+	ctx, p.cancel = context.WithCancel(ctx)
+
+	p.logTopology()
+
+	// Démarrer un groupe de consommation partitionné pour chaque abonnement
+	p.groupsMu.Lock()
 	for name, processor := range p.processors {
-		go p.startConsumer(ctx, name, processor)
+		slots := make(chan struct{}, p.consumerOpts.Workers)
+		handler := p.handlerFor(name, processor)
+		for _, sub := range subscriptionsOf(processor) {
+			g := newConsumerGroup(p, sub, processor, handler, slots)
+			p.groups[sub.Stream+"|"+name] = g
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				g.run(ctx)
+			}()
+		}
 	}
+	p.groupsMu.Unlock()
+
+	// Publier les messages différés échus
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.runScheduler(ctx)
+	}()
+
+	// Élaguer les streams soumis à une politique de rétention
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.runRetention(ctx)
+	}()
 
 	return nil
 }
 
-// Shutdown arrête le pipeline
+// Shutdown arrête la lecture de nouveaux messages puis attend la fin des lots en cours.
+// L'attente est bornée par ShutdownTimeout, ou par l'échéance de ctx si elle est plus proche;
+// les messages non acquittés à l'échéance seront relivrés.
 func (p *Pipeline) Shutdown(ctx context.Context) error {
 	p.logger.Info("Shutting down Pipeline")
-	p.stopped = true
-	// Attendre que les goroutines se terminent
-	time.Sleep(500 * time.Millisecond)
-	return nil
+	p.coalescer.flushAll()
+	if p.cancel != nil {
+		p.cancel()
+	}
+
+	timeout := p.consumerOpts.ShutdownTimeout
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < timeout {
+		timeout = time.Until(deadline)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		p.logger.Info("Pipeline drained")
+		return nil
+	case <-timer.C:
+		inFlight := atomic.LoadInt64(&p.inFlight)
+		p.logger.WithFields(logrus.Fields{
+			"in_flight": inFlight,
+		}).Warn("Pipeline shutdown deadline exceeded")
+		return fmt.Errorf("pipeline shutdown deadline exceeded with %d messages in flight", inFlight)
+	}
 }
 
-// RegisterProcessor enregistre un processeur de messages
-func (p *Pipeline) RegisterProcessor(processor Processor) {
+// RegisterProcessor enregistre un processeur de messages, enveloppé dans les
+// middlewares fournis en plus des middlewares communs
+func (p *Pipeline) RegisterProcessor(processor Processor, middlewares ...Middleware) {
 	p.processors[processor.GetName()] = processor
+	p.chains[processor.GetName()] = middlewares
+	p.validateSubscriptions(processor)
+
+	streams := make([]string, 0)
+	for _, sub := range subscriptionsOf(processor) {
+		streams = append(streams, sub.Stream)
+	}
 	p.logger.WithFields(logrus.Fields{
 		"processor": processor.GetName(),
+		"streams":   streams,
 	}).Info("Processor registered")
 }
 
@@ -111,14 +257,16 @@ func (p *Pipeline) PublishMessage(streamName string, message Message) error {
 		}
 	}
 
-	// Publier dans Redis Stream
-	err = p.cache.XAdd(streamName, messageMap)
+	// Publier dans la partition Redis Stream du token
+	tokenAddress, _ := message.Payload["token_address"].(string)
+	target := p.routeStream(streamName, tokenAddress)
+	_, err = p.broker.Publish(target, messageMap)
 	if err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
 
 	p.logger.WithFields(logrus.Fields{
-		"stream": streamName,
+		"stream": target,
 		"msg_id": message.ID,
 		"type":   message.Type,
 	}).Debug("Message published")
@@ -126,113 +274,187 @@ func (p *Pipeline) PublishMessage(streamName string, message Message) error {
 	return nil
 }
 
-// startConsumer démarre un consumer pour un processeur spécifique
-func (p *Pipeline) startConsumer(ctx context.Context, streamName string, processor Processor) {
-	p.logger.WithFields(logrus.Fields{
-		"stream":    streamName,
-		"processor": processor.GetName(),
-	}).Info("Starting consumer")
+// handleMessage traite un message et l'acquitte en cas de succès.
+// En cas d'échec, le message reste en attente et sera repris par le reclaimer,
+// sauf s'il est invalide: il part alors directement en dead-letter.
+// Les messages hors du filtre de l'abonnement, ou rejoués pour un autre groupe, sont
+// acquittés sans traitement.
+func (p *Pipeline) handleMessage(part partition, g *consumerGroup, msg StreamMessage, attempt int64) {
+	streamName := part.stream
+	atomic.AddInt64(&p.inFlight, 1)
+	defer atomic.AddInt64(&p.inFlight, -1)
+
+	processor := g.processor
+	message := p.decodeMessage(msg)
+	message.Stream = streamName
+
+	if !g.sub.Accepts(message.Type) || !replayedFor(msg, processor.GetName()) {
+		if p.ack(streamName, processor.GetName(), msg.ID) {
+			atomic.AddInt64(&g.counters.skipped, 1)
+		}
+		return
+	}
 
-	// Créer un consumer group si n'existe pas
-	err := p.cache.XGroupCreate(streamName, processor.GetName())
-	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
+	// Un message relivré déjà traité par un processeur idempotent est seulement acquitté
+	var idempotencyKey string
+	if idempotent, ok := processor.(Idempotent); ok {
+		idempotencyKey = idempotent.IdempotencyKey(message)
+	}
+	if p.alreadyProcessed(processor.GetName(), idempotencyKey) {
+		if p.ack(streamName, processor.GetName(), msg.ID) {
+			p.failures.take(streamName, processor.GetName(), msg.ID)
+			atomic.AddInt64(&g.counters.duplicates, 1)
+		}
+		return
+	}
+
+	// Traiter le message
+	err := g.handler.Process(message)
+	if err != nil {
 		p.logger.WithFields(logrus.Fields{
 			"stream":    streamName,
 			"processor": processor.GetName(),
+			"msg_id":    msg.ID,
 			"error":     err.Error(),
-		}).Error("Failed to create consumer group")
+		}).Error("Error processing message")
+		// Ne pas ACK, sera repris après backoff
+		p.failures.record(streamName, processor.GetName(), msg.ID, err)
+		atomic.AddInt64(&g.counters.failed, 1)
+		if errors.Is(err, ErrInvalidMessage) {
+			if err := p.deadLetter(part, g, msg.ID, attempt); err != nil {
+				p.logger.WithFields(logrus.Fields{
+					"stream":    streamName,
+					"processor": processor.GetName(),
+					"msg_id":    msg.ID,
+					"error":     err.Error(),
+				}).Error("Failed to dead-letter message")
+			}
+		}
 		return
 	}
 
-	// Boucle de consommation
-	for !p.stopped {
-		select {
-		case <-ctx.Done():
-			return
-		default:
-			// Lire les messages
-			messages, err := p.cache.XReadGroup(streamName, processor.GetName(), "consumer1", 10, 1*time.Second)
-			if err != nil {
-				// Ignorer les timeouts (cas normal quand pas de messages)
-				if err.Error() != "redis: nil" {
-					p.logger.WithFields(logrus.Fields{
-						"stream":    streamName,
-						"processor": processor.GetName(),
-						"error":     err.Error(),
-					}).Error("Error reading from stream")
-				}
-				time.Sleep(100 * time.Millisecond)
-				continue
-			}
+	// Enregistrer avant l'ACK: une relivraison après un ACK perdu sera dédupliquée
+	p.markProcessed(processor.GetName(), idempotencyKey)
 
-			// Traiter chaque message
-			for _, msg := range messages {
-				message := Message{
-					ID:        msg.ID,
-					Timestamp: time.Now(),
-					Payload:   make(map[string]interface{}),
-				}
+	// ACK si traité avec succès
+	if !p.ack(streamName, processor.GetName(), msg.ID) {
+		return
+	}
+	p.failures.take(streamName, processor.GetName(), msg.ID)
 
-				// Extraire les champs du message
-				for k, v := range msg.Values {
-					if k == "type" {
-						message.Type = v.(string)
-					} else if k == "timestamp" {
-						// Détecter si c'est un timestamp sous forme de string ou un unix timestamp
-						switch tv := v.(type) {
-						case string:
-							ts, err := time.Parse(time.RFC3339, tv)
-							if err == nil {
-								message.Timestamp = ts
-							}
-						case float64:
-							message.Timestamp = time.Unix(int64(tv), 0)
-						}
-					} else {
-						// Pour les autres champs, vérifier si c'est du JSON sérialisé
-						if strVal, ok := v.(string); ok && (strings.HasPrefix(strVal, "{") || strings.HasPrefix(strVal, "[")) {
-							// Tenter de désérialiser le JSON
-							var obj interface{}
-							if err := json.Unmarshal([]byte(strVal), &obj); err == nil {
-								// Si c'est bien du JSON, l'ajouter tel quel
-								message.Payload[k] = obj
-							} else {
-								// Sinon ajouter comme string
-								message.Payload[k] = strVal
-							}
-						} else {
-							// Ajouter directement si ce n'est pas un JSON sérialisé
-							message.Payload[k] = v
-						}
-					}
-				}
+	atomic.AddInt64(&g.counters.processed, 1)
+	g.counters.rate.add(1)
+}
 
-				// Traiter le message
-				err := processor.Process(message)
-				if err != nil {
-					p.logger.WithFields(logrus.Fields{
-						"stream":    streamName,
-						"processor": processor.GetName(),
-						"msg_id":    msg.ID,
-						"error":     err.Error(),
-					}).Error("Error processing message")
-					// Ne pas ACK, sera retraité
-					continue
-				}
+// replayedFor indique si un message doit être traité par un groupe: un message rejoué
+// depuis le dead-letter ne l'est que par le groupe qui avait échoué
+func replayedFor(msg StreamMessage, group string) bool {
+	target, ok := msg.Values[fieldReplayGroup]
+	return !ok || fmt.Sprint(target) == group
+}
 
-				// ACK si traité avec succès
-				err = p.cache.XAck(streamName, processor.GetName(), msg.ID)
-				if err != nil {
-					p.logger.WithFields(logrus.Fields{
-						"stream":    streamName,
-						"processor": processor.GetName(),
-						"msg_id":    msg.ID,
-						"error":     err.Error(),
-					}).Error("Error acknowledging message")
+// ack acquitte un message et journalise l'échec éventuel
+func (p *Pipeline) ack(streamName, group, id string) bool {
+	if err := p.broker.Ack(streamName, group, id); err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"stream":    streamName,
+			"processor": group,
+			"msg_id":    id,
+			"error":     err.Error(),
+		}).Error("Error acknowledging message")
+		return false
+	}
+	return true
+}
+
+// decodeMessage convertit une entrée de stream en message.
+// Les événements typés sont décodés via le registre; les payloads historiques
+// sont migrés depuis la version 0 quand leur type est connu.
+func (p *Pipeline) decodeMessage(msg StreamMessage) Message {
+	message := Message{
+		ID:        msg.ID,
+		Timestamp: time.Now(),
+		Payload:   make(map[string]interface{}),
+	}
+
+	if env, ok := EnvelopeFromValues(msg.Values); ok {
+		message.Type = env.Type
+		message.SchemaVersion = env.Version
+		message.EventID = env.ID
+		if !env.Timestamp.IsZero() {
+			message.Timestamp = env.Timestamp
+		}
+		if err := json.Unmarshal(env.Data, &message.Payload); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"msg_id": msg.ID,
+				"error":  err.Error(),
+			}).Warn("Invalid event data")
+		}
+
+		event, err := p.registry.Decode(env)
+		if err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"msg_id": msg.ID,
+				"type":   env.Type,
+				"error":  err.Error(),
+			}).Warn("Failed to decode event")
+		}
+		message.Event = event
+		return message
+	}
+
+	// Extraire les champs du message
+	for k, v := range msg.Values {
+		if k == "type" {
+			message.Type, _ = v.(string)
+		} else if k == "timestamp" {
+			// Détecter si c'est un timestamp sous forme de string ou un unix timestamp
+			switch tv := v.(type) {
+			case string:
+				ts, err := time.Parse(time.RFC3339, tv)
+				if err == nil {
+					message.Timestamp = ts
+				}
+			case float64:
+				message.Timestamp = time.Unix(int64(tv), 0)
+			}
+		} else {
+			// Pour les autres champs, vérifier si c'est du JSON sérialisé
+			if strVal, ok := v.(string); ok && (strings.HasPrefix(strVal, "{") || strings.HasPrefix(strVal, "[")) {
+				// Tenter de désérialiser le JSON
+				var obj interface{}
+				if err := json.Unmarshal([]byte(strVal), &obj); err == nil {
+					// Si c'est bien du JSON, l'ajouter tel quel
+					message.Payload[k] = obj
+				} else {
+					// Sinon ajouter comme string
+					message.Payload[k] = strVal
 				}
+			} else {
+				// Ajouter directement si ce n'est pas un JSON sérialisé
+				message.Payload[k] = v
 			}
 		}
 	}
+
+	// Les anciens messages publiés via PublishMessage portent leur ID dans le champ "id"
+	message.EventID = msg.ID
+	if id, ok := message.Payload["id"].(string); ok && id != "" {
+		message.EventID = id
+	}
+
+	// Les anciens messages publiés via PublishMessage imbriquent le payload
+	if nested, ok := message.Payload["payload"].(map[string]interface{}); ok {
+		message.Payload = nested
+	}
+
+	if _, known := p.registry.Version(message.Type); known {
+		if event, err := p.registry.DecodeLegacy(message.Type, message.Payload); err == nil {
+			message.Event = event
+		}
+	}
+
+	return message
 }
 
 // TokenDetectionProcessor est un processeur pour les détections de tokens
@@ -258,6 +480,13 @@ func (p *TokenDetectionProcessor) GetName() string {
 	return p.name
 }
 
+// Subscriptions retourne les événements consommés par le processeur
+func (p *TokenDetectionProcessor) Subscriptions() []Subscription {
+	return []Subscription{
+		{Stream: StreamTokenEvents, EventTypes: []string{EventTypeTokenDetected}},
+	}
+}
+
 // TokenProcessor est un processeur pour les événements de tokens
 type TokenProcessor struct {
 	name        string
@@ -266,7 +495,7 @@ type TokenProcessor struct {
 		UpdateTokenState(tokenAddress, newState string) error
 		SaveReactivationMetrics(candidate models.ReactivationCandidate) error
 	}
-	logger      *logrus.Logger
+	logger *logrus.Logger
 }
 
 // NewTokenProcessor crée un nouveau processeur d'événements de tokens
@@ -285,45 +514,44 @@ func NewTokenProcessor(tokenEngine interface {
 // Process traite un événement de token
 func (p *TokenProcessor) Process(message Message) error {
 	p.logger.WithFields(logrus.Fields{
-		"msg_id":   message.ID,
-		"msg_type": message.Type,
+		"msg_id":         message.ID,
+		"msg_type":       message.Type,
+		"schema_version": message.SchemaVersion,
 	}).Debug("Processing token event")
 
-	// Valider le message
-	tokenAddress, ok := message.Payload["token_address"].(string)
-	if !ok {
-		return fmt.Errorf("missing or invalid token_address in message payload")
+	// Traiter différents types d'événements
+	switch event := message.Event.(type) {
+	case *PriceChange:
+		return p.processPriceChange(event)
+	case *VolumeSpike:
+		return p.processVolumeSpike(event)
+	case *Reactivation:
+		return p.processReactivation(event)
+	case *StateChange:
+		return p.processStateChange(event)
 	}
 
-	// Traiter différents types d'événements
+	// Un type connu sans événement décodé signale un payload invalide
 	switch message.Type {
-	case "price_change":
-		return p.processPriceChange(tokenAddress, message.Payload)
-	case "volume_spike":
-		return p.processVolumeSpike(tokenAddress, message.Payload)
-	case "reactivation":
-		return p.processReactivation(tokenAddress, message.Payload)
-	case "state_change":
-		return p.processStateChange(tokenAddress, message.Payload)
-	default:
-		p.logger.WithFields(logrus.Fields{
-			"event_type": message.Type,
-		}).Info("Unknown event type, ignoring")
-		return nil
+	case EventTypePriceChange, EventTypeVolumeSpike, EventTypeReactivation, EventTypeStateChange:
+		return fmt.Errorf("undecodable %s event", message.Type)
 	}
+
+	p.logger.WithFields(logrus.Fields{
+		"event_type": message.Type,
+	}).Info("Unknown event type, ignoring")
+	return nil
 }
 
 // processPriceChange traite un changement de prix
-func (p *TokenProcessor) processPriceChange(tokenAddress string, payload map[string]interface{}) error {
-	// Extraire les données
-	priceChange, ok := payload["price_change"].(float64)
-	if !ok {
-		return fmt.Errorf("missing or invalid price_change in payload")
+func (p *TokenProcessor) processPriceChange(event *PriceChange) error {
+	if event.TokenAddress == "" {
+		return fmt.Errorf("missing token_address in price_change event")
 	}
 
 	p.logger.WithFields(logrus.Fields{
-		"token_address": tokenAddress,
-		"price_change":  priceChange,
+		"token_address": event.TokenAddress,
+		"price_change":  event.PriceChange,
 	}).Info("Processing price change event")
 
 	// Logique à implémenter selon les besoins
@@ -331,16 +559,14 @@ func (p *TokenProcessor) processPriceChange(tokenAddress string, payload map[str
 }
 
 // processVolumeSpike traite un pic de volume
-func (p *TokenProcessor) processVolumeSpike(tokenAddress string, payload map[string]interface{}) error {
-	// Extraire les données
-	volume, ok := payload["volume"].(float64)
-	if !ok {
-		return fmt.Errorf("missing or invalid volume in payload")
+func (p *TokenProcessor) processVolumeSpike(event *VolumeSpike) error {
+	if event.TokenAddress == "" {
+		return fmt.Errorf("missing token_address in volume_spike event")
 	}
 
 	p.logger.WithFields(logrus.Fields{
-		"token_address": tokenAddress,
-		"volume":        volume,
+		"token_address": event.TokenAddress,
+		"volume_change": event.VolumeChange,
 	}).Info("Processing volume spike event")
 
 	// Logique à implémenter selon les besoins
@@ -348,20 +574,18 @@ func (p *TokenProcessor) processVolumeSpike(tokenAddress string, payload map[str
 }
 
 // processReactivation traite une réactivation de token
-func (p *TokenProcessor) processReactivation(tokenAddress string, payload map[string]interface{}) error {
-	// Extraire les données
-	reactivationScore, ok := payload["reactivation_score"].(float64)
-	if !ok {
-		return fmt.Errorf("missing or invalid reactivation_score in payload")
+func (p *TokenProcessor) processReactivation(event *Reactivation) error {
+	if event.TokenAddress == "" {
+		return fmt.Errorf("missing token_address in reactivation event")
 	}
 
 	p.logger.WithFields(logrus.Fields{
-		"token_address":      tokenAddress,
-		"reactivation_score": reactivationScore,
+		"token_address":      event.TokenAddress,
+		"reactivation_score": event.ReactivationScore,
 	}).Info("Processing token reactivation event")
 
 	// Changer l'état du token
-	err := p.tokenEngine.UpdateTokenState(tokenAddress, models.LifecycleStateReactivated)
+	err := p.tokenEngine.UpdateTokenState(event.TokenAddress, models.LifecycleStateReactivated)
 	if err != nil {
 		return fmt.Errorf("failed to update token state: %w", err)
 	}
@@ -370,20 +594,18 @@ func (p *TokenProcessor) processReactivation(tokenAddress string, payload map[st
 }
 
 // processStateChange traite un changement d'état de token
-func (p *TokenProcessor) processStateChange(tokenAddress string, payload map[string]interface{}) error {
-	// Extraire les données
-	newState, ok := payload["new_state"].(string)
-	if !ok {
-		return fmt.Errorf("missing or invalid new_state in payload")
+func (p *TokenProcessor) processStateChange(event *StateChange) error {
+	if event.TokenAddress == "" || event.NewState == "" {
+		return fmt.Errorf("missing token_address or new_state in state_change event")
 	}
 
 	p.logger.WithFields(logrus.Fields{
-		"token_address": tokenAddress,
-		"new_state":     newState,
+		"token_address": event.TokenAddress,
+		"new_state":     event.NewState,
 	}).Info("Processing token state change event")
 
 	// Mettre à jour l'état du token
-	err := p.tokenEngine.UpdateTokenState(tokenAddress, newState)
+	err := p.tokenEngine.UpdateTokenState(event.TokenAddress, event.NewState)
 	if err != nil {
 		return fmt.Errorf("failed to update token state: %w", err)
 	}
@@ -396,7 +618,36 @@ func (p *TokenProcessor) GetName() string {
 	return p.name
 }
 
-// GetRedisClient retourne le client Redis du pipeline
+// IdempotencyKey retourne l'ID de l'événement: un changement d'état relivré
+// n'est pas appliqué deux fois
+func (p *TokenProcessor) IdempotencyKey(message Message) string {
+	return message.EventID
+}
+
+// Subscriptions retourne les événements consommés par le processeur
+func (p *TokenProcessor) Subscriptions() []Subscription {
+	return []Subscription{
+		{
+			Stream: StreamTokenEvents,
+			EventTypes: []string{
+				EventTypePriceChange,
+				EventTypeVolumeSpike,
+				EventTypeReactivation,
+				EventTypeStateChange,
+			},
+		},
+	}
+}
+
+// GetBroker retourne le broker du pipeline
+func (p *Pipeline) GetBroker() Broker {
+	return p.broker
+}
+
+// GetRedisClient retourne le client Redis du pipeline, nil hors Redis Streams
 func (p *Pipeline) GetRedisClient() *cache.Redis {
-	return p.cache
-} 
\ No newline at end of file
+	if rb, ok := p.broker.(*RedisBroker); ok {
+		return rb.Client()
+	}
+	return nil
+}
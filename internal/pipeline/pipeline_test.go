@@ -0,0 +1,339 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// recordingProcessor transmet les messages reçus et peut échouer systématiquement
+type recordingProcessor struct {
+	name     string
+	subs     []Subscription
+	fail     bool
+	received chan Message
+}
+
+func (r *recordingProcessor) Process(message Message) error {
+	r.received <- message
+	if r.fail {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func (r *recordingProcessor) GetName() string { return r.name }
+
+func (r *recordingProcessor) Subscriptions() []Subscription { return r.subs }
+
+// newTestPipeline crée un pipeline en mémoire avec des délais courts
+func newTestPipeline(t *testing.T, partitions int) *Pipeline {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	p := NewPipelineWithBroker(NewMemoryBroker(), logger)
+	p.SetConsumerOptions(ConsumerOptions{
+		ConsumerID: "test",
+		Workers:    2,
+		Partitions: partitions,
+		BatchSize:  10,
+		LeaseTTL:   300 * time.Millisecond,
+
+		ScheduleInterval: 10 * time.Millisecond,
+	})
+	p.SetRetryPolicy(RetryPolicy{
+		MaxAttempts:     3,
+		BaseBackoff:     time.Millisecond,
+		MaxBackoff:      5 * time.Millisecond,
+		ReclaimInterval: 10 * time.Millisecond,
+		BatchSize:       10,
+	})
+	return p
+}
+
+// waitGroups attend que chaque groupe détienne toutes ses partitions
+func waitGroups(t *testing.T, p *Pipeline) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		ready := true
+		for _, stats := range p.Stats() {
+			if len(stats.OwnedPartitions) != stats.Partitions {
+				ready = false
+			}
+		}
+		if ready {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("consumer groups did not acquire their partitions")
+}
+
+func TestPipelineRoutesSubscribedEvents(t *testing.T) {
+	p := newTestPipeline(t, 4)
+	proc := &recordingProcessor{
+		name:     "prices",
+		subs:     []Subscription{{Stream: StreamTokenEvents, EventTypes: []string{EventTypePriceChange}}},
+		received: make(chan Message, 10),
+	}
+	p.RegisterProcessor(proc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	p.PublishEvent(StreamTokenEvents, &StateChange{TokenAddress: "tok", NewState: "HYPED"})
+	for i := 0; i < 3; i++ {
+		p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok", PriceChange: float64(i)})
+	}
+
+	// Les événements d'un même token arrivent dans l'ordre, hors filtre exclus
+	for i := 0; i < 3; i++ {
+		select {
+		case msg := <-proc.received:
+			event, ok := msg.Event.(*PriceChange)
+			if !ok {
+				t.Fatalf("received %T, want *PriceChange", msg.Event)
+			}
+			if event.PriceChange != float64(i) {
+				t.Fatalf("event %d out of order: %v", i, event.PriceChange)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("event %d not delivered", i)
+		}
+	}
+
+	topology := p.Topology()
+	if len(topology) != 1 || topology[0].Stream != StreamTokenEvents || len(topology[0].Partitions) != 4 {
+		t.Errorf("unexpected topology: %+v", topology)
+	}
+}
+
+func TestPipelineDeadLettersAfterMaxAttempts(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	proc := &recordingProcessor{
+		name:     "failing",
+		subs:     []Subscription{{Stream: StreamTokenEvents}},
+		fail:     true,
+		received: make(chan Message, 10),
+	}
+	p.RegisterProcessor(proc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok"})
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		letters, err := p.ListDeadLetters(StreamTokenEvents, 10)
+		if err != nil {
+			t.Fatalf("ListDeadLetters: %v", err)
+		}
+		if len(letters) == 1 {
+			if letters[0].Attempts != 3 || letters[0].Error != "boom" || letters[0].Group != "failing" {
+				t.Errorf("unexpected dead letter: %+v", letters[0])
+			}
+			if got := len(proc.received); got != 3 {
+				t.Errorf("processed %d times, want 3", got)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("message was not dead-lettered")
+}
+
+// flakyProcessor échoue sur ses premières livraisons puis réussit
+type flakyProcessor struct {
+	recordingProcessor
+	failures int64
+}
+
+func (f *flakyProcessor) Process(message Message) error {
+	f.received <- message
+	if atomic.AddInt64(&f.failures, -1) >= 0 {
+		return errors.New("boom")
+	}
+	return nil
+}
+
+func TestPipelineReplaysDeadLetterToFailingGroupOnly(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	healthy := &recordingProcessor{
+		name:     "healthy",
+		subs:     []Subscription{{Stream: StreamTokenEvents}},
+		received: make(chan Message, 10),
+	}
+	flaky := &flakyProcessor{
+		recordingProcessor: recordingProcessor{
+			name:     "flaky",
+			subs:     []Subscription{{Stream: StreamTokenEvents}},
+			received: make(chan Message, 10),
+		},
+		failures: 3,
+	}
+	p.RegisterProcessor(healthy)
+	p.RegisterProcessor(flaky)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok"})
+
+	var letter DeadLetter
+	deadline := time.Now().Add(3 * time.Second)
+	for letter.ID == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("message was not dead-lettered")
+		}
+		letters, err := p.ListDeadLetters(StreamTokenEvents, 10)
+		if err != nil {
+			t.Fatalf("ListDeadLetters: %v", err)
+		}
+		if len(letters) == 1 {
+			letter = letters[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if letter.Group != "flaky" {
+		t.Fatalf("dead letter group = %q, want flaky", letter.Group)
+	}
+	if _, ok := letter.Values[fieldReplayGroup]; ok {
+		t.Errorf("dead letter exposes the replay field: %+v", letter.Values)
+	}
+
+	if err := p.ReplayDeadLetter(StreamTokenEvents, letter.ID); err != nil {
+		t.Fatalf("ReplayDeadLetter: %v", err)
+	}
+
+	// Seul le groupe en échec reçoit le message rejoué
+	for len(flaky.received) < 4 {
+		if time.Now().After(deadline.Add(2 * time.Second)) {
+			t.Fatal("replayed message not delivered to the failing group")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if got := len(healthy.received); got != 1 {
+		t.Errorf("healthy group processed %d times, want 1", got)
+	}
+	if got := len(flaky.received); got != 4 {
+		t.Errorf("failing group processed %d times, want 4", got)
+	}
+}
+
+// idempotentProcessor déduplique par ID d'événement
+type idempotentProcessor struct {
+	recordingProcessor
+}
+
+func (p *idempotentProcessor) IdempotencyKey(message Message) string {
+	return message.EventID
+}
+
+func TestPipelineSkipsRedeliveredEvents(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	proc := &idempotentProcessor{recordingProcessor{
+		name:     "state",
+		subs:     []Subscription{{Stream: StreamTokenEvents}},
+		received: make(chan Message, 10),
+	}}
+	p.RegisterProcessor(proc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	// Le même événement publié deux fois, comme après un rejeu de dead letter
+	env, err := p.registry.Encode(&StateChange{TokenAddress: "tok", NewState: "HYPED"})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.broker.Publish(StreamTokenEvents, env.Values()); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	select {
+	case msg := <-proc.received:
+		if msg.EventID != env.ID {
+			t.Errorf("event ID = %q, want %q", msg.EventID, env.ID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not delivered")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if stats := p.Stats(); len(stats) == 1 && stats[0].Duplicates == 1 {
+			if got := len(proc.received); got != 0 {
+				t.Errorf("duplicate processed %d times", got)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("duplicate was not detected")
+}
+
+// blockingProcessor bloque chaque message jusqu'à la fermeture de release
+type blockingProcessor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingProcessor) Process(message Message) error {
+	b.started <- struct{}{}
+	<-b.release
+	return nil
+}
+
+func (b *blockingProcessor) GetName() string { return "blocking" }
+
+func (b *blockingProcessor) Subscriptions() []Subscription {
+	return []Subscription{{Stream: StreamTokenEvents}}
+}
+
+func TestPipelineShutdownDrainsInFlightMessages(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	proc := &blockingProcessor{started: make(chan struct{}, 1), release: make(chan struct{})}
+	p.RegisterProcessor(proc)
+
+	p.Start(context.Background())
+	waitGroups(t, p)
+
+	p.PublishEvent(StreamTokenEvents, &PriceChange{TokenAddress: "tok"})
+	select {
+	case <-proc.started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("event not delivered")
+	}
+
+	// Le message en cours n'est pas terminé: l'arrêt dépasse son délai
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); err == nil {
+		t.Fatal("Shutdown returned before in-flight message completed")
+	}
+
+	close(proc.release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown after drain: %v", err)
+	}
+	if stats := p.Stats(); len(stats) != 1 || stats[0].Processed != 1 {
+		t.Errorf("unexpected stats after drain: %+v", stats)
+	}
+}
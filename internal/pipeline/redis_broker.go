@@ -0,0 +1,184 @@
+package pipeline
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/storage/cache"
+)
+
+// RedisBroker implémente Broker sur Redis Streams
+type RedisBroker struct {
+	redis *cache.Redis
+}
+
+// NewRedisBroker crée un broker Redis Streams
+func NewRedisBroker(redis *cache.Redis) *RedisBroker {
+	return &RedisBroker{redis: redis}
+}
+
+// Client retourne le client Redis sous-jacent
+func (b *RedisBroker) Client() *cache.Redis {
+	return b.redis
+}
+
+// Publish ajoute un message à un stream
+func (b *RedisBroker) Publish(stream string, values map[string]interface{}) (string, error) {
+	return b.redis.XAddID(stream, values)
+}
+
+// CreateGroup crée un groupe de consommateurs
+func (b *RedisBroker) CreateGroup(stream, group string) error {
+	return b.redis.XGroupCreate(stream, group)
+}
+
+// ReadGroup lit les nouveaux messages d'un groupe
+func (b *RedisBroker) ReadGroup(stream, group, consumer string, count int, block time.Duration) ([]StreamMessage, error) {
+	messages, err := b.redis.XReadGroup(stream, group, consumer, count, block)
+	if err != nil {
+		return nil, err
+	}
+	return fromXMessages(messages), nil
+}
+
+// Ack acquitte des messages
+func (b *RedisBroker) Ack(stream, group string, ids ...string) error {
+	for _, id := range ids {
+		if err := b.redis.XAck(stream, group, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending liste les messages en attente d'un groupe
+func (b *RedisBroker) Pending(stream, group string, count int) ([]PendingMessage, error) {
+	entries, err := b.redis.XPending(stream, group, count)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]PendingMessage, 0, len(entries))
+	for _, e := range entries {
+		pending = append(pending, PendingMessage{
+			ID:         e.ID,
+			Consumer:   e.Consumer,
+			Idle:       e.Idle,
+			Deliveries: e.RetryCount,
+		})
+	}
+	return pending, nil
+}
+
+// Claim réattribue des messages en attente
+func (b *RedisBroker) Claim(stream, group, consumer string, minIdle time.Duration, ids ...string) ([]StreamMessage, error) {
+	messages, err := b.redis.XClaim(stream, group, consumer, minIdle, ids...)
+	if err != nil {
+		return nil, err
+	}
+	return fromXMessages(messages), nil
+}
+
+// Range lit les messages entre deux IDs
+func (b *RedisBroker) Range(stream, start, stop string, count int) ([]StreamMessage, error) {
+	messages, err := b.redis.XRange(stream, start, stop, count)
+	if err != nil {
+		return nil, err
+	}
+	return fromXMessages(messages), nil
+}
+
+// Delete supprime des messages
+func (b *RedisBroker) Delete(stream string, ids ...string) error {
+	return b.redis.XDel(stream, ids...)
+}
+
+// Groups décrit les groupes d'un stream
+func (b *RedisBroker) Groups(stream string) ([]GroupInfo, error) {
+	infos, err := b.redis.XInfoGroups(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]GroupInfo, 0, len(infos))
+	for _, g := range infos {
+		groups = append(groups, GroupInfo{
+			Name:            g.Name,
+			Consumers:       g.Consumers,
+			Pending:         g.Pending,
+			LastDeliveredID: g.LastDeliveredID,
+		})
+	}
+	return groups, nil
+}
+
+// Trim supprime les messages les plus anciens. La limite de longueur est approximative
+// (XTRIM MAXLEN ~) pour ne supprimer que des nœuds entiers du stream.
+func (b *RedisBroker) Trim(stream string, maxLen int64, minID string) (int64, error) {
+	var trimmed int64
+	if minID != "" {
+		n, err := b.redis.XTrimMinID(stream, minID)
+		if err != nil {
+			return trimmed, err
+		}
+		trimmed += n
+	}
+	if maxLen > 0 {
+		n, err := b.redis.XTrimMaxLen(stream, maxLen)
+		if err != nil {
+			return trimmed, err
+		}
+		trimmed += n
+	}
+	return trimmed, nil
+}
+
+// AcquireLease pose un bail
+func (b *RedisBroker) AcquireLease(key, owner string, ttl time.Duration) (bool, error) {
+	return b.redis.AcquireLock(key, owner, ttl)
+}
+
+// RefreshLease prolonge un bail
+func (b *RedisBroker) RefreshLease(key, owner string, ttl time.Duration) (bool, error) {
+	return b.redis.RefreshLock(key, owner, ttl)
+}
+
+// ReleaseLease libère un bail
+func (b *RedisBroker) ReleaseLease(key, owner string) error {
+	_, err := b.redis.ReleaseLock(key, owner)
+	return err
+}
+
+// Heartbeat signale la présence d'un membre dans un ensemble trié horodaté
+func (b *RedisBroker) Heartbeat(key, member string, ttl time.Duration) (int64, error) {
+	if err := b.redis.ZAdd(key, float64(time.Now().UnixMilli()), member); err != nil {
+		return 0, err
+	}
+	return b.Members(key, ttl)
+}
+
+// Members compte les membres vus depuis ttl après purge des membres expirés
+func (b *RedisBroker) Members(key string, ttl time.Duration) (int64, error) {
+	expired := strconv.FormatInt(time.Now().Add(-ttl).UnixMilli(), 10)
+	if err := b.redis.ZRemRangeByScore(key, "-inf", "("+expired); err != nil {
+		return 0, err
+	}
+	return b.redis.ZCount(key, "-inf", "+inf")
+}
+
+// Leave retire un membre
+func (b *RedisBroker) Leave(key, member string) error {
+	return b.redis.ZRem(key, member)
+}
+
+// fromXMessages convertit les messages Redis au format du pipeline
+func fromXMessages(messages []cache.XMessage) []StreamMessage {
+	result := make([]StreamMessage, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, StreamMessage{
+			ID:     m.ID,
+			Values: m.Values,
+		})
+	}
+	return result
+}
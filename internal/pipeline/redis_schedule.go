@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/storage/cache"
+	"github.com/go-redis/redis/v8"
+)
+
+// Clés Redis des messages différés: ensemble trié par date de publication (ms)
+// et hash des messages indexé par clé de programmation
+const (
+	scheduleIndexKey    = "pipeline:scheduled"
+	scheduleMessagesKey = "pipeline:scheduled:messages"
+)
+
+// RedisScheduleStore conserve les messages différés dans Redis: ils survivent aux
+// redémarrages et sont partagés entre instances
+type RedisScheduleStore struct {
+	redis *cache.Redis
+}
+
+// NewRedisScheduleStore crée un store de messages différés Redis
+func NewRedisScheduleStore(redis *cache.Redis) *RedisScheduleStore {
+	return &RedisScheduleStore{redis: redis}
+}
+
+// Schedule enregistre un message
+func (s *RedisScheduleStore) Schedule(msg ScheduledMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scheduled message: %w", err)
+	}
+	return s.redis.ZAddWithHash(scheduleIndexKey, scheduleMessagesKey, msg.Key, scheduleScore(msg.DeliverAt), string(data))
+}
+
+// Cancel supprime le message d'une clé
+func (s *RedisScheduleStore) Cancel(key string) (bool, error) {
+	n, err := s.redis.ZRemWithHash(scheduleIndexKey, scheduleMessagesKey, key)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Due retourne les messages échus par date croissante
+func (s *RedisScheduleStore) Due(now time.Time, limit int) ([]ScheduledMessage, error) {
+	members, err := s.redis.ZRangeByScore(scheduleIndexKey, "-inf", strconv.FormatInt(now.UnixMilli(), 10), int64(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]ScheduledMessage, 0, len(members))
+	for _, m := range members {
+		msg, err := s.Get(m.Member)
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			// Annulé entre la lecture de l'index et celle du message
+			continue
+		}
+		due = append(due, *msg)
+	}
+	return due, nil
+}
+
+// Complete retire un message publié s'il n'a pas été reprogrammé
+func (s *RedisScheduleStore) Complete(msg ScheduledMessage) error {
+	_, err := s.redis.ZRemWithHashIfScore(scheduleIndexKey, scheduleMessagesKey, msg.Key, scheduleScore(msg.DeliverAt))
+	return err
+}
+
+// Get retourne le message programmé d'une clé
+func (s *RedisScheduleStore) Get(key string) (*ScheduledMessage, error) {
+	data, err := s.redis.HGet(scheduleMessagesKey, key)
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var msg ScheduledMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scheduled message: %w", err)
+	}
+	return &msg, nil
+}
+
+// scheduleScore convertit une date de publication en score de l'index
+func scheduleScore(at time.Time) float64 {
+	return float64(at.UnixMilli())
+}
@@ -0,0 +1,354 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+// Champs ajoutés aux messages déplacés dans un stream de dead-letter
+const (
+	deadLetterSuffix       = ":dlq"
+	dlqFieldOriginalID     = "dlq_original_id"
+	dlqFieldStream         = "dlq_stream"
+	dlqFieldGroup          = "dlq_group"
+	dlqFieldAttempts       = "dlq_attempts"
+	dlqFieldError          = "dlq_error"
+	dlqFieldDeadLetteredAt = "dlq_dead_lettered_at"
+)
+
+// fieldReplayGroup désigne le seul groupe auquel un message rejoué depuis le dead-letter
+// est livré; les autres groupes l'ont déjà traité et l'acquittent sans le traiter
+const fieldReplayGroup = "replay_group"
+
+// RetryPolicy contrôle la reprise des messages non acquittés
+type RetryPolicy struct {
+	MaxAttempts     int           // Nombre de livraisons avant passage en dead-letter
+	BaseBackoff     time.Duration // Délai avant la première reprise
+	MaxBackoff      time.Duration // Délai maximal entre deux reprises
+	ReclaimInterval time.Duration // Fréquence d'inspection des messages en attente
+	BatchSize       int           // Nombre de messages en attente inspectés par passe
+}
+
+// DefaultRetryPolicy retourne la politique de reprise par défaut
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     5,
+		BaseBackoff:     5 * time.Second,
+		MaxBackoff:      5 * time.Minute,
+		ReclaimInterval: 5 * time.Second,
+		BatchSize:       50,
+	}
+}
+
+// RetryPolicyFromConfig construit la politique de reprise à partir de la configuration
+func RetryPolicyFromConfig(cfg *config.PipelineConfig) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if cfg == nil {
+		return policy
+	}
+	if cfg.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.RetryBaseDelayMs > 0 {
+		policy.BaseBackoff = time.Duration(cfg.RetryBaseDelayMs) * time.Millisecond
+	}
+	if cfg.RetryMaxDelayMs > 0 {
+		policy.MaxBackoff = time.Duration(cfg.RetryMaxDelayMs) * time.Millisecond
+	}
+	if cfg.ReclaimIntervalMs > 0 {
+		policy.ReclaimInterval = time.Duration(cfg.ReclaimIntervalMs) * time.Millisecond
+	}
+	return policy
+}
+
+// Backoff retourne le délai d'inactivité requis avant la reprise suivante.
+// Le délai double à chaque livraison: base, 2×base, 4×base... plafonné à MaxBackoff.
+func (r RetryPolicy) Backoff(deliveries int64) time.Duration {
+	if deliveries < 1 {
+		deliveries = 1
+	}
+	backoff := r.BaseBackoff
+	for i := int64(1); i < deliveries; i++ {
+		backoff *= 2
+		if backoff >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return backoff
+}
+
+// DeadLetter est un message déplacé dans un stream de dead-letter
+type DeadLetter struct {
+	ID             string                 `json:"id"`
+	OriginalID     string                 `json:"original_id"`
+	Stream         string                 `json:"stream"`
+	Group          string                 `json:"group"`
+	Attempts       int64                  `json:"attempts"`
+	Error          string                 `json:"error,omitempty"`
+	DeadLetteredAt time.Time              `json:"dead_lettered_at"`
+	Values         map[string]interface{} `json:"values"`
+}
+
+// failureLog conserve la dernière erreur de traitement de chaque message
+type failureLog struct {
+	mu     sync.Mutex
+	errors map[string]string
+}
+
+// newFailureLog crée un journal d'erreurs vide
+func newFailureLog() *failureLog {
+	return &failureLog{errors: make(map[string]string)}
+}
+
+func (f *failureLog) key(stream, group, id string) string {
+	return stream + "|" + group + "|" + id
+}
+
+// record enregistre l'erreur d'un message
+func (f *failureLog) record(stream, group, id string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errors[f.key(stream, group, id)] = err.Error()
+}
+
+// take retourne et oublie l'erreur d'un message
+func (f *failureLog) take(stream, group, id string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := f.key(stream, group, id)
+	msg := f.errors[k]
+	delete(f.errors, k)
+	return msg
+}
+
+// DeadLetterStream retourne le nom du stream de dead-letter associé à un stream
+func DeadLetterStream(streamName string) string {
+	return streamName + deadLetterSuffix
+}
+
+// SetRetryPolicy remplace la politique de reprise des messages en échec
+func (p *Pipeline) SetRetryPolicy(policy RetryPolicy) {
+	p.retryPolicy = policy
+}
+
+// reclaimPending effectue une passe de reprise sur les messages en attente d'une partition.
+// Seule l'instance détentrice de la partition la reprend, y compris les messages
+// laissés en attente par une instance disparue.
+func (p *Pipeline) reclaimPending(part partition, g *consumerGroup) {
+	group := g.processor.GetName()
+	streamName := part.stream
+
+	pending, err := p.broker.Pending(streamName, group, p.retryPolicy.BatchSize)
+	if err != nil {
+		p.logger.WithFields(logrus.Fields{
+			"stream":    streamName,
+			"processor": group,
+			"error":     err.Error(),
+		}).Error("Failed to list pending messages")
+		return
+	}
+
+	for _, entry := range pending {
+		if entry.Deliveries >= int64(p.retryPolicy.MaxAttempts) {
+			if err := p.deadLetter(part, g, entry.ID, entry.Deliveries); err != nil {
+				p.logger.WithFields(logrus.Fields{
+					"stream":    streamName,
+					"processor": group,
+					"msg_id":    entry.ID,
+					"error":     err.Error(),
+				}).Error("Failed to dead-letter message")
+			}
+			continue
+		}
+
+		backoff := p.retryPolicy.Backoff(entry.Deliveries)
+		if entry.Idle < backoff {
+			continue
+		}
+
+		// XCLAIM incrémente le compteur de livraisons
+		claimed, err := p.broker.Claim(streamName, group, p.consumerOpts.ConsumerID, backoff, entry.ID)
+		if err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"stream":    streamName,
+				"processor": group,
+				"msg_id":    entry.ID,
+				"error":     err.Error(),
+			}).Error("Failed to claim pending message")
+			continue
+		}
+
+		for _, msg := range claimed {
+			p.logger.WithFields(logrus.Fields{
+				"stream":    streamName,
+				"processor": group,
+				"msg_id":    msg.ID,
+				"attempt":   entry.Deliveries + 1,
+			}).Info("Retrying pending message")
+			p.handleMessage(part, g, msg, entry.Deliveries+1)
+		}
+	}
+}
+
+// deadLetter déplace un message dans le stream de dead-letter du stream logique puis l'acquitte
+func (p *Pipeline) deadLetter(part partition, g *consumerGroup, id string, attempts int64) error {
+	streamName := part.stream
+	group := g.processor.GetName()
+	messages, err := p.broker.Range(streamName, id, id, 1)
+	if err != nil {
+		return fmt.Errorf("failed to read message: %w", err)
+	}
+
+	values := make(map[string]interface{})
+	if len(messages) > 0 {
+		for k, v := range messages[0].Values {
+			values[k] = v
+		}
+	}
+	values[dlqFieldOriginalID] = id
+	values[dlqFieldStream] = streamName
+	values[dlqFieldGroup] = group
+	values[dlqFieldAttempts] = strconv.FormatInt(attempts, 10)
+	values[dlqFieldError] = p.failures.take(streamName, group, id)
+	values[dlqFieldDeadLetteredAt] = time.Now().UTC().Format(time.RFC3339Nano)
+
+	if _, err := p.broker.Publish(DeadLetterStream(part.logical), values); err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
+	}
+
+	if err := p.broker.Ack(streamName, group, id); err != nil {
+		return fmt.Errorf("failed to acknowledge dead letter: %w", err)
+	}
+	atomic.AddInt64(&g.counters.deadLettered, 1)
+
+	p.logger.WithFields(logrus.Fields{
+		"stream":    streamName,
+		"processor": group,
+		"msg_id":    id,
+		"attempts":  attempts,
+	}).Warn("Message moved to dead-letter stream")
+
+	return nil
+}
+
+// ListDeadLetters liste les messages du stream de dead-letter d'un stream
+func (p *Pipeline) ListDeadLetters(streamName string, count int) ([]DeadLetter, error) {
+	messages, err := p.broker.Range(DeadLetterStream(streamName), "-", "+", count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter stream: %w", err)
+	}
+
+	letters := make([]DeadLetter, 0, len(messages))
+	for _, msg := range messages {
+		letters = append(letters, toDeadLetter(msg.ID, msg.Values))
+	}
+
+	return letters, nil
+}
+
+// GetDeadLetter récupère un message du stream de dead-letter
+func (p *Pipeline) GetDeadLetter(streamName, id string) (*DeadLetter, error) {
+	messages, err := p.broker.Range(DeadLetterStream(streamName), id, id, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead letter: %w", err)
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("dead letter not found: %s", id)
+	}
+
+	letter := toDeadLetter(messages[0].ID, messages[0].Values)
+	return &letter, nil
+}
+
+// ReplayDeadLetter republie un message dans son stream d'origine et le retire du dead-letter.
+// Le message est routé vers la partition courante de son token et livré à nouveau au seul
+// groupe qui a échoué; les autres groupes l'acquittent sans le traiter.
+func (p *Pipeline) ReplayDeadLetter(streamName, id string) error {
+	letter, err := p.GetDeadLetter(streamName, id)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]interface{}, len(letter.Values)+1)
+	for k, v := range letter.Values {
+		values[k] = v
+	}
+	if letter.Group != "" {
+		values[fieldReplayGroup] = letter.Group
+	}
+
+	target := p.routeStream(streamName, tokenAddressFromValues(letter.Values))
+	if _, err := p.broker.Publish(target, values); err != nil {
+		return fmt.Errorf("failed to replay dead letter: %w", err)
+	}
+
+	if err := p.broker.Delete(DeadLetterStream(streamName), id); err != nil {
+		return fmt.Errorf("failed to remove replayed dead letter: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"stream":      streamName,
+		"dlq_id":      id,
+		"original_id": letter.OriginalID,
+		"processor":   letter.Group,
+	}).Info("Dead letter replayed")
+
+	return nil
+}
+
+// DropDeadLetter supprime définitivement un message du stream de dead-letter
+func (p *Pipeline) DropDeadLetter(streamName, id string) error {
+	if _, err := p.GetDeadLetter(streamName, id); err != nil {
+		return err
+	}
+
+	if err := p.broker.Delete(DeadLetterStream(streamName), id); err != nil {
+		return fmt.Errorf("failed to drop dead letter: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"stream": streamName,
+		"dlq_id": id,
+	}).Info("Dead letter dropped")
+
+	return nil
+}
+
+// toDeadLetter sépare les métadonnées de dead-letter des champs d'origine
+func toDeadLetter(id string, values map[string]interface{}) DeadLetter {
+	letter := DeadLetter{
+		ID:     id,
+		Values: make(map[string]interface{}),
+	}
+
+	for k, v := range values {
+		str := fmt.Sprint(v)
+		switch k {
+		case dlqFieldOriginalID:
+			letter.OriginalID = str
+		case dlqFieldStream:
+			letter.Stream = str
+		case dlqFieldGroup:
+			letter.Group = str
+		case dlqFieldAttempts:
+			letter.Attempts, _ = strconv.ParseInt(str, 10, 64)
+		case dlqFieldError:
+			letter.Error = str
+		case dlqFieldDeadLetteredAt:
+			letter.DeadLetteredAt, _ = time.Parse(time.RFC3339Nano, str)
+		default:
+			if !strings.HasPrefix(k, "dlq_") && k != fieldReplayGroup {
+				letter.Values[k] = v
+			}
+		}
+	}
+
+	return letter
+}
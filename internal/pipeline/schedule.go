@@ -0,0 +1,164 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// schedulerLeaseKey désigne l'instance qui publie les messages différés échus
+const schedulerLeaseKey = "pipeline:lease:scheduler"
+
+// ScheduledMessage est un message à publier dans un stream à une date donnée
+type ScheduledMessage struct {
+	Key       string                 `json:"key"`
+	Stream    string                 `json:"stream"`
+	DeliverAt time.Time              `json:"deliver_at"`
+	Values    map[string]interface{} `json:"values"`
+}
+
+// ScheduleStore conserve les messages différés jusqu'à leur publication.
+// Un message reste dans le store jusqu'à Complete: après un redémarrage,
+// les messages échus non terminés sont publiés à nouveau (at-least-once).
+type ScheduleStore interface {
+	// Schedule enregistre un message; remplace le message de même clé
+	Schedule(msg ScheduledMessage) error
+	// Cancel supprime le message d'une clé et indique s'il existait
+	Cancel(key string) (bool, error)
+	// Due retourne au plus limit messages échus à now, par date croissante
+	Due(now time.Time, limit int) ([]ScheduledMessage, error)
+	// Complete retire un message publié, sauf s'il a été reprogrammé entre-temps
+	Complete(msg ScheduledMessage) error
+	// Get retourne le message programmé d'une clé, nil s'il n'existe pas
+	Get(key string) (*ScheduledMessage, error)
+}
+
+// SetScheduleStore remplace le store des messages différés
+func (p *Pipeline) SetScheduleStore(store ScheduleStore) {
+	p.schedule = store
+}
+
+// PublishEventAt programme la publication d'un événement à la date at.
+// key identifie la programmation pour l'annuler ou la remplacer; vide, l'ID de
+// l'événement est utilisé. Retourne la clé de programmation.
+func (p *Pipeline) PublishEventAt(streamName string, event Event, at time.Time, key string) (string, error) {
+	env, err := p.registry.Encode(event)
+	if err != nil {
+		return "", err
+	}
+	if key == "" {
+		key = env.ID
+	}
+
+	msg := ScheduledMessage{
+		Key:       key,
+		Stream:    streamName,
+		DeliverAt: at.UTC(),
+		Values:    env.Values(),
+	}
+	if err := p.schedule.Schedule(msg); err != nil {
+		return "", fmt.Errorf("failed to schedule event: %w", err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"stream":     streamName,
+		"key":        key,
+		"event_id":   env.ID,
+		"type":       env.Type,
+		"deliver_at": msg.DeliverAt,
+	}).Debug("Event scheduled")
+
+	return key, nil
+}
+
+// PublishEventAfter programme la publication d'un événement après delay
+func (p *Pipeline) PublishEventAfter(streamName string, event Event, delay time.Duration, key string) (string, error) {
+	return p.PublishEventAt(streamName, event, time.Now().Add(delay), key)
+}
+
+// CancelScheduled annule une publication programmée et indique si elle existait
+func (p *Pipeline) CancelScheduled(key string) (bool, error) {
+	return p.schedule.Cancel(key)
+}
+
+// GetScheduled retourne la publication programmée d'une clé, nil si elle n'existe pas
+func (p *Pipeline) GetScheduled(key string) (*ScheduledMessage, error) {
+	return p.schedule.Get(key)
+}
+
+// runScheduler publie les messages échus tant que l'instance détient le bail du scheduler
+func (p *Pipeline) runScheduler(ctx context.Context) {
+	owner := p.consumerOpts.ConsumerID
+	ttl := p.consumerOpts.LeaseTTL
+	held := false
+
+	ticker := time.NewTicker(p.consumerOpts.ScheduleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if held {
+				if err := p.broker.ReleaseLease(schedulerLeaseKey, owner); err != nil {
+					p.logger.WithField("error", err.Error()).Warn("Failed to release scheduler lease")
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+
+		var err error
+		if held {
+			held, err = p.broker.RefreshLease(schedulerLeaseKey, owner, ttl)
+		} else {
+			held, err = p.broker.AcquireLease(schedulerLeaseKey, owner, ttl)
+		}
+		if err != nil {
+			p.logger.WithField("error", err.Error()).Warn("Failed to hold scheduler lease")
+			held = false
+			continue
+		}
+		if held {
+			p.dispatchDue()
+		}
+	}
+}
+
+// dispatchDue publie les messages échus puis les retire du store.
+// Un arrêt entre les deux republiera le message: l'ID d'événement étant conservé,
+// les processeurs idempotents le dédupliquent.
+func (p *Pipeline) dispatchDue() {
+	due, err := p.schedule.Due(time.Now(), p.consumerOpts.BatchSize)
+	if err != nil {
+		p.logger.WithField("error", err.Error()).Error("Failed to list scheduled messages")
+		return
+	}
+
+	for _, msg := range due {
+		target := p.routeStream(msg.Stream, tokenAddressFromValues(msg.Values))
+		if _, err := p.broker.Publish(target, msg.Values); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"stream": target,
+				"key":    msg.Key,
+				"error":  err.Error(),
+			}).Error("Failed to publish scheduled message")
+			continue
+		}
+
+		if err := p.schedule.Complete(msg); err != nil {
+			p.logger.WithFields(logrus.Fields{
+				"key":   msg.Key,
+				"error": err.Error(),
+			}).Warn("Failed to complete scheduled message")
+			continue
+		}
+
+		p.logger.WithFields(logrus.Fields{
+			"stream": target,
+			"key":    msg.Key,
+			"late":   time.Since(msg.DeliverAt).String(),
+		}).Debug("Scheduled message published")
+	}
+}
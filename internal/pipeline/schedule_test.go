@@ -0,0 +1,75 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPipelinePublishesScheduledEvents(t *testing.T) {
+	p := newTestPipeline(t, 1)
+	proc := &recordingProcessor{
+		name:     "scheduled",
+		subs:     []Subscription{{Stream: StreamTokenEvents}},
+		received: make(chan Message, 10),
+	}
+	p.RegisterProcessor(proc)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.Start(ctx)
+	waitGroups(t, p)
+
+	start := time.Now()
+	if _, err := p.PublishEventAfter(StreamTokenEvents, &StateChange{TokenAddress: "tok", NewState: "HYPED"}, 100*time.Millisecond, ""); err != nil {
+		t.Fatalf("PublishEventAfter: %v", err)
+	}
+	cancelled, err := p.PublishEventAfter(StreamTokenEvents, &StateChange{TokenAddress: "tok", NewState: "DEAD"}, 50*time.Millisecond, "recheck:tok")
+	if err != nil {
+		t.Fatalf("PublishEventAfter: %v", err)
+	}
+	if ok, err := p.CancelScheduled(cancelled); err != nil || !ok {
+		t.Fatalf("CancelScheduled = %v, %v", ok, err)
+	}
+
+	select {
+	case msg := <-proc.received:
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("delivered after %v, before its delay", elapsed)
+		}
+		if event := msg.Event.(*StateChange); event.NewState != "HYPED" {
+			t.Errorf("received cancelled event %+v", event)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("scheduled event not delivered")
+	}
+
+	select {
+	case msg := <-proc.received:
+		t.Errorf("unexpected event: %+v", msg.Event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMemoryScheduleStoreKeepsRescheduledMessages(t *testing.T) {
+	s := NewMemoryScheduleStore()
+	now := time.Now()
+
+	s.Schedule(ScheduledMessage{Key: "k", DeliverAt: now.Add(-time.Second)})
+	due, _ := s.Due(now, 10)
+	if len(due) != 1 {
+		t.Fatalf("got %d due messages, want 1", len(due))
+	}
+
+	// Reprogrammé pendant la publication: Complete ne doit pas le retirer
+	s.Schedule(ScheduledMessage{Key: "k", DeliverAt: now.Add(time.Hour)})
+	s.Complete(due[0])
+
+	msg, _ := s.Get("k")
+	if msg == nil || !msg.DeliverAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("rescheduled message lost: %+v", msg)
+	}
+	if due, _ := s.Due(now, 10); len(due) != 0 {
+		t.Errorf("got %d due messages after reschedule, want 0", len(due))
+	}
+}
@@ -0,0 +1,123 @@
+package pipeline
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Subscription déclare un stream consommé par un processeur et les types d'événements retenus
+type Subscription struct {
+	Stream     string   `json:"stream"`
+	EventTypes []string `json:"event_types,omitempty"` // Vide = tous les types
+}
+
+// Subscriber est implémenté par les processeurs qui déclarent leurs abonnements.
+// Un processeur sans abonnement consomme le stream portant son nom.
+type Subscriber interface {
+	Subscriptions() []Subscription
+}
+
+// subscriptionsOf retourne les abonnements d'un processeur
+func subscriptionsOf(processor Processor) []Subscription {
+	if s, ok := processor.(Subscriber); ok {
+		if subs := s.Subscriptions(); len(subs) > 0 {
+			return subs
+		}
+	}
+	return []Subscription{{Stream: processor.GetName()}}
+}
+
+// Accepts indique si un type d'événement passe le filtre de l'abonnement
+func (s Subscription) Accepts(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupTopology décrit un groupe de consommateurs abonné à un stream
+type GroupTopology struct {
+	Group      string   `json:"group"`
+	Processor  string   `json:"processor"`
+	EventTypes []string `json:"event_types,omitempty"`
+}
+
+// StreamTopology décrit un stream, ses partitions et les groupes qui le consomment
+type StreamTopology struct {
+	Stream     string          `json:"stream"`
+	Partitions []string        `json:"partitions"`
+	Groups     []GroupTopology `json:"groups"`
+}
+
+// Topology retourne le routage streams -> groupes -> processeurs des processeurs enregistrés
+func (p *Pipeline) Topology() []StreamTopology {
+	byStream := make(map[string]*StreamTopology)
+
+	for name, processor := range p.processors {
+		for _, sub := range subscriptionsOf(processor) {
+			st, ok := byStream[sub.Stream]
+			if !ok {
+				st = &StreamTopology{Stream: sub.Stream}
+				for _, part := range p.partitionsOf(sub.Stream) {
+					st.Partitions = append(st.Partitions, part.stream)
+				}
+				byStream[sub.Stream] = st
+			}
+			st.Groups = append(st.Groups, GroupTopology{
+				Group:      name,
+				Processor:  name,
+				EventTypes: sub.EventTypes,
+			})
+		}
+	}
+
+	topology := make([]StreamTopology, 0, len(byStream))
+	for _, st := range byStream {
+		sort.Slice(st.Groups, func(i, j int) bool { return st.Groups[i].Group < st.Groups[j].Group })
+		topology = append(topology, *st)
+	}
+	sort.Slice(topology, func(i, j int) bool { return topology[i].Stream < topology[j].Stream })
+
+	return topology
+}
+
+// logTopology journalise le routage au démarrage du pipeline
+func (p *Pipeline) logTopology() {
+	for _, st := range p.Topology() {
+		for _, g := range st.Groups {
+			types := "*"
+			if len(g.EventTypes) > 0 {
+				types = strings.Join(g.EventTypes, ",")
+			}
+			p.logger.WithFields(logrus.Fields{
+				"stream":      st.Stream,
+				"partitions":  len(st.Partitions),
+				"group":       g.Group,
+				"processor":   g.Processor,
+				"event_types": types,
+			}).Info("Pipeline route")
+		}
+	}
+}
+
+// validateSubscriptions signale les abonnements à des types d'événements inconnus du registre
+func (p *Pipeline) validateSubscriptions(processor Processor) {
+	for _, sub := range subscriptionsOf(processor) {
+		for _, eventType := range sub.EventTypes {
+			if _, ok := p.registry.Version(eventType); !ok {
+				p.logger.WithFields(logrus.Fields{
+					"processor":  processor.GetName(),
+					"stream":     sub.Stream,
+					"event_type": eventType,
+				}).Warn("Subscription to unregistered event type")
+			}
+		}
+	}
+}
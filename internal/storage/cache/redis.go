@@ -112,6 +112,12 @@ func (r *Redis) PurgePattern(pattern string) error {
 
 // XAdd ajoute un message à un stream
 func (r *Redis) XAdd(stream string, values map[string]interface{}) error {
+	_, err := r.XAddID(stream, values)
+	return err
+}
+
+// XAddID ajoute un message à un stream et retourne l'ID attribué
+func (r *Redis) XAddID(stream string, values map[string]interface{}) (string, error) {
 	// Convertir les valeurs complexes en string JSON
 	processedValues := make(map[string]interface{})
 	for k, v := range values {
@@ -120,7 +126,7 @@ func (r *Redis) XAdd(stream string, values map[string]interface{}) error {
 			// Sérialiser les structures complexes en JSON
 			jsonBytes, err := json.Marshal(val)
 			if err != nil {
-				return fmt.Errorf("marshaling error: %w", err)
+				return "", fmt.Errorf("marshaling error: %w", err)
 			}
 			processedValues[k] = string(jsonBytes)
 		default:
@@ -133,7 +139,7 @@ func (r *Redis) XAdd(stream string, values map[string]interface{}) error {
 		Stream: stream,
 		ID:     "*", // Auto-generate ID
 		Values: processedValues,
-	}).Err()
+	}).Result()
 }
 
 // XGroupCreate crée un groupe de consommateurs pour un stream
@@ -194,4 +200,283 @@ func (r *Redis) XReadGroup(stream, group, consumer string, count int, timeout ti
 	}
 
 	return messages, nil
-} 
\ No newline at end of file
+} 
+// XPendingEntry représente un message en attente d'acquittement dans un groupe
+type XPendingEntry struct {
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	RetryCount int64
+}
+
+// XPending liste les messages en attente d'acquittement d'un groupe de consommateurs
+func (r *Redis) XPending(stream, group string, count int) ([]XPendingEntry, error) {
+	result, err := r.client.XPendingExt(r.ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(count),
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return []XPendingEntry{}, nil
+		}
+		return nil, err
+	}
+
+	entries := make([]XPendingEntry, 0, len(result))
+	for _, p := range result {
+		entries = append(entries, XPendingEntry{
+			ID:         p.ID,
+			Consumer:   p.Consumer,
+			Idle:       p.Idle,
+			RetryCount: p.RetryCount,
+		})
+	}
+
+	return entries, nil
+}
+
+// XClaim transfère la propriété de messages en attente à un consommateur
+func (r *Redis) XClaim(stream, group, consumer string, minIdle time.Duration, ids ...string) ([]XMessage, error) {
+	result, err := r.client.XClaim(r.ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return []XMessage{}, nil
+		}
+		return nil, err
+	}
+
+	return toXMessages(result), nil
+}
+
+// XRange lit les messages d'un stream entre deux IDs
+func (r *Redis) XRange(stream, start, stop string, count int) ([]XMessage, error) {
+	result, err := r.client.XRangeN(r.ctx, stream, start, stop, int64(count)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return toXMessages(result), nil
+}
+
+// XDel supprime des messages d'un stream
+func (r *Redis) XDel(stream string, ids ...string) error {
+	return r.client.XDel(r.ctx, stream, ids...).Err()
+}
+
+// XLen retourne le nombre de messages d'un stream
+func (r *Redis) XLen(stream string) (int64, error) {
+	return r.client.XLen(r.ctx, stream).Result()
+}
+
+// XTrimMaxLen conserve environ les maxLen derniers messages d'un stream.
+// Retourne le nombre de messages supprimés.
+func (r *Redis) XTrimMaxLen(stream string, maxLen int64) (int64, error) {
+	return r.client.XTrimMaxLenApprox(r.ctx, stream, maxLen, 0).Result()
+}
+
+// XTrimMinID supprime les messages d'ID inférieur à minID (Redis >= 6.2).
+// Retourne le nombre de messages supprimés.
+func (r *Redis) XTrimMinID(stream, minID string) (int64, error) {
+	return r.client.XTrimMinID(r.ctx, stream, minID).Result()
+}
+
+// toXMessages convertit les messages go-redis au format interne
+func toXMessages(messages []redis.XMessage) []XMessage {
+	result := make([]XMessage, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, XMessage{
+			ID:     m.ID,
+			Values: m.Values,
+		})
+	}
+	return result
+}
+
+// XGroupInfo décrit l'état d'un groupe de consommateurs d'un stream
+type XGroupInfo struct {
+	Name            string
+	Consumers       int64
+	Pending         int64
+	LastDeliveredID string
+}
+
+// XInfoGroups retourne les groupes de consommateurs d'un stream
+func (r *Redis) XInfoGroups(stream string) ([]XGroupInfo, error) {
+	result, err := r.client.XInfoGroups(r.ctx, stream).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]XGroupInfo, 0, len(result))
+	for _, g := range result {
+		groups = append(groups, XGroupInfo{
+			Name:            g.Name,
+			Consumers:       g.Consumers,
+			Pending:         g.Pending,
+			LastDeliveredID: g.LastDeliveredID,
+		})
+	}
+
+	return groups, nil
+}
+
+// XLastID retourne l'ID du dernier message ajouté à un stream
+func (r *Redis) XLastID(stream string) (string, error) {
+	info, err := r.client.XInfoStream(r.ctx, stream).Result()
+	if err != nil {
+		return "", err
+	}
+	return info.LastGeneratedID, nil
+}
+
+// refreshLockScript prolonge un verrou uniquement s'il appartient encore au détenteur
+var refreshLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// releaseLockScript supprime un verrou uniquement s'il appartient encore au détenteur
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// AcquireLock pose un verrou expirant si la clé est libre
+func (r *Redis) AcquireLock(key, owner string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(r.ctx, key, owner, ttl).Result()
+}
+
+// RefreshLock prolonge un verrou détenu par owner
+func (r *Redis) RefreshLock(key, owner string, ttl time.Duration) (bool, error) {
+	n, err := refreshLockScript.Run(r.ctx, r.client, []string{key}, owner, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ReleaseLock libère un verrou détenu par owner
+func (r *Redis) ReleaseLock(key, owner string) (bool, error) {
+	n, err := releaseLockScript.Run(r.ctx, r.client, []string{key}, owner).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// ZAdd ajoute ou met à jour un membre d'un ensemble trié
+func (r *Redis) ZAdd(key string, score float64, member string) error {
+	return r.client.ZAdd(r.ctx, key, &redis.Z{
+		Score:  score,
+		Member: member,
+	}).Err()
+}
+
+// ZRem retire des membres d'un ensemble trié
+func (r *Redis) ZRem(key string, members ...string) error {
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return r.client.ZRem(r.ctx, key, args...).Err()
+}
+
+// ZRemRangeByScore retire les membres dont le score est compris entre min et max
+func (r *Redis) ZRemRangeByScore(key, min, max string) error {
+	return r.client.ZRemRangeByScore(r.ctx, key, min, max).Err()
+}
+
+// ZCount compte les membres dont le score est compris entre min et max
+func (r *Redis) ZCount(key, min, max string) (int64, error) {
+	return r.client.ZCount(r.ctx, key, min, max).Result()
+}
+
+// ZMember est un membre d'un ensemble trié avec son score
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// ZRangeByScore retourne au plus count membres dont le score est compris entre min et max, par score croissant
+func (r *Redis) ZRangeByScore(key, min, max string, count int64) ([]ZMember, error) {
+	zs, err := r.client.ZRangeByScoreWithScores(r.ctx, key, &redis.ZRangeBy{
+		Min:   min,
+		Max:   max,
+		Count: count,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]ZMember, 0, len(zs))
+	for _, z := range zs {
+		member, _ := z.Member.(string)
+		members = append(members, ZMember{Member: member, Score: z.Score})
+	}
+	return members, nil
+}
+
+// HGet lit un champ d'un hash
+func (r *Redis) HGet(key, field string) (string, error) {
+	return r.client.HGet(r.ctx, key, field).Result()
+}
+
+// ZAddWithHash ajoute un membre à un ensemble trié et sa valeur dans un hash, atomiquement
+func (r *Redis) ZAddWithHash(zkey, hkey, member string, score float64, value string) error {
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(r.ctx, hkey, member, value)
+		pipe.ZAdd(r.ctx, zkey, &redis.Z{Score: score, Member: member})
+		return nil
+	})
+	return err
+}
+
+// ZRemWithHash retire des membres d'un ensemble trié et leurs valeurs du hash associé.
+// Retourne le nombre de membres retirés de l'ensemble.
+func (r *Redis) ZRemWithHash(zkey, hkey string, members ...string) (int64, error) {
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+
+	var removed *redis.IntCmd
+	_, err := r.client.TxPipelined(r.ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(r.ctx, zkey, args...)
+		pipe.HDel(r.ctx, hkey, members...)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed.Val(), nil
+}
+
+// zremWithHashIfScoreScript retire un membre et sa valeur seulement si son score n'a pas changé
+var zremWithHashIfScoreScript = redis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if score and tonumber(score) == tonumber(ARGV[2]) then
+	redis.call("ZREM", KEYS[1], ARGV[1])
+	redis.call("HDEL", KEYS[2], ARGV[1])
+	return 1
+end
+return 0`)
+
+// ZRemWithHashIfScore retire un membre et sa valeur si son score vaut toujours score
+func (r *Redis) ZRemWithHashIfScore(zkey, hkey, member string, score float64) (bool, error) {
+	n, err := zremWithHashIfScoreScript.Run(r.ctx, r.client, []string{zkey, hkey}, member, score).Int64()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
@@ -0,0 +1,526 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// alertColumns sont les colonnes lues pour reconstruire une alerte
+const alertColumns = `id, COALESCE(token_address, ''), token_symbol, alert_type, severity, message,
+	detected_at, confirmation_count, is_confirmed, COALESCE(related_wallets, '{}'),
+	COALESCE(dedup_key, ''), COALESCE(status, 'OPEN'), driver_value, COALESCE(occurrence_count, 1),
+	COALESCE(last_seen_at, detected_at), resolved_at, COALESCE(xscore, 0), COALESCE(token_state, '')`
+
+// SaveTokenAlert enregistre une alerte
+func (c *Connection) SaveTokenAlert(alert *models.TokenAlert) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO token_alerts (
+			id, token_address, token_symbol, alert_type, severity, message,
+			detected_at, confirmation_count, is_confirmed, related_wallets,
+			dedup_key, status, driver_value, occurrence_count, last_seen_at, resolved_at,
+			xscore, token_state
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18
+		) ON CONFLICT (id) DO UPDATE SET
+			severity = $5,
+			message = $6,
+			confirmation_count = $8,
+			is_confirmed = $9,
+			status = $12,
+			driver_value = $13,
+			occurrence_count = $14,
+			last_seen_at = $15,
+			resolved_at = $16,
+			xscore = $17,
+			token_state = $18
+	`
+
+	_, err := c.pool.Exec(ctx, query,
+		alert.ID,
+		alert.TokenAddress,
+		alert.TokenSymbol,
+		alert.AlertType,
+		alert.Severity,
+		alert.Message,
+		alert.DetectedAt,
+		alert.ConfirmationCount,
+		alert.IsConfirmed,
+		alert.RelatedWallets,
+		alert.DedupKey,
+		alert.Status,
+		alert.DriverValue,
+		alert.OccurrenceCount,
+		alert.LastSeenAt,
+		alert.ResolvedAt,
+		alert.XScore,
+		alert.TokenState,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement de l'alerte: %w", err)
+	}
+
+	return nil
+}
+
+// GetTokenAlert récupère une alerte par son ID, nil si elle n'existe pas
+func (c *Connection) GetTokenAlert(id string) (*models.TokenAlert, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + alertColumns + ` FROM token_alerts WHERE id = $1`
+
+	alert, err := scanTokenAlert(c.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération de l'alerte: %w", err)
+	}
+
+	return alert, nil
+}
+
+// ConfirmTokenAlert incrémente le compteur de confirmations d'une alerte.
+// Retourne nil si l'alerte n'existe pas.
+func (c *Connection) ConfirmTokenAlert(id string) (*models.TokenAlert, error) {
+	ctx := context.Background()
+
+	query := `
+		UPDATE token_alerts
+		SET confirmation_count = confirmation_count + 1, is_confirmed = TRUE
+		WHERE id = $1
+		RETURNING ` + alertColumns
+
+	alert, err := scanTokenAlert(c.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("échec de la confirmation de l'alerte: %w", err)
+	}
+
+	return alert, nil
+}
+
+// QueryTokenAlerts récupère les alertes correspondant à un filtre, des plus récentes aux plus anciennes
+func (c *Connection) QueryTokenAlerts(filter models.AlertFilter) ([]models.TokenAlert, error) {
+	ctx := context.Background()
+
+	conditions := make([]string, 0)
+	args := make([]interface{}, 0)
+	where := func(condition string, values ...interface{}) {
+		for _, v := range values {
+			args = append(args, v)
+			condition = strings.Replace(condition, "?", fmt.Sprintf("$%d", len(args)), 1)
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if filter.TokenAddress != "" {
+		where("token_address = ?", filter.TokenAddress)
+	}
+	if filter.AlertType != "" {
+		where("alert_type = ?", filter.AlertType)
+	}
+	if filter.Severity != "" {
+		where("severity = ?", filter.Severity)
+	}
+	if !filter.From.IsZero() {
+		where("detected_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("detected_at < ?", filter.To)
+	}
+	if filter.Confirmed != nil {
+		where("is_confirmed = ?", *filter.Confirmed)
+	}
+	if filter.Status != "" {
+		where("COALESCE(status, 'OPEN') = ?", filter.Status)
+	}
+	if !filter.BeforeTime.IsZero() {
+		where("(detected_at, id) < (?, ?)", filter.BeforeTime, filter.BeforeID)
+	}
+
+	query := `SELECT ` + alertColumns + ` FROM token_alerts`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY detected_at DESC, id DESC`
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+
+	rows, err := c.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des alertes: %w", err)
+	}
+	defer rows.Close()
+
+	alerts := make([]models.TokenAlert, 0)
+
+	for rows.Next() {
+		alert, err := scanTokenAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des alertes: %w", err)
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// DeleteTokenAlertsBefore supprime les alertes détectées avant une date et retourne leur nombre
+func (c *Connection) DeleteTokenAlertsBefore(before time.Time) (int64, error) {
+	ctx := context.Background()
+
+	tag, err := c.pool.Exec(ctx, `DELETE FROM token_alerts WHERE detected_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("échec de la suppression des alertes expirées: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// scanTokenAlert lit une alerte depuis une ligne de résultat
+func scanTokenAlert(row pgx.Row) (*models.TokenAlert, error) {
+	var alert models.TokenAlert
+
+	err := row.Scan(
+		&alert.ID,
+		&alert.TokenAddress,
+		&alert.TokenSymbol,
+		&alert.AlertType,
+		&alert.Severity,
+		&alert.Message,
+		&alert.DetectedAt,
+		&alert.ConfirmationCount,
+		&alert.IsConfirmed,
+		&alert.RelatedWallets,
+		&alert.DedupKey,
+		&alert.Status,
+		&alert.DriverValue,
+		&alert.OccurrenceCount,
+		&alert.LastSeenAt,
+		&alert.ResolvedAt,
+		&alert.XScore,
+		&alert.TokenState,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &alert, nil
+}
+
+// SaveAlertStateChange ajoute une étape à l'historique d'une alerte
+func (c *Connection) SaveAlertStateChange(change *models.AlertStateChange) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO token_alert_history (
+			alert_id, transition, status, severity, driver_value, reason, changed_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		)
+	`
+
+	_, err := c.pool.Exec(ctx, query,
+		change.AlertID,
+		change.Transition,
+		change.Status,
+		change.Severity,
+		change.DriverValue,
+		change.Reason,
+		change.ChangedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement de l'historique de l'alerte: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlertStateChanges récupère l'historique d'une alerte, du plus ancien au plus récent
+func (c *Connection) GetAlertStateChanges(alertID string) ([]models.AlertStateChange, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT alert_id, transition, status, severity, driver_value, reason, changed_at
+		FROM token_alert_history
+		WHERE alert_id = $1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := c.pool.Query(ctx, query, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération de l'historique de l'alerte: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]models.AlertStateChange, 0)
+
+	for rows.Next() {
+		var change models.AlertStateChange
+		err := rows.Scan(
+			&change.AlertID,
+			&change.Transition,
+			&change.Status,
+			&change.Severity,
+			&change.DriverValue,
+			&change.Reason,
+			&change.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan de l'historique de l'alerte: %w", err)
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return changes, nil
+}
+
+// outcomeColumns sont les colonnes lues pour reconstruire le suivi d'une alerte
+const outcomeColumns = `alert_id, token_address, alert_type, severity, bearish, detected_at,
+	price_at_alert, market_cap_at_alert, last_price, last_market_cap, max_gain, max_drawdown,
+	return_1h, return_6h, return_24h, status, reason, decided_at, updated_at`
+
+// SaveAlertOutcome enregistre ou met à jour le suivi d'une alerte
+func (c *Connection) SaveAlertOutcome(outcome *models.AlertOutcome) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO alert_outcomes (` + outcomeColumns + `) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
+		) ON CONFLICT (alert_id) DO UPDATE SET
+			price_at_alert = $7,
+			market_cap_at_alert = $8,
+			last_price = $9,
+			last_market_cap = $10,
+			max_gain = $11,
+			max_drawdown = $12,
+			return_1h = $13,
+			return_6h = $14,
+			return_24h = $15,
+			status = $16,
+			reason = $17,
+			decided_at = $18,
+			updated_at = $19
+	`
+
+	_, err := c.pool.Exec(ctx, query,
+		outcome.AlertID,
+		outcome.TokenAddress,
+		outcome.AlertType,
+		outcome.Severity,
+		outcome.Bearish,
+		outcome.DetectedAt,
+		outcome.PriceAtAlert,
+		outcome.MarketCapAtAlert,
+		outcome.LastPrice,
+		outcome.LastMarketCap,
+		outcome.MaxGain,
+		outcome.MaxDrawdown,
+		outcome.Return1h,
+		outcome.Return6h,
+		outcome.Return24h,
+		outcome.Status,
+		outcome.Reason,
+		outcome.DecidedAt,
+		outcome.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement du suivi de l'alerte: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlertOutcome récupère le suivi d'une alerte, nil s'il n'existe pas
+func (c *Connection) GetAlertOutcome(alertID string) (*models.AlertOutcome, error) {
+	ctx := context.Background()
+
+	query := `SELECT ` + outcomeColumns + ` FROM alert_outcomes WHERE alert_id = $1`
+
+	outcome, err := scanAlertOutcome(c.pool.QueryRow(ctx, query, alertID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération du suivi de l'alerte: %w", err)
+	}
+
+	return outcome, nil
+}
+
+// QueryAlertOutcomes récupère les suivis des alertes détectées depuis une date
+func (c *Connection) QueryAlertOutcomes(since time.Time) ([]models.AlertOutcome, error) {
+	return c.queryAlertOutcomes(`SELECT `+outcomeColumns+` FROM alert_outcomes WHERE detected_at >= $1`, since)
+}
+
+// ListTrackingAlertOutcomes récupère les suivis en cours
+func (c *Connection) ListTrackingAlertOutcomes() ([]models.AlertOutcome, error) {
+	return c.queryAlertOutcomes(`SELECT ` + outcomeColumns + ` FROM alert_outcomes WHERE status = 'TRACKING'`)
+}
+
+// queryAlertOutcomes exécute une requête de suivis d'alertes
+func (c *Connection) queryAlertOutcomes(query string, args ...interface{}) ([]models.AlertOutcome, error) {
+	ctx := context.Background()
+
+	rows, err := c.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des suivis d'alertes: %w", err)
+	}
+	defer rows.Close()
+
+	outcomes := make([]models.AlertOutcome, 0)
+
+	for rows.Next() {
+		outcome, err := scanAlertOutcome(rows)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des suivis d'alertes: %w", err)
+		}
+		outcomes = append(outcomes, *outcome)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return outcomes, nil
+}
+
+// scanAlertOutcome lit le suivi d'une alerte depuis une ligne de résultat
+func scanAlertOutcome(row pgx.Row) (*models.AlertOutcome, error) {
+	var outcome models.AlertOutcome
+
+	err := row.Scan(
+		&outcome.AlertID,
+		&outcome.TokenAddress,
+		&outcome.AlertType,
+		&outcome.Severity,
+		&outcome.Bearish,
+		&outcome.DetectedAt,
+		&outcome.PriceAtAlert,
+		&outcome.MarketCapAtAlert,
+		&outcome.LastPrice,
+		&outcome.LastMarketCap,
+		&outcome.MaxGain,
+		&outcome.MaxDrawdown,
+		&outcome.Return1h,
+		&outcome.Return6h,
+		&outcome.Return24h,
+		&outcome.Status,
+		&outcome.Reason,
+		&outcome.DecidedAt,
+		&outcome.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &outcome, nil
+}
+
+// ListAlertRules récupère les règles d'alerte enregistrées
+func (c *Connection) ListAlertRules() ([]models.AlertRule, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT name, expression, severity, alert_type, message, enabled, updated_at
+		FROM alert_rules
+		ORDER BY name
+	`
+
+	rows, err := c.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des règles d'alerte: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]models.AlertRule, 0)
+
+	for rows.Next() {
+		var rule models.AlertRule
+		err := rows.Scan(
+			&rule.Name,
+			&rule.Expression,
+			&rule.Severity,
+			&rule.AlertType,
+			&rule.Message,
+			&rule.Enabled,
+			&rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des règles d'alerte: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return rules, nil
+}
+
+// SaveAlertRule enregistre ou remplace une règle d'alerte
+func (c *Connection) SaveAlertRule(rule *models.AlertRule) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO alert_rules (
+			name, expression, severity, alert_type, message, enabled, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7
+		) ON CONFLICT (name) DO UPDATE SET
+			expression = $2,
+			severity = $3,
+			alert_type = $4,
+			message = $5,
+			enabled = $6,
+			updated_at = $7
+	`
+
+	_, err := c.pool.Exec(ctx, query,
+		rule.Name,
+		rule.Expression,
+		rule.Severity,
+		rule.AlertType,
+		rule.Message,
+		rule.Enabled,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement de la règle d'alerte: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAlertRule supprime une règle d'alerte et indique si elle existait
+func (c *Connection) DeleteAlertRule(name string) (bool, error) {
+	ctx := context.Background()
+
+	tag, err := c.pool.Exec(ctx, `DELETE FROM alert_rules WHERE name = $1`, name)
+	if err != nil {
+		return false, fmt.Errorf("échec de la suppression de la règle d'alerte: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// ListAPIKeys récupère les clés d'API, révoquées comprises
+func (c *Connection) ListAPIKeys() ([]models.APIKey, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT id, name, prefix, key_hash, scopes, rate_limit, burst, COALESCE(rotated_from, ''),
+			created_at, expires_at, revoked_at, last_used_at
+		FROM api_keys
+		ORDER BY created_at
+	`
+
+	rows, err := c.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des clés d'API: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+
+	for rows.Next() {
+		var key models.APIKey
+		err := rows.Scan(
+			&key.ID,
+			&key.Name,
+			&key.Prefix,
+			&key.Hash,
+			&key.Scopes,
+			&key.RateLimit,
+			&key.Burst,
+			&key.RotatedFrom,
+			&key.CreatedAt,
+			&key.ExpiresAt,
+			&key.RevokedAt,
+			&key.LastUsedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des clés d'API: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return keys, nil
+}
+
+// SaveAPIKey enregistre une clé d'API ou met à jour sa révocation
+func (c *Connection) SaveAPIKey(key *models.APIKey) error {
+	ctx := context.Background()
+
+	query := `
+		INSERT INTO api_keys (
+			id, name, prefix, key_hash, scopes, rate_limit, burst, rotated_from,
+			created_at, expires_at, revoked_at, last_used_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11, $12
+		) ON CONFLICT (id) DO UPDATE SET
+			name = $2,
+			scopes = $5,
+			rate_limit = $6,
+			burst = $7,
+			expires_at = $10,
+			revoked_at = $11
+	`
+
+	_, err := c.pool.Exec(ctx, query,
+		key.ID,
+		key.Name,
+		key.Prefix,
+		key.Hash,
+		key.Scopes,
+		key.RateLimit,
+		key.Burst,
+		key.RotatedFrom,
+		key.CreatedAt,
+		key.ExpiresAt,
+		key.RevokedAt,
+		key.LastUsedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement de la clé d'API: %w", err)
+	}
+
+	return nil
+}
+
+// SaveAPIKeyUsage ajoute des entrées au journal d'audit et met à jour la dernière
+// utilisation des clés concernées
+func (c *Connection) SaveAPIKeyUsage(entries []models.APIKeyUsage) error {
+	ctx := context.Background()
+
+	tx, err := c.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("échec du démarrage de la transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	insertQuery := `
+		INSERT INTO api_key_usage (key_id, prefix, method, path, status, remote_addr, reason, used_at)
+		VALUES (NULLIF($1, ''), NULLIF($2, ''), $3, $4, $5, $6, NULLIF($7, ''), $8)
+	`
+
+	lastUsed := make(map[string]time.Time)
+	for _, entry := range entries {
+		_, err := tx.Exec(ctx, insertQuery,
+			entry.KeyID,
+			entry.Prefix,
+			entry.Method,
+			entry.Path,
+			entry.Status,
+			entry.RemoteAddr,
+			entry.Reason,
+			entry.At,
+		)
+		if err != nil {
+			return fmt.Errorf("échec de l'enregistrement de l'audit des clés d'API: %w", err)
+		}
+		if entry.KeyID != "" && entry.Reason == "" && entry.At.After(lastUsed[entry.KeyID]) {
+			lastUsed[entry.KeyID] = entry.At
+		}
+	}
+
+	updateQuery := `
+		UPDATE api_keys SET last_used_at = $2
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < $2)
+	`
+	for id, at := range lastUsed {
+		if _, err := tx.Exec(ctx, updateQuery, id, at); err != nil {
+			return fmt.Errorf("échec de la mise à jour de la dernière utilisation de la clé: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("échec de la validation de la transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ListAPIKeyUsage récupère les dernières entrées d'audit d'une clé, ou de toutes les
+// clés si keyID est vide
+func (c *Connection) ListAPIKeyUsage(keyID string, limit int) ([]models.APIKeyUsage, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT COALESCE(key_id, ''), COALESCE(prefix, ''), method, path, status,
+			COALESCE(remote_addr, ''), COALESCE(reason, ''), used_at
+		FROM api_key_usage
+		WHERE $1 = '' OR key_id = $1
+		ORDER BY used_at DESC
+		LIMIT $2
+	`
+
+	rows, err := c.pool.Query(ctx, query, keyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération de l'audit des clés d'API: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.APIKeyUsage, 0)
+
+	for rows.Next() {
+		var entry models.APIKeyUsage
+		err := rows.Scan(
+			&entry.KeyID,
+			&entry.Prefix,
+			&entry.Method,
+			&entry.Path,
+			&entry.Status,
+			&entry.RemoteAddr,
+			&entry.Reason,
+			&entry.At,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan de l'audit des clés d'API: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveDigest enregistre ou remplace un digest
+func (c *Connection) SaveDigest(digest *models.Digest) error {
+	ctx := context.Background()
+
+	content, err := json.Marshal(digest.Content)
+	if err != nil {
+		return fmt.Errorf("échec de l'encodage du digest: %w", err)
+	}
+
+	query := `
+		INSERT INTO digests (
+			id, period, title, period_start, period_end, content, markdown, html, generated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		) ON CONFLICT (id) DO UPDATE SET
+			title = $3,
+			content = $6,
+			markdown = $7,
+			html = $8,
+			generated_at = $9
+	`
+
+	_, err = c.pool.Exec(ctx, query,
+		digest.ID,
+		digest.Period,
+		digest.Title,
+		digest.From,
+		digest.To,
+		content,
+		digest.Markdown,
+		digest.HTML,
+		digest.GeneratedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement du digest: %w", err)
+	}
+
+	return nil
+}
+
+// GetDigest récupère un digest par son ID
+func (c *Connection) GetDigest(id string) (*models.Digest, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT id, period, title, period_start, period_end, content, markdown, html, generated_at
+		FROM digests
+		WHERE id = $1
+	`
+
+	digest, err := scanDigest(c.pool.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération du digest: %w", err)
+	}
+
+	return digest, nil
+}
+
+// ListDigests récupère les derniers digests, d'une période si period n'est pas vide
+func (c *Connection) ListDigests(period string, limit int) ([]models.Digest, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT id, period, title, period_start, period_end, content, markdown, html, generated_at
+		FROM digests
+		WHERE ($1 = '' OR period = $1)
+		ORDER BY period_start DESC, id DESC
+	`
+	args := []interface{}{period}
+	if limit > 0 {
+		query += ` LIMIT $2`
+		args = append(args, limit)
+	}
+
+	rows, err := c.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des digests: %w", err)
+	}
+	defer rows.Close()
+
+	digests := make([]models.Digest, 0)
+
+	for rows.Next() {
+		digest, err := scanDigest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des digests: %w", err)
+		}
+		digests = append(digests, *digest)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return digests, nil
+}
+
+// scanDigest lit un digest depuis une ligne de résultat
+func scanDigest(row pgx.Row) (*models.Digest, error) {
+	var digest models.Digest
+	var content []byte
+	err := row.Scan(
+		&digest.ID,
+		&digest.Period,
+		&digest.Title,
+		&digest.From,
+		&digest.To,
+		&content,
+		&digest.Markdown,
+		&digest.HTML,
+		&digest.GeneratedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &digest.Content); err != nil {
+		return nil, fmt.Errorf("échec du décodage du digest: %w", err)
+	}
+
+	return &digest, nil
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// SaveMaintenanceJob enregistre un job de maintenance ou met à jour son statut
+func (c *Connection) SaveMaintenanceJob(job *models.MaintenanceJob) error {
+	ctx := context.Background()
+
+	history, err := json.Marshal(job.History)
+	if err != nil {
+		return fmt.Errorf("échec de l'encodage de l'historique du job: %w", err)
+	}
+
+	query := `
+		INSERT INTO maintenance_jobs (
+			id, kind, target, status, done, total, progress, error, requested_by,
+			created_at, started_at, finished_at, history
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+		) ON CONFLICT (id) DO UPDATE SET
+			status = $4,
+			done = $5,
+			total = $6,
+			progress = $7,
+			error = $8,
+			started_at = $11,
+			finished_at = $12,
+			history = $13
+	`
+
+	_, err = c.pool.Exec(ctx, query,
+		job.ID,
+		job.Kind,
+		job.Target,
+		job.Status,
+		job.Done,
+		job.Total,
+		job.Progress,
+		job.Error,
+		job.RequestedBy,
+		job.CreatedAt,
+		job.StartedAt,
+		job.FinishedAt,
+		history,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement du job de maintenance: %w", err)
+	}
+
+	return nil
+}
+
+// ListMaintenanceJobs récupère les derniers jobs de maintenance, les plus récents d'abord
+func (c *Connection) ListMaintenanceJobs(limit int) ([]models.MaintenanceJob, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT id, kind, target, status, done, total, progress, error, requested_by,
+			created_at, started_at, finished_at, history
+		FROM maintenance_jobs
+		ORDER BY created_at DESC
+		LIMIT $1
+	`
+
+	rows, err := c.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des jobs de maintenance: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]models.MaintenanceJob, 0)
+
+	for rows.Next() {
+		var job models.MaintenanceJob
+		var history []byte
+		err := rows.Scan(
+			&job.ID,
+			&job.Kind,
+			&job.Target,
+			&job.Status,
+			&job.Done,
+			&job.Total,
+			&job.Progress,
+			&job.Error,
+			&job.RequestedBy,
+			&job.CreatedAt,
+			&job.StartedAt,
+			&job.FinishedAt,
+			&history,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des jobs de maintenance: %w", err)
+		}
+		if err := json.Unmarshal(history, &job.History); err != nil {
+			return nil, fmt.Errorf("échec du décodage de l'historique du job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return jobs, nil
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// ListAlertSubscribers récupère les abonnés aux alertes
+func (c *Connection) ListAlertSubscribers() ([]models.Subscriber, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT id, name, enabled, min_xscore, COALESCE(min_severity, ''),
+			COALESCE(alert_types, '{}'), COALESCE(states, '{}'), COALESCE(watchlist, '{}'),
+			quiet_hours, channels, created_at, updated_at
+		FROM alert_subscribers
+		ORDER BY id
+	`
+
+	rows, err := c.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des abonnés: %w", err)
+	}
+	defer rows.Close()
+
+	subscribers := make([]models.Subscriber, 0)
+
+	for rows.Next() {
+		var sub models.Subscriber
+		var quietHours, channels []byte
+		err := rows.Scan(
+			&sub.ID,
+			&sub.Name,
+			&sub.Enabled,
+			&sub.MinXScore,
+			&sub.MinSeverity,
+			&sub.AlertTypes,
+			&sub.States,
+			&sub.Watchlist,
+			&quietHours,
+			&channels,
+			&sub.CreatedAt,
+			&sub.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des abonnés: %w", err)
+		}
+
+		if len(quietHours) > 0 && string(quietHours) != "null" {
+			if err := json.Unmarshal(quietHours, &sub.QuietHours); err != nil {
+				return nil, fmt.Errorf("échec du décodage des heures calmes: %w", err)
+			}
+		}
+		if err := json.Unmarshal(channels, &sub.Channels); err != nil {
+			return nil, fmt.Errorf("échec du décodage des canaux: %w", err)
+		}
+
+		subscribers = append(subscribers, sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return subscribers, nil
+}
+
+// SaveAlertSubscriber enregistre ou remplace un abonné aux alertes
+func (c *Connection) SaveAlertSubscriber(sub *models.Subscriber) error {
+	ctx := context.Background()
+
+	quietHours, err := json.Marshal(sub.QuietHours)
+	if err != nil {
+		return fmt.Errorf("échec de l'encodage des heures calmes: %w", err)
+	}
+	channels, err := json.Marshal(sub.Channels)
+	if err != nil {
+		return fmt.Errorf("échec de l'encodage des canaux: %w", err)
+	}
+
+	query := `
+		INSERT INTO alert_subscribers (
+			id, name, enabled, min_xscore, min_severity, alert_types, states, watchlist,
+			quiet_hours, channels, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		) ON CONFLICT (id) DO UPDATE SET
+			name = $2,
+			enabled = $3,
+			min_xscore = $4,
+			min_severity = $5,
+			alert_types = $6,
+			states = $7,
+			watchlist = $8,
+			quiet_hours = $9,
+			channels = $10,
+			updated_at = $12
+	`
+
+	_, err = c.pool.Exec(ctx, query,
+		sub.ID,
+		sub.Name,
+		sub.Enabled,
+		sub.MinXScore,
+		sub.MinSeverity,
+		sub.AlertTypes,
+		sub.States,
+		sub.Watchlist,
+		quietHours,
+		channels,
+		sub.CreatedAt,
+		sub.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement de l'abonné: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAlertSubscriber supprime un abonné et indique s'il existait
+func (c *Connection) DeleteAlertSubscriber(id string) (bool, error) {
+	ctx := context.Background()
+
+	tag, err := c.pool.Exec(ctx, `DELETE FROM alert_subscribers WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("échec de la suppression de l'abonné: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// SaveXScoreHistory enregistre un X-Score calculé dans l'historique
+func (c *Connection) SaveXScoreHistory(result *models.XScoreResult) error {
+	ctx := context.Background()
+
+	components, err := json.Marshal(result.Components)
+	if err != nil {
+		return fmt.Errorf("échec de la sérialisation des composantes: %w", err)
+	}
+
+	factors, err := json.Marshal(result.Factors)
+	if err != nil {
+		return fmt.Errorf("échec de la sérialisation des facteurs: %w", err)
+	}
+
+	query := `
+		INSERT INTO token_xscore_history (
+			token_address, calculated_at, x_score, base_score, price, market_cap, components, factors
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8
+		)
+	`
+
+	_, err = c.pool.Exec(ctx, query,
+		result.TokenAddress,
+		result.CalculatedAt,
+		result.XScore,
+		result.BaseScore,
+		result.Price,
+		result.MarketCap,
+		components,
+		factors,
+	)
+	if err != nil {
+		return fmt.Errorf("échec de l'enregistrement de l'historique X-Score: %w", err)
+	}
+
+	return nil
+}
+
+// GetXScoreHistory récupère les X-Scores calculés sur une période
+func (c *Connection) GetXScoreHistory(from, to time.Time) ([]models.XScoreResult, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT token_address, calculated_at, x_score, base_score,
+			COALESCE(price, 0), COALESCE(market_cap, 0), components, factors
+		FROM token_xscore_history
+		WHERE calculated_at >= $1 AND calculated_at < $2
+		ORDER BY calculated_at ASC
+	`
+
+	rows, err := c.pool.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération de l'historique X-Score: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]models.XScoreResult, 0)
+
+	for rows.Next() {
+		var result models.XScoreResult
+		var components, factors []byte
+
+		err := rows.Scan(
+			&result.TokenAddress,
+			&result.CalculatedAt,
+			&result.XScore,
+			&result.BaseScore,
+			&result.Price,
+			&result.MarketCap,
+			&components,
+			&factors,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan de l'historique X-Score: %w", err)
+		}
+
+		if err := json.Unmarshal(components, &result.Components); err != nil {
+			return nil, fmt.Errorf("échec de la désérialisation des composantes: %w", err)
+		}
+		if err := json.Unmarshal(factors, &result.Factors); err != nil {
+			return nil, fmt.Errorf("échec de la désérialisation des facteurs: %w", err)
+		}
+
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetTokenPricePoints récupère les points de prix d'un token sur une période
+func (c *Connection) GetTokenPricePoints(tokenAddress string, from, to time.Time) ([]models.TokenPricePoint, error) {
+	ctx := context.Background()
+
+	query := `
+		SELECT token_address, timestamp, open, high, low, close, volume
+		FROM token_price_points
+		WHERE token_address = $1 AND timestamp >= $2 AND timestamp <= $3
+		ORDER BY timestamp ASC
+	`
+
+	rows, err := c.pool.Query(ctx, query, tokenAddress, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la récupération des points de prix: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]models.TokenPricePoint, 0)
+
+	for rows.Next() {
+		var point models.TokenPricePoint
+
+		err := rows.Scan(
+			&point.TokenAddress,
+			&point.Timestamp,
+			&point.Open,
+			&point.High,
+			&point.Low,
+			&point.Close,
+			&point.Volume,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("échec du scan des points de prix: %w", err)
+		}
+
+		points = append(points, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erreur pendant l'itération sur les résultats: %w", err)
+	}
+
+	return points, nil
+}
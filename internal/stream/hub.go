@@ -0,0 +1,455 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/alerting"
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/pkg/utils/config"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidEventID est retourné quand l'ID de reprise n'est pas un offset de stream
+	ErrInvalidEventID = errors.New("invalid last event ID")
+	// ErrInvalidFilter est retourné pour un filtre au type ou à la sévérité inconnus
+	ErrInvalidFilter = errors.New("invalid stream filter")
+	// ErrLagging ferme un abonnement qui ne consomme pas ses événements assez vite;
+	// le client doit se reconnecter avec le dernier ID reçu
+	ErrLagging = errors.New("subscriber lagging behind")
+	// ErrClosed est retourné quand le hub n'est pas démarré et ferme les abonnements à son arrêt
+	ErrClosed = errors.New("stream hub not running")
+)
+
+// DefaultSeverity est la sévérité des événements qui ne sont pas des alertes
+const DefaultSeverity = "LOW"
+
+// offsetPattern reconnaît un offset de stream <ms>-<seq>, ou "0" pour le début du journal
+var offsetPattern = regexp.MustCompile(`^(0|\d+-\d+)$`)
+
+// EventSource lit le journal d'un stream logique par offsets croissants
+type EventSource interface {
+	ReadEvents(streamName, after string, limit int) (*pipeline.EventPage, error)
+}
+
+// Event est un événement du pipeline diffusé aux clients. Son ID est l'offset du
+// message dans le stream: un client le renvoie pour reprendre là où il s'était arrêté.
+type Event struct {
+	ID           string                 `json:"id"`
+	Type         string                 `json:"type"`
+	TokenAddress string                 `json:"token_address,omitempty"`
+	Severity     string                 `json:"severity"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Payload      map[string]interface{} `json:"payload"`
+}
+
+// Filter sélectionne les événements d'un abonnement; un champ vide accepte tout
+type Filter struct {
+	Tokens      []string `json:"tokens,omitempty"`
+	Types       []string `json:"types,omitempty"`
+	MinSeverity string   `json:"min_severity,omitempty"`
+}
+
+// Validate vérifie les types d'événements et la sévérité minimale du filtre
+func (f Filter) Validate() error {
+	for _, t := range f.Types {
+		if !isEventType(t) {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidFilter, t)
+		}
+	}
+	if f.MinSeverity != "" && alerting.SeverityRank(f.MinSeverity) < 0 {
+		return fmt.Errorf("%w: unknown severity %q", ErrInvalidFilter, f.MinSeverity)
+	}
+	return nil
+}
+
+// Match indique si l'événement passe le filtre
+func (f Filter) Match(event Event) bool {
+	if len(f.Tokens) > 0 && !contains(f.Tokens, event.TokenAddress, false) {
+		return false
+	}
+	if len(f.Types) > 0 && !contains(f.Types, event.Type, true) {
+		return false
+	}
+	if f.MinSeverity != "" && alerting.SeverityRank(event.Severity) < alerting.SeverityRank(f.MinSeverity) {
+		return false
+	}
+	return true
+}
+
+// Options règle la lecture du stream et les abonnements
+type Options struct {
+	Stream       string
+	PollInterval time.Duration
+	BatchSize    int
+	BufferSize   int
+	Heartbeat    time.Duration
+}
+
+// DefaultOptions retourne les réglages par défaut du hub
+func DefaultOptions() Options {
+	return Options{
+		Stream:       pipeline.StreamTokenEvents,
+		PollInterval: 250 * time.Millisecond,
+		BatchSize:    500,
+		BufferSize:   256,
+		Heartbeat:    15 * time.Second,
+	}
+}
+
+// Hub suit le journal d'un stream et diffuse ses nouveaux événements aux abonnés.
+// Un seul lecteur interroge le broker, quel que soit le nombre de clients connectés.
+type Hub struct {
+	source  EventSource
+	logger  *logrus.Logger
+	options Options
+
+	mu      sync.Mutex
+	offset  string // Dernier offset diffusé
+	subs    map[*Subscription]struct{}
+	closed  bool
+	started bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewHub crée un hub de diffusion sur le journal des événements
+func NewHub(source EventSource, logger *logrus.Logger) *Hub {
+	return &Hub{
+		source:  source,
+		logger:  logger,
+		options: DefaultOptions(),
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// ApplyConfig applique la configuration du flux en direct
+func (h *Hub) ApplyConfig(cfg *config.StreamConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.Stream != "" {
+		h.options.Stream = cfg.Stream
+	}
+	if cfg.PollIntervalMs > 0 {
+		h.options.PollInterval = time.Duration(cfg.PollIntervalMs) * time.Millisecond
+	}
+	if cfg.BatchSize > 0 {
+		h.options.BatchSize = cfg.BatchSize
+	}
+	if cfg.BufferSize > 0 {
+		h.options.BufferSize = cfg.BufferSize
+	}
+	if cfg.HeartbeatSeconds > 0 {
+		h.options.Heartbeat = time.Duration(cfg.HeartbeatSeconds) * time.Second
+	}
+}
+
+// Options retourne les réglages courants du hub
+func (h *Hub) Options() Options {
+	return h.options
+}
+
+// Start commence la lecture du stream à partir de maintenant
+func (h *Hub) Start(ctx context.Context) error {
+	h.mu.Lock()
+	h.offset = pipeline.OffsetBefore(time.Now())
+	h.started = true
+	h.mu.Unlock()
+
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.wg.Add(1)
+	go h.run(ctx)
+
+	h.logger.WithFields(logrus.Fields{
+		"stream":        h.options.Stream,
+		"poll_interval": h.options.PollInterval.String(),
+	}).Info("Event stream hub started")
+	return nil
+}
+
+// Shutdown arrête la lecture et ferme tous les abonnements
+func (h *Hub) Shutdown(ctx context.Context) error {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.wg.Wait()
+
+	h.mu.Lock()
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub, ErrClosed)
+	}
+	h.mu.Unlock()
+	return nil
+}
+
+// Subscribers retourne le nombre d'abonnés connectés
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// Offset retourne le dernier offset diffusé
+func (h *Hub) Offset() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.offset
+}
+
+// run interroge le stream et diffuse les nouveaux messages jusqu'à l'annulation de ctx
+func (h *Hub) run(ctx context.Context) {
+	defer h.wg.Done()
+
+	ticker := time.NewTicker(h.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for h.poll() {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll diffuse une page de nouveaux messages; retourne vrai si la page était pleine
+func (h *Hub) poll() bool {
+	page, err := h.source.ReadEvents(h.options.Stream, h.Offset(), h.options.BatchSize)
+	if err != nil {
+		h.logger.WithError(err).Warn("Failed to read event stream")
+		return false
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, message := range page.Events {
+		event := FromMessage(message)
+		h.offset = event.ID
+		for sub := range h.subs {
+			if !sub.filter.Match(event) {
+				continue
+			}
+			select {
+			case sub.live <- event:
+			default:
+				h.drop(sub, ErrLagging)
+			}
+		}
+	}
+	return len(page.Events) >= h.options.BatchSize
+}
+
+// Subscribe abonne un client aux événements qui passent le filtre. Si lastEventID
+// n'est pas vide, les événements manqués depuis cet offset sont d'abord relus dans le stream.
+func (h *Hub) Subscribe(filter Filter, lastEventID string) (*Subscription, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if lastEventID != "" && !offsetPattern.MatchString(lastEventID) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEventID, lastEventID)
+	}
+
+	sub := &Subscription{
+		hub:    h,
+		filter: filter,
+		live:   make(chan Event, h.options.BufferSize),
+		events: make(chan Event),
+		done:   make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	if h.closed || !h.started {
+		h.mu.Unlock()
+		return nil, ErrClosed
+	}
+	h.subs[sub] = struct{}{}
+	// Les messages postérieurs à cet offset arriveront par le canal live
+	caughtUp := h.offset
+	h.mu.Unlock()
+
+	go sub.forward(lastEventID, caughtUp)
+	return sub, nil
+}
+
+// drop retire un abonné et ferme son canal live. Appelé sous h.mu.
+func (h *Hub) drop(sub *Subscription, reason error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.setErr(reason)
+	close(sub.live)
+}
+
+// Subscription est l'abonnement d'un client au hub
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	live   chan Event // Alimenté par le hub
+	events chan Event // Lu par le client: rejeu puis événements en direct
+
+	errMu sync.Mutex
+	err   error
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Events retourne le canal des événements, fermé à la fin de l'abonnement
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err retourne la raison de la fin de l'abonnement, nil s'il est encore actif
+// ou s'il a été fermé par le client
+func (s *Subscription) Err() error {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	return s.err
+}
+
+// Close termine l'abonnement
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.hub.mu.Lock()
+		if _, ok := s.hub.subs[s]; ok {
+			delete(s.hub.subs, s)
+			close(s.live)
+		}
+		s.hub.mu.Unlock()
+	})
+}
+
+// setErr enregistre la première raison de fin de l'abonnement
+func (s *Subscription) setErr(err error) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// forward relit les événements de lastEventID à caughtUp, puis transmet les événements en direct
+func (s *Subscription) forward(lastEventID, caughtUp string) {
+	defer close(s.events)
+
+	cursor := caughtUp
+	if lastEventID != "" {
+		var err error
+		if cursor, err = s.replay(lastEventID, caughtUp); err != nil {
+			s.setErr(err)
+			s.Close()
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case event, ok := <-s.live:
+			if !ok {
+				return
+			}
+			if pipeline.CompareOffsets(event.ID, cursor) <= 0 {
+				continue
+			}
+			if !s.send(event) {
+				return
+			}
+		}
+	}
+}
+
+// replay transmet les événements du stream d'offset dans ]after, until] et retourne le dernier offset relu
+func (s *Subscription) replay(after, until string) (string, error) {
+	options := s.hub.options
+	cursor := after
+	for pipeline.CompareOffsets(cursor, until) < 0 {
+		page, err := s.hub.source.ReadEvents(options.Stream, cursor, options.BatchSize)
+		if err != nil {
+			return cursor, fmt.Errorf("replay from %s: %w", cursor, err)
+		}
+		if len(page.Events) == 0 {
+			break
+		}
+		for _, message := range page.Events {
+			if pipeline.CompareOffsets(message.ID, until) > 0 {
+				return until, nil
+			}
+			cursor = message.ID
+			event := FromMessage(message)
+			if s.filter.Match(event) && !s.send(event) {
+				return cursor, nil
+			}
+		}
+	}
+	if pipeline.CompareOffsets(cursor, until) < 0 {
+		cursor = until
+	}
+	return cursor, nil
+}
+
+// send transmet un événement au client; retourne faux si l'abonnement est fermé
+func (s *Subscription) send(event Event) bool {
+	select {
+	case s.events <- event:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// FromMessage convertit un message du pipeline en événement diffusé
+func FromMessage(message pipeline.Message) Event {
+	event := Event{
+		ID:        message.ID,
+		Type:      message.Type,
+		Severity:  DefaultSeverity,
+		Timestamp: message.Timestamp,
+		Payload:   message.Payload,
+	}
+	if message.Event != nil {
+		event.TokenAddress = message.Event.GetTokenAddress()
+	} else if address, ok := message.Payload["token_address"].(string); ok {
+		event.TokenAddress = address
+	}
+	if severity, ok := message.Payload["severity"].(string); ok && alerting.SeverityRank(severity) >= 0 {
+		event.Severity = strings.ToUpper(severity)
+	}
+	return event
+}
+
+// isEventType indique si t est un type d'événement du pipeline
+func isEventType(t string) bool {
+	switch strings.ToLower(t) {
+	case pipeline.EventTypeTokenDetected, pipeline.EventTypePriceChange, pipeline.EventTypeVolumeSpike,
+		pipeline.EventTypeStateChange, pipeline.EventTypeReactivation, pipeline.EventTypeAlertRaised,
+		pipeline.EventTypeXScore:
+		return true
+	}
+	return false
+}
+
+// contains indique si values contient value
+func contains(values []string, value string, foldCase bool) bool {
+	for _, v := range values {
+		if v == value || (foldCase && strings.EqualFold(v, value)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestHub(t *testing.T, bufferSize int) (*Hub, *pipeline.Pipeline) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	p := pipeline.NewPipelineWithBroker(pipeline.NewMemoryBroker(), logger)
+	hub := NewHub(p, logger)
+	hub.options.PollInterval = 5 * time.Millisecond
+	hub.options.BufferSize = bufferSize
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { hub.Shutdown(context.Background()) })
+	return hub, p
+}
+
+func publish(t *testing.T, p *pipeline.Pipeline, event pipeline.Event) {
+	t.Helper()
+	if err := p.PublishEvent(pipeline.StreamTokenEvents, event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func next(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatalf("subscription closed: %v", sub.Err())
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+	return Event{}
+}
+
+// waitOffset attend que le hub ait diffusé au moins n messages depuis from
+func waitOffset(t *testing.T, hub *Hub, from string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for pipeline.CompareOffsets(hub.Offset(), from) <= 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("hub did not advance")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubscriptionFilters(t *testing.T) {
+	hub, p := newTestHub(t, 16)
+
+	sub, err := hub.Subscribe(Filter{Tokens: []string{"tok"}, MinSeverity: "HIGH"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	publish(t, p, &pipeline.StateChange{TokenAddress: "tok", NewState: "HYPED"})
+	publish(t, p, &pipeline.AlertRaised{TokenAddress: "other", AlertType: "DUMP_DETECTED", Severity: "CRITICAL"})
+	publish(t, p, &pipeline.AlertRaised{TokenAddress: "tok", AlertType: "HIGH_SCORE", Severity: "MEDIUM"})
+	publish(t, p, &pipeline.AlertRaised{TokenAddress: "tok", AlertType: "DUMP_DETECTED", Severity: "critical"})
+
+	event := next(t, sub)
+	if event.Type != pipeline.EventTypeAlertRaised || event.Severity != "CRITICAL" || event.TokenAddress != "tok" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+
+	if _, err := hub.Subscribe(Filter{Types: []string{"moon"}}, ""); !errors.Is(err, ErrInvalidFilter) {
+		t.Fatalf("unknown type accepted: %v", err)
+	}
+	if _, err := hub.Subscribe(Filter{}, "not-an-offset"); !errors.Is(err, ErrInvalidEventID) {
+		t.Fatalf("invalid event ID accepted: %v", err)
+	}
+}
+
+func TestSubscriptionResume(t *testing.T) {
+	hub, p := newTestHub(t, 16)
+
+	first, err := hub.Subscribe(Filter{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := hub.Offset()
+	publish(t, p, &pipeline.StateChange{TokenAddress: "tok", NewState: "VALIDATED"})
+	publish(t, p, &pipeline.StateChange{TokenAddress: "tok", NewState: "HYPED"})
+	received := next(t, first)
+	waitOffset(t, hub, start)
+	first.Close()
+
+	// Le client se reconnecte après le premier événement: le second est relu dans le stream
+	resumed, err := hub.Subscribe(Filter{}, received.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resumed.Close()
+	publish(t, p, &pipeline.StateChange{TokenAddress: "tok", NewState: "COMPLETED"})
+
+	for _, want := range []string{"HYPED", "COMPLETED"} {
+		event := next(t, resumed)
+		if event.Payload["new_state"] != want {
+			t.Fatalf("got %v, want %s", event.Payload["new_state"], want)
+		}
+	}
+}
+
+func TestLaggingSubscriberDropped(t *testing.T) {
+	hub, p := newTestHub(t, 1)
+
+	sub, err := hub.Subscribe(Filter{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		publish(t, p, &pipeline.StateChange{TokenAddress: "tok", NewState: "HYPED"})
+	}
+
+	deadline := time.After(2 * time.Second)
+	for {
+		select {
+		case _, ok := <-sub.Events():
+			if ok {
+				continue
+			}
+			if !errors.Is(sub.Err(), ErrLagging) {
+				t.Fatalf("closed with %v", sub.Err())
+			}
+			if hub.Subscribers() != 0 {
+				t.Fatalf("%d subscribers left", hub.Subscribers())
+			}
+			return
+		case <-deadline:
+			t.Fatal("lagging subscriber not dropped")
+		}
+	}
+}
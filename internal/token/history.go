@@ -0,0 +1,92 @@
+package token
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// historyQueueSize borne les X-Scores en attente d'historisation
+const historyQueueSize = 1024
+
+// historyWriter historise les X-Scores en tâche de fond. L'historique ne sert qu'à la
+// calibration hors ligne: un score est abandonné plutôt que de ralentir le pipeline
+// quand la file est pleine.
+type historyWriter struct {
+	store   XScoreHistoryStore
+	logger  *logrus.Logger
+	queue   chan *models.XScoreResult
+	dropped int64
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// newHistoryWriter crée l'écrivain et lance son worker
+func newHistoryWriter(store XScoreHistoryStore, logger *logrus.Logger) *historyWriter {
+	w := &historyWriter{
+		store:  store,
+		logger: logger,
+		queue:  make(chan *models.XScoreResult, historyQueueSize),
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+// record met un score en file sans bloquer
+func (w *historyWriter) record(result *models.XScoreResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+
+	select {
+	case w.queue <- result:
+	default:
+		// Journaliser le premier abandon puis un sur cent pour ne pas inonder les logs
+		if dropped := atomic.AddInt64(&w.dropped, 1); dropped%100 == 1 {
+			w.logger.WithField("dropped", dropped).Warn("X-Score history queue full, dropping score")
+		}
+	}
+}
+
+// run écrit les scores en file jusqu'à la fermeture
+func (w *historyWriter) run() {
+	defer w.wg.Done()
+	for result := range w.queue {
+		if err := w.store.SaveXScoreHistory(result); err != nil {
+			w.logger.WithError(err).WithField("token_address", result.TokenAddress).Warn("Failed to save X-Score history")
+		}
+	}
+}
+
+// close refuse les nouveaux scores et écrit ceux en file jusqu'à l'échéance de ctx
+func (w *historyWriter) close(ctx context.Context) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("X-Score history shutdown with %d scores pending: %w", len(w.queue), ctx.Err())
+	}
+}
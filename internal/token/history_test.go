@@ -0,0 +1,56 @@
+package token
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// blockingHistory bloque chaque écriture jusqu'à release
+type blockingHistory struct {
+	release chan struct{}
+	mu      sync.Mutex
+	saved   int
+}
+
+func (h *blockingHistory) SaveXScoreHistory(result *models.XScoreResult) error {
+	<-h.release
+	h.mu.Lock()
+	h.saved++
+	h.mu.Unlock()
+	return nil
+}
+
+func TestHistoryWriterDoesNotBlockScoring(t *testing.T) {
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	store := &blockingHistory{release: make(chan struct{})}
+	w := newHistoryWriter(store, quiet)
+
+	// Une base lente ne ralentit pas les calculs: les scores en trop sont abandonnés
+	start := time.Now()
+	for i := 0; i < historyQueueSize+10; i++ {
+		w.record(&models.XScoreResult{TokenAddress: "tok"})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("record blocked for %v", elapsed)
+	}
+	if w.dropped == 0 {
+		t.Fatal("no score dropped with a full queue")
+	}
+
+	// Shutdown écrit les scores en file
+	close(store.release)
+	if err := w.close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if store.saved < historyQueueSize || int64(store.saved)+w.dropped != historyQueueSize+10 {
+		t.Fatalf("saved %d, dropped %d", store.saved, w.dropped)
+	}
+	w.record(&models.XScoreResult{TokenAddress: "late"})
+}
@@ -0,0 +1,258 @@
+package token
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/internal/pipeline"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// StateTransition est un changement d'état du cycle de vie d'un token
+type StateTransition struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+	EventID string    `json:"event_id"`
+	Offset  string    `json:"offset"`
+}
+
+// XScorePoint est un X-Score calculé à un instant
+type XScorePoint struct {
+	XScore           float64   `json:"xscore"`
+	Price            float64   `json:"price"`
+	MarketCap        float64   `json:"market_cap"`
+	AntiDumpDetected bool      `json:"anti_dump_detected"`
+	At               time.Time `json:"at"`
+}
+
+// AlertRecord est une alerte levée sur un token
+type AlertRecord struct {
+	ID        string    `json:"id"`
+	AlertType string    `json:"alert_type"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+}
+
+// TokenReadModel est l'état d'un token dérivé du journal d'événements
+type TokenReadModel struct {
+	Address       string            `json:"address"`
+	Symbol        string            `json:"symbol"`
+	State         string            `json:"state"`
+	StateHistory  []StateTransition `json:"state_history"`
+	XScore        float64           `json:"xscore"`
+	XScoreHistory []XScorePoint     `json:"xscore_history"`
+	Alerts        []AlertRecord     `json:"alerts"`
+	Price         float64           `json:"price"`
+	MarketCap     float64           `json:"market_cap"`
+	EventCount    int               `json:"event_count"`
+	FirstSeenAt   time.Time         `json:"first_seen_at"`
+	LastEventAt   time.Time         `json:"last_event_at"`
+}
+
+// Projector construit les modèles de lecture des tokens à partir du stream token_events.
+// Il consomme le stream en continu comme processeur et peut reconstruire son état
+// en rejouant le journal. Chaque message est appliqué une seule fois, dans l'ordre
+// de sa partition: un message d'offset déjà vu est ignoré.
+type Projector struct {
+	logger *logrus.Logger
+
+	mu      sync.RWMutex
+	tokens  map[string]*TokenReadModel
+	offsets map[string]string // Dernier offset appliqué par partition
+}
+
+// NewProjector crée un projecteur vide
+func NewProjector(logger *logrus.Logger) *Projector {
+	return &Projector{
+		logger:  logger,
+		tokens:  make(map[string]*TokenReadModel),
+		offsets: make(map[string]string),
+	}
+}
+
+// GetName retourne le nom du processeur
+func (p *Projector) GetName() string {
+	return "token_projector"
+}
+
+// Subscriptions retourne les événements consommés par le projecteur
+func (p *Projector) Subscriptions() []pipeline.Subscription {
+	return []pipeline.Subscription{{Stream: pipeline.StreamTokenEvents}}
+}
+
+// Process applique un événement reçu du pipeline
+func (p *Projector) Process(message pipeline.Message) error {
+	p.Apply(message)
+	return nil
+}
+
+// Rebuild rejoue le journal depuis son début et retourne le nombre d'événements appliqués
+func (p *Projector) Rebuild(events *pipeline.Pipeline) (int, error) {
+	applied := 0
+	last, err := events.Replay(pipeline.StreamTokenEvents, "", func(message pipeline.Message) error {
+		if p.Apply(message) {
+			applied++
+		}
+		return nil
+	})
+
+	p.logger.WithFields(logrus.Fields{
+		"events": applied,
+		"tokens": p.Count(),
+		"offset": last,
+	}).Info("Token projections rebuilt from event log")
+
+	return applied, err
+}
+
+// Apply met à jour le modèle de lecture du token concerné par un message.
+// Retourne false si le message a déjà été appliqué ou ne concerne pas un token.
+func (p *Projector) Apply(message pipeline.Message) bool {
+	if message.Event == nil || message.Event.GetTokenAddress() == "" {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if message.Stream != "" {
+		if pipeline.CompareOffsets(message.ID, p.offsets[message.Stream]) <= 0 {
+			return false
+		}
+		p.offsets[message.Stream] = message.ID
+	}
+
+	address := message.Event.GetTokenAddress()
+	model, ok := p.tokens[address]
+	if !ok {
+		model = &TokenReadModel{
+			Address:       address,
+			StateHistory:  make([]StateTransition, 0),
+			XScoreHistory: make([]XScorePoint, 0),
+			Alerts:        make([]AlertRecord, 0),
+			FirstSeenAt:   message.Timestamp,
+		}
+		p.tokens[address] = model
+	}
+	model.EventCount++
+	model.LastEventAt = message.Timestamp
+
+	switch event := message.Event.(type) {
+	case *pipeline.TokenDetected:
+		model.setSymbol(event.TokenSymbol)
+		model.MarketCap = event.MarketCap
+		if model.State == "" {
+			model.transition(models.LifecycleStateDiscovered, "detected", message)
+		}
+	case *pipeline.StateChange:
+		model.setSymbol(event.TokenSymbol)
+		if event.NewState != model.State {
+			model.transition(event.NewState, event.Reason, message)
+		}
+	case *pipeline.Reactivation:
+		model.setSymbol(event.TokenSymbol)
+	case *pipeline.PriceChange:
+		model.setSymbol(event.TokenSymbol)
+		model.Price = event.Price
+	case *pipeline.VolumeSpike:
+		model.setSymbol(event.TokenSymbol)
+	case *pipeline.XScoreCalculated:
+		model.setSymbol(event.TokenSymbol)
+		model.XScore = event.XScore
+		model.Price = event.Price
+		model.MarketCap = event.MarketCap
+		model.XScoreHistory = append(model.XScoreHistory, XScorePoint{
+			XScore:           event.XScore,
+			Price:            event.Price,
+			MarketCap:        event.MarketCap,
+			AntiDumpDetected: event.AntiDumpDetected,
+			At:               event.CalculatedAt,
+		})
+	case *pipeline.AlertRaised:
+		model.setSymbol(event.TokenSymbol)
+		model.Alerts = append(model.Alerts, AlertRecord{
+			ID:        event.AlertID,
+			AlertType: event.AlertType,
+			Severity:  event.Severity,
+			Message:   event.Message,
+			At:        event.DetectedAt,
+		})
+	}
+
+	return true
+}
+
+// setSymbol renseigne le symbole s'il est connu
+func (m *TokenReadModel) setSymbol(symbol string) {
+	if symbol != "" {
+		m.Symbol = symbol
+	}
+}
+
+// transition enregistre un changement d'état
+func (m *TokenReadModel) transition(to, reason string, message pipeline.Message) {
+	m.StateHistory = append(m.StateHistory, StateTransition{
+		From:    m.State,
+		To:      to,
+		Reason:  reason,
+		At:      message.Timestamp,
+		EventID: message.EventID,
+		Offset:  message.ID,
+	})
+	m.State = to
+}
+
+// Token retourne une copie du modèle de lecture d'un token, nil s'il est inconnu
+func (p *Projector) Token(address string) *TokenReadModel {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	model, ok := p.tokens[address]
+	if !ok {
+		return nil
+	}
+	return model.clone()
+}
+
+// Tokens retourne les modèles de lecture des tokens, filtrés par état si states n'est pas vide,
+// triés par adresse
+func (p *Projector) Tokens(states ...string) []TokenReadModel {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(states))
+	for _, s := range states {
+		wanted[s] = true
+	}
+
+	result := make([]TokenReadModel, 0, len(p.tokens))
+	for _, model := range p.tokens {
+		if len(wanted) > 0 && !wanted[model.State] {
+			continue
+		}
+		result = append(result, *model.clone())
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Address < result[j].Address })
+	return result
+}
+
+// Count retourne le nombre de tokens projetés
+func (p *Projector) Count() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.tokens)
+}
+
+// clone copie un modèle de lecture et ses historiques
+func (m *TokenReadModel) clone() *TokenReadModel {
+	c := *m
+	c.StateHistory = append([]StateTransition(nil), m.StateHistory...)
+	c.XScoreHistory = append([]XScorePoint(nil), m.XScoreHistory...)
+	c.Alerts = append([]AlertRecord(nil), m.Alerts...)
+	return &c
+}
@@ -2,8 +2,10 @@ package token
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
 	"github.com/franky69420/crypto-oracle/internal/memory"
@@ -12,6 +14,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ErrTokenNotFound indique que la source de données ne connaît pas le token
+var ErrTokenNotFound = errors.New("token not found")
+
 // Engine gère les opérations sur les tokens
 type Engine struct {
 	gmgn          interface {
@@ -24,8 +29,19 @@ type Engine struct {
 	memoryOfTrust memory.MemoryOfTrust
 	pipelineSvc   *pipeline.Pipeline
 	logger        *logrus.Logger
-	tokens        map[string]*models.Token // Cache en mémoire, à remplacer par Redis en prod
+	tokens        map[string]*models.Token // Tokens suivis par le pipeline, à remplacer par Redis en prod
 	metrics       map[string]*models.TokenMetrics
+	tokensMu      sync.RWMutex // Protège tokens et metrics
+	states        map[string]string // État du cycle de vie courant par token
+	statesMu      sync.RWMutex
+	weights       Weights
+	weightsMu     sync.RWMutex
+	xscoreHistory *historyWriter
+}
+
+// XScoreHistoryStore persiste les X-Scores calculés pour la calibration hors ligne
+type XScoreHistoryStore interface {
+	SaveXScoreHistory(result *models.XScoreResult) error
 }
 
 // NewEngine crée un nouveau moteur de token
@@ -43,7 +59,51 @@ func NewEngine(gmgn interface {
 		logger:        logger,
 		tokens:        make(map[string]*models.Token),
 		metrics:       make(map[string]*models.TokenMetrics),
+		states:        make(map[string]string),
+		weights:       DefaultWeights(),
+	}
+}
+
+// SetWeights remplace les poids des facteurs du X-Score, y compris pendant les calculs en cours
+func (e *Engine) SetWeights(weights Weights) error {
+	if err := weights.Validate(); err != nil {
+		return fmt.Errorf("invalid X-Score weights: %w", err)
+	}
+	weights = weights.clone()
+
+	e.weightsMu.Lock()
+	e.weights = weights
+	e.weightsMu.Unlock()
+	return nil
+}
+
+// LoadWeights charge les poids du X-Score depuis un fichier produit par la calibration
+func (e *Engine) LoadWeights(path string) error {
+	file, err := LoadWeightsFile(path)
+	if err != nil {
+		return err
 	}
+
+	e.logger.WithFields(logrus.Fields{
+		"path":         path,
+		"target":       file.Target,
+		"generated_at": file.GeneratedAt,
+	}).Info("Loaded X-Score weights")
+
+	return e.SetWeights(file.Weights)
+}
+
+// GetWeights retourne une copie des poids courants du X-Score
+func (e *Engine) GetWeights() Weights {
+	e.weightsMu.RLock()
+	defer e.weightsMu.RUnlock()
+	return e.weights.clone()
+}
+
+// SetXScoreHistoryStore active l'historisation des X-Scores calculés. Les écritures se font
+// en tâche de fond jusqu'à Shutdown; à appeler avant le premier calcul.
+func (e *Engine) SetXScoreHistoryStore(store XScoreHistoryStore) {
+	e.xscoreHistory = newHistoryWriter(store, e.logger)
 }
 
 // Start initialise le moteur de token
@@ -55,21 +115,49 @@ func (e *Engine) Start(ctx context.Context) error {
 // Shutdown arrête proprement le moteur de token
 func (e *Engine) Shutdown(ctx context.Context) error {
 	e.logger.Info("Shutting down Token Engine")
+	if e.xscoreHistory != nil {
+		return e.xscoreHistory.close(ctx)
+	}
 	return nil
 }
 
-// GetToken récupère les informations d'un token
+// GetToken récupère les informations d'un token. Un token qui n'est pas suivi est lu
+// chez GMGN sans être mis en cache: une consultation ne l'ajoute pas à la surveillance des prix.
 func (e *Engine) GetToken(tokenAddress string) (*models.Token, error) {
-	// Vérifier le cache en mémoire
-	if token, ok := e.tokens[tokenAddress]; ok {
+	e.tokensMu.RLock()
+	token, ok := e.tokens[tokenAddress]
+	e.tokensMu.RUnlock()
+	if ok {
 		return token, nil
 	}
+	return e.fetchToken(tokenAddress)
+}
 
-	// Récupérer via l'API GMGN
+// trackToken récupère un token et l'ajoute aux tokens suivis par la surveillance des prix
+func (e *Engine) trackToken(tokenAddress string) (*models.Token, error) {
+	token, err := e.GetToken(tokenAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	e.tokensMu.Lock()
+	defer e.tokensMu.Unlock()
+	if existing, ok := e.tokens[tokenAddress]; ok {
+		return existing, nil
+	}
+	e.tokens[tokenAddress] = token
+	return token, nil
+}
+
+// fetchToken récupère un token via l'API GMGN
+func (e *Engine) fetchToken(tokenAddress string) (*models.Token, error) {
 	tokenInfo, err := e.gmgn.GetTokenInfo(tokenAddress)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get token info: %w", err)
 	}
+	if tokenInfo == nil {
+		return nil, ErrTokenNotFound
+	}
 
 	// Le token est déjà dans le bon format
 	token := &models.Token{
@@ -85,9 +173,6 @@ func (e *Engine) GetToken(tokenAddress string) (*models.Token, error) {
 		CachedAt:    time.Now(),
 	}
 
-	// Mettre en cache
-	e.tokens[tokenAddress] = token
-
 	return token, nil
 }
 
@@ -98,6 +183,9 @@ func (e *Engine) GetTokenMetrics(tokenAddress string) (*models.TokenMetrics, err
 	if err != nil {
 		return nil, fmt.Errorf("failed to get token stats: %w", err)
 	}
+	if tokenStats == nil {
+		return nil, ErrTokenNotFound
+	}
 
 	// Créer l'objet métriques
 	metrics := &models.TokenMetrics{
@@ -228,14 +316,25 @@ func (e *Engine) GetWalletTokenHistory(walletAddress, tokenAddress string) ([]mo
 
 // UpdateTokenState met à jour l'état d'un token et publie un événement
 func (e *Engine) UpdateTokenState(tokenAddress, newState string) error {
-	// Récupérer le token
-	token, err := e.GetToken(tokenAddress)
+	// Récupérer le token et le suivre
+	token, err := e.trackToken(tokenAddress)
 	if err != nil {
 		return fmt.Errorf("failed to get token: %w", err)
 	}
 
-	// Mettre à jour l'état (dans une implémentation réelle, il faudrait modifier la base de données)
-	oldState := "unknown" // Dans une implémentation complète, récupérer l'ancien état
+	// Un état inchangé ne produit pas d'événement: un state_change reçu du pipeline
+	// et déjà appliqué ne doit pas être republié
+	e.statesMu.Lock()
+	oldState, known := e.states[tokenAddress]
+	if known && oldState == newState {
+		e.statesMu.Unlock()
+		return nil
+	}
+	e.states[tokenAddress] = newState
+	e.statesMu.Unlock()
+	if !known {
+		oldState = "unknown"
+	}
 
 	e.logger.WithFields(logrus.Fields{
 		"token_address": tokenAddress,
@@ -246,18 +345,14 @@ func (e *Engine) UpdateTokenState(tokenAddress, newState string) error {
 
 	// Publier un événement si le pipeline est disponible
 	if e.pipelineSvc != nil {
-		event := pipeline.Message{
-			Type:      "state_change",
-			Timestamp: time.Now(),
-			Payload: map[string]interface{}{
-				"token_address": tokenAddress,
-				"token_symbol":  token.Symbol,
-				"old_state":     oldState,
-				"new_state":     newState,
-			},
-		}
-
-		if err := e.pipelineSvc.PublishMessage("token_events", event); err != nil {
+		event := &pipeline.StateChange{
+			TokenAddress: tokenAddress,
+			TokenSymbol:  token.Symbol,
+			OldState:     oldState,
+			NewState:     newState,
+		}
+
+		if err := e.pipelineSvc.PublishEvent(pipeline.StreamTokenEvents, event); err != nil {
 			e.logger.WithError(err).Warn("Failed to publish state change event")
 			// Continuer malgré l'erreur
 		}
@@ -266,6 +361,37 @@ func (e *Engine) UpdateTokenState(tokenAddress, newState string) error {
 	return nil
 }
 
+// GetTokenState retourne l'état du cycle de vie courant d'un token, vide s'il est inconnu
+func (e *Engine) GetTokenState(tokenAddress string) string {
+	e.statesMu.RLock()
+	defer e.statesMu.RUnlock()
+	return e.states[tokenAddress]
+}
+
+// Restore réinitialise les tokens et leurs états à partir des modèles reconstruits
+// depuis le journal d'événements, sans republier d'événement
+func (e *Engine) Restore(projections []TokenReadModel) {
+	e.statesMu.Lock()
+	defer e.statesMu.Unlock()
+	e.tokensMu.Lock()
+	defer e.tokensMu.Unlock()
+
+	for _, projection := range projections {
+		if projection.State != "" {
+			e.states[projection.Address] = projection.State
+		}
+		if _, ok := e.tokens[projection.Address]; !ok {
+			e.tokens[projection.Address] = &models.Token{
+				Address:  projection.Address,
+				Symbol:   projection.Symbol,
+				CachedAt: time.Now(),
+			}
+		}
+	}
+
+	e.logger.WithField("tokens", len(projections)).Info("Token states restored from event log")
+}
+
 // SaveReactivationMetrics enregistre les métriques de réactivation et génère un événement
 func (e *Engine) SaveReactivationMetrics(candidate models.ReactivationCandidate) error {
 	e.logger.WithFields(logrus.Fields{
@@ -278,20 +404,16 @@ func (e *Engine) SaveReactivationMetrics(candidate models.ReactivationCandidate)
 
 	// Publier un événement si le pipeline est disponible
 	if e.pipelineSvc != nil {
-		event := pipeline.Message{
-			Type:      "reactivation",
-			Timestamp: time.Now(),
-			Payload: map[string]interface{}{
-				"token_address":      candidate.TokenAddress,
-				"token_symbol":       candidate.TokenSymbol,
-				"reactivation_score": candidate.ReactivationScore,
-				"changes":            candidate.Changes,
-				"detected_at":        candidate.DetectedAt.Format(time.RFC3339),
-				"smart_returns":      candidate.SmartReturns != nil,
-			},
-		}
-
-		if err := e.pipelineSvc.PublishMessage("token_events", event); err != nil {
+		event := &pipeline.Reactivation{
+			TokenAddress:      candidate.TokenAddress,
+			TokenSymbol:       candidate.TokenSymbol,
+			ReactivationScore: candidate.ReactivationScore,
+			Changes:           candidate.Changes,
+			SmartReturns:      candidate.SmartReturns != nil,
+			DetectedAt:        candidate.DetectedAt,
+		}
+
+		if err := e.pipelineSvc.PublishEvent(pipeline.StreamTokenEvents, event); err != nil {
 			e.logger.WithError(err).Warn("Failed to publish reactivation event")
 			// Continuer malgré l'erreur
 		}
@@ -323,8 +445,17 @@ func (e *Engine) checkPriceMovements() {
 	e.logger.Debug("Checking price movements")
 
 	// Dans une implémentation réelle, récupérer les tokens à surveiller depuis la base de données
-	// Pour l'exemple, utiliser les tokens en cache
-	for addr, token := range e.tokens {
+	// Pour l'exemple, utiliser les tokens suivis, copiés pour appeler GMGN sans verrou
+	e.tokensMu.RLock()
+	tracked := make([]*models.Token, 0, len(e.tokens))
+	for _, token := range e.tokens {
+		tracked = append(tracked, token)
+	}
+	e.tokensMu.RUnlock()
+
+	for _, token := range tracked {
+		addr := token.Address
+
 		// Récupérer les métriques actuelles
 		currentMetrics, err := e.GetTokenMetrics(addr)
 		if err != nil {
@@ -332,12 +463,13 @@ func (e *Engine) checkPriceMovements() {
 			continue
 		}
 
-		// Récupérer les métriques précédentes depuis le cache
-		var prevMetrics *models.TokenMetrics
-		var ok bool
-		if prevMetrics, ok = e.metrics[addr]; !ok {
-			// Si pas de métriques précédentes, enregistrer les actuelles et continuer
-			e.metrics[addr] = currentMetrics
+		// Remplacer les métriques précédentes en cache par les actuelles
+		e.tokensMu.Lock()
+		prevMetrics, ok := e.metrics[addr]
+		e.metrics[addr] = currentMetrics
+		e.tokensMu.Unlock()
+		if !ok {
+			// Si pas de métriques précédentes, attendre le prochain passage
 			continue
 		}
 
@@ -353,24 +485,21 @@ func (e *Engine) checkPriceMovements() {
 			volumeChange = (currentMetrics.Volume24h - prevMetrics.Volume24h) / prevMetrics.Volume24h * 100
 		}
 
-		// Mettre à jour les métriques en cache
-		e.metrics[addr] = currentMetrics
-
 		// Générer des événements si changements significatifs
 		if math.Abs(priceChange) >= 5 {
 			// Changement de prix de 5% ou plus
-			e.publishPriceChangeEvent(token, priceChange)
+			e.publishPriceChangeEvent(token, priceChange, prevMetrics.Price, currentMetrics.Price)
 		}
 
 		if volumeChange >= 20 {
 			// Augmentation de volume de 20% ou plus
-			e.publishVolumeChangeEvent(token, volumeChange)
+			e.publishVolumeChangeEvent(token, volumeChange, prevMetrics.Volume24h, currentMetrics.Volume24h)
 		}
 	}
 }
 
 // publishPriceChangeEvent publie un événement de changement de prix
-func (e *Engine) publishPriceChangeEvent(token *models.Token, priceChange float64) {
+func (e *Engine) publishPriceChangeEvent(token *models.Token, priceChange, previousPrice, price float64) {
 	if e.pipelineSvc == nil {
 		return
 	}
@@ -381,24 +510,22 @@ func (e *Engine) publishPriceChangeEvent(token *models.Token, priceChange float6
 		"price_change":  priceChange,
 	}).Info("Significant price change detected")
 
-	event := pipeline.Message{
-		Type:      "price_change",
-		Timestamp: time.Now(),
-		Payload: map[string]interface{}{
-			"token_address": token.Address,
-			"token_symbol":  token.Symbol,
-			"price_change":  priceChange,
-			"is_positive":   priceChange > 0,
-		},
+	event := &pipeline.PriceChange{
+		TokenAddress:  token.Address,
+		TokenSymbol:   token.Symbol,
+		PriceChange:   priceChange,
+		Price:         price,
+		PreviousPrice: previousPrice,
+		IsPositive:    priceChange > 0,
 	}
 
-	if err := e.pipelineSvc.PublishMessage("token_events", event); err != nil {
+	if err := e.pipelineSvc.PublishEvent(pipeline.StreamTokenEvents, event); err != nil {
 		e.logger.WithError(err).Warn("Failed to publish price change event")
 	}
 }
 
 // publishVolumeChangeEvent publie un événement de changement de volume
-func (e *Engine) publishVolumeChangeEvent(token *models.Token, volumeChange float64) {
+func (e *Engine) publishVolumeChangeEvent(token *models.Token, volumeChange, previousVolume, volume float64) {
 	if e.pipelineSvc == nil {
 		return
 	}
@@ -409,33 +536,66 @@ func (e *Engine) publishVolumeChangeEvent(token *models.Token, volumeChange floa
 		"volume_change": volumeChange,
 	}).Info("Significant volume change detected")
 
-	event := pipeline.Message{
-		Type:      "volume_spike",
-		Timestamp: time.Now(),
-		Payload: map[string]interface{}{
-			"token_address": token.Address,
-			"token_symbol":  token.Symbol,
-			"volume_change": volumeChange,
-		},
+	event := &pipeline.VolumeSpike{
+		TokenAddress:      token.Address,
+		TokenSymbol:       token.Symbol,
+		VolumeChange:      volumeChange,
+		Volume24h:         volume,
+		PreviousVolume24h: previousVolume,
 	}
 
-	if err := e.pipelineSvc.PublishMessage("token_events", event); err != nil {
+	if err := e.pipelineSvc.PublishEvent(pipeline.StreamTokenEvents, event); err != nil {
 		e.logger.WithError(err).Warn("Failed to publish volume change event")
 	}
 }
 
-// CalculateXScore calcule le X-Score pour un token
+// CalculateXScore calcule le X-Score pour un token, le publie dans le journal d'événements
+// et l'historise
 func (e *Engine) CalculateXScore(tokenAddress string, walletAnalysis *models.WalletAnalysis) (*models.XScoreResult, error) {
+	// Un token évalué par le pipeline est suivi par la surveillance des prix
+	if _, err := e.trackToken(tokenAddress); err != nil {
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	result, event, err := e.computeXScore(tokenAddress, walletAnalysis)
+	if err != nil {
+		return nil, err
+	}
+
+	// Journaliser le score: l'historique du token est reconstruit à partir des événements
+	if e.pipelineSvc != nil {
+		if err := e.pipelineSvc.PublishEvent(pipeline.StreamTokenEvents, event); err != nil {
+			e.logger.WithError(err).Warn("Failed to publish X-Score event")
+		}
+	}
+
+	// Historiser pour la calibration hors ligne, sans attendre la base
+	if e.xscoreHistory != nil {
+		e.xscoreHistory.record(result)
+	}
+
+	return result, nil
+}
+
+// ComputeXScore calcule le X-Score d'un token sans le publier ni l'historiser, pour les
+// calculs à la demande
+func (e *Engine) ComputeXScore(tokenAddress string, walletAnalysis *models.WalletAnalysis) (*models.XScoreResult, error) {
+	result, _, err := e.computeXScore(tokenAddress, walletAnalysis)
+	return result, err
+}
+
+// computeXScore calcule le X-Score et l'événement qui le journalise, sans effet de bord
+func (e *Engine) computeXScore(tokenAddress string, walletAnalysis *models.WalletAnalysis) (*models.XScoreResult, *pipeline.XScoreCalculated, error) {
 	// Récupérer les métriques du token
 	metrics, err := e.GetTokenMetrics(tokenAddress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get token metrics: %w", err)
+		return nil, nil, fmt.Errorf("failed to get token metrics: %w", err)
 	}
 	
 	// Récupérer le token
 	token, err := e.GetToken(tokenAddress)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get token: %w", err)
+		return nil, nil, fmt.Errorf("failed to get token: %w", err)
 	}
 	
 	// Si l'analyse des wallets n'est pas fournie, en faire une
@@ -449,30 +609,31 @@ func (e *Engine) CalculateXScore(tokenAddress string, walletAnalysis *models.Wal
 	
 	// Initialiser les composants du score
 	components := make(map[string]float64)
+	factors := make(map[string]float64)
+	
+	// 1. Qualité Token (20% par défaut)
+	factors[FactorTokenQuality] = e.calculateTokenQuality(token, metrics)
 	
-	// 1. Qualité Token (20%)
-	tokenQuality := e.calculateTokenQuality(token, metrics)
-	components["token_quality"] = tokenQuality * 0.20
+	// 2. Wallet Quality (25% par défaut)
+	factors[FactorWalletQuality] = e.calculateWalletQuality(walletAnalysis)
 	
-	// 2. Wallet Quality (25%)
-	walletQuality := e.calculateWalletQuality(walletAnalysis)
-	components["wallet_quality"] = walletQuality * 0.25
+	// 3. Memory of Trust (20% par défaut)
+	factors[FactorTrust] = e.calculateTrustFactor(token, walletAnalysis)
 	
-	// 3. Memory of Trust (20%)
-	trustFactor := e.calculateTrustFactor(token, walletAnalysis)
-	components["trust_factor"] = trustFactor * 0.20
+	// 4. Market Dynamics (15% par défaut)
+	factors[FactorMarket] = e.calculateMarketDynamics(metrics)
 	
-	// 4. Market Dynamics (15%)
-	marketFactor := e.calculateMarketDynamics(metrics)
-	components["market_factor"] = marketFactor * 0.15
+	// 5. Temporal Patterns (10% par défaut)
+	factors[FactorTemporal] = e.calculateTemporalPatterns(metrics)
 	
-	// 5. Temporal Patterns (10%)
-	temporalFactor := e.calculateTemporalPatterns(metrics)
-	components["temporal_factor"] = temporalFactor * 0.10
+	// 6. Reactivation Boost (10% par défaut)
+	factors[FactorReactivation] = e.calculateReactivationFactor(token, metrics)
 	
-	// 6. Reactivation Boost (10%)
-	reactivationFactor := e.calculateReactivationFactor(token, metrics)
-	components["reactivation_factor"] = reactivationFactor * 0.10
+	// Appliquer les poids (fixes ou issus de la calibration)
+	weights := e.GetWeights()
+	for _, factor := range WeightedFactors {
+		components[factor] = factors[factor] * weights[factor]
+	}
 	
 	// NOUVEAU: Bonus Sniper Wallets
 	sniperCount := walletAnalysis.SniperCount
@@ -508,14 +669,35 @@ func (e *Engine) CalculateXScore(tokenAddress string, walletAnalysis *models.Wal
 	// Range final 0-100
 	finalScore = math.Max(0, math.Min(100, finalScore))
 	
-	return &models.XScoreResult{
+	result := &models.XScoreResult{
 		TokenAddress: tokenAddress,
 		XScore:       finalScore,
 		BaseScore:    baseScore,
 		Components:   components,
+		Factors:      factors,
+		Price:        metrics.Price,
+		MarketCap:    metrics.MarketCap,
 		AntiDump:     antiDump,
 		CalculatedAt: time.Now(),
-	}, nil
+	}
+	
+	event := &pipeline.XScoreCalculated{
+		TokenAddress:     tokenAddress,
+		TokenSymbol:      token.Symbol,
+		XScore:           result.XScore,
+		BaseScore:        result.BaseScore,
+		Components:       result.Components,
+		Factors:          result.Factors,
+		Metrics:          metrics.Values(),
+		Wallets:          walletAnalysis.Values(),
+		Price:            result.Price,
+		MarketCap:        result.MarketCap,
+		AntiDumpDetected: antiDump.Detected,
+		AntiDumpSeverity: antiDump.Severity,
+		CalculatedAt:     result.CalculatedAt,
+	}
+	
+	return result, event, nil
 }
 
 // calculateTokenQuality calcule le score de qualité du token
@@ -709,6 +891,11 @@ func (e *Engine) calculateReactivationFactor(token *models.Token, metrics *model
 	return 0.0 // Par défaut pas de bonus réactivation
 }
 
+// CheckAntiDump analyse les ventes coordonnées des dernières 24h d'un token, sans calculer le X-Score
+func (e *Engine) CheckAntiDump(tokenAddress string) *models.AntiDumpResult {
+	return e.checkAntiDumpPattern(tokenAddress, nil)
+}
+
 // checkAntiDumpPattern vérifie les patterns de dump coordonnés
 func (e *Engine) checkAntiDumpPattern(tokenAddress string, walletAnalysis *models.WalletAnalysis) *models.AntiDumpResult {
 	// Récupérer transactions récentes (24h)
@@ -0,0 +1,104 @@
+package token
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/franky69420/crypto-oracle/internal/memory"
+	"github.com/franky69420/crypto-oracle/pkg/models"
+	"github.com/sirupsen/logrus"
+)
+
+// stubSource connaît tous les tokens et n'a aucun trade
+type stubSource struct{}
+
+func (stubSource) GetTokenInfo(tokenAddress string) (*models.Token, error) {
+	return &models.Token{Address: tokenAddress, Symbol: "T"}, nil
+}
+
+func (stubSource) GetTokenStats(tokenAddress string) (*models.TokenStats, error) {
+	return &models.TokenStats{HolderCount: 100, Price: 0.01, Volume24h: 1000}, nil
+}
+
+func (stubSource) GetTokenTrades(tokenAddress string, limit int) ([]models.TokenTrade, error) {
+	return nil, nil
+}
+
+func (stubSource) GetTokenPrice(tokenAddress string) (*models.TokenPrice, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (stubSource) GetWalletTokenTrades(walletAddress, tokenAddress string, limit int) ([]models.TokenTrade, error) {
+	return nil, nil
+}
+
+// stubTrust n'a aucune métrique de confiance
+type stubTrust struct {
+	memory.MemoryOfTrust
+}
+
+func (stubTrust) GetTokenTrustMetrics(tokenAddress string) (*models.TokenTrustMetrics, error) {
+	return nil, errors.New("no trust metrics")
+}
+
+func newTestEngine() *Engine {
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	return NewEngine(stubSource{}, stubTrust{}, nil, quiet)
+}
+
+func trackedCount(e *Engine) int {
+	e.tokensMu.RLock()
+	defer e.tokensMu.RUnlock()
+	return len(e.tokens)
+}
+
+func TestTokenCacheConcurrentAccess(t *testing.T) {
+	e := newTestEngine()
+	e.Restore([]TokenReadModel{{Address: "tracked", Symbol: "TRK"}})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := e.GetToken(fmt.Sprintf("t%d", i)); err != nil {
+				t.Error(err)
+			}
+			if _, err := e.ComputeXScore(fmt.Sprintf("t%d", i), nil); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	for i := 0; i < 5; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			e.checkPriceMovements()
+		}()
+		go func(i int) {
+			defer wg.Done()
+			e.Restore([]TokenReadModel{{Address: fmt.Sprintf("restored%d", i)}})
+		}(i)
+	}
+	wg.Wait()
+
+	// Les consultations ne sont pas suivies
+	if got := trackedCount(e); got != 6 {
+		t.Fatalf("tracked %d tokens, want 6", got)
+	}
+
+	// Les tokens traités par le pipeline le sont
+	if err := e.UpdateTokenState("t1", models.LifecycleStateHyped); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.CalculateXScore("t2", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := trackedCount(e); got != 8 {
+		t.Fatalf("tracked %d tokens after pipeline updates, want 8", got)
+	}
+}
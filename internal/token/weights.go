@@ -0,0 +1,148 @@
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+// Noms des facteurs pondérés du X-Score
+const (
+	FactorTokenQuality  = "token_quality"
+	FactorWalletQuality = "wallet_quality"
+	FactorTrust         = "trust_factor"
+	FactorMarket        = "market_factor"
+	FactorTemporal      = "temporal_factor"
+	FactorReactivation  = "reactivation_factor"
+)
+
+// WeightedFactors liste les facteurs pondérés dans l'ordre de calcul du X-Score
+var WeightedFactors = []string{
+	FactorTokenQuality,
+	FactorWalletQuality,
+	FactorTrust,
+	FactorMarket,
+	FactorTemporal,
+	FactorReactivation,
+}
+
+// Weights associe chaque facteur du X-Score à son poids
+type Weights map[string]float64
+
+// DefaultWeights retourne les poids historiques choisis à la main
+func DefaultWeights() Weights {
+	return Weights{
+		FactorTokenQuality:  0.20,
+		FactorWalletQuality: 0.25,
+		FactorTrust:         0.20,
+		FactorMarket:        0.15,
+		FactorTemporal:      0.10,
+		FactorReactivation:  0.10,
+	}
+}
+
+// weightsSumTolerance est l'écart toléré entre la somme des poids et 1
+const weightsSumTolerance = 1e-3
+
+// Validate vérifie que tous les facteurs sont présents avec un poids positif et que les
+// poids somment à 1, pour que le X-Score reste sur l'échelle des facteurs
+func (w Weights) Validate() error {
+	total := 0.0
+	for _, factor := range WeightedFactors {
+		weight, ok := w[factor]
+		if !ok {
+			return fmt.Errorf("missing weight for factor %s", factor)
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight for factor %s: %f", factor, weight)
+		}
+		total += weight
+	}
+	for factor := range w {
+		if !isWeightedFactor(factor) {
+			return fmt.Errorf("unknown factor %s", factor)
+		}
+	}
+	if math.Abs(total-1) > weightsSumTolerance {
+		return fmt.Errorf("weights sum to %f, want 1", total)
+	}
+	return nil
+}
+
+// isWeightedFactor indique si le nom correspond à un facteur pondéré
+func isWeightedFactor(name string) bool {
+	for _, factor := range WeightedFactors {
+		if factor == name {
+			return true
+		}
+	}
+	return false
+}
+
+// clone retourne une copie des poids
+func (w Weights) clone() Weights {
+	copied := make(Weights, len(w))
+	for factor, weight := range w {
+		copied[factor] = weight
+	}
+	return copied
+}
+
+// WeightsFile représente un fichier de poids candidats produit par la calibration
+type WeightsFile struct {
+	Version     int                    `json:"version"`
+	Target      string                 `json:"target,omitempty"`
+	GeneratedAt time.Time              `json:"generated_at"`
+	Weights     Weights                `json:"weights"`
+	Evaluation  map[string]interface{} `json:"evaluation,omitempty"`
+}
+
+// WeightsFileVersion est la version courante du format de fichier de poids
+const WeightsFileVersion = 1
+
+// LoadWeightsFile charge et valide un fichier de poids
+func LoadWeightsFile(path string) (*WeightsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read weights file: %w", err)
+	}
+
+	var file WeightsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode weights file: %w", err)
+	}
+
+	if file.Version != WeightsFileVersion {
+		return nil, fmt.Errorf("unsupported weights file version %d", file.Version)
+	}
+
+	if err := file.Weights.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid weights file: %w", err)
+	}
+
+	return &file, nil
+}
+
+// WriteWeightsFile écrit un fichier de poids au format JSON
+func WriteWeightsFile(path string, file *WeightsFile) error {
+	if file.Version == 0 {
+		file.Version = WeightsFileVersion
+	}
+
+	if err := file.Weights.Validate(); err != nil {
+		return fmt.Errorf("invalid weights: %w", err)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode weights file: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write weights file: %w", err)
+	}
+
+	return nil
+}
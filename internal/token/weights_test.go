@@ -0,0 +1,68 @@
+package token
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestWeightsConcurrentAccess(t *testing.T) {
+	quiet := logrus.New()
+	quiet.SetOutput(io.Discard)
+	e := NewEngine(nil, nil, nil, quiet)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := e.SetWeights(DefaultWeights()); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = e.GetWeights()[FactorTrust]
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Les poids passés et retournés sont des copies
+	weights := DefaultWeights()
+	if err := e.SetWeights(weights); err != nil {
+		t.Fatal(err)
+	}
+	weights[FactorTrust] = 0.9
+	e.GetWeights()[FactorMarket] = 0.9
+	if got := e.GetWeights(); got[FactorTrust] != 0.20 || got[FactorMarket] != 0.15 {
+		t.Fatalf("weights shared with callers: %v", got)
+	}
+}
+
+func TestWeightsValidateRequiresUnitSum(t *testing.T) {
+	if err := DefaultWeights().Validate(); err != nil {
+		t.Fatalf("default weights: %v", err)
+	}
+
+	scaled := DefaultWeights()
+	for factor := range scaled {
+		scaled[factor] *= 2
+	}
+	if err := scaled.Validate(); err == nil {
+		t.Fatal("weights summing to 2 accepted")
+	}
+
+	// Les arrondis d'un fichier JSON restent acceptés
+	rounded := DefaultWeights()
+	rounded[FactorTrust] += 0.0004
+	if err := rounded.Validate(); err != nil {
+		t.Fatalf("rounded weights: %v", err)
+	}
+}
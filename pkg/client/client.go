@@ -0,0 +1,475 @@
+// Package client est un client Go typé de l'API HTTP de Crypto Oracle, décrite par
+// /api/openapi.json: tokens, wallets, graphe de confiance, alertes et flux d'événements en direct.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// Config contient la configuration du client
+type Config struct {
+	BaseURL    string       // Par exemple http://localhost:8080
+	APIKey     string       // Envoyée dans Authorization: Bearer, vide si l'authentification est désactivée
+	HTTPClient *http.Client // http.DefaultClient si nil; son Timeout s'applique aussi aux flux
+}
+
+// Client appelle l'API de Crypto Oracle. Il peut être utilisé depuis plusieurs goroutines.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// APIError est une réponse d'erreur de l'API
+type APIError struct {
+	StatusCode int
+	Code       string // Code stable de l'enveloppe d'erreur, vide pour les routes qui répondent en texte
+	Message    string
+	RetryAfter time.Duration // Renseigné pour les réponses 429
+}
+
+// Error implémente l'interface error
+func (e *APIError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("crypto-oracle API: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("crypto-oracle API: %d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound indique si err est une réponse 404 de l'API
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// NewClient crée un nouveau client
+func NewClient(config Config) *Client {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		httpClient: httpClient,
+	}
+}
+
+// newRequest prépare une requête authentifiée vers path
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("échec de l'encodage de la requête: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, fmt.Errorf("échec de la création de la requête: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	return req, nil
+}
+
+// do exécute une requête et décode la réponse JSON dans out si out n'est pas nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("échec de la requête %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return readError(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("échec du décodage de la réponse %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// readError construit l'erreur d'une réponse en échec, enveloppe JSON ou texte
+func readError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &envelope) == nil && envelope.Error.Code != "" {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
+
+// escape encode un segment de chemin
+func escape(segment string) string {
+	return url.PathEscape(segment)
+}
+
+// limitQuery retourne les paramètres avec limit si elle est positive
+func limitQuery(limit int) url.Values {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	return query
+}
+
+// Tokens
+
+// ListTokens liste les tokens suivis, filtrés par états de cycle de vie
+func (c *Client) ListTokens(ctx context.Context, states []string, limit int) (*TokenList, error) {
+	query := limitQuery(limit)
+	if len(states) > 0 {
+		query.Set("state", strings.Join(states, ","))
+	}
+	var list TokenList
+	if err := c.do(ctx, http.MethodGet, "/api/tokens", query, nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetToken retourne les métadonnées d'un token avec son état de cycle de vie
+func (c *Client) GetToken(ctx context.Context, tokenAddress string) (*TokenDetails, error) {
+	var details TokenDetails
+	if err := c.do(ctx, http.MethodGet, "/api/tokens/"+escape(tokenAddress), nil, nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// GetTokenMetrics retourne les métriques en direct d'un token
+func (c *Client) GetTokenMetrics(ctx context.Context, tokenAddress string) (*models.TokenMetrics, error) {
+	var metrics models.TokenMetrics
+	if err := c.do(ctx, http.MethodGet, "/api/tokens/"+escape(tokenAddress)+"/metrics", nil, nil, &metrics); err != nil {
+		return nil, err
+	}
+	return &metrics, nil
+}
+
+// GetTokenXScore calcule le X-Score d'un token
+func (c *Client) GetTokenXScore(ctx context.Context, tokenAddress string) (*XScore, error) {
+	var score XScore
+	if err := c.do(ctx, http.MethodGet, "/api/tokens/"+escape(tokenAddress)+"/xscore", nil, nil, &score); err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// GetTokenState retourne l'état de cycle de vie d'un token et ses transitions
+func (c *Client) GetTokenState(ctx context.Context, tokenAddress string) (*TokenState, error) {
+	var state TokenState
+	if err := c.do(ctx, http.MethodGet, "/api/tokens/"+escape(tokenAddress)+"/state", nil, nil, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// GetTokenAntiDump retourne l'analyse des ventes coordonnées d'un token sur 24h
+func (c *Client) GetTokenAntiDump(ctx context.Context, tokenAddress string) (*models.AntiDumpResult, error) {
+	var body struct {
+		AntiDump *models.AntiDumpResult `json:"anti_dump"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/tokens/"+escape(tokenAddress)+"/anti-dump", nil, nil, &body); err != nil {
+		return nil, err
+	}
+	return body.AntiDump, nil
+}
+
+// GetTokenActiveWallets retourne les wallets actifs sur un token
+func (c *Client) GetTokenActiveWallets(ctx context.Context, tokenAddress string, limit int) ([]models.ActiveWallet, error) {
+	var body struct {
+		ActiveWallets []models.ActiveWallet `json:"active_wallets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/tokens/"+escape(tokenAddress)+"/active-wallets", limitQuery(limit), nil, &body); err != nil {
+		return nil, err
+	}
+	return body.ActiveWallets, nil
+}
+
+// Wallets
+
+// GetTrustedWallets retourne les wallets avec les meilleurs scores de confiance
+func (c *Client) GetTrustedWallets(ctx context.Context, limit int) ([]models.WalletTrustScore, error) {
+	var body struct {
+		Wallets []models.WalletTrustScore `json:"wallets"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/trusted", limitQuery(limit), nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Wallets, nil
+}
+
+// GetWalletProfile retourne le profil complet d'un wallet
+func (c *Client) GetWalletProfile(ctx context.Context, walletAddress string) (*models.WalletProfile, error) {
+	var profile models.WalletProfile
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/"+escape(walletAddress), nil, nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// GetWalletTrust retourne le score de confiance courant d'un wallet
+func (c *Client) GetWalletTrust(ctx context.Context, walletAddress string) (float64, error) {
+	var body struct {
+		TrustScore float64 `json:"trust_score"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/"+escape(walletAddress)+"/trust", nil, nil, &body); err != nil {
+		return 0, err
+	}
+	return body.TrustScore, nil
+}
+
+// GetWalletTrustTrend retourne l'évolution journalière du score de confiance sur days jours
+func (c *Client) GetWalletTrustTrend(ctx context.Context, walletAddress string, days int) ([]models.TrustScorePoint, error) {
+	query := url.Values{}
+	if days > 0 {
+		query.Set("days", strconv.Itoa(days))
+	}
+	var body struct {
+		Points []models.TrustScorePoint `json:"points"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/"+escape(walletAddress)+"/trust/trend", query, nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Points, nil
+}
+
+// GetSimilarWallets retourne les wallets au comportement proche; minSimilarity 0 garde la valeur du serveur
+func (c *Client) GetSimilarWallets(ctx context.Context, walletAddress string, minSimilarity float64, limit int) ([]models.WalletSimilarity, error) {
+	query := limitQuery(limit)
+	if minSimilarity > 0 {
+		query.Set("min_similarity", strconv.FormatFloat(minSimilarity, 'f', -1, 64))
+	}
+	var body struct {
+		Similar []models.WalletSimilarity `json:"similar"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/"+escape(walletAddress)+"/similar", query, nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Similar, nil
+}
+
+// GetWalletRisk retourne les facteurs de risque d'un wallet
+func (c *Client) GetWalletRisk(ctx context.Context, walletAddress string) (*models.WalletRiskFactors, error) {
+	var risk models.WalletRiskFactors
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/"+escape(walletAddress)+"/risk", nil, nil, &risk); err != nil {
+		return nil, err
+	}
+	return &risk, nil
+}
+
+// GetWalletTokens retourne les tokens tradés par un wallet
+func (c *Client) GetWalletTokens(ctx context.Context, walletAddress string, limit int) ([]models.WalletToken, error) {
+	var body struct {
+		Tokens []models.WalletToken `json:"tokens"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/"+escape(walletAddress)+"/tokens", limitQuery(limit), nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Tokens, nil
+}
+
+// GetWalletClassification indique si un wallet se comporte en sniper et en smart money
+func (c *Client) GetWalletClassification(ctx context.Context, walletAddress string) (*WalletClassification, error) {
+	var classification WalletClassification
+	if err := c.do(ctx, http.MethodGet, "/api/wallets/"+escape(walletAddress)+"/classification", nil, nil, &classification); err != nil {
+		return nil, err
+	}
+	return &classification, nil
+}
+
+// Graphe de confiance
+
+// GetWalletNeighborhood retourne les wallets et tokens à au plus hops liens d'un wallet
+func (c *Client) GetWalletNeighborhood(ctx context.Context, walletAddress string, hops, maxNodes int) (*models.TrustSubgraph, error) {
+	var subgraph models.TrustSubgraph
+	if err := c.do(ctx, http.MethodGet, "/api/graph/wallets/"+escape(walletAddress)+"/neighborhood", graphQuery("hops", hops, maxNodes), nil, &subgraph); err != nil {
+		return nil, err
+	}
+	return &subgraph, nil
+}
+
+// GetTokenNeighborhood retourne les wallets et tokens à au plus hops liens d'un token
+func (c *Client) GetTokenNeighborhood(ctx context.Context, tokenAddress string, hops, maxNodes int) (*models.TrustSubgraph, error) {
+	var subgraph models.TrustSubgraph
+	if err := c.do(ctx, http.MethodGet, "/api/graph/tokens/"+escape(tokenAddress)+"/neighborhood", graphQuery("hops", hops, maxNodes), nil, &subgraph); err != nil {
+		return nil, err
+	}
+	return &subgraph, nil
+}
+
+// GetEarlyBuyersSubgraph retourne le sous-graphe des premiers acheteurs d'un token, lus dans
+// ses buyers premières transactions
+func (c *Client) GetEarlyBuyersSubgraph(ctx context.Context, tokenAddress string, buyers, maxNodes int) (*models.TrustSubgraph, error) {
+	var subgraph models.TrustSubgraph
+	if err := c.do(ctx, http.MethodGet, "/api/graph/tokens/"+escape(tokenAddress)+"/early-buyers", graphQuery("buyers", buyers, maxNodes), nil, &subgraph); err != nil {
+		return nil, err
+	}
+	return &subgraph, nil
+}
+
+// GetShortestWalletPath retourne un plus court chemin entre deux wallets via au plus maxHops tokens partagés
+func (c *Client) GetShortestWalletPath(ctx context.Context, from, to string, maxHops int) (*models.TrustPath, error) {
+	query := url.Values{}
+	query.Set("from", from)
+	query.Set("to", to)
+	if maxHops > 0 {
+		query.Set("max_hops", strconv.Itoa(maxHops))
+	}
+	var path models.TrustPath
+	if err := c.do(ctx, http.MethodGet, "/api/graph/paths", query, nil, &path); err != nil {
+		return nil, err
+	}
+	return &path, nil
+}
+
+// graphQuery retourne les paramètres d'une requête sur le graphe; les valeurs nulles gardent celles du serveur
+func graphQuery(name string, value, maxNodes int) url.Values {
+	query := url.Values{}
+	if value > 0 {
+		query.Set(name, strconv.Itoa(value))
+	}
+	if maxNodes > 0 {
+		query.Set("max_nodes", strconv.Itoa(maxNodes))
+	}
+	return query
+}
+
+// Alertes
+
+// ListAlerts retourne une page d'alertes; passer NextCursor dans q.Cursor pour la suivante
+func (c *Client) ListAlerts(ctx context.Context, q AlertQuery) (*AlertPage, error) {
+	query := limitQuery(q.Limit)
+	for name, value := range map[string]string{
+		"token": q.Token, "type": q.Type, "severity": q.Severity, "status": q.Status, "cursor": q.Cursor,
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if !q.From.IsZero() {
+		query.Set("from", q.From.Format(time.RFC3339))
+	}
+	if !q.To.IsZero() {
+		query.Set("to", q.To.Format(time.RFC3339))
+	}
+	if q.Confirmed != nil {
+		query.Set("confirmed", strconv.FormatBool(*q.Confirmed))
+	}
+
+	var page AlertPage
+	if err := c.do(ctx, http.MethodGet, "/api/alerts", query, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetAlert retourne une alerte
+func (c *Client) GetAlert(ctx context.Context, alertID string) (*models.TokenAlert, error) {
+	var alert models.TokenAlert
+	if err := c.do(ctx, http.MethodGet, "/api/alerts/"+escape(alertID), nil, nil, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// ConfirmAlert confirme une alerte et retourne sa nouvelle version
+func (c *Client) ConfirmAlert(ctx context.Context, alertID string) (*models.TokenAlert, error) {
+	var alert models.TokenAlert
+	if err := c.do(ctx, http.MethodPost, "/api/alerts/"+escape(alertID)+"/confirm", nil, nil, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// ResolveAlert résout une alerte ouverte avec un motif
+func (c *Client) ResolveAlert(ctx context.Context, alertID, reason string) (*models.TokenAlert, error) {
+	body := map[string]string{"reason": reason}
+	var alert models.TokenAlert
+	if err := c.do(ctx, http.MethodPost, "/api/alerts/"+escape(alertID)+"/resolve", nil, body, &alert); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// GetAlertHistory retourne l'historique des états d'une alerte
+func (c *Client) GetAlertHistory(ctx context.Context, alertID string) ([]models.AlertStateChange, error) {
+	var body struct {
+		History []models.AlertStateChange `json:"history"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/alerts/"+escape(alertID)+"/history", nil, nil, &body); err != nil {
+		return nil, err
+	}
+	return body.History, nil
+}
+
+// GetAlertOutcome retourne le suivi du marché après une alerte
+func (c *Client) GetAlertOutcome(ctx context.Context, alertID string) (*models.AlertOutcome, error) {
+	var outcome models.AlertOutcome
+	if err := c.do(ctx, http.MethodGet, "/api/alerts/"+escape(alertID)+"/outcome", nil, nil, &outcome); err != nil {
+		return nil, err
+	}
+	return &outcome, nil
+}
+
+// GetAlertStats retourne les performances des alertes par type depuis since (30 jours si zéro)
+func (c *Client) GetAlertStats(ctx context.Context, since time.Time, alertType string) ([]models.AlertTypeStats, error) {
+	query := url.Values{}
+	if !since.IsZero() {
+		query.Set("since", since.Format(time.RFC3339))
+	}
+	if alertType != "" {
+		query.Set("type", alertType)
+	}
+	var body struct {
+		Stats []models.AlertTypeStats `json:"stats"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/alerts/stats", query, nil, &body); err != nil {
+		return nil, err
+	}
+	return body.Stats, nil
+}
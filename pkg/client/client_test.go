@@ -0,0 +1,242 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const testKey = "co_test_secret"
+
+// newSpecServer sert uniquement les routes décrites par openapi.json et retient les
+// opérations appelées. Une requête hors de la spec échoue le test.
+func newSpecServer(t *testing.T, bodies map[string]string) (*Client, map[string]bool) {
+	t.Helper()
+	data, err := os.ReadFile("../../internal/api/openapi.json")
+	if err != nil {
+		t.Fatalf("read spec: %v", err)
+	}
+	var spec struct {
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+
+	var mu sync.Mutex
+	called := make(map[string]bool)
+	// Les chemins littéraux passent avant les chemins paramétrés (/api/alerts/stats avant /api/alerts/{id})
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if ci, cj := strings.Count(paths[i], "{"), strings.Count(paths[j], "{"); ci != cj {
+			return ci < cj
+		}
+		return paths[i] < paths[j]
+	})
+
+	router := mux.NewRouter()
+	for _, path := range paths {
+		for method, op := range spec.Paths[path] {
+			operationID := op.OperationID
+			router.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "Bearer "+testKey {
+					t.Errorf("%s: missing API key", operationID)
+				}
+				mu.Lock()
+				called[operationID] = true
+				mu.Unlock()
+				body, ok := bodies[operationID]
+				if !ok {
+					body = "{}"
+				}
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprint(w, body)
+			}).Methods(strings.ToUpper(method))
+		}
+	}
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("%s %s is not documented in openapi.json", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+	})
+	router.MethodNotAllowedHandler = router.NotFoundHandler
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return NewClient(Config{BaseURL: server.URL + "/", APIKey: testKey}), called
+}
+
+func TestClientUsesDocumentedRoutes(t *testing.T) {
+	client, called := newSpecServer(t, map[string]string{
+		"getToken":       `{"token":{"address":"tok1","symbol":"ABC"},"state":"HYPED","xscore":72.5}`,
+		"getWalletTrust": `{"wallet_address":"w1","trust_score":81.2}`,
+		"listAlerts":     `{"alerts":[{"id":"a1"}],"next_cursor":"c2"}`,
+	})
+	ctx := context.Background()
+	confirmed := true
+
+	details, err := client.GetToken(ctx, "tok1")
+	if err != nil || details.Token.Symbol != "ABC" || details.State != "HYPED" || details.XScore != 72.5 {
+		t.Fatalf("GetToken = %+v, %v", details, err)
+	}
+	trust, err := client.GetWalletTrust(ctx, "w1")
+	if err != nil || trust != 81.2 {
+		t.Fatalf("GetWalletTrust = %v, %v", trust, err)
+	}
+	page, err := client.ListAlerts(ctx, AlertQuery{Token: "tok1", Confirmed: &confirmed, From: time.Now(), Limit: 10})
+	if err != nil || len(page.Alerts) != 1 || page.NextCursor != "c2" {
+		t.Fatalf("ListAlerts = %+v, %v", page, err)
+	}
+
+	calls := []func() error{
+		func() error { _, err := client.ListTokens(ctx, []string{"HYPED", "REACTIVATED"}, 10); return err },
+		func() error { _, err := client.GetTokenMetrics(ctx, "tok1"); return err },
+		func() error { _, err := client.GetTokenXScore(ctx, "tok1"); return err },
+		func() error { _, err := client.GetTokenState(ctx, "tok1"); return err },
+		func() error { _, err := client.GetTokenAntiDump(ctx, "tok1"); return err },
+		func() error { _, err := client.GetTokenActiveWallets(ctx, "tok1", 5); return err },
+		func() error { _, err := client.GetTrustedWallets(ctx, 5); return err },
+		func() error { _, err := client.GetWalletProfile(ctx, "w1"); return err },
+		func() error { _, err := client.GetWalletTrustTrend(ctx, "w1", 7); return err },
+		func() error { _, err := client.GetSimilarWallets(ctx, "w1", 0.5, 5); return err },
+		func() error { _, err := client.GetWalletRisk(ctx, "w1"); return err },
+		func() error { _, err := client.GetWalletTokens(ctx, "w1", 5); return err },
+		func() error { _, err := client.GetWalletClassification(ctx, "w1"); return err },
+		func() error { _, err := client.GetWalletNeighborhood(ctx, "w1", 2, 100); return err },
+		func() error { _, err := client.GetTokenNeighborhood(ctx, "tok1", 0, 0); return err },
+		func() error { _, err := client.GetEarlyBuyersSubgraph(ctx, "tok1", 50, 0); return err },
+		func() error { _, err := client.GetShortestWalletPath(ctx, "w1", "w2", 3); return err },
+		func() error { _, err := client.GetAlert(ctx, "a1"); return err },
+		func() error { _, err := client.ConfirmAlert(ctx, "a1"); return err },
+		func() error { _, err := client.ResolveAlert(ctx, "a1", "done"); return err },
+		func() error { _, err := client.GetAlertHistory(ctx, "a1"); return err },
+		func() error { _, err := client.GetAlertOutcome(ctx, "a1"); return err },
+		func() error { _, err := client.GetAlertStats(ctx, time.Now(), "BREAKOUT"); return err },
+		func() error { _, err := client.GetStreamStats(ctx); return err },
+	}
+	for i, call := range calls {
+		if err := call(); err != nil {
+			t.Errorf("call %d: %v", i, err)
+		}
+	}
+
+	// Chaque appel correspond à une opération distincte de la spec
+	if len(called) != len(calls)+3 {
+		t.Errorf("%d operations called, want %d: %v", len(called), len(calls)+3, called)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/tokens/missing/state":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"status":404,"code":"not_found","message":"Aucun état connu pour ce token"}}`)
+		case "/api/wallets/trusted":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"status":429,"code":"rate_limited","message":"Limite de requêtes atteinte"}}`)
+		default:
+			http.Error(w, "Alerte introuvable", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(Config{BaseURL: server.URL})
+	ctx := context.Background()
+
+	_, err := client.GetTokenState(ctx, "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "not_found" || !IsNotFound(err) {
+		t.Fatalf("GetTokenState error = %v", err)
+	}
+
+	_, err = client.GetTrustedWallets(ctx, 0)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != 7*time.Second {
+		t.Fatalf("GetTrustedWallets error = %#v", err)
+	}
+
+	// Les routes qui répondent en texte gardent leur message
+	_, err = client.GetAlert(ctx, "a1")
+	if !errors.As(err, &apiErr) || apiErr.Code != "" || apiErr.Message != "Alerte introuvable" || !IsNotFound(err) {
+		t.Fatalf("GetAlert error = %#v", err)
+	}
+}
+
+func TestSubscribeResumesAfterLagging(t *testing.T) {
+	var mu sync.Mutex
+	var resumeIDs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("type") != "ALERT" || r.URL.Query().Get("min_severity") != "HIGH" {
+			t.Errorf("filter not sent: %s", r.URL.RawQuery)
+		}
+		mu.Lock()
+		resumeIDs = append(resumeIDs, r.Header.Get("Last-Event-ID"))
+		attempt := len(resumeIDs)
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "retry: 10\n\n: heartbeat\n\n")
+		if attempt == 1 {
+			fmt.Fprint(w, "id: 100-0\nevent: ALERT\ndata: {\"id\":\"100-0\",\"type\":\"ALERT\",\"severity\":\"HIGH\"}\n\n")
+			fmt.Fprint(w, "event: error\ndata: {\"error\":{\"status\":503,\"code\":\"lagging\",\"message\":\"slow\"}}\n\n")
+			return
+		}
+		fmt.Fprint(w, "id: 101-0\nevent: ALERT\ndata: {\"id\":\"101-0\",\"type\":\"ALERT\",\"severity\":\"CRITICAL\",\n")
+		fmt.Fprint(w, "data: \"payload\":{\"score\":91}}\n\n")
+	}))
+	defer server.Close()
+	client := NewClient(Config{BaseURL: server.URL})
+
+	stop := errors.New("stop")
+	var received []StreamEvent
+	err := client.Subscribe(context.Background(), StreamFilter{Types: []string{"ALERT"}, MinSeverity: "HIGH"}, "", func(event StreamEvent) error {
+		received = append(received, event)
+		if len(received) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("Subscribe error = %v", err)
+	}
+	if received[0].ID != "100-0" || received[1].ID != "101-0" || received[1].Payload["score"] != float64(91) {
+		t.Fatalf("received %+v", received)
+	}
+	if len(resumeIDs) != 2 || resumeIDs[0] != "" || resumeIDs[1] != "100-0" {
+		t.Fatalf("Last-Event-ID sent %q, want [\"\" \"100-0\"]", resumeIDs)
+	}
+}
+
+func TestSubscribeStopsOnRejectedKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"status":401,"code":"unauthorized","message":"Clé d'API invalide"}}`)
+	}))
+	defer server.Close()
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "bad"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := client.Subscribe(ctx, StreamFilter{}, "", func(StreamEvent) error { return nil })
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "unauthorized" {
+		t.Fatalf("Subscribe error = %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultRetry est le délai de reconnexion tant que le serveur n'en a pas suggéré
+const defaultRetry = 3 * time.Second
+
+// EventStream est une connexion Server-Sent Events au flux d'événements
+type EventStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	lastID string
+	retry  time.Duration
+}
+
+// OpenStream ouvre le flux d'événements filtré. Si lastEventID n'est pas vide, le serveur
+// rejoue d'abord les événements qui le suivent.
+func (c *Client) OpenStream(ctx context.Context, filter StreamFilter, lastEventID string) (*EventStream, error) {
+	query := url.Values{}
+	for _, token := range filter.Tokens {
+		query.Add("token", token)
+	}
+	for _, eventType := range filter.Types {
+		query.Add("type", eventType)
+	}
+	if filter.MinSeverity != "" {
+		query.Set("min_severity", filter.MinSeverity)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/stream/events", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("échec de l'ouverture du flux: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, readError(resp)
+	}
+
+	return &EventStream{
+		body:   resp.Body,
+		reader: bufio.NewReader(resp.Body),
+		lastID: lastEventID,
+		retry:  defaultRetry,
+	}, nil
+}
+
+// Next bloque jusqu'au prochain événement. Il retourne io.EOF quand le serveur ferme le
+// flux et une *APIError quand il le termine avec un événement error (client trop lent, arrêt).
+func (s *EventStream) Next() (*StreamEvent, error) {
+	var eventType, id string
+	var data strings.Builder
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" {
+				return nil, io.EOF
+			}
+			if err != io.EOF {
+				return nil, fmt.Errorf("échec de la lecture du flux: %w", err)
+			}
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if data.Len() == 0 {
+				eventType, id = "", ""
+				continue
+			}
+			if eventType == "error" {
+				return nil, streamError(data.String())
+			}
+			var event StreamEvent
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				return nil, fmt.Errorf("échec du décodage de l'événement: %w", err)
+			}
+			if id != "" {
+				s.lastID = id
+			}
+			return &event, nil
+		}
+
+		// Les lignes commençant par ':' sont des commentaires (heartbeats)
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			eventType = value
+		case "id":
+			id = value
+		case "data":
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// LastEventID retourne l'ID du dernier événement reçu, à passer à OpenStream pour reprendre
+func (s *EventStream) LastEventID() string {
+	return s.lastID
+}
+
+// Close ferme la connexion au flux
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// streamError décode l'erreur envoyée par le serveur avant de fermer le flux
+func streamError(data string) error {
+	var envelope struct {
+		Error struct {
+			Status  int    `json:"status"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(data), &envelope); err != nil {
+		return &APIError{StatusCode: http.StatusInternalServerError, Message: data}
+	}
+	return &APIError{
+		StatusCode: envelope.Error.Status,
+		Code:       envelope.Error.Code,
+		Message:    envelope.Error.Message,
+	}
+}
+
+// Subscribe reçoit les événements du flux et les passe à handle, en se reconnectant avec
+// le dernier ID reçu quand la connexion est coupée ou que le serveur demande une reprise.
+// Il s'arrête à l'annulation de ctx, quand handle retourne une erreur, ou sur une erreur
+// définitive (clé refusée, filtre invalide).
+func (c *Client) Subscribe(ctx context.Context, filter StreamFilter, lastEventID string, handle func(StreamEvent) error) error {
+	retry := defaultRetry
+	for {
+		s, err := c.OpenStream(ctx, filter, lastEventID)
+		if err == nil {
+			err = consume(s, handle)
+			lastEventID = s.LastEventID()
+			retry = s.retry
+			s.Close()
+			var handlerErr *handlerError
+			if errors.As(err, &handlerErr) {
+				return handlerErr.err
+			}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !retryable(err) {
+			return err
+		}
+
+		delay := retry
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			delay = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// handlerError distingue une erreur du handler d'une erreur du flux
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string { return e.err.Error() }
+
+// consume passe les événements du flux à handle jusqu'à sa fin
+func consume(s *EventStream, handle func(StreamEvent) error) error {
+	for {
+		event, err := s.Next()
+		if err != nil {
+			return err
+		}
+		if err := handle(*event); err != nil {
+			return &handlerError{err: err}
+		}
+	}
+}
+
+// retryable indique si une reconnexion peut réussir: coupure réseau, flux fermé,
+// client trop lent, limite de débit ou indisponibilité du serveur
+func retryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+}
+
+// GetStreamStats retourne l'état du flux côté serveur
+func (c *Client) GetStreamStats(ctx context.Context) (*StreamStats, error) {
+	var stats StreamStats
+	if err := c.do(ctx, http.MethodGet, "/api/stream/stats", nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
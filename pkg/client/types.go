@@ -0,0 +1,114 @@
+package client
+
+import (
+	"time"
+
+	"github.com/franky69420/crypto-oracle/pkg/models"
+)
+
+// TokenSummary est un token suivi, tel que listé par ListTokens
+type TokenSummary struct {
+	Address     string    `json:"address"`
+	Symbol      string    `json:"symbol"`
+	State       string    `json:"state"`
+	XScore      float64   `json:"xscore"`
+	Price       float64   `json:"price"`
+	MarketCap   float64   `json:"market_cap"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastEventAt time.Time `json:"last_event_at"`
+}
+
+// TokenList est une liste de tokens; Total compte les tokens avant la limite
+type TokenList struct {
+	Tokens []TokenSummary `json:"tokens"`
+	Count  int            `json:"count"`
+	Total  int            `json:"total"`
+}
+
+// TokenDetails contient les métadonnées d'un token et son état de cycle de vie
+type TokenDetails struct {
+	Token       *models.Token `json:"token"`
+	State       string        `json:"state"`
+	XScore      float64       `json:"xscore"`
+	FirstSeenAt *time.Time    `json:"first_seen_at,omitempty"`
+	LastEventAt *time.Time    `json:"last_event_at,omitempty"`
+}
+
+// XScore est un X-Score calculé à la demande, avec les poids appliqués
+type XScore struct {
+	XScore  *models.XScoreResult `json:"xscore"`
+	Weights map[string]float64   `json:"weights"`
+}
+
+// StateTransition est un changement d'état de cycle de vie d'un token
+type StateTransition struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+	EventID string    `json:"event_id"`
+	Offset  string    `json:"offset"`
+}
+
+// TokenState est l'état courant d'un token et l'historique de ses transitions
+type TokenState struct {
+	TokenAddress string            `json:"token_address"`
+	State        string            `json:"state"`
+	History      []StateTransition `json:"history"`
+}
+
+// WalletClass est le résultat d'une classification de wallet
+type WalletClass struct {
+	Detected bool    `json:"detected"`
+	Score    float64 `json:"score"`
+}
+
+// WalletClassification indique si un wallet se comporte en sniper et en smart money
+type WalletClassification struct {
+	WalletAddress string      `json:"wallet_address"`
+	Sniper        WalletClass `json:"sniper"`
+	SmartMoney    WalletClass `json:"smart_money"`
+}
+
+// AlertQuery filtre ListAlerts; les champs vides sont ignorés
+type AlertQuery struct {
+	Token     string
+	Type      string
+	Severity  string
+	Status    string // OPEN ou RESOLVED
+	From      time.Time
+	To        time.Time
+	Confirmed *bool
+	Cursor    string // NextCursor de la page précédente
+	Limit     int
+}
+
+// AlertPage est une page d'alertes, des plus récentes aux plus anciennes
+type AlertPage struct {
+	Alerts     []models.TokenAlert `json:"alerts"`
+	NextCursor string              `json:"next_cursor,omitempty"` // Vide sur la dernière page
+}
+
+// StreamFilter sélectionne les événements du flux; un champ vide accepte tout
+type StreamFilter struct {
+	Tokens      []string
+	Types       []string
+	MinSeverity string
+}
+
+// StreamEvent est un événement du pipeline reçu en direct. ID sert à reprendre le flux.
+type StreamEvent struct {
+	ID           string                 `json:"id"`
+	Type         string                 `json:"type"`
+	TokenAddress string                 `json:"token_address,omitempty"`
+	Severity     string                 `json:"severity"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Payload      map[string]interface{} `json:"payload"`
+}
+
+// StreamStats décrit l'état du flux côté serveur
+type StreamStats struct {
+	Stream      string `json:"stream"`
+	Offset      string `json:"offset"`
+	Subscribers int    `json:"subscribers"`
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+)
+
+// Scopes des clés d'API
+const (
+	ScopeReadTokens  = "read:tokens"
+	ScopeReadWallets = "read:wallets"
+	ScopeStream      = "stream"
+	ScopeAdmin       = "admin" // Donne aussi tous les autres scopes
+)
+
+// APIKeyScopes liste les scopes connus
+var APIKeyScopes = []string{ScopeReadTokens, ScopeReadWallets, ScopeStream, ScopeAdmin}
+
+// APIKey est une clé d'accès à l'API. Seule l'empreinte de la clé est conservée:
+// la clé complète n'est connue qu'à son émission.
+type APIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"` // Partie publique de la clé, pour la retrouver et l'identifier
+	Hash        string     `json:"-"`      // SHA-256 de la clé complète
+	Scopes      []string   `json:"scopes"`
+	RateLimit   int        `json:"rate_limit"` // Requêtes par minute, 0 = valeur par défaut
+	Burst       int        `json:"burst"`      // Requêtes consécutives tolérées, 0 = valeur par défaut
+	RotatedFrom string     `json:"rotated_from,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+}
+
+// HasScope indique si la clé donne le scope demandé; admin donne tous les scopes
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Active indique si la clé est utilisable à l'instant now
+func (k *APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil && !now.Before(*k.RevokedAt) {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// APIKeyUsage est une entrée du journal d'audit des clés d'API
+type APIKeyUsage struct {
+	KeyID      string    `json:"key_id,omitempty"` // Vide si la clé présentée est inconnue
+	Prefix     string    `json:"prefix,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	RemoteAddr string    `json:"remote_addr"`
+	Reason     string    `json:"reason,omitempty"` // Motif du refus
+	At         time.Time `json:"at"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+)
+
+// Périodes des digests
+const (
+	DigestPeriodHourly = "hourly"
+	DigestPeriodDaily  = "daily"
+)
+
+// Digest est un rapport périodique sur les tokens et les alertes, rendu en Markdown et en HTML
+type Digest struct {
+	ID          string        `json:"id"` // "<période>-<début>", par exemple "daily-20240101T0000Z"
+	Period      string        `json:"period"`
+	Title       string        `json:"title"`
+	From        time.Time     `json:"from"` // Inclus
+	To          time.Time     `json:"to"`   // Exclu
+	Content     DigestContent `json:"content"`
+	Markdown    string        `json:"markdown"`
+	HTML        string        `json:"html"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// DigestContent regroupe les sections d'un digest
+type DigestContent struct {
+	NewTokens      []DigestTokenGroup `json:"new_tokens"` // Tokens entrés dans un état pendant la période
+	Movers         []DigestMover      `json:"movers"`     // Plus fortes variations du X-Score
+	Outcomes       []AlertOutcome     `json:"outcomes"`   // Suivis d'alertes décidés pendant la période
+	OutcomeStats   []AlertTypeStats   `json:"outcome_stats"`
+	Reactivations  []DigestToken      `json:"reactivations"`
+	TrustedWallets []DigestWallet     `json:"trusted_wallets"` // Wallets de confiance actifs pendant la période
+	Rugs           []DigestAlert      `json:"rugs"`
+}
+
+// DigestTokenGroup liste les tokens entrés dans un état
+type DigestTokenGroup struct {
+	State  string        `json:"state"`
+	Tokens []DigestToken `json:"tokens"`
+}
+
+// DigestToken est un token mentionné dans un digest
+type DigestToken struct {
+	Address string    `json:"address"`
+	Symbol  string    `json:"symbol"`
+	State   string    `json:"state"`
+	XScore  float64   `json:"xscore"` // Dernier X-Score de la période, 0 si inconnu
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// DigestMover est la variation du X-Score d'un token sur la période
+type DigestMover struct {
+	Address string  `json:"address"`
+	Symbol  string  `json:"symbol"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Change  float64 `json:"change"` // End - Start, en points
+}
+
+// DigestWallet est un wallet de confiance actif pendant la période
+type DigestWallet struct {
+	Address      string    `json:"address"`
+	TrustScore   float64   `json:"trust_score"`
+	Tokens       []string  `json:"tokens"` // Symboles des tokens sur lesquels il a été actif
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// DigestAlert est une alerte mentionnée dans un digest
+type DigestAlert struct {
+	ID         string    `json:"id"`
+	Address    string    `json:"address"`
+	Symbol     string    `json:"symbol"`
+	AlertType  string    `json:"alert_type"`
+	Severity   string    `json:"severity"`
+	Message    string    `json:"message"`
+	DetectedAt time.Time `json:"detected_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+)
+
+// Types de jobs de maintenance du Memory of Trust
+const (
+	JobRebuildTrustGraph  = "rebuild_trust_graph"
+	JobUpdateSimilarities = "update_wallet_similarities"
+	JobCleanCaches        = "clean_obsolete_caches"
+	JobPurgeWallet        = "purge_wallet"              // Target: adresse du wallet
+	JobResetTokenMetrics  = "reset_token_trust_metrics" // Target: adresse du token
+)
+
+// MaintenanceJobKinds liste les types de jobs connus
+var MaintenanceJobKinds = []string{
+	JobRebuildTrustGraph,
+	JobUpdateSimilarities,
+	JobCleanCaches,
+	JobPurgeWallet,
+	JobResetTokenMetrics,
+}
+
+// Statuts d'un job de maintenance
+const (
+	JobQueued     = "queued"
+	JobRunning    = "running"
+	JobCancelling = "cancelling" // Annulation demandée, en attente de l'arrêt de l'opération
+	JobSucceeded  = "succeeded"
+	JobFailed     = "failed"
+	JobCancelled  = "cancelled"
+)
+
+// MaintenanceJob est une opération de maintenance lancée en tâche de fond par l'API
+type MaintenanceJob struct {
+	ID          string                 `json:"id"`
+	Kind        string                 `json:"kind"`
+	Target      string                 `json:"target,omitempty"`
+	Status      string                 `json:"status"`
+	Done        int                    `json:"done"`
+	Total       int                    `json:"total"`    // 0 tant que l'opération n'a pas rapporté d'avancement
+	Progress    float64                `json:"progress"` // De 0 à 1
+	Error       string                 `json:"error,omitempty"`
+	RequestedBy string                 `json:"requested_by,omitempty"` // ID de la clé d'API
+	CreatedAt   time.Time              `json:"created_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	FinishedAt  *time.Time             `json:"finished_at,omitempty"`
+	History     []MaintenanceJobStatus `json:"history"`
+}
+
+// MaintenanceJobStatus est un changement de statut d'un job de maintenance
+type MaintenanceJobStatus struct {
+	Status  string    `json:"status"`
+	Message string    `json:"message,omitempty"`
+	At      time.Time `json:"at"`
+}
+
+// Finished indique si le job est terminé, quelle qu'en soit l'issue
+func (j *MaintenanceJob) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
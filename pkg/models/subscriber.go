@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+)
+
+// Subscriber est un destinataire d'alertes avec ses propres filtres et canaux.
+// Un filtre vide accepte tout.
+type Subscriber struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Enabled     bool                `json:"enabled"`
+	MinXScore   float64             `json:"min_xscore"`             // Ignoré pour les alertes sans X-Score
+	MinSeverity string              `json:"min_severity,omitempty"` // Sévérité minimale, par exemple "URGENT"
+	AlertTypes  []string            `json:"alert_types,omitempty"`
+	States      []string            `json:"states,omitempty"`    // États du cycle de vie du token
+	Watchlist   []string            `json:"watchlist,omitempty"` // Adresses ou symboles de tokens
+	QuietHours  *QuietHours         `json:"quiet_hours,omitempty"`
+	Channels    []SubscriberChannel `json:"channels"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// QuietHours est une plage horaire quotidienne sans notification
+type QuietHours struct {
+	Start         string `json:"start"`              // "22:00"
+	End           string `json:"end"`                // "07:00", peut être le lendemain
+	Timezone      string `json:"timezone,omitempty"` // Nom IANA, UTC par défaut
+	AllowCritical bool   `json:"allow_critical"`     // Les alertes CRITICAL passent quand même
+}
+
+// SubscriberChannel est un canal de notification propre à un abonné
+type SubscriberChannel struct {
+	Name    string            `json:"name"`
+	Type    string            `json:"type"` // "telegram", "discord", "slack", "webhook"
+	Token   string            `json:"token,omitempty"`
+	ChatID  string            `json:"chat_id,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
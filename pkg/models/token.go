@@ -15,6 +15,27 @@ const (
 	LifecycleStateReactivated     = "REACTIVATED"
 )
 
+// LifecycleStates liste tous les états du cycle de vie connus
+var LifecycleStates = []string{
+	LifecycleStateDiscovered,
+	LifecycleStateValidated,
+	LifecycleStateHyped,
+	LifecycleStateCompleted,
+	LifecycleStateSleepMode,
+	LifecycleStateMonitoringLight,
+	LifecycleStateReactivated,
+}
+
+// IsLifecycleState indique si state est un état du cycle de vie connu
+func IsLifecycleState(state string) bool {
+	for _, known := range LifecycleStates {
+		if known == state {
+			return true
+		}
+	}
+	return false
+}
+
 // Token représente un token avec ses métadonnées
 type Token struct {
 	Address            string    `json:"address"`
@@ -96,6 +117,96 @@ type TokenAlert struct {
 	ConfirmationCount int     `json:"confirmation_count"`
 	IsConfirmed      bool     `json:"is_confirmed"`
 	RelatedWallets  []string  `json:"related_wallets,omitempty"`
+	XScore          float64   `json:"xscore,omitempty"`      // X-Score du token au déclenchement, 0 si inconnu
+	TokenState      string    `json:"token_state,omitempty"` // État du cycle de vie du token au déclenchement
+	// Déduplication: une seule alerte ouverte par (token, type d'alerte)
+	DedupKey        string             `json:"dedup_key,omitempty"`
+	Status          string             `json:"status"` // "OPEN" ou "RESOLVED"
+	DriverValue     *float64           `json:"driver_value,omitempty"` // Grandeur qui a déclenché l'alerte, plus grande = plus grave
+	OccurrenceCount int                `json:"occurrence_count"`
+	LastSeenAt      time.Time          `json:"last_seen_at"`
+	ResolvedAt      *time.Time         `json:"resolved_at,omitempty"`
+	History         []AlertStateChange `json:"history,omitempty"`
+	Outcome         *AlertOutcome      `json:"outcome,omitempty"`
+}
+
+// AlertOutcome est le suivi du marché après une alerte: un rendement positif confirme
+// une alerte haussière, une baisse confirme une alerte baissière (dump)
+type AlertOutcome struct {
+	AlertID          string     `json:"alert_id"`
+	TokenAddress     string     `json:"token_address"`
+	AlertType        string     `json:"alert_type"`
+	Severity         string     `json:"severity"`
+	Bearish          bool       `json:"bearish"` // L'alerte annonce une baisse
+	DetectedAt       time.Time  `json:"detected_at"`
+	PriceAtAlert     float64    `json:"price_at_alert"`
+	MarketCapAtAlert float64    `json:"market_cap_at_alert"`
+	LastPrice        float64    `json:"last_price"`
+	LastMarketCap    float64    `json:"last_market_cap"`
+	MaxGain          float64    `json:"max_gain"`     // Plus forte hausse depuis l'alerte (0.5 = +50%)
+	MaxDrawdown      float64    `json:"max_drawdown"` // Plus forte baisse depuis l'alerte (-0.3 = -30%)
+	Return1h         *float64   `json:"return_1h,omitempty"`
+	Return6h         *float64   `json:"return_6h,omitempty"`
+	Return24h        *float64   `json:"return_24h,omitempty"`
+	Status           string     `json:"status"` // "TRACKING", "CONFIRMED", "FAILED", "EXPIRED"
+	Reason           string     `json:"reason,omitempty"`
+	DecidedAt        *time.Time `json:"decided_at,omitempty"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// AlertTypeStats agrège les résultats des alertes d'un type
+type AlertTypeStats struct {
+	AlertType      string   `json:"alert_type"`
+	Total          int      `json:"total"`
+	Tracking       int      `json:"tracking"`
+	Confirmed      int      `json:"confirmed"`
+	Failed         int      `json:"failed"`
+	Expired        int      `json:"expired"`
+	HitRate        float64  `json:"hit_rate"` // Confirmées / décidées (hors expirées)
+	AvgMaxGain     float64  `json:"avg_max_gain"`
+	AvgMaxDrawdown float64  `json:"avg_max_drawdown"`
+	AvgReturn1h    *float64 `json:"avg_return_1h,omitempty"`
+	AvgReturn6h    *float64 `json:"avg_return_6h,omitempty"`
+	AvgReturn24h   *float64 `json:"avg_return_24h,omitempty"`
+}
+
+// AlertStateChange est une étape de la vie d'une alerte: ouverture, escalade, résolution
+type AlertStateChange struct {
+	AlertID     string    `json:"alert_id"`
+	Transition  string    `json:"transition"` // "OPENED", "ESCALATED", "RESOLVED"
+	Status      string    `json:"status"`
+	Severity    string    `json:"severity"`
+	DriverValue *float64  `json:"driver_value,omitempty"`
+	Reason      string    `json:"reason"`
+	ChangedAt   time.Time `json:"changed_at"`
+}
+
+// AlertFilter sélectionne des alertes, de la plus récente à la plus ancienne.
+// Les champs vides ne filtrent pas.
+type AlertFilter struct {
+	TokenAddress string
+	AlertType    string
+	Severity     string
+	From         time.Time // Détectées à partir de cette date (incluse)
+	To           time.Time // Détectées avant cette date (exclue)
+	Confirmed    *bool
+	Status       string // "OPEN" ou "RESOLVED"
+	// Position de pagination: alertes strictement antérieures à (BeforeTime, BeforeID)
+	BeforeTime time.Time
+	BeforeID   string
+	Limit      int
+}
+
+// AlertRule est une règle d'alerte définie par l'utilisateur: une alerte est levée
+// quand l'expression est vraie pour un token
+type AlertRule struct {
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Severity   string    `json:"severity"`
+	AlertType  string    `json:"alert_type"`
+	Message    string    `json:"message"` // Modèle text/template du message
+	Enabled    bool      `json:"enabled"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // TokenHistoricalMetrics représente des métriques historiques pour un token
@@ -202,6 +313,9 @@ type XScoreResult struct {
 	XScore       float64            `json:"x_score"`
 	BaseScore    float64            `json:"base_score"`
 	Components   map[string]float64 `json:"components"`
+	Factors      map[string]float64 `json:"factors"` // Valeurs brutes (0-100) avant pondération
+	Price        float64            `json:"price"`
+	MarketCap    float64            `json:"market_cap"`
 	AntiDump     *AntiDumpResult    `json:"anti_dump"`
 	CalculatedAt time.Time          `json:"calculated_at"`
 }
@@ -244,4 +358,49 @@ type ReactivationCandidate struct {
 	SmartReturns      *SmartWalletReturns   `json:"smart_returns"`
 	CurrentMetrics    *TokenMetrics         `json:"current_metrics"`
 	DetectedAt        time.Time             `json:"detected_at"`
-}
\ No newline at end of file
+}
+// Values retourne les métriques numériques du token, indexées par leur nom JSON
+func (m *TokenMetrics) Values() map[string]float64 {
+	return map[string]float64{
+		"holder_count":        float64(m.HolderCount),
+		"intelligent_holders": float64(m.IntelligentHolders),
+		"average_hold_time":   m.AverageHoldTime,
+		"creator_trust_score": m.CreatorTrustScore,
+		"dev_trust_score":     m.DevTrustScore,
+		"smart_money_holders": float64(m.SmartMoneyHolders),
+		"average_trust_score": m.AverageTrustScore,
+		"risk_factor":         m.RiskFactor,
+		"volume_1h":           m.Volume1h,
+		"volume_24h":          m.Volume24h,
+		"price":               m.Price,
+		"market_cap":          m.MarketCap,
+		"price_change_1h":     m.PriceChange1h,
+		"buy_count_1h":        float64(m.BuyCount1h),
+		"sell_count_1h":       float64(m.SellCount1h),
+	}
+}
+
+// Values retourne les ratios et compteurs de l'analyse des wallets, indexés par leur nom JSON
+func (a *WalletAnalysis) Values() map[string]float64 {
+	return map[string]float64{
+		"total_wallets":        float64(a.TotalWallets),
+		"smart":                float64(a.WalletCategories.Smart),
+		"trusted":              float64(a.WalletCategories.Trusted),
+		"fresh":                float64(a.WalletCategories.Fresh),
+		"bot":                  float64(a.WalletCategories.Bot),
+		"sniper":               float64(a.WalletCategories.Sniper),
+		"bluechip":             float64(a.WalletCategories.Bluechip),
+		"bundler":              float64(a.WalletCategories.Bundler),
+		"avg_trust_score":      a.TrustMetrics.AvgTrustScore,
+		"smart_money_ratio":    a.TrustMetrics.SmartMoneyRatio,
+		"smart_money_count":    float64(a.TrustMetrics.SmartMoneyCount),
+		"early_trusted_ratio":  a.TrustMetrics.EarlyTrustedRatio,
+		"smart_money_activity": a.TrustMetrics.SmartMoneyActivity,
+		"buy_orders":           float64(a.TradePatterns.BuyOrders),
+		"sell_orders":          float64(a.TradePatterns.SellOrders),
+		"buy_sell_ratio":       a.TradePatterns.BuySellRatio,
+		"avg_hold_time":        a.TradePatterns.AvgHoldTime,
+		"sniper_count":         float64(a.SniperCount),
+		"sniper_ratio":         a.SniperRatio,
+	}
+}
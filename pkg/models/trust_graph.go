@@ -0,0 +1,42 @@
+package models
+
+// Types de nœuds du graphe de confiance
+const (
+	GraphNodeWallet = "wallet"
+	GraphNodeToken  = "token"
+)
+
+// GraphNode est un wallet ou un token du graphe de confiance. Son ID est préfixé par
+// son type ("wallet:<adresse>", "token:<adresse>").
+type GraphNode struct {
+	ID         string  `json:"id"`
+	Kind       string  `json:"kind"`
+	Address    string  `json:"address"`
+	TrustScore float64 `json:"trust_score,omitempty"` // Wallets uniquement
+	Degree     int     `json:"degree"`                // Nombre de voisins dans le graphe complet
+	Depth      int     `json:"depth"`                 // Distance au nœud de départ
+}
+
+// GraphEdge relie un wallet à un token qu'il a tradé
+type GraphEdge struct {
+	Source       string `json:"source"` // ID du wallet
+	Target       string `json:"target"` // ID du token
+	Interactions int    `json:"interactions"`
+}
+
+// TrustSubgraph est une partie du graphe de confiance: tous les liens entre ses nœuds sont inclus
+type TrustSubgraph struct {
+	Root      string      `json:"root"`
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Truncated bool        `json:"truncated"` // Vrai si la limite de nœuds a été atteinte
+}
+
+// TrustPath est un plus court chemin entre deux wallets, alternant wallets et tokens partagés
+type TrustPath struct {
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Hops  int         `json:"hops"` // Nombre de tokens partagés traversés
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
@@ -14,6 +14,9 @@ type Config struct {
 	Database  *DatabaseConfig `mapstructure:"database"`
 	Redis     *RedisConfig    `mapstructure:"redis"`
 	GMGN      *GMGNConfig     `mapstructure:"gmgn"`
+	XScore    *XScoreConfig   `mapstructure:"xscore"`
+	Pipeline  *PipelineConfig `mapstructure:"pipeline"`
+	Alerts    *AlertsConfig   `mapstructure:"alerts"`
 }
 
 // APIConfig contient la configuration du serveur API
@@ -23,6 +26,31 @@ type APIConfig struct {
 	ReadTimeout    int    `mapstructure:"read_timeout"`
 	WriteTimeout   int    `mapstructure:"write_timeout"`
 	MaxHeaderBytes int    `mapstructure:"max_header_bytes"`
+
+	AllowedOrigins []string `mapstructure:"allowed_origins"` // Origines CORS autorisées, toutes si vide
+
+	Stream *StreamConfig `mapstructure:"stream"` // Flux d'événements en direct (WebSocket et SSE)
+	Auth   *AuthConfig   `mapstructure:"auth"`   // Authentification par clé d'API
+}
+
+// AuthConfig décrit l'authentification par clé d'API, la limitation de débit et l'audit
+type AuthConfig struct {
+	Enabled               bool   `mapstructure:"enabled"`                 // Exiger une clé sur toutes les routes sauf /api/health
+	BootstrapKey          string `mapstructure:"bootstrap_key"`           // Clé admin hors base pour émettre les premières clés, au moins 32 caractères
+	DefaultRateLimit      int    `mapstructure:"default_rate_limit"`      // Requêtes par minute des clés sans limite propre
+	DefaultBurst          int    `mapstructure:"default_burst"`           // Requêtes consécutives tolérées des clés sans limite propre
+	ReloadIntervalSeconds int    `mapstructure:"reload_interval_seconds"` // Relecture des clés en base (révocations des autres instances)
+	AuditBufferSize       int    `mapstructure:"audit_buffer_size"`       // Entrées d'audit en attente avant abandon
+	AuditFlushMs          int    `mapstructure:"audit_flush_ms"`          // Intervalle d'écriture du journal d'audit
+}
+
+// StreamConfig décrit la diffusion en direct des événements du pipeline
+type StreamConfig struct {
+	Stream           string `mapstructure:"stream"`            // Stream logique diffusé, token_events par défaut
+	PollIntervalMs   int    `mapstructure:"poll_interval_ms"`  // Intervalle de lecture du stream
+	BatchSize        int    `mapstructure:"batch_size"`        // Messages lus par requête
+	BufferSize       int    `mapstructure:"buffer_size"`       // Événements en attente par client avant déconnexion
+	HeartbeatSeconds int    `mapstructure:"heartbeat_seconds"` // Intervalle des messages de maintien de connexion
 }
 
 // DatabaseConfig contient la configuration de la base de données
@@ -64,6 +92,109 @@ type GMGNConfig struct {
 	RateLimitDelay int    `mapstructure:"rate_limit_delay"`
 }
 
+// XScoreConfig contient la configuration du calcul du X-Score
+type XScoreConfig struct {
+	WeightsFile   string `mapstructure:"weights_file"`   // Poids candidats produits par la commande calibrate
+	RecordHistory bool   `mapstructure:"record_history"` // Historiser chaque X-Score pour la calibration
+}
+
+// PipelineConfig contient la configuration du pipeline de traitement
+type PipelineConfig struct {
+	Workers           int    `mapstructure:"workers"`
+	BatchSize         int    `mapstructure:"batch_size"`
+	Interval          int    `mapstructure:"interval"`
+	MaxAttempts       int    `mapstructure:"max_attempts"`        // Livraisons avant passage en dead-letter
+	RetryBaseDelayMs  int    `mapstructure:"retry_base_delay_ms"` // Premier délai de reprise
+	RetryMaxDelayMs   int    `mapstructure:"retry_max_delay_ms"`  // Délai de reprise maximal
+	ReclaimIntervalMs int    `mapstructure:"reclaim_interval_ms"` // Fréquence d'inspection des messages en attente
+	ConsumerID        string `mapstructure:"consumer_id"`         // Identité de l'instance, générée si vide
+	Partitions        int    `mapstructure:"partitions"`          // Partitions par stream (clé: token_address)
+	LeaseTTLMs        int    `mapstructure:"lease_ttl_ms"`        // Durée de détention d'une partition sans renouvellement
+	ShutdownTimeoutMs int    `mapstructure:"shutdown_timeout_ms"` // Attente maximale des messages en cours à l'arrêt
+	IdempotencyTTLMs  int    `mapstructure:"idempotency_ttl_ms"`  // Rétention des clés des messages déjà traités
+
+	CoalesceWindowMs   int      `mapstructure:"coalesce_window_ms"`   // Silence avant publication d'un événement fusionné, 0 = pas de fusion
+	CoalesceMaxWaitMs  int      `mapstructure:"coalesce_max_wait_ms"` // Retenue maximale d'un événement fusionné
+	CoalesceEventTypes []string `mapstructure:"coalesce_event_types"` // Types d'événements fusionnés par token
+	ScheduleIntervalMs int      `mapstructure:"schedule_interval_ms"` // Fréquence de publication des messages différés
+
+	RetentionIntervalMs int                        `mapstructure:"retention_interval_ms"` // Fréquence d'élagage des streams
+	Retention           map[string]RetentionConfig `mapstructure:"retention"`             // Politiques de rétention par stream
+}
+
+// RetentionConfig borne la taille d'un stream; les streams absents sont conservés intégralement
+type RetentionConfig struct {
+	MaxLen      int64 `mapstructure:"max_len"`       // Messages conservés par partition, 0 = sans limite
+	MaxAgeHours int   `mapstructure:"max_age_hours"` // Âge maximal des messages, 0 = sans limite
+}
+
+
+
+// AlertsConfig contient la configuration des alertes
+type AlertsConfig struct {
+	RetentionDays       int `mapstructure:"retention_days"`        // Conservation des alertes en base, 0 = sans limite
+	RetentionIntervalMs int `mapstructure:"retention_interval_ms"` // Fréquence de suppression des alertes expirées
+
+	Channels                 []AlertChannelConfig `mapstructure:"channels"`
+	DeliveryWorkers          int                  `mapstructure:"delivery_workers"`             // Envois simultanés
+	DeliveryMaxAttempts      int                  `mapstructure:"delivery_max_attempts"`        // Tentatives par envoi
+	DeliveryRetryBaseDelayMs int                  `mapstructure:"delivery_retry_base_delay_ms"` // Délai avant la deuxième tentative, doublé ensuite
+	DeliveryTimeoutMs        int                  `mapstructure:"delivery_timeout_ms"`          // Durée maximale d'une tentative
+
+	Rules []AlertRuleConfig `mapstructure:"rules"` // Remplacent les règles par défaut si non vide
+
+	CooldownMs     int            `mapstructure:"cooldown_ms"`     // Délai après résolution avant une nouvelle alerte de même (token, type)
+	Cooldowns      map[string]int `mapstructure:"cooldowns"`       // Délais en ms par type d'alerte
+	EscalationStep float64        `mapstructure:"escalation_step"` // Aggravation relative qui fait monter une alerte d'un niveau
+	ResolveAfter   int            `mapstructure:"resolve_after"`   // Évaluations négatives consécutives avant résolution
+	StaleAfterMs   int            `mapstructure:"stale_after_ms"`  // Résolution des alertes non observées depuis ce délai
+
+	OutcomeConfirmMove  float64  `mapstructure:"outcome_confirm_move"`  // Mouvement dans le sens de l'alerte qui la confirme (0.2 = 20%)
+	OutcomeFailMove     float64  `mapstructure:"outcome_fail_move"`     // Mouvement contraire qui la fait échouer
+	OutcomeWindowMs     int      `mapstructure:"outcome_window_ms"`     // Durée du suivi après l'alerte
+	OutcomeBearishTypes []string `mapstructure:"outcome_bearish_types"` // Types d'alertes qui annoncent une baisse
+
+	Digests *DigestsConfig `mapstructure:"digests"` // Rapports périodiques envoyés sur les canaux
+}
+
+// DigestsConfig décrit les rapports périodiques (digests) des tokens et des alertes
+type DigestsConfig struct {
+	Periods           []string `mapstructure:"periods"`            // "hourly", "daily"; vide = aucun digest
+	Timezone          string   `mapstructure:"timezone"`           // Fuseau des journées des digests quotidiens, UTC par défaut
+	Channels          []string `mapstructure:"channels"`           // Noms des canaux destinataires, vide = tous
+	States            []string `mapstructure:"states"`             // États dont les nouveaux tokens sont listés
+	TopMovers         int      `mapstructure:"top_movers"`         // Variations du X-Score listées
+	TopWallets        int      `mapstructure:"top_wallets"`        // Wallets de confiance listés
+	MinWalletTrust    float64  `mapstructure:"min_wallet_trust"`   // Score de confiance minimal des wallets listés
+	MaxItems          int      `mapstructure:"max_items"`          // Éléments par section
+	ReactivationTypes []string `mapstructure:"reactivation_types"` // Types d'alertes de réactivation
+	RugTypes          []string `mapstructure:"rug_types"`          // Types d'alertes de rug
+	MarkdownTemplate  string   `mapstructure:"markdown_template"`  // Fichier text/template, modèle intégré si vide
+	HTMLTemplate      string   `mapstructure:"html_template"`      // Fichier html/template, modèle intégré si vide
+}
+
+// AlertRuleConfig décrit une règle d'alerte: une alerte est levée quand l'expression est vraie
+type AlertRuleConfig struct {
+	Name       string `mapstructure:"name"`
+	Expression string `mapstructure:"expression"`
+	Severity   string `mapstructure:"severity"`
+	AlertType  string `mapstructure:"alert_type"` // Par défaut, le nom de la règle en majuscules
+	Message    string `mapstructure:"message"`    // Modèle text/template
+	Enabled    *bool  `mapstructure:"enabled"`    // Active si absent
+}
+
+// AlertChannelConfig décrit un canal de notification et les alertes qu'il reçoit
+type AlertChannelConfig struct {
+	Name       string            `mapstructure:"name"`
+	Type       string            `mapstructure:"type"` // telegram, discord, slack, webhook ou console
+	Enabled    bool              `mapstructure:"enabled"`
+	Token      string            `mapstructure:"token"`       // Telegram: jeton du bot
+	ChatID     string            `mapstructure:"chat_id"`     // Telegram: chat destinataire
+	URL        string            `mapstructure:"url"`         // URL du webhook (ou de l'API Bot Telegram)
+	Headers    map[string]string `mapstructure:"headers"`     // En-têtes ajoutés aux requêtes du webhook
+	Severities []string          `mapstructure:"severities"`  // Sévérités acceptées, vide = toutes
+	AlertTypes []string          `mapstructure:"alert_types"` // Types d'alertes acceptés, vide = tous
+}
 // Load charge la configuration à partir d'un fichier
 func Load() (*Config, error) {
 	// Régler les valeurs par défaut
@@ -158,4 +289,43 @@ func setDefaults() {
 	viper.SetDefault("gmgn.from_app", "gmgn")
 	viper.SetDefault("gmgn.request_timeout", 30)
 	viper.SetDefault("gmgn.rate_limit_delay", 300) // 300ms entre les requêtes
+
+	// Valeurs par défaut pour le pipeline
+	viper.SetDefault("pipeline.workers", 5)
+	viper.SetDefault("pipeline.batch_size", 100)
+	viper.SetDefault("pipeline.interval", 60)
+	viper.SetDefault("pipeline.max_attempts", 5)
+	viper.SetDefault("pipeline.retry_base_delay_ms", 5000)
+	viper.SetDefault("pipeline.retry_max_delay_ms", 300000)
+	viper.SetDefault("pipeline.reclaim_interval_ms", 5000)
+	viper.SetDefault("pipeline.consumer_id", "")
+	viper.SetDefault("pipeline.partitions", 1)
+	viper.SetDefault("pipeline.lease_ttl_ms", 15000)
+	viper.SetDefault("pipeline.shutdown_timeout_ms", 10000)
+	viper.SetDefault("pipeline.idempotency_ttl_ms", 86400000)
+	viper.SetDefault("pipeline.coalesce_window_ms", 60000)
+	viper.SetDefault("pipeline.coalesce_max_wait_ms", 300000)
+	viper.SetDefault("pipeline.coalesce_event_types", []string{"price_change", "volume_spike"})
+	viper.SetDefault("pipeline.schedule_interval_ms", 1000)
+	viper.SetDefault("pipeline.retention_interval_ms", 300000)
+
+	// Valeurs par défaut pour les alertes
+	viper.SetDefault("alerts.retention_days", 90)
+	viper.SetDefault("alerts.retention_interval_ms", 3600000)
+	viper.SetDefault("alerts.delivery_workers", 4)
+	viper.SetDefault("alerts.delivery_max_attempts", 5)
+	viper.SetDefault("alerts.delivery_retry_base_delay_ms", 2000)
+	viper.SetDefault("alerts.delivery_timeout_ms", 10000)
+	viper.SetDefault("alerts.cooldown_ms", 1800000)
+	viper.SetDefault("alerts.escalation_step", 0.1)
+	viper.SetDefault("alerts.resolve_after", 2)
+	viper.SetDefault("alerts.stale_after_ms", 21600000)
+	viper.SetDefault("alerts.outcome_confirm_move", 0.2)
+	viper.SetDefault("alerts.outcome_fail_move", 0.3)
+	viper.SetDefault("alerts.outcome_window_ms", 86400000)
+	viper.SetDefault("alerts.outcome_bearish_types", []string{"DUMP_DETECTED"})
+
+	// Valeurs par défaut pour le X-Score
+	viper.SetDefault("xscore.weights_file", "")
+	viper.SetDefault("xscore.record_history", true)
 } 
\ No newline at end of file
@@ -70,7 +70,147 @@ CREATE TABLE IF NOT EXISTS token_alerts (
     message TEXT NOT NULL,
     detected_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
     confirmation_count INTEGER DEFAULT 0,
-    is_confirmed BOOLEAN DEFAULT FALSE
+    is_confirmed BOOLEAN DEFAULT FALSE,
+    related_wallets TEXT[] DEFAULT '{}',
+    dedup_key VARCHAR(320),
+    status VARCHAR(20) DEFAULT 'OPEN',
+    driver_value DOUBLE PRECISION,
+    occurrence_count INTEGER DEFAULT 1,
+    last_seen_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    xscore DOUBLE PRECISION DEFAULT 0,
+    token_state VARCHAR(50) DEFAULT ''
+);
+
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS related_wallets TEXT[] DEFAULT '{}';
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS dedup_key VARCHAR(320);
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS status VARCHAR(20) DEFAULT 'OPEN';
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS driver_value DOUBLE PRECISION;
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS occurrence_count INTEGER DEFAULT 1;
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS xscore DOUBLE PRECISION DEFAULT 0;
+ALTER TABLE token_alerts ADD COLUMN IF NOT EXISTS token_state VARCHAR(50) DEFAULT '';
+
+-- Historique des états des alertes (ouverture, escalades, résolution)
+CREATE TABLE IF NOT EXISTS token_alert_history (
+    id BIGSERIAL PRIMARY KEY,
+    alert_id VARCHAR(255) REFERENCES token_alerts(id) ON DELETE CASCADE,
+    transition VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    driver_value DOUBLE PRECISION,
+    reason TEXT NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Suivi du marché après chaque alerte
+CREATE TABLE IF NOT EXISTS alert_outcomes (
+    alert_id VARCHAR(255) PRIMARY KEY REFERENCES token_alerts(id) ON DELETE CASCADE,
+    token_address VARCHAR(255) NOT NULL,
+    alert_type VARCHAR(50) NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    bearish BOOLEAN DEFAULT FALSE,
+    detected_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    price_at_alert DOUBLE PRECISION DEFAULT 0,
+    market_cap_at_alert DOUBLE PRECISION DEFAULT 0,
+    last_price DOUBLE PRECISION DEFAULT 0,
+    last_market_cap DOUBLE PRECISION DEFAULT 0,
+    max_gain DOUBLE PRECISION DEFAULT 0,
+    max_drawdown DOUBLE PRECISION DEFAULT 0,
+    return_1h DOUBLE PRECISION,
+    return_6h DOUBLE PRECISION,
+    return_24h DOUBLE PRECISION,
+    status VARCHAR(20) NOT NULL,
+    reason TEXT DEFAULT '',
+    decided_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Digests horaires et quotidiens des tokens et des alertes, avec leurs rendus
+CREATE TABLE IF NOT EXISTS digests (
+    id VARCHAR(100) PRIMARY KEY,
+    period VARCHAR(20) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    period_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    content JSONB NOT NULL,
+    markdown TEXT NOT NULL,
+    html TEXT NOT NULL,
+    generated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Abonnés aux alertes: filtres et canaux propres à chaque destinataire
+CREATE TABLE IF NOT EXISTS alert_subscribers (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    min_xscore DOUBLE PRECISION DEFAULT 0,
+    min_severity VARCHAR(20) DEFAULT '',
+    alert_types TEXT[] DEFAULT '{}',
+    states TEXT[] DEFAULT '{}',
+    watchlist TEXT[] DEFAULT '{}',
+    quiet_hours JSONB,
+    channels JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Table des règles d'alerte créées par l'API
+CREATE TABLE IF NOT EXISTS alert_rules (
+    name VARCHAR(100) PRIMARY KEY,
+    expression TEXT NOT NULL,
+    severity VARCHAR(20) NOT NULL,
+    alert_type VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Clés d'API: seule l'empreinte SHA-256 de la clé est conservée
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash CHAR(64) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    rate_limit INTEGER DEFAULT 0,
+    burst INTEGER DEFAULT 0,
+    rotated_from VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Journal d'audit de l'utilisation des clés d'API
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    id BIGSERIAL PRIMARY KEY,
+    key_id VARCHAR(100),
+    prefix VARCHAR(32),
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    remote_addr VARCHAR(100),
+    reason TEXT,
+    used_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Jobs de maintenance du Memory of Trust lancés par l'API, avec l'historique de leurs statuts
+CREATE TABLE IF NOT EXISTS maintenance_jobs (
+    id VARCHAR(100) PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    target VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    done INTEGER DEFAULT 0,
+    total INTEGER DEFAULT 0,
+    progress DOUBLE PRECISION DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    requested_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+    history JSONB NOT NULL DEFAULT '[]'
 );
 
 -- Table des métriques historiques des tokens
@@ -87,6 +227,19 @@ CREATE TABLE IF NOT EXISTS token_historical_metrics (
     PRIMARY KEY (token_address, date)
 );
 
+-- Table de l'historique des X-Scores (utilisée par la calibration hors ligne)
+CREATE TABLE IF NOT EXISTS token_xscore_history (
+    id BIGSERIAL PRIMARY KEY,
+    token_address VARCHAR(255) NOT NULL,
+    calculated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    x_score DOUBLE PRECISION NOT NULL,
+    base_score DOUBLE PRECISION NOT NULL,
+    price DOUBLE PRECISION,
+    market_cap DOUBLE PRECISION,
+    components JSONB NOT NULL,
+    factors JSONB NOT NULL
+);
+
 -- Table des points de prix des tokens
 CREATE TABLE IF NOT EXISTS token_price_points (
     token_address VARCHAR(255) REFERENCES tokens(address),
@@ -208,6 +361,19 @@ CREATE INDEX IF NOT EXISTS idx_token_trades_wallet ON token_trades(wallet_addres
 CREATE INDEX IF NOT EXISTS idx_token_trades_timestamp ON token_trades(timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_token_metrics_updated ON token_metrics(updated_at DESC);
 CREATE INDEX IF NOT EXISTS idx_wallet_trust_scores_score ON wallet_trust_scores(trust_score DESC);
+CREATE INDEX IF NOT EXISTS idx_token_xscore_history_token ON token_xscore_history(token_address, calculated_at);
+CREATE INDEX IF NOT EXISTS idx_token_xscore_history_calculated ON token_xscore_history(calculated_at);
+CREATE INDEX IF NOT EXISTS idx_token_alerts_detected ON token_alerts(detected_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_token_alerts_token ON token_alerts(token_address, detected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_token_alerts_type ON token_alerts(alert_type, detected_at DESC);
+CREATE INDEX IF NOT EXISTS idx_token_alerts_open ON token_alerts(dedup_key) WHERE status = 'OPEN';
+CREATE INDEX IF NOT EXISTS idx_token_alert_history_alert ON token_alert_history(alert_id, changed_at);
+CREATE INDEX IF NOT EXISTS idx_alert_outcomes_detected ON alert_outcomes(detected_at);
+CREATE INDEX IF NOT EXISTS idx_alert_outcomes_tracking ON alert_outcomes(status) WHERE status = 'TRACKING';
+CREATE INDEX IF NOT EXISTS idx_digests_period_start ON digests(period, period_start DESC);
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_key ON api_key_usage(key_id, used_at DESC);
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_used ON api_key_usage(used_at DESC);
+CREATE INDEX IF NOT EXISTS idx_maintenance_jobs_created ON maintenance_jobs(created_at DESC);
 
 -- Vues pour les requêtes fréquentes
 CREATE OR REPLACE VIEW token_recent_metrics AS